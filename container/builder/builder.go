@@ -595,6 +595,7 @@ func (b *WorkflowBuilder) Build() (*job.Definition, error) {
 		job.WithExecute(func(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in any) (client.WorkflowRun, error) {
 			return c.ExecuteWorkflow(ctx, opts, wfType, in)
 		}),
+		workflow.WithWorkflowType(wfType),
 		job.WithNewInput(newInputFn),
 	)
 }
@@ -892,6 +893,7 @@ func (lb *LoopBuilder) Build() (*job.Definition, error) {
 		job.WithExecute(func(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in any) (client.WorkflowRun, error) {
 			return c.ExecuteWorkflow(ctx, opts, wfType, in)
 		}),
+		workflow.WithWorkflowType(wfType),
 		job.WithNewInput(newInputFn),
 	)
 }
@@ -0,0 +1,325 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jasoet/pkg/v2/temporal/job"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+
+	"github.com/jasoet/go-wf/v2/container"
+	"github.com/jasoet/go-wf/v2/container/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
+)
+
+// DAGBuilder provides a fluent API for constructing container DAG workflow
+// inputs and producing a *job.Definition that runs DAGWorkflow.
+//
+// Node methods other than AddNode* take the target node name; referring to a
+// node that was not added yet records an error returned by BuildDAG/Build.
+//
+// Example usage:
+//
+//	def, err := NewDAGBuilder("release").
+//	    AddNodeWithInput("build", buildInput).
+//	    WithOutputs("build", payload.OutputDefinition{Name: "version", ValueFrom: "stdout"}).
+//	    AddNodeWithInput("test", testInput, "build").
+//	    AddNodeWithInput("deploy", deployInput, "test").
+//	    WithInputs("deploy", payload.InputMapping{Name: "VERSION", From: "build.version", Required: true}).
+//	    FailFast(true).
+//	    Build()
+type DAGBuilder struct {
+	name        string
+	taskQueue   string
+	nodes       []payload.DAGNode
+	nodeIndex   map[string]int // name -> index in nodes slice
+	parameters  []payload.WorkflowParameter
+	failFast    bool
+	maxParallel int
+	errors      []error
+}
+
+// NewDAGBuilder creates a new container DAG builder. The name is used as the
+// job name by Build.
+func NewDAGBuilder(name string) *DAGBuilder {
+	return &DAGBuilder{
+		name:      name,
+		nodes:     make([]payload.DAGNode, 0),
+		nodeIndex: make(map[string]int),
+	}
+}
+
+// TaskQueue overrides the default task queue (which is "container-<name>").
+func (b *DAGBuilder) TaskQueue(tq string) *DAGBuilder {
+	b.taskQueue = tq
+	return b
+}
+
+// AddNode adds a node from a WorkflowSource with optional dependencies.
+func (b *DAGBuilder) AddNode(name string, source WorkflowSource, deps ...string) *DAGBuilder {
+	if source == nil {
+		b.errors = append(b.errors, fmt.Errorf("cannot add nil source for node %q", name))
+		return b
+	}
+
+	return b.AddNodeWithInput(name, source.ToInput(), deps...)
+}
+
+// AddNodeWithInput adds a node running the given container with optional
+// dependencies.
+func (b *DAGBuilder) AddNodeWithInput(name string, input payload.ContainerExecutionInput, deps ...string) *DAGBuilder {
+	return b.AddExtendedNode(name, payload.ExtendedContainerInput{ContainerExecutionInput: input}, deps...)
+}
+
+// AddExtendedNode adds a node with a fully specified ExtendedContainerInput.
+// Dependencies given here are merged with any listed in input.DependsOn.
+func (b *DAGBuilder) AddExtendedNode(name string, input payload.ExtendedContainerInput, deps ...string) *DAGBuilder {
+	if _, exists := b.nodeIndex[name]; exists {
+		b.errors = append(b.errors, fmt.Errorf("duplicate node name: %s", name))
+		return b
+	}
+
+	node := payload.DAGNode{
+		Name:      name,
+		Container: input,
+	}
+	node.Dependencies = appendUnique(node.Dependencies, input.DependsOn...)
+	node.Dependencies = appendUnique(node.Dependencies, deps...)
+
+	b.nodeIndex[name] = len(b.nodes)
+	b.nodes = append(b.nodes, node)
+	return b
+}
+
+// DependsOn adds dependencies to the named node.
+func (b *DAGBuilder) DependsOn(nodeName string, deps ...string) *DAGBuilder {
+	if node := b.node(nodeName, "dependency"); node != nil {
+		node.Dependencies = appendUnique(node.Dependencies, deps...)
+	}
+	return b
+}
+
+// WithOutputs appends output definitions captured from the named node.
+func (b *DAGBuilder) WithOutputs(nodeName string, outputs ...payload.OutputDefinition) *DAGBuilder {
+	if node := b.node(nodeName, "output definition"); node != nil {
+		node.Container.Outputs = append(node.Container.Outputs, outputs...)
+	}
+	return b
+}
+
+// WithInputs appends input mappings to the named node. Each mapping's From
+// ("node-name.output-name") must refer to a node in the DAG.
+func (b *DAGBuilder) WithInputs(nodeName string, inputs ...payload.InputMapping) *DAGBuilder {
+	if node := b.node(nodeName, "input mapping"); node != nil {
+		node.Container.Inputs = append(node.Container.Inputs, inputs...)
+	}
+	return b
+}
+
+// WithInputArtifacts appends artifacts made available to the named node
+// before it runs.
+func (b *DAGBuilder) WithInputArtifacts(nodeName string, artifacts ...payload.Artifact) *DAGBuilder {
+	if node := b.node(nodeName, "input artifact"); node != nil {
+		node.Container.InputArtifacts = append(node.Container.InputArtifacts, artifacts...)
+	}
+	return b
+}
+
+// WithOutputArtifacts appends artifacts uploaded after the named node
+// succeeds.
+func (b *DAGBuilder) WithOutputArtifacts(nodeName string, artifacts ...payload.Artifact) *DAGBuilder {
+	if node := b.node(nodeName, "output artifact"); node != nil {
+		node.Container.OutputArtifacts = append(node.Container.OutputArtifacts, artifacts...)
+	}
+	return b
+}
+
+// When sets the condition expression of the named node, for example
+// "{{steps.test.exitCode}} == 0".
+func (b *DAGBuilder) When(nodeName, expr string) *DAGBuilder {
+	if node := b.node(nodeName, "condition"); node != nil {
+		conditional(node).When = expr
+	}
+	return b
+}
+
+// ContinueOnFail lets the DAG continue when the named node fails.
+func (b *DAGBuilder) ContinueOnFail(nodeName string, cont bool) *DAGBuilder {
+	if node := b.node(nodeName, "condition"); node != nil {
+		conditional(node).ContinueOnFail = cont
+	}
+	return b
+}
+
+// WithParameter adds a workflow parameter.
+func (b *DAGBuilder) WithParameter(param payload.WorkflowParameter) *DAGBuilder {
+	b.parameters = append(b.parameters, param)
+	return b
+}
+
+// AddSubWorkflowNode adds a node that runs sub, a registered workflow or an
+// inline nested DAG or pipeline, as a child workflow.
+func (b *DAGBuilder) AddSubWorkflowNode(name string, sub payload.SubWorkflowNode, deps ...string) *DAGBuilder {
+	if _, exists := b.nodeIndex[name]; exists {
+		b.errors = append(b.errors, fmt.Errorf("duplicate node name: %s", name))
+		return b
+	}
+
+	node := payload.DAGNode{Name: name, SubWorkflow: &sub}
+	node.Dependencies = appendUnique(node.Dependencies, deps...)
+	b.nodeIndex[name] = len(b.nodes)
+	b.nodes = append(b.nodes, node)
+	return b
+}
+
+// AddDefinitionNode adds a node that runs def, with its own input snapshot,
+// as a child workflow.
+func (b *DAGBuilder) AddDefinitionNode(name string, def *job.Definition, deps ...string) *DAGBuilder {
+	sub, err := generic.ResolveDefinition(def, nil)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Errorf("node %s: %w", name, err))
+		return b
+	}
+	return b.AddSubWorkflowNode(name, payload.SubWorkflowNode{SubWorkflow: sub}, deps...)
+}
+
+// WithSubWorkflowOptions applies child workflow options, such as the ID
+// template, parent-close policy and output mappings, to a sub-workflow node.
+func (b *DAGBuilder) WithSubWorkflowOptions(nodeName string, opts ...generic.SubWorkflowOption) *DAGBuilder {
+	node := b.node(nodeName, "sub-workflow options")
+	if node == nil {
+		return b
+	}
+	if node.SubWorkflow == nil {
+		b.errors = append(b.errors, fmt.Errorf("node %s is not a sub-workflow node", nodeName))
+		return b
+	}
+	node.SubWorkflow.Apply(opts...)
+	return b
+}
+
+// FailFast configures fail-fast behavior for the DAG workflow.
+func (b *DAGBuilder) FailFast(ff bool) *DAGBuilder {
+	b.failFast = ff
+	return b
+}
+
+// MaxParallel sets the maximum number of parallel node executions.
+func (b *DAGBuilder) MaxParallel(max int) *DAGBuilder {
+	b.maxParallel = max
+	return b
+}
+
+// Errors returns all errors accumulated during building.
+func (b *DAGBuilder) Errors() []error {
+	return b.errors
+}
+
+// BuildDAG creates the DAG workflow input, returning an error if validation
+// fails. Validation covers node names, dependencies, cycles and the sources of
+// input mappings. Set ArtifactStore on the result to enable artifacts.
+func (b *DAGBuilder) BuildDAG() (*payload.DAGWorkflowInput, error) {
+	if len(b.errors) > 0 {
+		return nil, b.errors[0]
+	}
+
+	if len(b.nodes) == 0 {
+		return nil, fmt.Errorf("DAG workflow requires at least one node")
+	}
+
+	input := &payload.DAGWorkflowInput{
+		Nodes:       b.nodes,
+		Parameters:  b.parameters,
+		FailFast:    b.failFast,
+		MaxParallel: b.maxParallel,
+	}
+
+	if err := input.ValidateAs(b.name); err != nil {
+		return nil, fmt.Errorf("DAG validation failed: %w", err)
+	}
+	if err := b.validateInputSources(); err != nil {
+		return nil, fmt.Errorf("DAG validation failed: %w", err)
+	}
+
+	return input, nil
+}
+
+// Build validates the DAG and returns a *job.Definition ready for
+// registration with a Temporal worker and execution via the job registry.
+// The workflow input carries no ArtifactStore: a store cannot cross the
+// Temporal boundary, so artifacts are skipped for jobs started this way.
+func (b *DAGBuilder) Build() (*job.Definition, error) {
+	if b.name == "" {
+		return nil, fmt.Errorf("container.DAGBuilder: Name is required")
+	}
+
+	input, err := b.BuildDAG()
+	if err != nil {
+		return nil, err
+	}
+
+	tq := b.taskQueue
+	if tq == "" {
+		tq = "container-" + b.name
+	}
+
+	snapshot := *input
+	return job.New(b.name, tq,
+		job.WithRegister(func(w worker.Worker) { container.RegisterAll(w) }),
+		job.WithExecute(func(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in any) (client.WorkflowRun, error) {
+			return c.ExecuteWorkflow(ctx, opts, "DAGWorkflow", in)
+		}),
+		generic.WithWorkflowType("DAGWorkflow"),
+		job.WithNewInput(func() any {
+			cp := snapshot
+			return cp
+		}),
+	)
+}
+
+// node returns the named node, or records an error and returns nil.
+func (b *DAGBuilder) node(name, what string) *payload.DAGNode {
+	idx, exists := b.nodeIndex[name]
+	if !exists {
+		b.errors = append(b.errors, fmt.Errorf("unknown node for %s: %s", what, name))
+		return nil
+	}
+	return &b.nodes[idx]
+}
+
+// validateInputSources checks that every input mapping reads from a node in
+// the DAG.
+func (b *DAGBuilder) validateInputSources() error {
+	for _, node := range b.nodes {
+		for _, mapping := range node.Container.Inputs {
+			source, _, ok := strings.Cut(mapping.From, ".")
+			if !ok {
+				return fmt.Errorf("node %s: input %s: from %q must be \"node.output\"", node.Name, mapping.Name, mapping.From)
+			}
+			if _, exists := b.nodeIndex[source]; !exists {
+				return fmt.Errorf("node %s: input %s: unknown source node %s", node.Name, mapping.Name, source)
+			}
+		}
+	}
+	return nil
+}
+
+func conditional(node *payload.DAGNode) *payload.ConditionalBehavior {
+	if node.Container.Conditional == nil {
+		node.Container.Conditional = &payload.ConditionalBehavior{}
+	}
+	return node.Container.Conditional
+}
+
+// appendUnique appends values not already present in list.
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
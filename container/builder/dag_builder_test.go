@@ -0,0 +1,207 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/container/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
+)
+
+func TestDAGBuilder_BuildDAG(t *testing.T) {
+	dag, err := NewDAGBuilder("release").
+		AddNodeWithInput("build", payload.ContainerExecutionInput{Image: "golang:1.26"}).
+		WithOutputs("build", payload.OutputDefinition{Name: "version", ValueFrom: "stdout"}).
+		WithOutputArtifacts("build", payload.Artifact{Name: "binary", Path: "/out/app", Type: "file"}).
+		AddNode("test", NewContainerSource(payload.ContainerExecutionInput{Image: "golang:1.26"}), "build").
+		AddNodeWithInput("deploy", payload.ContainerExecutionInput{Image: "alpine:latest"}).
+		DependsOn("deploy", "test", "test").
+		WithInputs("deploy", payload.InputMapping{Name: "VERSION", From: "build.version", Required: true}).
+		WithInputArtifacts("deploy", payload.Artifact{Name: "binary", Path: "/in/app", Type: "file"}).
+		When("deploy", "{{steps.test.exitCode}} == 0").
+		ContinueOnFail("test", true).
+		WithParameter(payload.WorkflowParameter{Name: "env", Value: "prod"}).
+		FailFast(true).
+		MaxParallel(2).
+		BuildDAG()
+	require.NoError(t, err)
+
+	require.Len(t, dag.Nodes, 3)
+	assert.Equal(t, []string{"build"}, dag.Nodes[1].Dependencies)
+	assert.Equal(t, []string{"test"}, dag.Nodes[2].Dependencies)
+
+	build := dag.Nodes[0].Container
+	assert.Equal(t, "version", build.Outputs[0].Name)
+	assert.Equal(t, "binary", build.OutputArtifacts[0].Name)
+
+	deploy := dag.Nodes[2].Container
+	assert.Equal(t, "build.version", deploy.Inputs[0].From)
+	assert.Equal(t, "binary", deploy.InputArtifacts[0].Name)
+	require.NotNil(t, deploy.Conditional)
+	assert.Equal(t, "{{steps.test.exitCode}} == 0", deploy.Conditional.When)
+	assert.True(t, dag.Nodes[1].Container.Conditional.ContinueOnFail)
+
+	assert.Len(t, dag.Parameters, 1)
+	assert.True(t, dag.FailFast)
+	assert.Equal(t, 2, dag.MaxParallel)
+}
+
+func TestDAGBuilder_AddExtendedNodeMergesDependsOn(t *testing.T) {
+	dag, err := NewDAGBuilder("extended").
+		AddNodeWithInput("a", payload.ContainerExecutionInput{Image: "alpine"}).
+		AddNodeWithInput("b", payload.ContainerExecutionInput{Image: "alpine"}).
+		AddExtendedNode("c", payload.ExtendedContainerInput{
+			ContainerExecutionInput: payload.ContainerExecutionInput{Image: "alpine"},
+			DependsOn:               []string{"a"},
+		}, "b", "a").
+		BuildDAG()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, dag.Nodes[2].Dependencies)
+}
+
+func TestDAGBuilder_ValidationErrors(t *testing.T) {
+	alpine := payload.ContainerExecutionInput{Image: "alpine"}
+
+	tests := []struct {
+		name    string
+		builder *DAGBuilder
+		wantErr string
+	}{
+		{
+			name:    "empty",
+			builder: NewDAGBuilder("empty"),
+			wantErr: "at least one node",
+		},
+		{
+			name:    "nil source",
+			builder: NewDAGBuilder("x").AddNode("a", nil),
+			wantErr: "nil source",
+		},
+		{
+			name:    "duplicate node",
+			builder: NewDAGBuilder("x").AddNodeWithInput("a", alpine).AddNodeWithInput("a", alpine),
+			wantErr: "duplicate node name",
+		},
+		{
+			name:    "invalid node name",
+			builder: NewDAGBuilder("x").AddNodeWithInput("1-bad name", alpine),
+			wantErr: "invalid node name",
+		},
+		{
+			name:    "unknown node for mapping",
+			builder: NewDAGBuilder("x").AddNodeWithInput("a", alpine).WithOutputs("missing", payload.OutputDefinition{Name: "o", ValueFrom: "stdout"}),
+			wantErr: "unknown node for output definition",
+		},
+		{
+			name:    "unknown dependency",
+			builder: NewDAGBuilder("x").AddNodeWithInput("a", alpine, "missing"),
+			wantErr: "dependency node not found",
+		},
+		{
+			name: "cycle",
+			builder: NewDAGBuilder("x").
+				AddNodeWithInput("a", alpine, "c").
+				AddNodeWithInput("b", alpine, "a").
+				AddNodeWithInput("c", alpine, "b"),
+			wantErr: "circular dependency",
+		},
+		{
+			name: "unknown input source",
+			builder: NewDAGBuilder("x").
+				AddNodeWithInput("a", alpine).
+				WithInputs("a", payload.InputMapping{Name: "V", From: "missing.out"}),
+			wantErr: "unknown source node missing",
+		},
+		{
+			name: "malformed input source",
+			builder: NewDAGBuilder("x").
+				AddNodeWithInput("a", alpine).
+				WithInputs("a", payload.InputMapping{Name: "V", From: "a"}),
+			wantErr: `must be "node.output"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildDAG()
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDAGBuilder_Build(t *testing.T) {
+	t.Run("produces definition", func(t *testing.T) {
+		def, err := NewDAGBuilder("release").
+			AddNodeWithInput("build", payload.ContainerExecutionInput{Image: "alpine"}).
+			AddNodeWithInput("deploy", payload.ContainerExecutionInput{Image: "alpine"}, "build").
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, "release", def.Name)
+		assert.Equal(t, "container-release", def.TaskQueue)
+
+		in, ok := def.NewInput().(payload.DAGWorkflowInput)
+		require.True(t, ok)
+		assert.Len(t, in.Nodes, 2)
+	})
+
+	t.Run("custom task queue is used", func(t *testing.T) {
+		def, err := NewDAGBuilder("release").
+			TaskQueue("custom-queue").
+			AddNodeWithInput("build", payload.ContainerExecutionInput{Image: "alpine"}).
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, "custom-queue", def.TaskQueue)
+	})
+
+	t.Run("requires name", func(t *testing.T) {
+		_, err := NewDAGBuilder("").
+			AddNodeWithInput("build", payload.ContainerExecutionInput{Image: "alpine"}).
+			Build()
+		assert.ErrorContains(t, err, "Name is required")
+	})
+
+	t.Run("propagates validation errors", func(t *testing.T) {
+		_, err := NewDAGBuilder("release").
+			AddNodeWithInput("a", payload.ContainerExecutionInput{Image: "alpine"}, "a").
+			Build()
+		assert.ErrorContains(t, err, "circular dependency")
+	})
+}
+
+func TestDAGBuilder_AddSubWorkflowNode(t *testing.T) {
+	child, err := NewDAGBuilder("child").
+		AddNodeWithInput("step", payload.ContainerExecutionInput{Image: "alpine:latest"}).
+		Build()
+	require.NoError(t, err)
+
+	dag, err := NewDAGBuilder("parent").
+		AddDefinitionNode("run-child", child).
+		WithSubWorkflowOptions("run-child", generic.WithChildOutputs(generic.SubWorkflowOutput{Name: "ok", Path: "$.total_success"})).
+		AddSubWorkflowNode("checks", payload.SubWorkflowNode{
+			Pipeline: &payload.PipelineInput{Containers: []payload.ContainerExecutionInput{{Image: "lint:latest"}}},
+		}, "run-child").
+		AddNodeWithInput("deploy", payload.ContainerExecutionInput{Image: "deploy:latest"}, "checks").
+		WithInputs("deploy", payload.InputMapping{Name: "OK", From: "run-child.ok"}).
+		BuildDAG()
+	require.NoError(t, err)
+
+	require.NotNil(t, dag.Nodes[0].SubWorkflow)
+	assert.Equal(t, payload.DAGWorkflowType, dag.Nodes[0].SubWorkflow.WorkflowType)
+	assert.Equal(t, "container-child", dag.Nodes[0].SubWorkflow.TaskQueue)
+	assert.Equal(t, []string{"run-child"}, dag.Nodes[1].Dependencies)
+}
+
+func TestDAGBuilder_SubWorkflowCycle(t *testing.T) {
+	a, err := NewDAGBuilder("a").
+		AddNodeWithInput("step", payload.ContainerExecutionInput{Image: "alpine:latest"}).
+		Build()
+	require.NoError(t, err)
+	b, err := NewDAGBuilder("b").AddDefinitionNode("run-a", a).Build()
+	require.NoError(t, err)
+
+	_, err = NewDAGBuilder("a").AddDefinitionNode("run-b", b).BuildDAG()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sub-workflow cycle: a -> b -> a")
+}
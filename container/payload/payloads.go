@@ -93,6 +93,7 @@ type ContainerExecutionOutput struct {
 	Name        string            `json:"name,omitempty"`
 	ExitCode    int               `json:"exit_code"`
 	Stdout      string            `json:"stdout,omitempty"`
+	StdoutRef   string            `json:"stdout_ref,omitempty"` // Artifact store key of a Stdout too large to keep inline.
 	Stderr      string            `json:"stderr,omitempty"`
 	Endpoint    string            `json:"endpoint,omitempty"`
 	Ports       map[string]string `json:"ports,omitempty"`
@@ -119,6 +120,9 @@ type PipelineOutput struct {
 	TotalDuration time.Duration              `json:"total_duration"`
 }
 
+// FailedSteps implements workflow.ChildOutput.
+func (o *PipelineOutput) FailedSteps() int { return o.TotalFailed }
+
 // ParallelInput defines parallel container execution.
 type ParallelInput struct {
 	Containers []ContainerExecutionInput `json:"containers" validate:"required,min=1"`
@@ -137,6 +141,9 @@ type ParallelOutput struct {
 	TotalDuration time.Duration              `json:"total_duration"`
 }
 
+// FailedSteps implements workflow.ChildOutput.
+func (o *ParallelOutput) FailedSteps() int { return o.TotalFailed }
+
 // Validate validates input using struct tags.
 func (i *ContainerExecutionInput) Validate() error {
 	if err := pkgValidator.Struct(i); err != nil {
@@ -214,6 +221,9 @@ type LoopOutput struct {
 	ItemCount     int                        `json:"item_count"`
 }
 
+// FailedSteps implements workflow.ChildOutput.
+func (o *LoopOutput) FailedSteps() int { return o.TotalFailed }
+
 // Validate validates loop input using struct tags.
 func (i *LoopInput) Validate() error {
 	if err := pkgValidator.Struct(i); err != nil {
@@ -48,16 +48,63 @@ type Artifact struct {
 	// Name is the artifact identifier
 	Name string `json:"name" validate:"required"`
 
-	// Path is the file/directory path
-	Path string `json:"path" validate:"required"`
+	// Path is the file/directory path (not used for URL input artifacts)
+	Path string `json:"path" validate:"required_without=URLEnv"`
 
 	// Type can be "file", "directory", or "archive"
 	Type string `json:"type" validate:"oneof=file directory archive"`
 
 	// Optional indicates if the artifact is optional
 	Optional bool `json:"optional"`
+
+	// URLEnv, on an input artifact, passes a presigned download URL to the
+	// container in this environment variable instead of downloading the file.
+	// The artifact store must implement store.Presigner. The URL is recorded
+	// in workflow history, so anyone who can read the history can download
+	// the artifact until it expires.
+	URLEnv string `json:"url_env,omitempty"`
+
+	// URLExpiry is how long the presigned URL stays valid (defaults to
+	// DefaultArtifactURLExpiry, at most MaxArtifactURLExpiry).
+	URLExpiry time.Duration `json:"url_expiry,omitempty"`
+
+	// Include limits a directory artifact to paths matching these
+	// gitignore-style patterns. On inputs it selects which entries are
+	// extracted from the stored archive.
+	Include []string `json:"include,omitempty"`
+
+	// Exclude drops paths matching these gitignore-style patterns from a
+	// directory artifact (for example "node_modules/" or "*.log").
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Format is the archive format for directory artifacts: "tar.gz"
+	// (default), "tar" or "zip". Inputs detect the format when unset.
+	Format string `json:"format,omitempty" validate:"omitempty,oneof=tar.gz tar zip"`
 }
 
+// ArchiveOptions returns the store archive options for this artifact.
+func (a Artifact) ArchiveOptions() []store.ArchiveOption {
+	var opts []store.ArchiveOption
+	if a.Format != "" {
+		opts = append(opts, store.WithArchiveFormat(a.Format))
+	}
+	if len(a.Include) > 0 {
+		opts = append(opts, store.WithInclude(a.Include...))
+	}
+	if len(a.Exclude) > 0 {
+		opts = append(opts, store.WithExclude(a.Exclude...))
+	}
+	return opts
+}
+
+// DefaultArtifactURLExpiry is the validity of presigned input artifact URLs
+// when Artifact.URLExpiry is not set.
+const DefaultArtifactURLExpiry = time.Hour
+
+// MaxArtifactURLExpiry is the longest Artifact.URLExpiry a DAG accepts. It
+// bounds how long a presigned URL read from workflow history stays usable.
+const MaxArtifactURLExpiry = 12 * time.Hour
+
 // SecretReference defines a reference to a secret.
 type SecretReference struct {
 	// Name is the secret name
@@ -166,6 +213,10 @@ type DAGNode struct {
 
 	// Dependencies are the nodes that must complete before this node
 	Dependencies []string `json:"dependencies,omitempty"`
+
+	// SubWorkflow, when set, runs the node as a child workflow instead of
+	// Container.
+	SubWorkflow *SubWorkflowNode `json:"sub_workflow,omitempty"`
 }
 
 // DAGWorkflowInput defines a DAG (Directed Acyclic Graph) workflow.
@@ -187,8 +238,13 @@ type DAGWorkflowInput struct {
 	ArtifactStore store.RawStore `json:"-"`
 }
 
-// Validate validates DAG workflow input including cycle detection.
+// Validate validates DAG workflow input including cycle detection. Nested
+// sub-workflows are validated recursively.
 func (i *DAGWorkflowInput) Validate() error {
+	return i.validate(nil)
+}
+
+func (i *DAGWorkflowInput) validate(chain []string) error {
 	if len(i.Nodes) == 0 {
 		return errors.ErrInvalidInput.Wrap("at least one node is required")
 	}
@@ -222,7 +278,16 @@ func (i *DAGWorkflowInput) Validate() error {
 		return err
 	}
 
-	return nil
+	for _, node := range i.Nodes {
+		for _, artifact := range node.Container.InputArtifacts {
+			if artifact.URLExpiry > MaxArtifactURLExpiry {
+				return errors.ErrInvalidInput.Wrap(fmt.Sprintf("node %s: artifact %s: url expiry %s exceeds %s",
+					node.Name, artifact.Name, artifact.URLExpiry, MaxArtifactURLExpiry))
+			}
+		}
+	}
+
+	return validateSubWorkflowNodes(i.Nodes, chain)
 }
 
 // detectDAGCycles uses DFS to find circular dependencies in the DAG.
@@ -308,3 +373,6 @@ type DAGWorkflowOutput struct {
 	// TotalDuration is the total execution time
 	TotalDuration time.Duration `json:"total_duration"`
 }
+
+// FailedSteps implements workflow.ChildOutput.
+func (o *DAGWorkflowOutput) FailedSteps() int { return o.TotalFailed }
@@ -1,11 +1,15 @@
 package payload
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/workflow"
 )
 
 func TestDAGWorkflowInput_Validate(t *testing.T) {
@@ -132,6 +136,24 @@ func TestDAGWorkflowInput_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "dependency node not found",
 		},
+		{
+			name: "invalid - artifact url expiry above the cap",
+			input: DAGWorkflowInput{
+				Nodes: []DAGNode{
+					{
+						Name: "consume",
+						Container: ExtendedContainerInput{
+							ContainerExecutionInput: ContainerExecutionInput{Image: "alpine:latest"},
+							InputArtifacts: []Artifact{
+								{Name: "data", Type: "file", URLEnv: "DATA_URL", URLExpiry: MaxArtifactURLExpiry + time.Minute},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "url expiry",
+		},
 	}
 
 	for _, tt := range tests {
@@ -263,6 +285,16 @@ func TestArtifact_Validation(t *testing.T) {
 			input:   Artifact{Name: "test", Path: "/tmp", Type: "invalid"},
 			wantErr: true,
 		},
+		{
+			name:    "valid zip directory with filters",
+			input:   Artifact{Name: "site", Path: "/out", Type: "directory", Format: "zip", Exclude: []string{"*.map"}},
+			wantErr: false,
+		},
+		{
+			name:    "invalid - bad archive format",
+			input:   Artifact{Name: "site", Path: "/out", Type: "directory", Format: "rar"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -275,6 +307,20 @@ func TestArtifact_Validation(t *testing.T) {
 	}
 }
 
+func TestArtifact_ArchiveOptions(t *testing.T) {
+	assert.Empty(t, Artifact{Name: "plain", Path: "/out", Type: "directory"}.ArchiveOptions())
+
+	opts := Artifact{
+		Name:    "site",
+		Path:    "/out",
+		Type:    "directory",
+		Format:  "tar",
+		Include: []string{"dist/"},
+		Exclude: []string{"*.map"},
+	}.ArchiveOptions()
+	assert.Len(t, opts, 3)
+}
+
 func TestSecretReference_Validation(t *testing.T) {
 	validate := validator.New()
 
@@ -499,3 +545,52 @@ func TestDAGNode_Validation(t *testing.T) {
 		})
 	}
 }
+
+func TestDAGWorkflowInput_SubWorkflow(t *testing.T) {
+	leaf := DAGNode{Name: "leaf", Container: ExtendedContainerInput{ContainerExecutionInput: ContainerExecutionInput{Image: "alpine"}}}
+	nestedInput, err := json.Marshal(DAGWorkflowInput{Nodes: []DAGNode{{
+		Name:        "back",
+		SubWorkflow: &SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{Definition: "a", WorkflowType: DAGWorkflowType}},
+	}}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		sub     SubWorkflowNode
+		wantErr string
+	}{
+		{name: "inline pipeline", sub: SubWorkflowNode{Pipeline: &PipelineInput{Containers: []ContainerExecutionInput{{Image: "alpine"}}}}},
+		{name: "registered type", sub: SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{WorkflowType: "Other"}}},
+		{name: "no target", sub: SubWorkflowNode{}, wantErr: "exactly one of"},
+		{name: "invalid pipeline", sub: SubWorkflowNode{Pipeline: &PipelineInput{}}, wantErr: "Containers"},
+		{
+			name:    "bad parent close policy",
+			sub:     SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{WorkflowType: "Other", ParentClosePolicy: "detach"}},
+			wantErr: "ParentClosePolicy",
+		},
+		{
+			name:    "cycle across definitions",
+			sub:     SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{Definition: "b", WorkflowType: DAGWorkflowType, Input: nestedInput}},
+			wantErr: "sub-workflow cycle: a -> b -> a",
+		},
+		{
+			name:    "null nested input",
+			sub:     SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{Definition: "b", WorkflowType: DAGWorkflowType, Input: []byte("null")}},
+			wantErr: "input is null",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := tt.sub
+			input := DAGWorkflowInput{Nodes: []DAGNode{leaf, {Name: "child", SubWorkflow: &sub}}}
+			err := input.ValidateAs("a")
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
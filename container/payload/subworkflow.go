@@ -0,0 +1,56 @@
+package payload
+
+import (
+	"fmt"
+
+	"github.com/jasoet/go-wf/v2/workflow"
+	"github.com/jasoet/go-wf/v2/workflow/errors"
+)
+
+// Workflow types started for inline sub-workflows.
+const (
+	DAGWorkflowType      = "DAGWorkflow"
+	PipelineWorkflowType = "ContainerPipelineWorkflow"
+)
+
+// InlineWorkflowTypes names the container workflows that run inline nested
+// DAGs and pipelines.
+var InlineWorkflowTypes = workflow.InlineWorkflowTypes{DAG: DAGWorkflowType, Pipeline: PipelineWorkflowType}
+
+// ChildOutputs maps the container workflow types to the outputs a
+// sub-workflow node decodes their results into.
+var ChildOutputs = workflow.ChildOutputs{
+	DAGWorkflowType:              func() workflow.ChildOutput { return &DAGWorkflowOutput{} },
+	PipelineWorkflowType:         func() workflow.ChildOutput { return &PipelineOutput{} },
+	"ParallelContainersWorkflow": func() workflow.ChildOutput { return &ParallelOutput{} },
+	"LoopWorkflow":               func() workflow.ChildOutput { return &LoopOutput{} },
+	"ParameterizedLoopWorkflow":  func() workflow.ChildOutput { return &LoopOutput{} },
+}
+
+// SubWorkflowNode runs a DAG node as a child workflow instead of a container.
+type SubWorkflowNode = workflow.SubWorkflowNode[*DAGWorkflowInput, *PipelineInput]
+
+// ValidateWithin validates the input nested in chain, the enclosing
+// definitions, so a sub-workflow that references one of them is reported as
+// a cycle.
+func (i *DAGWorkflowInput) ValidateWithin(chain []string) error {
+	return i.validate(chain)
+}
+
+// ValidateAs validates the input as the input of the named definition, so a
+// nested sub-workflow that references that definition is reported as a cycle.
+func (i *DAGWorkflowInput) ValidateAs(definition string) error {
+	return i.validate([]string{definition})
+}
+
+func validateSubWorkflowNodes(nodes []DAGNode, chain []string) error {
+	for _, node := range nodes {
+		if node.SubWorkflow == nil {
+			continue
+		}
+		if err := node.SubWorkflow.ValidateWithin(chain, InlineWorkflowTypes); err != nil {
+			return errors.ErrInvalidInput.Wrap(fmt.Sprintf("sub-workflow node %s: %v", node.Name, err))
+		}
+	}
+	return nil
+}
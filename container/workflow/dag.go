@@ -11,6 +11,7 @@ import (
 	wf "go.temporal.io/sdk/workflow"
 
 	"github.com/jasoet/go-wf/v2/container/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
 	"github.com/jasoet/go-wf/v2/workflow/store"
 )
 
@@ -33,6 +34,8 @@ func newDAGState() *dagState {
 // DAGWorkflow executes containers in a DAG (Directed Acyclic Graph) pattern.
 // This allows for complex dependencies between containers where execution order
 // is determined by the dependency graph rather than simple sequential or parallel execution.
+// Nodes with a SubWorkflow run a registered workflow or a nested DAG or pipeline
+// as a child workflow instead of a container.
 //
 // Example:
 //
@@ -115,20 +118,29 @@ func executeDAGNode(ctx wf.Context, nodeName string, nodeMap map[string]*payload
 
 	logger.Info("Executing node", "name", nodeName)
 
-	containerInput := node.Container.ContainerExecutionInput
-	if err := applyInputMappings(logger, &containerInput, node, state); err != nil {
-		return err
-	}
+	var result payload.ContainerExecutionOutput
+	var err error
+	if node.SubWorkflow != nil {
+		result, err = executeSubWorkflowNode(ctx, input, node, state)
+	} else {
+		containerInput := node.Container.ContainerExecutionInput
+		if err := applyInputMappings(logger, &containerInput, node, state); err != nil {
+			return err
+		}
 
-	if err := downloadInputArtifacts(ctx, logger, input, node); err != nil {
-		return err
-	}
+		if err := presignInputArtifacts(ctx, logger, input, node, &containerInput); err != nil {
+			return err
+		}
 
-	var result payload.ContainerExecutionOutput
-	err := wf.ExecuteActivity(ctx, containerInput.ActivityName(), containerInput).Get(ctx, &result)
+		if err := downloadInputArtifacts(ctx, logger, input, node); err != nil {
+			return err
+		}
 
-	extractAndStoreOutputs(logger, node, &result, state)
-	uploadOutputArtifacts(ctx, logger, input, node, &result)
+		err = wf.ExecuteActivity(ctx, containerInput.ActivityName(), containerInput).Get(ctx, &result)
+
+		extractAndStoreOutputs(logger, node, &result, state)
+		uploadOutputArtifacts(ctx, logger, input, node, &result)
+	}
 
 	state.mu.Lock()
 	state.results[nodeName] = &result
@@ -143,6 +155,73 @@ func executeDAGNode(ctx wf.Context, nodeName string, nodeMap map[string]*payload
 	return nil
 }
 
+// childResultInlineLimit is the child result size above which a
+// sub-workflow node stores the result in the artifact store instead of its
+// Stdout.
+const childResultInlineLimit = 256 << 10
+
+// executeSubWorkflowNode runs the node as a child workflow. The child's JSON
+// result is returned as Stdout, or by StdoutRef when it is large and the DAG
+// has an artifact store, and its mapped outputs become the node's step
+// outputs.
+func executeSubWorkflowNode(ctx wf.Context, input *payload.DAGWorkflowInput, node *payload.DAGNode, state *dagState) (payload.ContainerExecutionOutput, error) {
+	workflowType, childInput := node.SubWorkflow.Target(payload.InlineWorkflowTypes)
+	child, err := generic.ExecuteSubWorkflow(ctx, node.Name, node.SubWorkflow.SubWorkflow, workflowType, childInput, payload.ChildOutputs.New(workflowType))
+
+	result := payload.ContainerExecutionOutput{
+		Name:       node.Name,
+		Stdout:     string(child.Result),
+		StartedAt:  child.StartedAt,
+		FinishedAt: child.FinishedAt,
+		Duration:   child.FinishedAt.Sub(child.StartedAt),
+		Success:    child.Success,
+		Error:      child.Error,
+	}
+	if input.ArtifactStore != nil && len(child.Result) > childResultInlineLimit {
+		if offloadErr := offloadChildResult(ctx, input.ArtifactStore, node.Name, &result); offloadErr != nil {
+			result.Success, result.Error = false, offloadErr.Error()
+			err = fmt.Errorf("sub-workflow node %s: offload result: %w", node.Name, offloadErr)
+		}
+	}
+	if !result.Success {
+		result.ExitCode = 1
+	}
+
+	if len(child.Outputs) > 0 {
+		state.mu.Lock()
+		state.stepOutputs[node.Name] = child.Outputs
+		state.mu.Unlock()
+	}
+	return result, err
+}
+
+// offloadChildResult moves a sub-workflow node's Stdout to the artifact store
+// and sets StdoutRef, so a large child result is not repeated in the DAG
+// output. Stdout is dropped even when the upload fails.
+func offloadChildResult(ctx wf.Context, raw store.RawStore, nodeName string, result *payload.ContainerExecutionOutput) error {
+	info := wf.GetInfo(ctx)
+	key := store.NewKeyBuilder().
+		WithWorkflow(info.WorkflowExecution.ID).
+		WithRun(info.WorkflowExecution.RunID).
+		WithStep(nodeName).
+		WithName("child-result").
+		Build()
+	laCtx := wf.WithLocalActivityOptions(ctx, wf.LocalActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+
+	data := result.Stdout
+	result.Stdout = ""
+	err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) error {
+		return raw.Upload(ctx, key, strings.NewReader(data))
+	}).Get(ctx, nil)
+	if err != nil {
+		return err
+	}
+	result.StdoutRef = key
+	return nil
+}
+
 func recordNodeResult(nodeName string, result *payload.ContainerExecutionOutput, err error, ctx wf.Context, failFast bool, output *payload.DAGWorkflowOutput, logger interface {
 	Info(string, ...interface{})
 	Error(string, ...interface{})
@@ -228,6 +307,68 @@ func findArtifactProducer(artifactName string, allNodes []payload.DAGNode) strin
 	return ""
 }
 
+// inputArtifactKey returns the store key of an input artifact, which lives
+// under the step that produced it.
+func inputArtifactKey(info *wf.Info, artifact payload.Artifact, allNodes []payload.DAGNode) string {
+	return store.NewKeyBuilder().
+		WithWorkflow(info.WorkflowExecution.ID).
+		WithRun(info.WorkflowExecution.RunID).
+		WithStep(findArtifactProducer(artifact.Name, allNodes)).
+		WithName(artifact.Name).
+		Build()
+}
+
+// presignInputArtifacts hands URL input artifacts (Artifact.URLEnv) to the
+// container as presigned download URLs in its environment. Presigning reads
+// the clock, so it runs as a local activity, like the transfers below. The
+// URLs are recorded in history; Validate caps their expiry at
+// payload.MaxArtifactURLExpiry.
+func presignInputArtifacts(ctx wf.Context, logger interface{ Info(string, ...interface{}) }, input *payload.DAGWorkflowInput, node *payload.DAGNode, containerInput *payload.ContainerExecutionInput) error {
+	if input.ArtifactStore == nil || len(node.Container.InputArtifacts) == 0 {
+		return nil
+	}
+
+	raw := input.ArtifactStore
+	info := wf.GetInfo(ctx)
+	laCtx := wf.WithLocalActivityOptions(ctx, wf.LocalActivityOptions{
+		StartToCloseTimeout: time.Minute,
+	})
+
+	for _, artifact := range node.Container.InputArtifacts {
+		if artifact.URLEnv == "" {
+			continue
+		}
+
+		key := inputArtifactKey(info, artifact, input.Nodes)
+		expiry := artifact.URLExpiry
+		if expiry <= 0 {
+			expiry = payload.DefaultArtifactURLExpiry
+		}
+
+		var presignedURL string
+		err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) (string, error) {
+			presigner, ok := store.PresignerOf(raw)
+			if !ok {
+				return "", fmt.Errorf("artifact store does not support presigned URLs")
+			}
+			return presigner.PresignGet(ctx, key, expiry)
+		}).Get(ctx, &presignedURL)
+		if err != nil {
+			if artifact.Optional {
+				continue
+			}
+			return fmt.Errorf("failed to presign artifact %s: %w", artifact.Name, err)
+		}
+
+		if containerInput.Env == nil {
+			containerInput.Env = make(map[string]string)
+		}
+		containerInput.Env[artifact.URLEnv] = presignedURL
+		logger.Info("Presigned artifact URL", "name", artifact.Name, "env", artifact.URLEnv)
+	}
+	return nil
+}
+
 // downloadInputArtifacts fetches the node's input artifacts from the store.
 // Transfer runs as local activities closing over the ArtifactStore — the store
 // is injected worker-side (json:"-") and must never be an activity argument.
@@ -243,16 +384,14 @@ func downloadInputArtifacts(ctx wf.Context, logger interface{ Info(string, ...in
 	})
 
 	for _, artifact := range node.Container.InputArtifacts {
-		producerStep := findArtifactProducer(artifact.Name, input.Nodes)
-		key := store.NewKeyBuilder().
-			WithWorkflow(info.WorkflowExecution.ID).
-			WithRun(info.WorkflowExecution.RunID).
-			WithStep(producerStep).
-			WithName(artifact.Name).
-			Build()
+		if artifact.URLEnv != "" {
+			continue // passed as a presigned URL by presignInputArtifacts
+		}
+
+		key := inputArtifactKey(info, artifact, input.Nodes)
 
 		err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) error {
-			return store.DownloadFile(ctx, raw, key, artifact.Path, artifact.Type)
+			return store.DownloadFile(ctx, raw, key, artifact.Path, artifact.Type, artifact.ArchiveOptions()...)
 		}).Get(ctx, nil)
 		if err != nil && !artifact.Optional {
 			return fmt.Errorf("failed to download artifact %s: %w", artifact.Name, err)
@@ -285,8 +424,10 @@ func extractAndStoreOutputs(logger interface {
 	logger.Info("Extracted outputs", "name", node.Name, "outputs", outputs)
 }
 
-// uploadOutputArtifacts stores the node's output artifacts. Like downloads,
-// uploads run as local activities closing over the ArtifactStore.
+// uploadOutputArtifacts stores the node's output artifacts. Uploads can be
+// large, so unlike downloads they run as a regular, heartbeating activity
+// (generic.UploadArtifactActivityName) that the worker registers with the
+// same store.
 func uploadOutputArtifacts(ctx wf.Context, logger interface {
 	Info(string, ...interface{})
 	Error(string, ...interface{})
@@ -296,23 +437,25 @@ func uploadOutputArtifacts(ctx wf.Context, logger interface {
 		return
 	}
 
-	raw := input.ArtifactStore
 	info := wf.GetInfo(ctx)
-	laCtx := wf.WithLocalActivityOptions(ctx, wf.LocalActivityOptions{
-		StartToCloseTimeout: 5 * time.Minute,
-	})
+	actCtx := generic.WithArtifactUploadOptions(ctx)
 
 	for _, artifact := range node.Container.OutputArtifacts {
-		key := store.NewKeyBuilder().
-			WithWorkflow(info.WorkflowExecution.ID).
-			WithRun(info.WorkflowExecution.RunID).
-			WithStep(node.Name).
-			WithName(artifact.Name).
-			Build()
+		upload := generic.ArtifactUpload{
+			Key: store.NewKeyBuilder().
+				WithWorkflow(info.WorkflowExecution.ID).
+				WithRun(info.WorkflowExecution.RunID).
+				WithStep(node.Name).
+				WithName(artifact.Name).
+				Build(),
+			Path:    artifact.Path,
+			Type:    artifact.Type,
+			Format:  artifact.Format,
+			Include: artifact.Include,
+			Exclude: artifact.Exclude,
+		}
 
-		err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) error {
-			return store.UploadFile(ctx, raw, key, artifact.Path, artifact.Type)
-		}).Get(ctx, nil)
+		err := wf.ExecuteActivity(actCtx, generic.UploadArtifactActivityName, upload).Get(ctx, nil)
 		if err != nil && !artifact.Optional {
 			logger.Error("Failed to upload artifact", "name", artifact.Name, "error", err)
 		} else if err == nil {
@@ -10,10 +10,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/testsuite"
 	wf "go.temporal.io/sdk/workflow"
 
 	"github.com/jasoet/go-wf/v2/container/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
 	"github.com/jasoet/go-wf/v2/workflow/store"
 )
 
@@ -26,6 +28,12 @@ func withContainerArtifactStore(raw store.RawStore) func(wf.Context, payload.DAG
 	}
 }
 
+// registerArtifactActivity registers the artifact upload activity for raw,
+// as a worker running DAG workflows with an artifact store does.
+func registerArtifactActivity(env *testsuite.TestWorkflowEnvironment, raw store.RawStore) {
+	env.RegisterActivityWithOptions(generic.NewUploadArtifactActivity(raw), activity.RegisterOptions{Name: generic.UploadArtifactActivityName})
+}
+
 func dagWithArtifacts(producerPath, consumerPath string, consumerOptional bool) payload.DAGWorkflowInput {
 	return payload.DAGWorkflowInput{
 		Nodes: []payload.DAGNode{
@@ -63,6 +71,7 @@ func TestDAGWorkflow_Artifacts_EndToEnd(t *testing.T) {
 	require.NoError(t, err)
 	defer raw.Close() //nolint:errcheck // test cleanup
 
+	registerArtifactActivity(env, raw)
 	env.OnActivity("StartContainerActivity", mock.Anything, mock.Anything).Return(
 		&payload.ContainerExecutionOutput{Success: true, ExitCode: 0, Duration: time.Second}, nil)
 
@@ -102,6 +111,7 @@ func TestDAGWorkflow_ArtifactDownloadFailure_NotOptional(t *testing.T) {
 	require.NoError(t, err)
 	defer raw.Close() //nolint:errcheck // test cleanup
 
+	registerArtifactActivity(env, raw)
 	env.OnActivity("StartContainerActivity", mock.Anything, mock.Anything).Return(
 		&payload.ContainerExecutionOutput{Success: true, ExitCode: 0, Duration: time.Second}, nil)
 
@@ -124,6 +134,7 @@ func TestDAGWorkflow_ArtifactDownloadFailure_Optional(t *testing.T) {
 	require.NoError(t, err)
 	defer raw.Close() //nolint:errcheck // test cleanup
 
+	registerArtifactActivity(env, raw)
 	env.OnActivity("StartContainerActivity", mock.Anything, mock.Anything).Return(
 		&payload.ContainerExecutionOutput{Success: true, ExitCode: 0, Duration: time.Second}, nil)
 
@@ -147,6 +158,7 @@ func TestDAGWorkflow_ArtifactUploadFailure_OnlyLogged(t *testing.T) {
 	require.NoError(t, err)
 	defer raw.Close() //nolint:errcheck // test cleanup
 
+	registerArtifactActivity(env, raw)
 	env.OnActivity("StartContainerActivity", mock.Anything, mock.Anything).Return(
 		&payload.ContainerExecutionOutput{Success: true, ExitCode: 0, Duration: time.Second}, nil)
 
@@ -167,3 +179,66 @@ func TestDAGWorkflow_ArtifactUploadFailure_OnlyLogged(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, keys)
 }
+
+func TestDAGWorkflow_ArtifactPresignedURL(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerContainerActivity(env)
+
+	local, err := store.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	raw, err := store.NewLocalPresigner(local, "http://artifacts.test", []byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+
+	var consumerEnv map[string]string
+	registerArtifactActivity(env, raw)
+	env.OnActivity("StartContainerActivity", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, in payload.ContainerExecutionInput) (*payload.ContainerExecutionOutput, error) {
+			if _, ok := in.Env["BINARY_URL"]; ok {
+				consumerEnv = in.Env
+			}
+			return &payload.ContainerExecutionOutput{Success: true, Duration: time.Second}, nil
+		})
+
+	src := filepath.Join(t.TempDir(), "binary")
+	require.NoError(t, os.WriteFile(src, []byte("binary-bytes"), 0o600))
+
+	input := dagWithArtifacts(src, "", false)
+	input.Nodes[1].Container.InputArtifacts[0].URLEnv = "BINARY_URL"
+
+	env.ExecuteWorkflow(withContainerArtifactStore(raw), input)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	require.NotNil(t, consumerEnv, "consumer should receive the URL in its environment")
+	assert.Contains(t, consumerEnv["BINARY_URL"], "http://artifacts.test/")
+	assert.Contains(t, consumerEnv["BINARY_URL"], "/producer/binary?")
+	assert.Contains(t, consumerEnv["BINARY_URL"], "X-Signature=")
+}
+
+func TestDAGWorkflow_ArtifactPresignedURL_UnsupportedStore(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerContainerActivity(env)
+
+	raw, err := store.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	registerArtifactActivity(env, raw)
+	env.OnActivity("StartContainerActivity", mock.Anything, mock.Anything).Return(
+		&payload.ContainerExecutionOutput{Success: true, ExitCode: 0, Duration: time.Second}, nil)
+
+	src := filepath.Join(t.TempDir(), "binary")
+	require.NoError(t, os.WriteFile(src, []byte("binary-bytes"), 0o600))
+
+	input := dagWithArtifacts(src, "", false)
+	input.Nodes[1].Container.InputArtifacts[0].URLEnv = "BINARY_URL"
+
+	env.ExecuteWorkflow(withContainerArtifactStore(raw), input)
+
+	require.True(t, env.IsWorkflowCompleted())
+	err = env.GetWorkflowError()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to presign artifact")
+}
@@ -0,0 +1,130 @@
+package workflow
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+	wf "go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/container/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+func TestDAGWorkflow_SubWorkflowInlinePipeline(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerContainerActivity(env)
+	env.RegisterWorkflowWithOptions(ContainerPipelineWorkflow, wf.RegisterOptions{Name: payload.PipelineWorkflowType})
+
+	var images []string
+	env.OnActivity("StartContainerActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.ContainerExecutionInput) (*payload.ContainerExecutionOutput, error) {
+			images = append(images, in.Image)
+			return &payload.ContainerExecutionOutput{Name: in.Name, Success: true}, nil
+		})
+
+	input := payload.DAGWorkflowInput{
+		Nodes: []payload.DAGNode{
+			{
+				Name: "checks",
+				SubWorkflow: &payload.SubWorkflowNode{
+					SubWorkflow: generic.SubWorkflow{
+						ParentClosePolicy: generic.ParentCloseAbandon,
+						Outputs:           []generic.SubWorkflowOutput{{Name: "passed", Path: "$.total_success"}},
+					},
+					Pipeline: &payload.PipelineInput{
+						Containers: []payload.ContainerExecutionInput{
+							{Image: "lint:latest"},
+							{Image: "test:latest"},
+						},
+						StopOnError: true,
+					},
+				},
+			},
+			{
+				Name:         "deploy",
+				Container:    payload.ExtendedContainerInput{ContainerExecutionInput: payload.ContainerExecutionInput{Image: "deploy:latest"}},
+				Dependencies: []string{"checks"},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(DAGWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.DAGWorkflowOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, []string{"lint:latest", "test:latest", "deploy:latest"}, images)
+	assert.Equal(t, "2", result.StepOutputs["checks"]["passed"])
+	assert.True(t, result.Results["checks"].Success)
+	assert.Contains(t, result.Results["checks"].Stdout, `"total_success":2`)
+}
+
+func TestDAGWorkflow_SubWorkflowChildError(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerContainerActivity(env)
+	env.RegisterWorkflowWithOptions(func(wf.Context) (string, error) {
+		return "", assert.AnError
+	}, wf.RegisterOptions{Name: "BrokenWorkflow"})
+
+	input := payload.DAGWorkflowInput{
+		FailFast: true,
+		Nodes: []payload.DAGNode{
+			{
+				Name:        "broken",
+				SubWorkflow: &payload.SubWorkflowNode{SubWorkflow: generic.SubWorkflow{WorkflowType: "BrokenWorkflow"}},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(DAGWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), "sub-workflow broken")
+}
+
+func TestDAGWorkflow_SubWorkflowOffloadsLargeResult(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerContainerActivity(env)
+
+	blob := strings.Repeat("x", childResultInlineLimit)
+	env.RegisterWorkflowWithOptions(func(wf.Context) (string, error) {
+		return blob, nil
+	}, wf.RegisterOptions{Name: "ReportWorkflow"})
+
+	raw := store.NewMemoryStore()
+	env.RegisterWorkflowWithOptions(withContainerArtifactStore(raw), wf.RegisterOptions{Name: "ArtifactDAGWorkflow"})
+	input := payload.DAGWorkflowInput{
+		Nodes: []payload.DAGNode{
+			{
+				Name:        "report",
+				SubWorkflow: &payload.SubWorkflowNode{SubWorkflow: generic.SubWorkflow{WorkflowType: "ReportWorkflow"}},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow("ArtifactDAGWorkflow", input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.DAGWorkflowOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	report := result.Results["report"]
+	assert.True(t, report.Success)
+	assert.Empty(t, report.Stdout)
+	require.NotEmpty(t, report.StdoutRef)
+
+	data, err := store.NewBytesStore(raw).Load(context.Background(), report.StdoutRef)
+	require.NoError(t, err)
+	assert.JSONEq(t, strconv.Quote(blob), string(data))
+}
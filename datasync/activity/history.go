@@ -0,0 +1,16 @@
+package activity
+
+import (
+	"context"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+)
+
+// NewRecordRunActivity returns an activity that stores a run's summary in
+// history. The sync workflow runs it after SyncData when the job has a
+// Store.
+func NewRecordRunActivity(history *datasync.RunHistory) func(context.Context, datasync.RunSummary) error {
+	return func(ctx context.Context, summary datasync.RunSummary) error {
+		return history.SaveSummary(ctx, summary)
+	}
+}
@@ -0,0 +1,81 @@
+package activity
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/internal/heartbeat"
+)
+
+// NewReplayActivity returns an activity that replays the dead letters of
+// jobName from queue through mapper and sink; see datasync.ReplayDeadLetters.
+// Pass the job's own mapper and sink, not ones wrapped with
+// datasync.WithDeadLetters: records that fail again are kept in the queue by
+// the replay itself.
+//
+// The activity heartbeats a datasync.ReplayResult with the running totals
+// after every batch. A retried attempt adds its results to the last
+// heartbeated ones and replays only what is left of input.Limit.
+func NewReplayActivity[T, U any](
+	jobName string,
+	queue datasync.DeadLetterQueue,
+	mapper datasync.Mapper[T, U],
+	sink datasync.Sink[U],
+) func(context.Context, payload.ReplayDeadLettersInput) (*payload.ReplayDeadLettersOutput, error) {
+	return func(ctx context.Context, input payload.ReplayDeadLettersInput) (*payload.ReplayDeadLettersOutput, error) {
+		var prev datasync.ReplayResult
+		limit := input.Limit
+		onBatch := func(datasync.ReplayResult) {}
+		if activity.IsActivity(ctx) {
+			if activity.HasHeartbeatDetails(ctx) {
+				_ = activity.GetHeartbeatDetails(ctx, &prev)
+			}
+			if limit > 0 {
+				limit -= prev.Replayed + prev.Failed
+				if limit <= 0 {
+					return replayOutput(jobName, prev), nil
+				}
+			}
+
+			var progress atomic.Pointer[datasync.ReplayResult]
+			progress.Store(&prev)
+			onBatch = func(r datasync.ReplayResult) {
+				total := addReplayResults(prev, r)
+				progress.Store(&total)
+				activity.RecordHeartbeat(ctx, total)
+			}
+
+			done := make(chan struct{})
+			defer close(done)
+			interval := heartbeat.Interval(activity.GetInfo(ctx).HeartbeatTimeout)
+			go heartbeat.LoopDetails(ctx, interval, func() any { return *progress.Load() }, done)
+		}
+
+		result, err := datasync.ReplayDeadLetters(ctx, queue, jobName, mapper, sink, limit, onBatch)
+		if err != nil {
+			return nil, err
+		}
+		return replayOutput(jobName, addReplayResults(prev, result)), nil
+	}
+}
+
+func addReplayResults(a, b datasync.ReplayResult) datasync.ReplayResult {
+	return datasync.ReplayResult{
+		Total:    a.Replayed + a.Failed + b.Total,
+		Replayed: a.Replayed + b.Replayed,
+		Failed:   a.Failed + b.Failed,
+	}
+}
+
+func replayOutput(jobName string, r datasync.ReplayResult) *payload.ReplayDeadLettersOutput {
+	return &payload.ReplayDeadLettersOutput{
+		JobName:  jobName,
+		Total:    r.Total,
+		Replayed: r.Replayed,
+		Failed:   r.Failed,
+	}
+}
@@ -0,0 +1,46 @@
+package activity
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+func TestReplayActivity_ResumesFromHeartbeat(t *testing.T) {
+	queue := datasync.NewStoreDeadLetterQueue(
+		store.NewTypedStore[datasync.DeadLetter](store.NewMemoryStore(), &store.JSONCodec[datasync.DeadLetter]{}))
+	for i := range 5 {
+		l, err := datasync.NewDeadLetter("orders", "", datasync.DeadLetterStageWrite, strconv.Itoa(i), "boom")
+		require.NoError(t, err)
+		require.NoError(t, queue.PutDeadLetters(context.Background(), []datasync.DeadLetter{l}))
+	}
+
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+	// An earlier attempt replayed 100 letters before it stopped.
+	testEnv.SetHeartbeatDetails(datasync.ReplayResult{Total: 100, Replayed: 100})
+
+	sink := &mockSink[string]{name: "dst", result: datasync.WriteResult{Inserted: 1}}
+	replay := NewReplayActivity("orders", queue, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(replay)
+
+	val, err := testEnv.ExecuteActivity(replay, payload.ReplayDeadLettersInput{Limit: 103})
+	require.NoError(t, err)
+
+	var output payload.ReplayDeadLettersOutput
+	require.NoError(t, val.Get(&output))
+	assert.Equal(t, payload.ReplayDeadLettersOutput{JobName: "orders", Total: 103, Replayed: 103}, output,
+		"only what is left of the limit is replayed")
+
+	left, err := queue.ListDeadLetters(context.Background(), "orders")
+	require.NoError(t, err)
+	assert.Len(t, left, 2)
+}
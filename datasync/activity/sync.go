@@ -12,8 +12,8 @@ import (
 	"go.temporal.io/sdk/activity"
 
 	"github.com/jasoet/go-wf/v2/datasync"
-	"github.com/jasoet/go-wf/v2/datasync/internal/heartbeat"
 	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/internal/heartbeat"
 )
 
 // ActivityInput is the activity input for the SyncData activity.
@@ -22,6 +22,8 @@ type ActivityInput struct {
 	SourceName string `json:"sourceName"`
 	SinkName   string `json:"sinkName"`
 	Params     any    `json:"params,omitempty"`
+	// DryRun plans the write instead of writing; see datasync.Planner.
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // ActivityOutput is the activity output for the SyncData activity.
@@ -30,8 +32,29 @@ type ActivityOutput struct {
 	Inserted     int           `json:"inserted"`
 	Updated      int           `json:"updated"`
 	Skipped      int           `json:"skipped"`
-	FetchTime    time.Duration `json:"fetchTime"`
-	WriteTime    time.Duration `json:"writeTime"`
+	Deleted      int           `json:"deleted,omitempty"` // Removed by a datasync.FinishingSink.
+	Pages        int           `json:"pages,omitempty"`   // Pages read from a PagedSource.
+	FetchTime    time.Duration `json:"fetchTime"`         // Not measured for a PagedSource.
+	WriteTime    time.Duration `json:"writeTime"`         // Not measured for a PagedSource.
+	// Plan is set by a dry run, which writes nothing.
+	Plan *payload.SyncPlan `json:"plan,omitempty"`
+	// Quality reports the data quality checks; see datasync.WithQualityChecks.
+	Quality *payload.QualityReport `json:"quality,omitempty"`
+	// Sinks breaks the counts down by sink name; see datasync.FanOutSink.
+	Sinks map[string]payload.SinkResult `json:"sinks,omitempty"`
+	// MapSkipped counts the records the mapper skipped; SkipReasons keeps
+	// the first datasync.MaxSkipReasons reasons.
+	MapSkipped  int      `json:"mapSkipped,omitempty"`
+	SkipReasons []string `json:"skipReasons,omitempty"`
+	// FinishSkipped says why the sink was not finished; see datasync.FinishRun.
+	FinishSkipped string `json:"finishSkipped,omitempty"`
+}
+
+// PageHeartbeat is the heartbeat detail SyncData records for a
+// datasync.PagedSource. A retried attempt resumes from Progress.
+type PageHeartbeat struct {
+	Phase    string                `json:"phase"`
+	Progress datasync.PageProgress `json:"progress"`
 }
 
 // Activities holds the source, mapper, and sink for a sync job's Temporal activities.
@@ -39,6 +62,9 @@ type Activities[T any, U any] struct {
 	source datasync.Source[T]
 	mapper datasync.Mapper[T, U]
 	sink   datasync.Sink[U]
+
+	history    *datasync.RunHistory
+	sampleSize int
 }
 
 // NewActivities creates a new Activities instance.
@@ -50,10 +76,35 @@ func NewActivities[T, U any](source datasync.Source[T], mapper datasync.Mapper[T
 	}
 }
 
-// SyncData fetches records from source, maps them, and writes to sink.
+// WithHistory makes SyncData record each run's mapper skip reasons and a
+// sample of up to sampleSize fetched records in history. Skip reasons are
+// only seen through a mapper wrapped with datasync.RecordSkips.
+func (a *Activities[T, U]) WithHistory(history *datasync.RunHistory, sampleSize int) *Activities[T, U] {
+	a.history = history
+	a.sampleSize = sampleSize
+	return a
+}
+
+// SyncData fetches records from source, maps them, and writes to sink. A
+// dry run plans the write with the sink's datasync.Planner instead, and
+// neither finishes the sink nor commits an incremental watermark.
 //
 //nolint:funlen // SyncData orchestrates heartbeat setup, fetch, map, and write — inherently multi-step.
 func (a *Activities[T, U]) SyncData(ctx context.Context, input ActivityInput) (*ActivityOutput, error) {
+	ctx = datasync.WithSyncRun(ctx, datasync.NewSyncRun())
+	if a.history != nil {
+		rec := datasync.NewRunRecorder(a.sampleSize)
+		ctx = datasync.WithRunRecorder(ctx, rec)
+		defer a.saveRecorder(ctx, input.JobName, rec)
+	}
+	if input.DryRun {
+		ctx = datasync.WithDryRun(ctx)
+	}
+
+	if paged, ok := a.source.(datasync.PagedSource[T]); ok {
+		return a.syncPages(ctx, input, paged)
+	}
+
 	var phase atomic.Pointer[string]
 	setPhase := func(p string) { phase.Store(&p) }
 	setPhase("starting")
@@ -82,7 +133,16 @@ func (a *Activities[T, U]) SyncData(ctx context.Context, input ActivityInput) (*
 	fetchLC := pkgotel.Layers.StartService(lc.Context(), "datasync", "Fetch",
 		pkgotel.F("source", input.SourceName))
 	setPhase("fetching")
-	records, err := a.source.Fetch(fetchLC.Context())
+	var changes *datasync.ChangeSet[T]
+	var records []T
+	var err error
+	if inc, ok := a.source.(datasync.IncrementalSource[T]); ok {
+		var cs datasync.ChangeSet[T]
+		cs, err = inc.FetchIncremental(fetchLC.Context(), input.JobName)
+		records, changes = cs.Records, &cs
+	} else {
+		records, err = a.source.Fetch(fetchLC.Context())
+	}
 	if err != nil {
 		//nolint:errcheck,gosec // we return the original error, not lc.Error's return
 		fetchLC.Error(err, "source fetch failed")
@@ -90,17 +150,35 @@ func (a *Activities[T, U]) SyncData(ctx context.Context, input ActivityInput) (*
 		recordFailure(ctx, start, attrs)
 		return nil, fmt.Errorf("source %s fetch failed: %w", a.source.Name(), err)
 	}
-	fetchLC.Success("fetch complete", pkgotel.F("records", len(records)))
+	if changes != nil {
+		fetchLC.Success("fetch complete", pkgotel.F("records", len(records)), pkgotel.F("since", changes.Since))
+	} else {
+		fetchLC.Success("fetch complete", pkgotel.F("records", len(records)))
+	}
 	fetchLC.End()
 	fetchTime := time.Since(fetchStart)
 
 	syncRecordsFetched.Add(ctx, int64(len(records)), metric.WithAttributes(attrs...))
+	datasync.RecordSample(ctx, records)
 	activity.RecordHeartbeat(ctx, fmt.Sprintf("fetched %d records", len(records)))
 
 	if len(records) == 0 {
+		quality, err := datasync.CheckRun(lc.Context(), a.sink, 0, nil)
+		if err != nil {
+			//nolint:errcheck,gosec // we return the original error, not lc.Error's return
+			lc.Error(err, "quality checks failed")
+			recordFailure(ctx, start, attrs)
+			return nil, fmt.Errorf("sink %s: %w", a.sink.Name(), err)
+		}
 		lc.Success("no records to sync")
 		recordSuccess(ctx, start, attrs)
-		return &ActivityOutput{FetchTime: fetchTime}, nil
+		out := &ActivityOutput{FetchTime: fetchTime}
+		if input.DryRun {
+			out.Plan = &payload.SyncPlan{Quality: quality}
+		} else {
+			out.Quality = quality
+		}
+		return out, nil
 	}
 
 	// === Map ===
@@ -108,7 +186,7 @@ func (a *Activities[T, U]) SyncData(ctx context.Context, input ActivityInput) (*
 		pkgotel.F("job", input.JobName),
 		pkgotel.F("records", len(records)))
 	setPhase("mapping")
-	mapped, err := a.mapper.Map(mapLC.Context(), records)
+	mr, err := datasync.MapRun(mapLC.Context(), a.mapper, records)
 	if err != nil {
 		//nolint:errcheck,gosec // we return the original error, not lc.Error's return
 		mapLC.Error(err, "mapper failed")
@@ -116,15 +194,30 @@ func (a *Activities[T, U]) SyncData(ctx context.Context, input ActivityInput) (*
 		recordFailure(ctx, start, attrs)
 		return nil, fmt.Errorf("mapper failed: %w", err)
 	}
-	mapLC.Success("map complete", pkgotel.F("mapped", len(mapped)))
+	mapped := mr.Records
+	mapLC.Success("map complete", pkgotel.F("mapped", len(mapped)), pkgotel.F("skipped", mr.Skipped))
 	mapLC.End()
 
+	// The run is one batch, so its count checks run before it is written.
+	counts, err := datasync.CheckCount(lc.Context(), a.sink, len(mapped))
+	if err != nil {
+		//nolint:errcheck,gosec // we return the original error, not lc.Error's return
+		lc.Error(err, "quality checks failed")
+		recordFailure(ctx, start, attrs)
+		return nil, fmt.Errorf("sink %s: %w", a.sink.Name(), err)
+	}
+
+	if input.DryRun {
+		return a.planWrite(ctx, lc, records, mapped, counts, fetchTime, start, attrs)
+	}
+
 	// === Write ===
 	writeStart := time.Now()
 	writeLC := pkgotel.Layers.StartRepository(lc.Context(), "datasync", "Write",
 		pkgotel.F("sink", input.SinkName),
 		pkgotel.F("records", len(mapped)))
 	setPhase("writing")
+	var finishSkipped string
 	wr, err := a.sink.Write(writeLC.Context(), mapped)
 	if err != nil {
 		//nolint:errcheck,gosec // we return the original error, not lc.Error's return
@@ -133,10 +226,40 @@ func (a *Activities[T, U]) SyncData(ctx context.Context, input ActivityInput) (*
 		recordFailure(ctx, start, attrs)
 		return nil, fmt.Errorf("sink %s write failed: %w", a.sink.Name(), err)
 	}
+	wr.Quality = payload.MergeQuality(wr.Quality, counts)
+	if err := datasync.SaveRunCount(writeLC.Context(), a.sink, len(mapped), wr.Quality); err != nil {
+		//nolint:errcheck,gosec // we return the original error, not lc.Error's return
+		writeLC.Error(err, "count baseline save failed")
+		writeLC.End()
+		recordFailure(ctx, start, attrs)
+		return nil, fmt.Errorf("sink %s: %w", a.sink.Name(), err)
+	}
+	if changes != nil {
+		// The watermark moves only once the records are written.
+		if err := changes.Commit(writeLC.Context()); err != nil {
+			//nolint:errcheck,gosec // we return the original error, not lc.Error's return
+			writeLC.Error(err, "watermark commit failed")
+			writeLC.End()
+			recordFailure(ctx, start, attrs)
+			return nil, fmt.Errorf("source %s commit watermark failed: %w", a.source.Name(), err)
+		}
+	} else {
+		fr, reason, err := datasync.FinishRun(writeLC.Context(), a.sink)
+		if err != nil {
+			//nolint:errcheck,gosec // we return the original error, not lc.Error's return
+			writeLC.Error(err, "sink finish failed")
+			writeLC.End()
+			recordFailure(ctx, start, attrs)
+			return nil, fmt.Errorf("sink %s finish failed: %w", a.sink.Name(), err)
+		}
+		wr.Add(fr)
+		finishSkipped = reason
+	}
 	writeLC.Success("write complete",
 		pkgotel.F("inserted", wr.Inserted),
 		pkgotel.F("updated", wr.Updated),
-		pkgotel.F("skipped", wr.Skipped))
+		pkgotel.F("skipped", wr.Skipped),
+		pkgotel.F("deleted", wr.Deleted))
 	writeLC.End()
 	writeTime := time.Since(writeStart)
 
@@ -149,16 +272,175 @@ func (a *Activities[T, U]) SyncData(ctx context.Context, input ActivityInput) (*
 
 	activity.RecordHeartbeat(ctx, fmt.Sprintf("wrote %d records", wr.Total()))
 
+	return &ActivityOutput{
+		TotalFetched:  len(records),
+		Inserted:      wr.Inserted,
+		Updated:       wr.Updated,
+		Skipped:       wr.Skipped,
+		Deleted:       wr.Deleted,
+		FetchTime:     fetchTime,
+		WriteTime:     writeTime,
+		Quality:       wr.Quality,
+		Sinks:         wr.Sinks,
+		MapSkipped:    mr.Skipped,
+		SkipReasons:   datasync.AddSkipReasons(nil, mr.SkipReasons),
+		FinishSkipped: finishSkipped,
+	}, nil
+}
+
+// planWrite is the write step of a dry run.
+func (a *Activities[T, U]) planWrite(
+	ctx context.Context,
+	lc *pkgotel.LayerContext,
+	records []T,
+	mapped []U,
+	counts *payload.QualityReport,
+	fetchTime time.Duration,
+	start time.Time,
+	attrs []attribute.KeyValue,
+) (*ActivityOutput, error) {
+	planStart := time.Now()
+	activity.RecordHeartbeat(ctx, fmt.Sprintf("planning %d records", len(mapped)))
+	plan, err := datasync.PlanWrite(lc.Context(), a.sink, mapped)
+	if err != nil {
+		//nolint:errcheck,gosec // we return the original error, not lc.Error's return
+		lc.Error(err, "sink plan failed")
+		recordFailure(ctx, start, attrs)
+		return nil, fmt.Errorf("sink %s plan failed: %w", a.sink.Name(), err)
+	}
+	plan.Quality = payload.MergeQuality(plan.Quality, counts)
+	recordSuccess(ctx, start, attrs)
+	lc.Success("dry run complete",
+		pkgotel.F("fetched", len(records)),
+		pkgotel.F("would_insert", plan.WouldInsert),
+		pkgotel.F("would_update", plan.WouldUpdate),
+		pkgotel.F("would_skip", plan.WouldSkip))
+
 	return &ActivityOutput{
 		TotalFetched: len(records),
-		Inserted:     wr.Inserted,
-		Updated:      wr.Updated,
-		Skipped:      wr.Skipped,
 		FetchTime:    fetchTime,
-		WriteTime:    writeTime,
+		WriteTime:    time.Since(planStart),
+		Plan:         &plan,
 	}, nil
 }
 
+// syncPages is SyncData for a PagedSource: it fetches, maps and writes one
+// page at a time, heartbeating a PageHeartbeat after every page, and resumes
+// from the last heartbeated page when the activity is retried.
+func (a *Activities[T, U]) syncPages(ctx context.Context, input ActivityInput, source datasync.PagedSource[T]) (*ActivityOutput, error) {
+	var from datasync.PageProgress
+	if activity.HasHeartbeatDetails(ctx) {
+		var hb PageHeartbeat
+		if err := activity.GetHeartbeatDetails(ctx, &hb); err == nil {
+			from = hb.Progress
+		}
+	}
+
+	var state atomic.Pointer[PageHeartbeat]
+	setState := func(p datasync.PageProgress) {
+		state.Store(&PageHeartbeat{Phase: fmt.Sprintf("syncing %s page %d", input.JobName, p.Pages+1), Progress: p})
+	}
+	setState(from)
+
+	interval := heartbeat.Interval(activity.GetInfo(ctx).HeartbeatTimeout)
+	done := make(chan struct{})
+	defer close(done)
+	go heartbeat.LoopDetails(ctx, interval, func() any { return *state.Load() }, done)
+
+	attrs := []attribute.KeyValue{
+		attribute.String("job", input.JobName),
+		attribute.String("source", input.SourceName),
+		attribute.String("sink", input.SinkName),
+	}
+	start := time.Now()
+
+	lc := pkgotel.Layers.StartOperations(ctx, "datasync", "ExecutePaged",
+		pkgotel.F("job", input.JobName),
+		pkgotel.F("source", input.SourceName),
+		pkgotel.F("sink", input.SinkName),
+		pkgotel.F("resume_cursor", from.Cursor))
+	defer lc.End()
+
+	if from.Pages > 0 {
+		// A resumed attempt did not write the earlier pages in this process,
+		// so a sink that tracks what it wrote would finish with a partial view.
+		datasync.MarkIncomplete(lc.Context(), fmt.Sprintf("resumed at page %d", from.Pages+1))
+	}
+
+	var finishSkipped string
+	last := from
+	progress, err := datasync.SyncPages(lc.Context(), source, a.mapper, a.sink, from, func(p datasync.PageProgress) {
+		syncRecordsFetched.Add(ctx, int64(p.TotalFetched-last.TotalFetched), metric.WithAttributes(attrs...))
+		syncRecordsWritten.Add(ctx, int64(p.WriteResult.Total()-last.WriteResult.Total()), metric.WithAttributes(attrs...))
+		last = p
+		setState(p)
+		activity.RecordHeartbeat(ctx, *state.Load())
+	})
+	if err != nil {
+		//nolint:errcheck,gosec // we return the original error, not lc.Error's return
+		lc.Error(err, "paged sync failed", pkgotel.F("pages", progress.Pages))
+		recordFailure(ctx, start, attrs)
+		return nil, err
+	}
+	if err := datasync.CheckPages(lc.Context(), a.sink, &progress); err != nil {
+		//nolint:errcheck,gosec // we return the original error, not lc.Error's return
+		lc.Error(err, "quality checks failed")
+		recordFailure(ctx, start, attrs)
+		return nil, fmt.Errorf("sink %s: %w", a.sink.Name(), err)
+	}
+
+	switch {
+	case input.DryRun:
+		if progress.Plan == nil {
+			progress.Plan = &payload.SyncPlan{}
+		}
+	case progress.TotalFetched > 0:
+		fr, reason, err := datasync.FinishRun(lc.Context(), a.sink)
+		if err != nil {
+			//nolint:errcheck,gosec // we return the original error, not lc.Error's return
+			lc.Error(err, "sink finish failed")
+			recordFailure(ctx, start, attrs)
+			return nil, fmt.Errorf("sink %s finish failed: %w", a.sink.Name(), err)
+		}
+		progress.WriteResult.Add(fr)
+		if reason != "" {
+			finishSkipped = reason
+			lc.Logger.Warn("sink finish skipped", pkgotel.F("reason", reason))
+		}
+	}
+
+	recordSuccess(ctx, start, attrs)
+	lc.Success("sync complete",
+		pkgotel.F("pages", progress.Pages),
+		pkgotel.F("fetched", progress.TotalFetched),
+		pkgotel.F("inserted", progress.WriteResult.Inserted))
+
+	return &ActivityOutput{
+		TotalFetched:  progress.TotalFetched,
+		Inserted:      progress.WriteResult.Inserted,
+		Updated:       progress.WriteResult.Updated,
+		Skipped:       progress.WriteResult.Skipped,
+		Deleted:       progress.WriteResult.Deleted,
+		Pages:         progress.Pages,
+		Plan:          progress.Plan,
+		Quality:       progress.WriteResult.Quality,
+		Sinks:         progress.WriteResult.Sinks,
+		MapSkipped:    progress.MapSkipped,
+		SkipReasons:   progress.SkipReasons,
+		FinishSkipped: finishSkipped,
+	}, nil
+}
+
+// saveRecorder stores what rec collected in the run history. History is
+// best effort: a failure is logged and does not fail the sync.
+func (a *Activities[T, U]) saveRecorder(ctx context.Context, jobName string, rec *datasync.RunRecorder) {
+	runID := activity.GetInfo(ctx).WorkflowExecution.RunID
+	if err := a.history.SaveRecorder(ctx, jobName, runID, rec); err != nil {
+		logger := pkgotel.NewLogHelper(ctx, pkgotel.ConfigFromContext(ctx), "datasync.history", jobName)
+		logger.Warn("run history not saved", pkgotel.F("run_id", runID), pkgotel.F("error", err.Error()))
+	}
+}
+
 // ToSyncExecutionOutput converts ActivityOutput to a payload.SyncExecutionOutput.
 func ToSyncExecutionOutput(jobName string, ao *ActivityOutput, processingTime time.Duration, err error) payload.SyncExecutionOutput {
 	if err != nil {
@@ -174,8 +456,15 @@ func ToSyncExecutionOutput(jobName string, ao *ActivityOutput, processingTime ti
 		Inserted:       ao.Inserted,
 		Updated:        ao.Updated,
 		Skipped:        ao.Skipped,
+		Deleted:        ao.Deleted,
 		ProcessingTime: processingTime,
 		Success:        true,
+		Plan:           ao.Plan,
+		Quality:        ao.Quality,
+		Sinks:          ao.Sinks,
+		MapSkipped:     ao.MapSkipped,
+		SkipReasons:    ao.SkipReasons,
+		FinishSkipped:  ao.FinishSkipped,
 	}
 }
 
@@ -17,6 +17,7 @@ import (
 	"go.temporal.io/sdk/worker"
 
 	"github.com/jasoet/go-wf/v2/datasync"
+	"github.com/jasoet/go-wf/v2/datasync/payload"
 )
 
 type mockSource[T any] struct {
@@ -288,3 +289,329 @@ func TestActivities_SyncData_HeartbeatsDuringSlowWrite(t *testing.T) {
 	}
 	assert.True(t, found, "expected at least one heartbeat payload to contain 'writing', got: %v", capturer.captured)
 }
+
+type pagedMockSource struct {
+	pages   [][]string
+	cursors []string
+}
+
+func (p *pagedMockSource) Name() string { return "paged" }
+func (p *pagedMockSource) Fetch(ctx context.Context) ([]string, error) {
+	return datasync.FetchAll[string](ctx, p)
+}
+
+func (p *pagedMockSource) FetchPage(_ context.Context, cursor string) ([]string, string, error) {
+	p.cursors = append(p.cursors, cursor)
+	i := 0
+	if cursor != "" {
+		_, _ = fmt.Sscanf(cursor, "%d", &i)
+	}
+	next := ""
+	if i+1 < len(p.pages) {
+		next = fmt.Sprint(i + 1)
+	}
+	return p.pages[i], next, nil
+}
+
+func TestActivities_SyncData_PagedSource(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	var (
+		mu       sync.Mutex
+		captured []PageHeartbeat
+	)
+	testEnv.SetOnActivityHeartbeatListener(func(_ *activity.Info, details converter.EncodedValues) {
+		var hb PageHeartbeat
+		if details.Get(&hb) == nil {
+			mu.Lock()
+			captured = append(captured, hb)
+			mu.Unlock()
+		}
+	})
+
+	source := &pagedMockSource{pages: [][]string{{"a", "b"}, {"c"}}}
+	sink := &mockSink[string]{name: "dst", result: datasync.WriteResult{Inserted: 1}}
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	val, err := testEnv.ExecuteActivity(activities.SyncData, ActivityInput{JobName: "paged"})
+	require.NoError(t, err)
+
+	var output ActivityOutput
+	require.NoError(t, val.Get(&output))
+	assert.Equal(t, 3, output.TotalFetched)
+	assert.Equal(t, 2, output.Pages)
+	assert.Equal(t, 2, output.Inserted, "one write per page")
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The SDK throttles heartbeats, so only the first page is sure to be seen.
+	require.NotEmpty(t, captured)
+	assert.Equal(t, 1, captured[0].Progress.Pages)
+	assert.Equal(t, "1", captured[0].Progress.Cursor)
+}
+
+func TestActivities_SyncData_PagedSourceResumesFromHeartbeat(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+	testEnv.SetHeartbeatDetails(PageHeartbeat{Progress: datasync.PageProgress{
+		Cursor:       "2",
+		Pages:        2,
+		TotalFetched: 4,
+		WriteResult:  datasync.WriteResult{Inserted: 4},
+	}})
+
+	source := &pagedMockSource{pages: [][]string{{"a", "b"}, {"c", "d"}, {"e"}}}
+	sink := &mockSink[string]{name: "dst", result: datasync.WriteResult{Inserted: 1}}
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	val, err := testEnv.ExecuteActivity(activities.SyncData, ActivityInput{JobName: "paged"})
+	require.NoError(t, err)
+
+	var output ActivityOutput
+	require.NoError(t, val.Get(&output))
+	assert.Equal(t, []string{"2"}, source.cursors, "only the remaining page is fetched")
+	assert.Equal(t, 5, output.TotalFetched)
+	assert.Equal(t, 3, output.Pages)
+	assert.Equal(t, 5, output.Inserted)
+}
+
+func TestActivities_SyncData_ResumedPagedSourceReportsSkippedFinish(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+	testEnv.SetHeartbeatDetails(PageHeartbeat{Progress: datasync.PageProgress{Cursor: "1", Pages: 1, TotalFetched: 1}})
+
+	source := &pagedMockSource{pages: [][]string{{"a"}, {"b"}}}
+	sink := &finishingMockSink{mockSink: mockSink[string]{name: "dst"}}
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	val, err := testEnv.ExecuteActivity(activities.SyncData, ActivityInput{JobName: "paged"})
+	require.NoError(t, err)
+
+	var output ActivityOutput
+	require.NoError(t, val.Get(&output))
+	assert.Zero(t, sink.finished)
+	assert.Equal(t, "resumed at page 2", output.FinishSkipped)
+}
+
+// finishingMockSink is a mockSink that counts Finish calls.
+type finishingMockSink struct {
+	mockSink[string]
+	finished int
+}
+
+func (s *finishingMockSink) Finish(context.Context) (datasync.WriteResult, error) {
+	s.finished++
+	return datasync.WriteResult{}, nil
+}
+
+// incrementalMockSource records whether its change set was committed.
+type incrementalMockSource struct {
+	records   []string
+	jobName   string
+	committed bool
+}
+
+func (s *incrementalMockSource) Name() string                              { return "inc" }
+func (s *incrementalMockSource) Fetch(_ context.Context) ([]string, error) { return s.records, nil }
+
+func (s *incrementalMockSource) FetchIncremental(_ context.Context, jobName string) (datasync.ChangeSet[string], error) {
+	s.jobName = jobName
+	return datasync.NewChangeSet(s.records, "since-1", func(context.Context) error {
+		s.committed = true
+		return nil
+	}), nil
+}
+
+func TestActivities_SyncData_IncrementalSourceCommitsAfterWrite(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	source := &incrementalMockSource{records: []string{"a", "b"}}
+	sink := &mockSink[string]{name: "dst", result: datasync.WriteResult{Updated: 2}}
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	val, err := testEnv.ExecuteActivity(activities.SyncData, ActivityInput{JobName: "orders"})
+	require.NoError(t, err)
+
+	var output ActivityOutput
+	require.NoError(t, val.Get(&output))
+	assert.Equal(t, 2, output.Updated)
+	assert.Equal(t, "orders", source.jobName, "the watermark is keyed by job name")
+	assert.True(t, source.committed)
+}
+
+func TestActivities_SyncData_IncrementalSourceNotCommittedOnWriteFailure(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	source := &incrementalMockSource{records: []string{"a"}}
+	sink := &mockSink[string]{name: "dst", err: fmt.Errorf("db down")}
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	_, err := testEnv.ExecuteActivity(activities.SyncData, ActivityInput{JobName: "orders"})
+	require.Error(t, err)
+	assert.False(t, source.committed)
+}
+
+// planningMockSink is a mockSink that plans every record as an update.
+type planningMockSink struct {
+	mockSink[string]
+	writes int
+}
+
+func (p *planningMockSink) Write(ctx context.Context, records []string) (datasync.WriteResult, error) {
+	p.writes++
+	return p.mockSink.Write(ctx, records)
+}
+
+func (p *planningMockSink) Plan(_ context.Context, records []string) (payload.SyncPlan, error) {
+	return payload.SyncPlan{WouldUpdate: len(records)}, nil
+}
+
+func TestActivities_SyncData_DryRun(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	source := &incrementalMockSource{records: []string{"a", "b"}}
+	sink := &planningMockSink{mockSink: mockSink[string]{name: "dst"}}
+
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	input := ActivityInput{JobName: "test", SourceName: "inc", SinkName: "dst", DryRun: true}
+	result, err := testEnv.ExecuteActivity(activities.SyncData, input)
+	require.NoError(t, err)
+
+	var output ActivityOutput
+	require.NoError(t, result.Get(&output))
+	assert.Equal(t, 2, output.TotalFetched)
+	require.NotNil(t, output.Plan)
+	assert.Equal(t, 2, output.Plan.WouldUpdate)
+	assert.Zero(t, sink.writes)
+	assert.False(t, source.committed, "a dry run does not commit the watermark")
+
+	out := ToSyncExecutionOutput("test", &output, time.Second, nil)
+	assert.Equal(t, output.Plan, out.Plan)
+}
+
+func TestActivities_SyncData_DryRunPagedSource(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	source := &pagedMockSource{pages: [][]string{{"a", "b"}, {"c"}}}
+	sink := &planningMockSink{mockSink: mockSink[string]{name: "dst"}}
+
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	input := ActivityInput{JobName: "test", SourceName: "paged", SinkName: "dst", DryRun: true}
+	result, err := testEnv.ExecuteActivity(activities.SyncData, input)
+	require.NoError(t, err)
+
+	var output ActivityOutput
+	require.NoError(t, result.Get(&output))
+	assert.Equal(t, 2, output.Pages)
+	require.NotNil(t, output.Plan)
+	assert.Equal(t, 3, output.Plan.WouldUpdate)
+	assert.Zero(t, output.Inserted)
+	assert.Zero(t, sink.writes)
+}
+
+func TestActivities_SyncData_QualityReport(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	source := &mockSource[string]{name: "src", records: []string{"a", "", "b"}}
+	checks := datasync.NewQualityChecks[string]().Record("non-empty", func(r *string) error {
+		if *r == "" {
+			return fmt.Errorf("empty value")
+		}
+		return nil
+	}, datasync.QualityWarn)
+	sink := datasync.WithQualityChecks[string]("test", &mockSink[string]{name: "dst", result: datasync.WriteResult{Inserted: 3}}, checks, nil)
+
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	input := ActivityInput{JobName: "test", SourceName: "src", SinkName: "dst"}
+	result, err := testEnv.ExecuteActivity(activities.SyncData, input)
+	require.NoError(t, err)
+
+	var output ActivityOutput
+	require.NoError(t, result.Get(&output))
+	require.NotNil(t, output.Quality)
+	require.Len(t, output.Quality.Checks, 1)
+	assert.Equal(t, 1, output.Quality.Checks[0].Violations)
+
+	out := ToSyncExecutionOutput("test", &output, time.Second, nil)
+	assert.Equal(t, output.Quality, out.Quality)
+}
+
+func TestActivities_SyncData_QualityChecksEmptyFetch(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	source := &mockSource[string]{name: "src", records: []string{}}
+	checks := datasync.NewQualityChecks[string]().MinCount(1, datasync.QualityFail)
+	sink := datasync.WithQualityChecks[string]("test", &mockSink[string]{name: "dst"}, checks, nil)
+
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	input := ActivityInput{JobName: "test", SourceName: "src", SinkName: "dst"}
+	_, err := testEnv.ExecuteActivity(activities.SyncData, input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "min-count")
+}
+
+func TestActivities_SyncData_CountChecksFailBeforeWrite(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	source := &mockSource[string]{name: "src", records: []string{"a", "b"}}
+	checks := datasync.NewQualityChecks[string]().MaxCount(1, datasync.QualityFail)
+	inner := &mockSink[string]{name: "dst", err: fmt.Errorf("write attempted")}
+	sink := datasync.WithQualityChecks[string]("test", inner, checks, nil)
+
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	_, err := testEnv.ExecuteActivity(activities.SyncData, ActivityInput{JobName: "test", SourceName: "src", SinkName: "dst"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max-count")
+	assert.NotContains(t, err.Error(), "write attempted", "the run fails before the sink is called")
+}
+
+func TestActivities_SyncData_FanOutSinkResults(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	source := &mockSource[string]{name: "src", records: []string{"a", "b"}}
+	sink := datasync.NewFanOutSink("fan-out",
+		datasync.FanOutTo[string](&mockSink[string]{name: "postgres", result: datasync.WriteResult{Inserted: 2}}),
+		datasync.FanOutTo[string](&mockSink[string]{name: "search", err: fmt.Errorf("index down")})).
+		BestEffort()
+
+	activities := NewActivities[string, string](source, datasync.IdentityMapper[string](), sink)
+	testEnv.RegisterActivity(activities.SyncData)
+
+	input := ActivityInput{JobName: "test", SourceName: "src", SinkName: "fan-out"}
+	result, err := testEnv.ExecuteActivity(activities.SyncData, input)
+	require.NoError(t, err)
+
+	var output ActivityOutput
+	require.NoError(t, result.Get(&output))
+	assert.Equal(t, 2, output.Inserted)
+
+	out := ToSyncExecutionOutput("test", &output, time.Second, nil)
+	assert.Equal(t, map[string]payload.SinkResult{
+		"postgres": {Inserted: 2},
+		"search":   {Error: "index down"},
+	}, out.Sinks)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/jasoet/go-wf/v2/datasync"
 	"github.com/jasoet/go-wf/v2/datasync/payload"
 	datasyncwf "github.com/jasoet/go-wf/v2/datasync/workflow"
+	generic "github.com/jasoet/go-wf/v2/workflow"
 	"github.com/jasoet/go-wf/v2/workflow/store"
 )
 
@@ -30,6 +31,10 @@ type SyncJobBuilder[T any, U any] struct {
 	retryBackoffCoefficient float64
 	retryMaxInterval        time.Duration
 	store                   store.RawStore
+	sampleSize              int
+	deadLetters             datasync.DeadLetterWriter
+	dryRun                  bool
+	quality                 *datasync.QualityChecks[U]
 }
 
 // NewSyncJobBuilder creates a new builder with the given job name.
@@ -103,12 +108,44 @@ func (b *SyncJobBuilder[T, U]) WithRetryMaxInterval(interval time.Duration) *Syn
 	return b
 }
 
-// WithStore sets the raw store for data persistence.
+// WithStore sets the store the job's run history is kept in; see
+// datasync.RunHistory.
 func (b *SyncJobBuilder[T, U]) WithStore(s store.RawStore) *SyncJobBuilder[T, U] {
 	b.store = s
 	return b
 }
 
+// WithRunSample keeps up to n fetched records of every run in the run
+// history. It requires WithStore.
+func (b *SyncJobBuilder[T, U]) WithRunSample(n int) *SyncJobBuilder[T, U] {
+	b.sampleSize = n
+	return b
+}
+
+// WithDeadLetters sends records that fail individually to dlq instead of
+// dropping them or failing the batch. A datasync.DeadLetterQueue also gets a
+// replay workflow; see datasyncwf.ReplayWorkflowName.
+func (b *SyncJobBuilder[T, U]) WithDeadLetters(dlq datasync.DeadLetterWriter) *SyncJobBuilder[T, U] {
+	b.deadLetters = dlq
+	return b
+}
+
+// WithQualityChecks checks mapped records before they are written; see
+// datasync.WithQualityChecks. Checks that quarantine records require
+// WithDeadLetters.
+func (b *SyncJobBuilder[T, U]) WithQualityChecks(checks *datasync.QualityChecks[U]) *SyncJobBuilder[T, U] {
+	b.quality = checks
+	return b
+}
+
+// WithDryRun makes every run of the job a dry run, which plans the write
+// instead of doing it; see datasync.Planner. To dry-run a single execution,
+// set DryRun on its payload.SyncExecutionInput instead.
+func (b *SyncJobBuilder[T, U]) WithDryRun() *SyncJobBuilder[T, U] {
+	b.dryRun = true
+	return b
+}
+
 // Build validates the configuration and returns a *job.Definition ready for
 // registration with a Temporal worker and execution via the job registry.
 func (b *SyncJobBuilder[T, U]) Build() (*job.Definition, error) {
@@ -127,6 +164,18 @@ func (b *SyncJobBuilder[T, U]) Build() (*job.Definition, error) {
 	if b.schedule <= 0 {
 		return nil, fmt.Errorf("schedule must be positive")
 	}
+	if b.sampleSize < 0 {
+		return nil, fmt.Errorf("run sample size must not be negative")
+	}
+	if b.sampleSize > 0 && b.store == nil {
+		return nil, fmt.Errorf("run sample requires a store")
+	}
+	if b.quality != nil && b.quality.Quarantines() && b.deadLetters == nil {
+		return nil, fmt.Errorf("quality checks that quarantine records require dead letters")
+	}
+	if b.dryRun && !datasync.CanPlan(b.sink) {
+		return nil, fmt.Errorf("dry run requires a sink that implements datasync.Planner")
+	}
 
 	j := datasync.Job[T, U]{
 		Name:                    b.name,
@@ -142,6 +191,10 @@ func (b *SyncJobBuilder[T, U]) Build() (*job.Definition, error) {
 		RetryBackoffCoefficient: b.retryBackoffCoefficient,
 		RetryMaxInterval:        b.retryMaxInterval,
 		Store:                   b.store,
+		SampleSize:              b.sampleSize,
+		DeadLetters:             b.deadLetters,
+		DryRun:                  b.dryRun,
+		Quality:                 b.quality,
 	}
 
 	return job.New(j.Name, datasyncwf.TaskQueue(j.Name),
@@ -149,6 +202,7 @@ func (b *SyncJobBuilder[T, U]) Build() (*job.Definition, error) {
 		job.WithExecute(func(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in any) (client.WorkflowRun, error) {
 			return c.ExecuteWorkflow(ctx, opts, j.Name, in)
 		}),
+		generic.WithWorkflowType(j.Name),
 		job.WithNewInput(func() any { return &payload.SyncExecutionInput{} }),
 		job.WithSchedule(&job.ScheduleSpec{Interval: b.schedule}),
 	)
@@ -96,3 +96,42 @@ func TestSyncJobBuilder_Build_InvalidSchedule(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "schedule")
 }
+
+func TestSyncJobBuilder_Build_RunSampleRequiresStore(t *testing.T) {
+	_, err := NewSyncJobBuilder[int, int]("test").
+		WithSource(&mockSource[int]{name: "src"}).
+		WithMapper(datasync.IdentityMapper[int]()).
+		WithSink(&mockSink[int]{name: "dst"}).
+		WithSchedule(time.Minute).
+		WithRunSample(10).
+		Build()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a store")
+}
+
+func TestSyncJobBuilder_Build_DryRunRequiresPlanner(t *testing.T) {
+	_, err := NewSyncJobBuilder[int, int]("test").
+		WithSource(&mockSource[int]{name: "src"}).
+		WithMapper(datasync.IdentityMapper[int]()).
+		WithSink(&mockSink[int]{name: "dst"}).
+		WithSchedule(time.Minute).
+		WithDryRun().
+		Build()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Planner")
+}
+
+func TestSyncJobBuilder_Build_QuarantineRequiresDeadLetters(t *testing.T) {
+	_, err := NewSyncJobBuilder[int, int]("test").
+		WithSource(&mockSource[int]{name: "src"}).
+		WithMapper(datasync.IdentityMapper[int]()).
+		WithSink(&mockSink[int]{name: "dst"}).
+		WithSchedule(time.Minute).
+		WithQualityChecks(datasync.NewQualityChecks[int]().Record("positive", func(r *int) error { return nil }, datasync.QualityQuarantine)).
+		Build()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dead letters")
+}
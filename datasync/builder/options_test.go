@@ -16,18 +16,24 @@ func TestSyncJobBuilder_OptionalSetters(t *testing.T) {
 	require.NoError(t, err)
 	t.Cleanup(func() { _ = local.Close() })
 
+	dlq := datasync.NewStoreDeadLetterQueue(store.NewTypedStore[datasync.DeadLetter](local, &store.JSONCodec[datasync.DeadLetter]{}))
+
 	b := NewSyncJobBuilder[int, int]("opts-job").
 		WithHeartbeatTimeout(15 * time.Second).
 		WithRetryInitialInterval(time.Second).
 		WithRetryBackoffCoefficient(3.5).
 		WithRetryMaxInterval(time.Minute).
-		WithStore(local)
+		WithStore(local).
+		WithRunSample(5).
+		WithDeadLetters(dlq)
 
 	assert.Equal(t, 15*time.Second, b.heartbeatTimeout)
 	assert.Equal(t, time.Second, b.retryInitialInterval)
 	assert.Equal(t, 3.5, b.retryBackoffCoefficient)
 	assert.Equal(t, time.Minute, b.retryMaxInterval)
 	assert.Same(t, local, b.store)
+	assert.Equal(t, 5, b.sampleSize)
+	assert.Same(t, dlq, b.deadLetters)
 }
 
 func TestSyncJobBuilder_Build_WithAllOptions(t *testing.T) {
@@ -89,6 +89,26 @@ func (d *DateChunkedSync[In, Out]) PartitionSleep(s time.Duration) *DateChunkedS
 	return d
 }
 
+func (d *DateChunkedSync[In, Out]) PartitionConcurrency(n int) *DateChunkedSync[In, Out] {
+	d.inner.PartitionConcurrency(n)
+	return d
+}
+
+func (d *DateChunkedSync[In, Out]) DeadLetters(dlq datasync.DeadLetterWriter) *DateChunkedSync[In, Out] {
+	d.inner.DeadLetters(dlq)
+	return d
+}
+
+func (d *DateChunkedSync[In, Out]) QualityChecks(checks *datasync.QualityChecks[Out]) *DateChunkedSync[In, Out] {
+	d.inner.QualityChecks(checks)
+	return d
+}
+
+func (d *DateChunkedSync[In, Out]) DryRun() *DateChunkedSync[In, Out] {
+	d.inner.DryRun()
+	return d
+}
+
 func (d *DateChunkedSync[In, Out]) ScheduleEvery(dur time.Duration) *DateChunkedSync[In, Out] {
 	d.inner.ScheduleEvery(dur)
 	return d
@@ -23,6 +23,7 @@ func TestChunkedSync_OptionSetters(t *testing.T) {
 		ActivityRetry(retry).
 		ActivityTimeouts(time.Minute, 10*time.Second).
 		MaxPartitionsPerExecution(25).
+		PartitionConcurrency(4).
 		Disabled(true)
 
 	assert.Equal(t, 2*time.Second, c.partitionSleep)
@@ -33,6 +34,7 @@ func TestChunkedSync_OptionSetters(t *testing.T) {
 	assert.Equal(t, time.Minute, c.startToClose)
 	assert.Equal(t, 10*time.Second, c.heartbeat)
 	assert.Equal(t, 25, c.maxPerExec)
+	assert.Equal(t, 4, c.concurrency)
 	assert.True(t, c.disabled)
 
 	c.ScheduleRaw(rawSpec)
@@ -51,6 +53,7 @@ func TestDateChunkedSync_OptionSetters(t *testing.T) {
 		ActivityRetry(retry).
 		ActivityTimeouts(2*time.Minute, 30*time.Second).
 		MaxPartitionsPerExecution(10).
+		PartitionConcurrency(3).
 		Disabled(true)
 
 	assert.Equal(t, time.Second, d.inner.partitionSleep)
@@ -58,6 +61,7 @@ func TestDateChunkedSync_OptionSetters(t *testing.T) {
 	assert.Equal(t, 5, d.inner.rateLimitOpts.MaxAttempts)
 	assert.Equal(t, int32(2), d.inner.activityRetry.MaximumAttempts)
 	assert.Equal(t, 2*time.Minute, d.inner.startToClose)
+	assert.Equal(t, 3, d.inner.concurrency)
 	assert.Equal(t, 30*time.Second, d.inner.heartbeat)
 	assert.Equal(t, 10, d.inner.maxPerExec)
 	assert.True(t, d.inner.disabled)
@@ -15,18 +15,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"regexp"
 	"time"
+
+	"github.com/jasoet/go-wf/v2/internal/sqlident"
 )
 
 // DefaultTable is the table name used when none is configured.
 const DefaultTable = "sync_watermark"
 
-// safeIdentifier guards the table name, which is interpolated into DDL and DML
-// because SQL placeholders cannot parameterise identifiers. Restricting it to
-// simple lowercase identifiers (optionally schema-qualified) keeps that safe.
-var safeIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
-
 // TimeTracker persists a time.Time cursor per job in Postgres. It satisfies
 // chunk.TimeProgressTracker.
 type TimeTracker struct {
@@ -56,7 +52,7 @@ func NewTimeTracker(db *sql.DB, opts ...Option) (*TimeTracker, error) {
 		opt(t)
 	}
 
-	if !safeIdentifier.MatchString(t.table) {
+	if !sqlident.Valid(t.table) {
 		return nil, fmt.Errorf("pgtracker: unsafe table name %q", t.table)
 	}
 	return t, nil
@@ -86,7 +82,7 @@ func (t *TimeTracker) EnsureSchema(ctx context.Context) error {
 // from "ran, and the cursor happens to be the zero time".
 func (t *TimeTracker) Cursor(ctx context.Context, jobName string) (time.Time, bool, error) {
 	//nolint:gosec // G201: the table name is interpolated because SQL cannot
-	// parameterise identifiers; it is validated against safeIdentifier at
+	// parameterise identifiers; it is validated with sqlident.Valid at
 	// construction. The job name is a real parameter.
 	query := fmt.Sprintf(`SELECT cursor_at FROM %s WHERE job_name = $1`, t.table)
 
@@ -1,6 +1,10 @@
 package chunk
 
-import "cmp"
+import (
+	"cmp"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
 
 // Partition is a half-open key range [Start, End) processed as one unit.
 type Partition[K cmp.Ordered] struct {
@@ -18,6 +22,12 @@ type PartitionResult[K cmp.Ordered] struct {
 	Inserted int `json:"inserted"`
 	Updated  int `json:"updated"`
 	Skipped  int `json:"skipped"`
+	// Plan is set by a dry run, which writes nothing.
+	Plan *payload.SyncPlan `json:"plan,omitempty"`
+	// Quality reports the data quality checks of the partition.
+	Quality *payload.QualityReport `json:"quality,omitempty"`
+	// Sinks breaks the counts down by sink name; see datasync.FanOutSink.
+	Sinks map[string]payload.SinkResult `json:"sinks,omitempty"`
 }
 
 // SyncResult is the workflow-level summary aggregating all partitions.
@@ -29,4 +39,29 @@ type SyncResult[K cmp.Ordered] struct {
 	TotalUpdated    int                  `json:"totalUpdated"`
 	TotalSkipped    int                  `json:"totalSkipped"`
 	Partitions      []PartitionResult[K] `json:"partitions,omitempty"`
+	// DryRun is set for a dry run, whose Plan adds up the partition plans.
+	DryRun bool              `json:"dryRun,omitempty"`
+	Plan   *payload.SyncPlan `json:"plan,omitempty"`
+	// Quality adds up the quality reports of the partitions.
+	Quality *payload.QualityReport `json:"quality,omitempty"`
+	// Sinks adds up the per-sink counts of the partitions.
+	Sinks map[string]payload.SinkResult `json:"sinks,omitempty"`
+}
+
+// add records one completed partition in the summary.
+func (r *SyncResult[K]) add(pr PartitionResult[K]) {
+	r.Partitions = append(r.Partitions, pr)
+	r.TotalPartitions++
+	r.TotalFetched += pr.Fetched
+	r.TotalInserted += pr.Inserted
+	r.TotalUpdated += pr.Updated
+	r.TotalSkipped += pr.Skipped
+	if pr.Plan != nil {
+		if r.Plan == nil {
+			r.Plan = &payload.SyncPlan{}
+		}
+		r.Plan.Add(*pr.Plan)
+	}
+	r.Quality = payload.MergeQuality(r.Quality, pr.Quality)
+	r.Sinks = payload.MergeSinkResults(r.Sinks, pr.Sinks)
 }
@@ -9,18 +9,21 @@ import (
 	"go.temporal.io/sdk/activity"
 
 	"github.com/jasoet/go-wf/v2/datasync"
-	"github.com/jasoet/go-wf/v2/datasync/internal/heartbeat"
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/internal/heartbeat"
 )
 
 // runPartitionInput is the activity input for a single partition.
 type runPartitionInput[K cmp.Ordered] struct {
 	Partition Partition[K] `json:"partition"`
 	JobName   string       `json:"jobName"`
+	DryRun    bool         `json:"dryRun,omitempty"`
 }
 
 // runPartition is the per-partition activity body. It records a starting
 // heartbeat, spawns a heartbeat goroutine that ticks every Interval(...) with
-// the current phase, then runs fetch -> map -> write.
+// the current phase, then runs fetch -> map -> write. A dry run plans the
+// write with the sink's datasync.Planner instead.
 //
 // Activity registration: callers wrap this in a closure that binds the
 // concrete In, Out, K parameters and registers it under "<jobName>.RunPartition".
@@ -48,20 +51,50 @@ func runPartition[In, Out any, K cmp.Ordered](
 	defer close(done)
 	go heartbeat.Loop(ctx, interval, heartbeat.PhaseMessage(prefix, &phase), done)
 
+	ctx = datasync.WithDeadLetterPartition(ctx, fmt.Sprintf("%v..%v", in.Partition.Start, in.Partition.End))
+	if in.DryRun {
+		ctx = datasync.WithDryRun(ctx)
+		result.Plan = &payload.SyncPlan{}
+	}
+
 	setPhase("fetching")
 	records, err := fetcher(ctx, in.Partition.Start, in.Partition.End)
 	if err != nil {
 		return result, fmt.Errorf("fetch %v..%v: %w", in.Partition.Start, in.Partition.End, err)
 	}
 	result.Fetched = len(records)
+
+	var mapped []Out
+	if result.Fetched > 0 {
+		setPhase("mapping")
+		if mapped, err = mapper.Map(ctx, records); err != nil {
+			return result, fmt.Errorf("map %v..%v: %w", in.Partition.Start, in.Partition.End, err)
+		}
+	}
+
+	// Count checks apply to each partition, before it is written.
+	counts, err := datasync.CheckCount(ctx, sink, len(mapped))
+	if err != nil {
+		return result, fmt.Errorf("check %v..%v: %w", in.Partition.Start, in.Partition.End, err)
+	}
 	if result.Fetched == 0 {
+		if in.DryRun {
+			result.Plan.Quality = counts
+		} else {
+			result.Quality = counts
+		}
 		return result, nil
 	}
 
-	setPhase("mapping")
-	mapped, err := mapper.Map(ctx, records)
-	if err != nil {
-		return result, fmt.Errorf("map %v..%v: %w", in.Partition.Start, in.Partition.End, err)
+	if in.DryRun {
+		setPhase("planning")
+		plan, err := datasync.PlanWrite(ctx, sink, mapped)
+		if err != nil {
+			return result, fmt.Errorf("plan %v..%v: %w", in.Partition.Start, in.Partition.End, err)
+		}
+		plan.Quality = payload.MergeQuality(plan.Quality, counts)
+		result.Plan = &plan
+		return result, nil
 	}
 
 	setPhase("writing")
@@ -72,5 +105,10 @@ func runPartition[In, Out any, K cmp.Ordered](
 	result.Inserted = wr.Inserted
 	result.Updated = wr.Updated
 	result.Skipped = wr.Skipped
+	result.Quality = payload.MergeQuality(wr.Quality, counts)
+	result.Sinks = wr.Sinks
+	if err := datasync.SaveRunCount(ctx, sink, len(mapped), result.Quality); err != nil {
+		return result, fmt.Errorf("write %v..%v: %w", in.Partition.Start, in.Partition.End, err)
+	}
 	return result, nil
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -17,6 +18,8 @@ import (
 	"go.temporal.io/sdk/worker"
 
 	"github.com/jasoet/go-wf/v2/datasync"
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
 )
 
 // heartbeatCaptureOutbound mirrors the pattern in datasync/activity/sync_test.go.
@@ -97,6 +100,29 @@ func (s *stubSink) Write(ctx context.Context, recs []string) (datasync.WriteResu
 	return datasync.WriteResult{Inserted: len(recs)}, nil
 }
 
+// stubPlanner is a stubSink that plans every record as an insert.
+type stubPlanner struct {
+	stubSink
+	writes int
+}
+
+func (s *stubPlanner) Write(ctx context.Context, recs []string) (datasync.WriteResult, error) {
+	s.writes++
+	return s.stubSink.Write(ctx, recs)
+}
+
+func (s *stubPlanner) Plan(_ context.Context, recs []string) (payload.SyncPlan, error) {
+	plan := payload.SyncPlan{WouldInsert: len(recs)}
+	for _, r := range recs {
+		diff, err := datasync.NewRecordDiff(r, nil, r)
+		if err != nil {
+			return plan, err
+		}
+		plan.Diffs = append(plan.Diffs, diff)
+	}
+	return plan, nil
+}
+
 func TestRunPartition_HappyPath(t *testing.T) {
 	env := &testsuite.WorkflowTestSuite{}
 	testEnv := env.NewTestActivityEnvironment()
@@ -126,6 +152,38 @@ func TestRunPartition_HappyPath(t *testing.T) {
 	assert.Equal(t, 2, got.Inserted)
 }
 
+func TestRunPartition_DryRunPlansWithoutWriting(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+	testEnv.SetOnActivityHeartbeatListener(func(_ *activity.Info, _ converter.EncodedValues) {})
+
+	fetcher := func(_ context.Context, _, _ int64) ([]string, error) {
+		return []string{"a", "b"}, nil
+	}
+	sink := &stubPlanner{stubSink: stubSink{name: "sink"}}
+
+	act := func(ctx context.Context, in runPartitionInput[int64]) (PartitionResult[int64], error) {
+		return runPartition[string, string, int64](ctx, in, fetcher, stubMapper{}, sink)
+	}
+	testEnv.RegisterActivity(act)
+
+	val, err := testEnv.ExecuteActivity(act, runPartitionInput[int64]{
+		Partition: Partition[int64]{Start: 0, End: 100},
+		JobName:   "job-x",
+		DryRun:    true,
+	})
+	require.NoError(t, err)
+	var got PartitionResult[int64]
+	require.NoError(t, val.Get(&got))
+	assert.Equal(t, 2, got.Fetched)
+	assert.Zero(t, got.Inserted)
+	assert.Zero(t, sink.writes)
+	require.NotNil(t, got.Plan)
+	assert.Equal(t, 2, got.Plan.WouldInsert)
+	require.Len(t, got.Plan.Diffs, 2)
+	assert.JSONEq(t, `"A"`, string(got.Plan.Diffs[0].After))
+}
+
 func TestRunPartition_EmptyFetchSkipsMapAndWrite(t *testing.T) {
 	env := &testsuite.WorkflowTestSuite{}
 	testEnv := env.NewTestActivityEnvironment()
@@ -218,3 +276,84 @@ func TestRunPartition_HeartbeatsDuringSlowWrite(t *testing.T) {
 	}
 	assert.True(t, foundWriting, "expected 'writing' phase in heartbeats; got %v", got)
 }
+
+func TestRunPartition_QualityQuarantine(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+	testEnv.SetOnActivityHeartbeatListener(func(_ *activity.Info, _ converter.EncodedValues) {})
+
+	fetcher := func(_ context.Context, _, _ int64) ([]string, error) {
+		return []string{"a", "a", "b"}, nil
+	}
+	queue := datasync.NewStoreDeadLetterQueue(store.NewTypedStore[datasync.DeadLetter](store.NewMemoryStore(), &store.JSONCodec[datasync.DeadLetter]{}))
+	checks := datasync.NewQualityChecks[string]().
+		Unique("unique", func(r *string) string { return *r }, datasync.QualityQuarantine)
+	sink := datasync.WithQualityChecks[string]("job-x", &stubSink{name: "sink"}, checks, queue)
+
+	act := func(ctx context.Context, in runPartitionInput[int64]) (PartitionResult[int64], error) {
+		return runPartition[string, string, int64](ctx, in, fetcher, stubMapper{}, sink)
+	}
+	testEnv.RegisterActivity(act)
+
+	val, err := testEnv.ExecuteActivity(act, runPartitionInput[int64]{
+		Partition: Partition[int64]{Start: 0, End: 100},
+		JobName:   "job-x",
+	})
+	require.NoError(t, err)
+	var got PartitionResult[int64]
+	require.NoError(t, val.Get(&got))
+	assert.Equal(t, 2, got.Inserted)
+	require.NotNil(t, got.Quality)
+	assert.Equal(t, 1, got.Quality.Quarantined)
+
+	letters, err := queue.ListDeadLetters(context.Background(), "job-x")
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, "0..100", letters[0].Partition)
+}
+
+func TestRunPartition_CountChecksPerPartition(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+	testEnv.SetOnActivityHeartbeatListener(func(_ *activity.Info, _ converter.EncodedValues) {})
+
+	var fetched []string
+	fetcher := func(_ context.Context, _, _ int64) ([]string, error) {
+		return fetched, nil
+	}
+	var writes atomic.Int32
+	checks := datasync.NewQualityChecks[string]().
+		MinCount(1, datasync.QualityWarn).
+		MaxCount(2, datasync.QualityFail)
+	sink := datasync.WithQualityChecks[string]("job-x", &countingSink{writes: &writes}, checks, nil)
+
+	act := func(ctx context.Context, in runPartitionInput[int64]) (PartitionResult[int64], error) {
+		return runPartition[string, string, int64](ctx, in, fetcher, stubMapper{}, sink)
+	}
+	testEnv.RegisterActivity(act)
+	in := runPartitionInput[int64]{Partition: Partition[int64]{Start: 0, End: 100}, JobName: "job-x"}
+
+	val, err := testEnv.ExecuteActivity(act, in)
+	require.NoError(t, err)
+	var got PartitionResult[int64]
+	require.NoError(t, val.Get(&got))
+	require.NotNil(t, got.Quality)
+	assert.Equal(t, 1, got.Quality.Checks[0].Violations, "an empty partition is checked too")
+
+	fetched = []string{"a", "b", "c"}
+	_, err = testEnv.ExecuteActivity(act, in)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max-count")
+	assert.Zero(t, writes.Load(), "the partition fails before it is written")
+}
+
+// countingSink counts Write calls.
+type countingSink struct {
+	writes *atomic.Int32
+}
+
+func (s *countingSink) Name() string { return "counting" }
+func (s *countingSink) Write(_ context.Context, recs []string) (datasync.WriteResult, error) {
+	s.writes.Add(1)
+	return datasync.WriteResult{Inserted: len(recs)}, nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/jasoet/go-wf/v2/datasync"
 	"github.com/jasoet/go-wf/v2/datasync/payload"
 	datasyncwf "github.com/jasoet/go-wf/v2/datasync/workflow"
+	generic "github.com/jasoet/go-wf/v2/workflow"
 )
 
 const (
@@ -60,7 +61,11 @@ type ChunkedSync[In, Out any, K cmp.Ordered] struct {
 	startToClose   time.Duration
 	heartbeat      time.Duration
 	maxPerExec     int
+	concurrency    int
+	deadLetters    datasync.DeadLetterWriter
 	disabled       bool
+	dryRun         bool
+	quality        *datasync.QualityChecks[Out]
 }
 
 // NewChunkedSync starts a builder for a job named name. The name appears in
@@ -99,6 +104,45 @@ func (c *ChunkedSync[In, Out, K]) PartitionSleep(d time.Duration) *ChunkedSync[I
 	return c
 }
 
+// PartitionConcurrency runs up to n partitions at once; n <= 1 keeps them
+// sequential. The tracker cursor still only advances over the contiguous
+// prefix of completed partitions, so a failed partition is retried by the
+// next run even when later partitions succeeded. PartitionSleep applies
+// between the partitions of each of the n lanes, and the rate-limit retry
+// wraps every fetch as before.
+func (c *ChunkedSync[In, Out, K]) PartitionConcurrency(n int) *ChunkedSync[In, Out, K] {
+	c.concurrency = n
+	return c
+}
+
+// DeadLetters sends records that fail individually to dlq, tagged with their
+// partition; see datasync.WithDeadLetters. A datasync.DeadLetterQueue also
+// gets a replay workflow, registered under datasyncwf.ReplayWorkflowName.
+func (c *ChunkedSync[In, Out, K]) DeadLetters(dlq datasync.DeadLetterWriter) *ChunkedSync[In, Out, K] {
+	c.deadLetters = dlq
+	return c
+}
+
+// QualityChecks checks each partition's mapped records before they are
+// written; see datasync.WithQualityChecks. Batch assertions and count
+// checks (MinCount, MaxCount) apply per partition, before it is written,
+// and CountDrift compares each partition with the last one written. Checks
+// that quarantine records require DeadLetters.
+func (c *ChunkedSync[In, Out, K]) QualityChecks(checks *datasync.QualityChecks[Out]) *ChunkedSync[In, Out, K] {
+	c.quality = checks
+	return c
+}
+
+// DryRun makes every execution a dry run: partitions are fetched and mapped,
+// and the sink, which must implement datasync.Planner, plans the write
+// instead of doing it. The tracker cursor is read but never advanced, and
+// only the first MaxPartitionsPerExecution partitions are planned. A single
+// execution can also be a dry run through payload.SyncExecutionInput.DryRun.
+func (c *ChunkedSync[In, Out, K]) DryRun() *ChunkedSync[In, Out, K] {
+	c.dryRun = true
+	return c
+}
+
 // ScheduleEvery configures the workflow to fire at fixed intervals.
 func (c *ChunkedSync[In, Out, K]) ScheduleEvery(d time.Duration) *ChunkedSync[In, Out, K] {
 	c.schedule = &job.ScheduleSpec{Interval: d}
@@ -166,6 +210,12 @@ func (c *ChunkedSync[In, Out, K]) buildValidate() {
 	if c.maxPerExec > 0 && c.tracker == nil {
 		panic(fmt.Sprintf("chunk.ChunkedSync(%q).Build: MaxPartitionsPerExecution requires WithTracker — without a tracker, the workflow re-processes the same partitions forever", c.name))
 	}
+	if c.quality != nil && c.quality.Quarantines() && c.deadLetters == nil {
+		panic(fmt.Sprintf("chunk.ChunkedSync(%q).Build: QualityChecks that quarantine records require DeadLetters", c.name))
+	}
+	if c.dryRun && !datasync.CanPlan(c.sink) {
+		panic(fmt.Sprintf("chunk.ChunkedSync(%q).Build: DryRun requires a Sink that implements datasync.Planner", c.name))
+	}
 }
 
 // buildActivityOptions resolves activity options from builder fields, applying defaults.
@@ -217,6 +267,14 @@ func (c *ChunkedSync[In, Out, K]) Build() (*job.Definition, error) {
 		fetcher = WithRateLimitRetry[In, K](c.fetcher, *c.rateLimitOpts)
 	}
 	mapper, sink, tracker, partitioner := c.mapper, c.sink, c.tracker, c.partitioner
+	if c.deadLetters != nil {
+		mapper, sink = datasync.WithDeadLetters(jobName, c.mapper, c.sink, c.deadLetters)
+	}
+	if c.quality != nil {
+		sink = datasync.WithQualityChecks(jobName, sink, c.quality, c.deadLetters)
+	}
+	replayQueue, _ := c.deadLetters.(datasync.DeadLetterQueue)
+	rawMapper, rawSink := c.mapper, c.sink
 
 	partitionActivityOptions, partitionsListOptions := c.buildActivityOptions()
 
@@ -251,6 +309,9 @@ func (c *ChunkedSync[In, Out, K]) Build() (*job.Definition, error) {
 		partitionSleep:            c.partitionSleep,
 		hasTracker:                tracker != nil,
 		maxPerExec:                c.maxPerExec,
+		concurrency:               c.concurrency,
+		dryRun:                    c.dryRun,
+		canPlan:                   datasync.CanPlan(sink),
 	}
 
 	schedule := c.schedule
@@ -265,10 +326,14 @@ func (c *ChunkedSync[In, Out, K]) Build() (*job.Definition, error) {
 				w.RegisterActivityWithOptions(readCursorActFn, activity.RegisterOptions{Name: readCursorActName})
 				w.RegisterActivityWithOptions(advanceCursorActFn, activity.RegisterOptions{Name: advanceCursorActName})
 			}
+			if replayQueue != nil {
+				datasyncwf.RegisterReplay(w, jobName, replayQueue, rawMapper, rawSink)
+			}
 		}),
 		job.WithExecute(func(ctx context.Context, c sdkclient.Client, sdkOpts sdkclient.StartWorkflowOptions, in any) (sdkclient.WorkflowRun, error) {
 			return c.ExecuteWorkflow(ctx, sdkOpts, jobName, in)
 		}),
+		generic.WithWorkflowType(jobName),
 		job.WithNewInput(func() any { return &payload.SyncExecutionInput{} }),
 	}
 	if schedule != nil {
@@ -294,11 +359,22 @@ type chunkedSyncWorkflow[In, Out any, K cmp.Ordered] struct {
 	partitionSleep            time.Duration
 	hasTracker                bool
 	maxPerExec                int
+	concurrency               int
+	dryRun                    bool
+	canPlan                   bool
 }
 
 // run is the Temporal workflow function.
 func (s chunkedSyncWorkflow[In, Out, K]) run(ctx workflow.Context, input payload.SyncExecutionInput) (SyncResult[K], error) {
 	summary := SyncResult[K]{JobName: s.jobName}
+	if s.dryRun || input.DryRun {
+		if !s.canPlan {
+			return summary, fmt.Errorf("dry run: %w", datasync.ErrDryRunUnsupported)
+		}
+		s.dryRun = true // s is a copy
+		summary.DryRun = true
+		summary.Plan = &payload.SyncPlan{}
+	}
 
 	listCtx := workflow.WithActivityOptions(ctx, s.partitionsListOptions)
 	var parts []Partition[K]
@@ -337,6 +413,25 @@ func (s chunkedSyncWorkflow[In, Out, K]) run(ctx workflow.Context, input payload
 		deferred = true
 	}
 
+	if s.concurrency > 1 {
+		if err := s.runConcurrent(ctx, parts, &summary); err != nil {
+			return summary, err
+		}
+	} else if err := s.runSequential(ctx, parts, &summary); err != nil {
+		return summary, err
+	}
+
+	// A dry run leaves the cursor alone, so continuing would plan the same
+	// partitions again.
+	if deferred && !s.dryRun {
+		return summary, workflow.NewContinueAsNewError(ctx, s.jobName, input)
+	}
+	return summary, nil
+}
+
+// runSequential processes parts one after another, advancing the cursor
+// after each.
+func (s chunkedSyncWorkflow[In, Out, K]) runSequential(ctx workflow.Context, parts []Partition[K], summary *SyncResult[K]) error {
 	var cursorAdvCtx workflow.Context
 	if s.hasTracker {
 		cursorAdvCtx = workflow.WithActivityOptions(ctx, defaultCursorActivityOptions)
@@ -347,31 +442,106 @@ func (s chunkedSyncWorkflow[In, Out, K]) run(ctx workflow.Context, input payload
 		if err := workflow.ExecuteActivity(partCtx, s.runPartitionActivityName, runPartitionInput[K]{
 			Partition: p,
 			JobName:   s.jobName,
+			DryRun:    s.dryRun,
 		}).Get(partCtx, &pr); err != nil {
-			return summary, fmt.Errorf("partition %v..%v: %w", p.Start, p.End, err)
+			return fmt.Errorf("partition %v..%v: %w", p.Start, p.End, err)
 		}
-		summary.Partitions = append(summary.Partitions, pr)
-		summary.TotalPartitions++
-		summary.TotalFetched += pr.Fetched
-		summary.TotalInserted += pr.Inserted
-		summary.TotalUpdated += pr.Updated
-		summary.TotalSkipped += pr.Skipped
-
-		if s.hasTracker {
+		summary.add(pr)
+
+		if s.hasTracker && !s.dryRun {
 			if err := workflow.ExecuteActivity(cursorAdvCtx, s.advanceCursorActivityName, p.End).Get(cursorAdvCtx, nil); err != nil {
-				return summary, fmt.Errorf("advance cursor: %w", err)
+				return fmt.Errorf("advance cursor: %w", err)
 			}
 		}
 
 		if i < len(parts)-1 && s.partitionSleep > 0 {
 			if err := workflow.Sleep(ctx, s.partitionSleep); err != nil {
-				return summary, fmt.Errorf("partition-sleep: %w", err)
+				return fmt.Errorf("partition-sleep: %w", err)
 			}
 		}
 	}
+	return nil
+}
 
-	if deferred {
-		return summary, workflow.NewContinueAsNewError(ctx, s.jobName, input)
+// runConcurrent processes parts in up to s.concurrency lanes. Each lane
+// takes the next unstarted partition and sleeps partitionSleep between its
+// partitions; no new partitions start after a failure. The cursor advances
+// only to the end of the contiguous completed prefix. Completed partitions
+// are reported in partition order.
+func (s chunkedSyncWorkflow[In, Out, K]) runConcurrent(ctx workflow.Context, parts []Partition[K], summary *SyncResult[K]) error {
+	partCtx := workflow.WithActivityOptions(ctx, s.partitionActivityOptions)
+	results := make([]*PartitionResult[K], len(parts))
+	next, running := 0, 0
+	var partErr error
+
+	lanes := min(s.concurrency, len(parts))
+	for range lanes {
+		running++
+		workflow.Go(ctx, func(gctx workflow.Context) {
+			defer func() { running-- }()
+			for partErr == nil && next < len(parts) {
+				i := next
+				next++
+				p := parts[i]
+				var pr PartitionResult[K]
+				if err := workflow.ExecuteActivity(partCtx, s.runPartitionActivityName, runPartitionInput[K]{
+					Partition: p,
+					JobName:   s.jobName,
+					DryRun:    s.dryRun,
+				}).Get(gctx, &pr); err != nil {
+					if partErr == nil {
+						partErr = fmt.Errorf("partition %v..%v: %w", p.Start, p.End, err)
+					}
+					return
+				}
+				results[i] = &pr
+
+				if next < len(parts) && s.partitionSleep > 0 {
+					if err := workflow.Sleep(gctx, s.partitionSleep); err != nil {
+						if partErr == nil {
+							partErr = fmt.Errorf("partition-sleep: %w", err)
+						}
+						return
+					}
+				}
+			}
+		})
 	}
-	return summary, nil
+
+	var cursorAdvCtx workflow.Context
+	if s.hasTracker {
+		cursorAdvCtx = workflow.WithActivityOptions(ctx, defaultCursorActivityOptions)
+	}
+	committed := 0
+	var cursorErr error
+	for {
+		if err := workflow.Await(ctx, func() bool {
+			return running == 0 || (committed < len(parts) && results[committed] != nil)
+		}); err != nil {
+			return err
+		}
+		prefix := committed
+		for prefix < len(parts) && results[prefix] != nil {
+			prefix++
+		}
+		if prefix == committed {
+			break // running == 0 and the prefix cannot grow any further
+		}
+		if s.hasTracker && !s.dryRun && cursorErr == nil {
+			if err := workflow.ExecuteActivity(cursorAdvCtx, s.advanceCursorActivityName, parts[prefix-1].End).Get(cursorAdvCtx, nil); err != nil {
+				cursorErr = fmt.Errorf("advance cursor: %w", err)
+				if partErr == nil {
+					partErr = cursorErr
+				}
+			}
+		}
+		committed = prefix
+	}
+
+	for _, pr := range results {
+		if pr != nil {
+			summary.add(*pr)
+		}
+	}
+	return partErr
 }
@@ -3,6 +3,7 @@ package chunk
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -327,6 +328,79 @@ func TestChunkedSync_Build_RequiresTrackerWhenMaxPerExecSet(t *testing.T) {
 		Build()
 }
 
+func TestChunkedSync_Build_DryRunRequiresPlanner(t *testing.T) {
+	defer func() { assert.NotNil(t, recover()) }()
+	_, _ = NewChunkedSync[string, string, int64]("job-x").
+		Partitioner(&stubPartitioner{}).
+		Fetcher(func(_ context.Context, _, _ int64) ([]string, error) { return nil, nil }).
+		Mapper(datasync.IdentityMapper[string]()).
+		Sink(&stubSink{name: "sink"}).
+		DryRun().
+		Build()
+}
+
+func TestChunkedSync_Workflow_DryRun_PlansWithoutAdvancingCursor(t *testing.T) {
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestWorkflowEnvironment()
+	registerStubActivities(env)
+
+	parts := []Partition[int64]{{Start: 0, End: 10}, {Start: 10, End: 20}, {Start: 20, End: 30}}
+	env.OnActivity("job-x.Partitions", mock.Anything).Return(parts, nil)
+	env.OnActivity("job-x.ReadCursor", mock.Anything, "job-x").
+		Return(cursorResult[int64]{Cursor: 10, Exists: true}, nil)
+	env.OnActivity("job-x.RunPartition", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in runPartitionInput[int64]) (PartitionResult[int64], error) {
+			if !in.DryRun {
+				return PartitionResult[int64]{}, errors.New("expected a dry run")
+			}
+			return PartitionResult[int64]{
+				Start: in.Partition.Start, End: in.Partition.End, Fetched: 3,
+				Plan: &payload.SyncPlan{WouldInsert: 1, WouldUpdate: 1, WouldSkip: 1},
+			}, nil
+		})
+
+	wf := chunkedSyncWorkflow[string, string, int64]{
+		jobName:                   "job-x",
+		partitionsActivityName:    "job-x.Partitions",
+		runPartitionActivityName:  "job-x.RunPartition",
+		readCursorActivityName:    "job-x.ReadCursor",
+		advanceCursorActivityName: "job-x.AdvanceCursor",
+		partitionActivityOptions:  workflow.ActivityOptions{StartToCloseTimeout: time.Minute, RetryPolicy: &temporal.RetryPolicy{MaximumAttempts: 1}},
+		partitionsListOptions:     workflow.ActivityOptions{StartToCloseTimeout: 30 * time.Second, RetryPolicy: &temporal.RetryPolicy{MaximumAttempts: 1}},
+		hasTracker:                true,
+		maxPerExec:                1,
+		canPlan:                   true,
+	}
+	env.RegisterWorkflowWithOptions(wf.run, workflow.RegisterOptions{Name: "job-x"})
+	env.ExecuteWorkflow("job-x", payload.SyncExecutionInput{JobName: "job-x", DryRun: true})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError(), "a dry run must not continue as new")
+	env.AssertNotCalled(t, "job-x.AdvanceCursor", mock.Anything, mock.Anything)
+
+	var res SyncResult[int64]
+	require.NoError(t, env.GetWorkflowResult(&res))
+	assert.True(t, res.DryRun)
+	assert.Equal(t, 1, res.TotalPartitions)
+	require.NotNil(t, res.Plan)
+	assert.Equal(t, payload.SyncPlan{WouldInsert: 1, WouldUpdate: 1, WouldSkip: 1}, *res.Plan)
+}
+
+func TestChunkedSync_Workflow_DryRun_RequiresPlanner(t *testing.T) {
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestWorkflowEnvironment()
+	registerStubActivities(env)
+
+	wf := chunkedSyncWorkflow[string, string, int64]{
+		jobName:                "job-x",
+		partitionsActivityName: "job-x.Partitions",
+		partitionsListOptions:  workflow.ActivityOptions{StartToCloseTimeout: 30 * time.Second},
+	}
+	env.RegisterWorkflowWithOptions(wf.run, workflow.RegisterOptions{Name: "job-x"})
+	env.ExecuteWorkflow("job-x", payload.SyncExecutionInput{JobName: "job-x", DryRun: true})
+	require.True(t, env.IsWorkflowCompleted())
+	require.ErrorContains(t, env.GetWorkflowError(), datasync.ErrDryRunUnsupported.Error())
+}
+
 func TestChunkedSync_Workflow_PartitionSleep_DelaysBetweenPartitions(t *testing.T) {
 	suite := &testsuite.WorkflowTestSuite{}
 	env := suite.NewTestWorkflowEnvironment()
@@ -358,6 +432,114 @@ func TestChunkedSync_Workflow_PartitionSleep_DelaysBetweenPartitions(t *testing.
 	assert.Equal(t, 2, result.TotalPartitions)
 }
 
+func concurrentTestWorkflow(concurrency int) chunkedSyncWorkflow[string, string, int64] {
+	return chunkedSyncWorkflow[string, string, int64]{
+		jobName:                   "job-x",
+		partitionsActivityName:    "job-x.Partitions",
+		runPartitionActivityName:  "job-x.RunPartition",
+		readCursorActivityName:    "job-x.ReadCursor",
+		advanceCursorActivityName: "job-x.AdvanceCursor",
+		partitionActivityOptions:  workflow.ActivityOptions{StartToCloseTimeout: time.Minute, RetryPolicy: &temporal.RetryPolicy{MaximumAttempts: 1}},
+		partitionsListOptions:     workflow.ActivityOptions{StartToCloseTimeout: 30 * time.Second, RetryPolicy: &temporal.RetryPolicy{MaximumAttempts: 1}},
+		hasTracker:                true,
+		concurrency:               concurrency,
+	}
+}
+
+func TestChunkedSync_Workflow_PartitionConcurrency_RunsInParallel(t *testing.T) {
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestWorkflowEnvironment()
+	registerStubActivities(env)
+
+	parts := []Partition[int64]{{0, 10}, {10, 20}, {20, 30}, {30, 40}, {40, 50}}
+	env.OnActivity("job-x.Partitions", mock.Anything).Return(parts, nil)
+	env.OnActivity("job-x.ReadCursor", mock.Anything, "job-x").Return(cursorResult[int64]{}, nil)
+	var mu sync.Mutex
+	advanced := []int64{}
+	env.OnActivity("job-x.AdvanceCursor", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, c int64) error {
+			mu.Lock()
+			defer mu.Unlock()
+			advanced = append(advanced, c)
+			return nil
+		})
+	inFlight, maxInFlight := 0, 0
+	env.OnActivity("job-x.RunPartition", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in runPartitionInput[int64]) (PartitionResult[int64], error) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return PartitionResult[int64]{Start: in.Partition.Start, End: in.Partition.End, Fetched: 1, Inserted: 1}, nil
+		})
+
+	wf := concurrentTestWorkflow(3)
+	env.RegisterWorkflowWithOptions(wf.run, workflow.RegisterOptions{Name: "job-x"})
+
+	env.ExecuteWorkflow("job-x", payload.SyncExecutionInput{JobName: "job-x"})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result SyncResult[int64]
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, 5, result.TotalPartitions)
+	assert.Equal(t, 5, result.TotalInserted)
+	for i, pr := range result.Partitions {
+		assert.Equal(t, parts[i].Start, pr.Start, "partitions are reported in order")
+	}
+
+	assert.LessOrEqual(t, maxInFlight, 3)
+	assert.Greater(t, maxInFlight, 1)
+	require.NotEmpty(t, advanced)
+	assert.IsNonDecreasing(t, advanced)
+	assert.Equal(t, int64(50), advanced[len(advanced)-1])
+}
+
+func TestChunkedSync_Workflow_PartitionConcurrency_CursorStopsAtFailure(t *testing.T) {
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestWorkflowEnvironment()
+	registerStubActivities(env)
+
+	parts := []Partition[int64]{{0, 10}, {10, 20}, {20, 30}, {30, 40}, {40, 50}, {50, 60}}
+	env.OnActivity("job-x.Partitions", mock.Anything).Return(parts, nil)
+	env.OnActivity("job-x.ReadCursor", mock.Anything, "job-x").Return(cursorResult[int64]{}, nil)
+	var mu sync.Mutex
+	advanced := []int64{}
+	env.OnActivity("job-x.AdvanceCursor", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, c int64) error {
+			mu.Lock()
+			defer mu.Unlock()
+			advanced = append(advanced, c)
+			return nil
+		})
+	env.OnActivity("job-x.RunPartition", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in runPartitionInput[int64]) (PartitionResult[int64], error) {
+			if in.Partition.Start == 20 {
+				return PartitionResult[int64]{}, errors.New("upstream down")
+			}
+			return PartitionResult[int64]{Start: in.Partition.Start, End: in.Partition.End, Fetched: 1}, nil
+		})
+
+	wf := concurrentTestWorkflow(2)
+	env.RegisterWorkflowWithOptions(wf.run, workflow.RegisterOptions{Name: "job-x"})
+
+	env.ExecuteWorkflow("job-x", payload.SyncExecutionInput{JobName: "job-x"})
+	require.True(t, env.IsWorkflowCompleted())
+	err := env.GetWorkflowError()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "partition 20..30")
+
+	require.NotEmpty(t, advanced)
+	for _, c := range advanced {
+		assert.LessOrEqual(t, c, int64(20), "cursor must not pass the failed partition")
+	}
+	assert.Equal(t, int64(20), advanced[len(advanced)-1])
+}
+
 // registerStubActivities registers stubs for the four activities referenced by
 // chunkedSyncWorkflow.run, satisfying Temporal's test environment requirement
 // that activities be registered before OnActivity mocks them.
@@ -379,3 +561,14 @@ func registerStubActivities(env *testsuite.TestWorkflowEnvironment) {
 		func(_ context.Context, _ int64) error { return nil },
 		activity.RegisterOptions{Name: "job-x.AdvanceCursor"})
 }
+
+func TestChunkedSync_Build_QuarantineRequiresDeadLetters(t *testing.T) {
+	defer func() { assert.NotNil(t, recover()) }()
+	_, _ = NewChunkedSync[string, string, int64]("job-x").
+		Partitioner(&stubPartitioner{}).
+		Fetcher(func(_ context.Context, _, _ int64) ([]string, error) { return nil, nil }).
+		Mapper(datasync.IdentityMapper[string]()).
+		Sink(&stubSink{name: "sink"}).
+		QualityChecks(datasync.NewQualityChecks[string]().ValidateStruct(datasync.QualityQuarantine)).
+		Build()
+}
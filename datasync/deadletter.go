@@ -0,0 +1,430 @@
+package datasync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	pkgotel "github.com/jasoet/pkg/v2/otel"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+// Dead-letter stages: where in the pipeline a record failed.
+const (
+	// DeadLetterStageMap marks a source record the mapper rejected.
+	DeadLetterStageMap = "map"
+	// DeadLetterStageWrite marks a mapped record the sink could not write.
+	DeadLetterStageWrite = "write"
+)
+
+// DeadLetter is a single record that failed to map or write, kept with
+// enough context to inspect and replay it.
+type DeadLetter struct {
+	// ID identifies the letter within its job. It is derived from the job,
+	// stage and record, so a retried batch dead-letters a record only once.
+	ID        string `json:"id"`
+	Job       string `json:"job"`
+	Partition string `json:"partition,omitempty"`
+	Stage     string `json:"stage"`
+
+	// Record is the JSON-encoded record: the source record for the map
+	// stage, the mapped record for the write stage.
+	Record json.RawMessage `json:"record"`
+
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failedAt"`
+	Attempts int       `json:"attempts"`
+}
+
+// NewDeadLetter builds a DeadLetter for record.
+func NewDeadLetter(job, partition, stage string, record any, cause string) (DeadLetter, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return DeadLetter{}, fmt.Errorf("dead letter: encode record: %w", err)
+	}
+	sum := sha256.Sum256([]byte(job + "\x00" + stage + "\x00" + string(data)))
+	return DeadLetter{
+		ID:        hex.EncodeToString(sum[:12]),
+		Job:       job,
+		Partition: partition,
+		Stage:     stage,
+		Record:    data,
+		Error:     cause,
+		FailedAt:  time.Now(),
+		Attempts:  1,
+	}, nil
+}
+
+// RecordError is the failure of one record in a batch.
+type RecordError struct {
+	// Index is the record's position in the batch passed to Map or Write.
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// PartialWriteError is returned by a Sink that wrote part of a batch and
+// failed the records listed in Failures. The WriteResult returned with it
+// counts the records that were written.
+type PartialWriteError struct {
+	Failures []RecordError
+}
+
+func (e *PartialWriteError) Error() string {
+	if len(e.Failures) == 0 {
+		return "partial write"
+	}
+	return fmt.Sprintf("%d records failed to write; first: record %d: %s",
+		len(e.Failures), e.Failures[0].Index, e.Failures[0].Error)
+}
+
+// DeadLetterWriter receives dead letters.
+type DeadLetterWriter interface {
+	PutDeadLetters(ctx context.Context, letters []DeadLetter) error
+}
+
+// DeadLetterQueue is a DeadLetterWriter that can also list and remove
+// letters, which replay requires.
+type DeadLetterQueue interface {
+	DeadLetterWriter
+	// ListDeadLetters returns the letters of job.
+	ListDeadLetters(ctx context.Context, job string) ([]DeadLetter, error)
+	// DeleteDeadLetters removes the letters of job with the given IDs.
+	DeleteDeadLetters(ctx context.Context, job string, ids ...string) error
+}
+
+// DeadLetterKeyPrefix is the key prefix StoreDeadLetterQueue stores letters under.
+const DeadLetterKeyPrefix = "dead-letters"
+
+// StoreDeadLetterQueue keeps dead letters in a store.Store, one key per
+// letter under "dead-letters/<job>/<id>".
+type StoreDeadLetterQueue struct {
+	store store.Store[DeadLetter]
+}
+
+// NewStoreDeadLetterQueue creates a DeadLetterQueue backed by s. Use
+// store.NewTypedStore with a store.JSONCodec to build one from a RawStore.
+func NewStoreDeadLetterQueue(s store.Store[DeadLetter]) *StoreDeadLetterQueue {
+	return &StoreDeadLetterQueue{store: s}
+}
+
+func (q *StoreDeadLetterQueue) key(job, id string) string {
+	return store.NewKeyBuilder().WithName(DeadLetterKeyPrefix).WithWorkflow(job).WithName(id).Build()
+}
+
+// PutDeadLetters saves letters, replacing letters with the same ID.
+func (q *StoreDeadLetterQueue) PutDeadLetters(ctx context.Context, letters []DeadLetter) error {
+	for _, l := range letters {
+		if err := q.store.Save(ctx, q.key(l.Job, l.ID), l); err != nil {
+			return fmt.Errorf("save dead letter %s/%s: %w", l.Job, l.ID, err)
+		}
+	}
+	return nil
+}
+
+// ListDeadLetters loads every letter of job.
+func (q *StoreDeadLetterQueue) ListDeadLetters(ctx context.Context, job string) ([]DeadLetter, error) {
+	keys, err := q.store.List(ctx, q.key(job, ""))
+	if err != nil {
+		return nil, fmt.Errorf("list dead letters of %s: %w", job, err)
+	}
+	letters := make([]DeadLetter, 0, len(keys))
+	for _, key := range keys {
+		l, err := q.store.Load(ctx, key)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue // removed since List
+			}
+			return nil, fmt.Errorf("load dead letter %s: %w", key, err)
+		}
+		letters = append(letters, l)
+	}
+	return letters, nil
+}
+
+// DeleteDeadLetters removes letters of job by ID.
+func (q *StoreDeadLetterQueue) DeleteDeadLetters(ctx context.Context, job string, ids ...string) error {
+	for _, id := range ids {
+		if err := q.store.Delete(ctx, q.key(job, id)); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("delete dead letter %s/%s: %w", job, id, err)
+		}
+	}
+	return nil
+}
+
+// sinkDeadLetters writes dead letters to a Sink.
+type sinkDeadLetters struct {
+	sink Sink[DeadLetter]
+}
+
+// DeadLettersToSink returns a DeadLetterWriter that writes letters to sink,
+// for example a table or topic shared by several jobs. It cannot be replayed
+// from; use a DeadLetterQueue for that.
+func DeadLettersToSink(sink Sink[DeadLetter]) DeadLetterWriter {
+	return sinkDeadLetters{sink: sink}
+}
+
+func (s sinkDeadLetters) PutDeadLetters(ctx context.Context, letters []DeadLetter) error {
+	if _, err := s.sink.Write(ctx, letters); err != nil {
+		return fmt.Errorf("dead-letter sink %s: %w", s.sink.Name(), err)
+	}
+	return nil
+}
+
+type deadLetterPartitionKey struct{}
+
+// WithDeadLetterPartition records the partition being synced in ctx, so
+// records dead-lettered under ctx carry it.
+func WithDeadLetterPartition(ctx context.Context, partition string) context.Context {
+	return context.WithValue(ctx, deadLetterPartitionKey{}, partition)
+}
+
+func deadLetterPartition(ctx context.Context) string {
+	p, _ := ctx.Value(deadLetterPartitionKey{}).(string)
+	return p
+}
+
+// WithDeadLetters wraps mapper and sink so records that fail individually
+// are sent to dlq instead of being dropped or failing the batch:
+//
+//   - a DetailedMapper's per-record failures (MapResult.Failures) are
+//     dead-lettered with the source record;
+//   - a sink's PartialWriteError is dead-lettered with the mapped records
+//     and the write is reported as successful.
+//
+// Errors that fail a whole batch are returned as before. If dlq itself
+// fails, the batch fails so no record is lost. In a dry run (WithDryRun)
+// mapper failures are logged instead of stored.
+func WithDeadLetters[T, U any](job string, mapper Mapper[T, U], sink Sink[U], dlq DeadLetterWriter) (Mapper[T, U], Sink[U]) {
+	return &deadLetterMapper[T, U]{job: job, inner: mapper, dlq: dlq},
+		&deadLetterSink[U]{job: job, inner: sink, dlq: dlq}
+}
+
+type deadLetterMapper[T, U any] struct {
+	job   string
+	inner Mapper[T, U]
+	dlq   DeadLetterWriter
+}
+
+func (m *deadLetterMapper[T, U]) Map(ctx context.Context, records []T) ([]U, error) {
+	result, err := m.mapResult(ctx, records)
+	return result.Records, err
+}
+
+// mapResult maps records, dead-letters the failures of a DetailedMapper
+// and reports them as skipped.
+func (m *deadLetterMapper[T, U]) mapResult(ctx context.Context, records []T) (MapResult[U], error) {
+	detailed, ok := m.inner.(DetailedMapper[T, U])
+	if !ok {
+		mapped, err := m.inner.Map(ctx, records)
+		return MapResult[U]{Records: mapped, Skipped: max(len(records)-len(mapped), 0)}, err
+	}
+	result := detailed.MapDetailed(ctx, records)
+	if len(result.Failures) == 0 {
+		return result, nil
+	}
+
+	if IsDryRun(ctx) {
+		logDryRunDeadLetters(ctx, m.job, len(result.Failures))
+		return result, nil
+	}
+
+	partition := deadLetterPartition(ctx)
+	letters := make([]DeadLetter, 0, len(result.Failures))
+	for _, f := range result.Failures {
+		if f.Index < 0 || f.Index >= len(records) {
+			continue
+		}
+		l, err := NewDeadLetter(m.job, partition, DeadLetterStageMap, records[f.Index], f.Error)
+		if err != nil {
+			return MapResult[U]{}, err
+		}
+		letters = append(letters, l)
+	}
+	if err := m.dlq.PutDeadLetters(ctx, letters); err != nil {
+		return MapResult[U]{}, fmt.Errorf("dead-letter %d mapper failures: %w", len(letters), err)
+	}
+	logDeadLettered(ctx, m.job, DeadLetterStageMap, len(letters))
+	MarkIncomplete(ctx, fmt.Sprintf("%d records dead-lettered by the mapper", len(letters)))
+	return result, nil
+}
+
+type deadLetterSink[U any] struct {
+	job   string
+	inner Sink[U]
+	dlq   DeadLetterWriter
+}
+
+func (s *deadLetterSink[U]) Name() string { return s.inner.Name() }
+
+func (s *deadLetterSink[U]) Finish(ctx context.Context) (WriteResult, error) {
+	return FinishSink(ctx, s.inner)
+}
+
+// Plan plans with the inner sink. Records it would fail cannot be known
+// without writing, so a dry run dead-letters nothing.
+func (s *deadLetterSink[U]) Plan(ctx context.Context, records []U) (payload.SyncPlan, error) {
+	return PlanWrite(ctx, s.inner, records)
+}
+
+func (s *deadLetterSink[U]) canPlan() bool { return CanPlan(s.inner) }
+
+func (s *deadLetterSink[U]) Write(ctx context.Context, records []U) (WriteResult, error) {
+	wr, err := s.inner.Write(ctx, records)
+	var partial *PartialWriteError
+	if !errors.As(err, &partial) {
+		return wr, err
+	}
+
+	partition := deadLetterPartition(ctx)
+	letters := make([]DeadLetter, 0, len(partial.Failures))
+	for _, f := range partial.Failures {
+		if f.Index < 0 || f.Index >= len(records) {
+			continue
+		}
+		l, lerr := NewDeadLetter(s.job, partition, DeadLetterStageWrite, records[f.Index], f.Error)
+		if lerr != nil {
+			return wr, lerr
+		}
+		letters = append(letters, l)
+	}
+	if perr := s.dlq.PutDeadLetters(ctx, letters); perr != nil {
+		return wr, fmt.Errorf("dead-letter %d write failures: %w (write error: %w)", len(letters), perr, err)
+	}
+	logDeadLettered(ctx, s.job, DeadLetterStageWrite, len(letters))
+	MarkIncomplete(ctx, fmt.Sprintf("%d records dead-lettered by the sink", len(letters)))
+	return wr, nil
+}
+
+func logDeadLettered(ctx context.Context, job, stage string, n int) {
+	logger := pkgotel.NewLogHelper(ctx, pkgotel.ConfigFromContext(ctx), "datasync.deadletter", job)
+	logger.Warn("records dead-lettered",
+		pkgotel.F("stage", stage),
+		pkgotel.F("count", n),
+		pkgotel.F("partition", deadLetterPartition(ctx)))
+}
+
+func logDryRunDeadLetters(ctx context.Context, job string, n int) {
+	logger := pkgotel.NewLogHelper(ctx, pkgotel.ConfigFromContext(ctx), "datasync.deadletter", job)
+	logger.Warn("dry run: records would be dead-lettered",
+		pkgotel.F("stage", DeadLetterStageMap),
+		pkgotel.F("count", n),
+		pkgotel.F("partition", deadLetterPartition(ctx)))
+}
+
+// ReplayResult summarises a dead-letter replay.
+type ReplayResult struct {
+	Total    int `json:"total"`
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// ReplayBatchSize is how many dead letters ReplayDeadLetters replays before
+// it deletes the replayed ones and puts back those that failed again.
+const ReplayBatchSize = 100
+
+// ReplayDeadLetters feeds up to limit (0 for all) of job's dead letters back
+// through mapper and sink, one record at a time: map-stage letters are mapped
+// and written, write-stage and quality-stage letters are written. Quality
+// checks are not applied again, so replaying a quarantined record accepts
+// it. Replayed letters are deleted; letters that fail again stay in the
+// queue with the new error and an incremented Attempts. The queue is updated
+// every ReplayBatchSize letters, so a replay that stops part way keeps the
+// work of its finished batches; onBatch, if non-nil, is called with the
+// running result after each one.
+func ReplayDeadLetters[T, U any](
+	ctx context.Context,
+	queue DeadLetterQueue,
+	job string,
+	mapper Mapper[T, U],
+	sink Sink[U],
+	limit int,
+	onBatch func(ReplayResult),
+) (ReplayResult, error) {
+	letters, err := queue.ListDeadLetters(ctx, job)
+	if err != nil {
+		return ReplayResult{}, err
+	}
+	if limit > 0 && len(letters) > limit {
+		letters = letters[:limit]
+	}
+
+	result := ReplayResult{Total: len(letters)}
+	for start := 0; start < len(letters); start += ReplayBatchSize {
+		batch := letters[start:min(start+ReplayBatchSize, len(letters))]
+		var replayed []string
+		var failed []DeadLetter
+		for _, l := range batch {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			if cause := replayLetter(WithDeadLetterPartition(ctx, l.Partition), l, mapper, sink); cause != nil {
+				l.Error = cause.Error()
+				l.Attempts++
+				l.FailedAt = time.Now()
+				failed = append(failed, l)
+				continue
+			}
+			replayed = append(replayed, l.ID)
+		}
+
+		if err := queue.DeleteDeadLetters(ctx, job, replayed...); err != nil {
+			return result, err
+		}
+		result.Replayed += len(replayed)
+		if err := queue.PutDeadLetters(ctx, failed); err != nil {
+			return result, err
+		}
+		result.Failed += len(failed)
+		if onBatch != nil {
+			onBatch(result)
+		}
+	}
+	return result, nil
+}
+
+func replayLetter[T, U any](ctx context.Context, l DeadLetter, mapper Mapper[T, U], sink Sink[U]) error {
+	var mapped []U
+	switch l.Stage {
+	case DeadLetterStageMap:
+		var record T
+		if err := json.Unmarshal(l.Record, &record); err != nil {
+			return fmt.Errorf("decode record: %w", err)
+		}
+		if detailed, ok := mapper.(DetailedMapper[T, U]); ok {
+			result := detailed.MapDetailed(ctx, []T{record})
+			if len(result.Failures) > 0 {
+				return errors.New(result.Failures[0].Error)
+			}
+			mapped = result.Records
+		} else {
+			var err error
+			if mapped, err = mapper.Map(ctx, []T{record}); err != nil {
+				return err
+			}
+		}
+		if len(mapped) == 0 {
+			return errors.New("mapper skipped the record")
+		}
+	case DeadLetterStageWrite, DeadLetterStageQuality:
+		var record U
+		if err := json.Unmarshal(l.Record, &record); err != nil {
+			return fmt.Errorf("decode record: %w", err)
+		}
+		mapped = []U{record}
+	default:
+		return fmt.Errorf("unknown dead-letter stage %q", l.Stage)
+	}
+
+	_, err := sink.Write(ctx, mapped)
+	var partial *PartialWriteError
+	if errors.As(err, &partial) && len(partial.Failures) > 0 {
+		return errors.New(partial.Failures[0].Error)
+	}
+	return err
+}
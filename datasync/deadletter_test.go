@@ -0,0 +1,185 @@
+package datasync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+func newMemoryDeadLetterQueue() *StoreDeadLetterQueue {
+	return NewStoreDeadLetterQueue(store.NewTypedStore[DeadLetter](store.NewMemoryStore(), &store.JSONCodec[DeadLetter]{}))
+}
+
+// positiveMapper rejects negative numbers.
+func positiveMapper() *RecordMapper[int, string] {
+	return NewRecordMapper[int, string]("positive", func(r *int) (string, error) {
+		if *r < 0 {
+			return "", fmt.Errorf("negative value %d", *r)
+		}
+		return strconv.Itoa(*r), nil
+	})
+}
+
+// rejectingSink fails the records listed in reject, per record.
+type rejectingSink struct {
+	reject  map[string]bool
+	written []string
+}
+
+func (s *rejectingSink) Name() string { return "rejecting" }
+func (s *rejectingSink) Write(_ context.Context, records []string) (WriteResult, error) {
+	var result WriteResult
+	var failures []RecordError
+	for i, r := range records {
+		if s.reject[r] {
+			failures = append(failures, RecordError{Index: i, Error: "rejected " + r})
+			continue
+		}
+		s.written = append(s.written, r)
+		result.Inserted++
+	}
+	if len(failures) > 0 {
+		return result, &PartialWriteError{Failures: failures}
+	}
+	return result, nil
+}
+
+func TestWithDeadLetters_CapturesMapAndWriteFailures(t *testing.T) {
+	queue := newMemoryDeadLetterQueue()
+	sink := &rejectingSink{reject: map[string]bool{"7": true}}
+	mapper, wrapped := WithDeadLetters[int, string]("orders", positiveMapper(), sink, queue)
+
+	ctx := WithDeadLetterPartition(context.Background(), "2026-10-01..2026-10-02")
+	mapped, err := mapper.Map(ctx, []int{1, -2, 7})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "7"}, mapped)
+
+	wr, err := wrapped.Write(ctx, mapped)
+	require.NoError(t, err, "partial write failures are dead-lettered, not returned")
+	assert.Equal(t, 1, wr.Inserted)
+	assert.Equal(t, "rejecting", wrapped.Name())
+
+	letters, err := queue.ListDeadLetters(context.Background(), "orders")
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+
+	byStage := map[string]DeadLetter{}
+	for _, l := range letters {
+		byStage[l.Stage] = l
+		assert.Equal(t, "orders", l.Job)
+		assert.Equal(t, "2026-10-01..2026-10-02", l.Partition)
+		assert.Equal(t, 1, l.Attempts)
+	}
+	assert.JSONEq(t, "-2", string(byStage[DeadLetterStageMap].Record))
+	assert.Equal(t, "negative value -2", byStage[DeadLetterStageMap].Error)
+	assert.JSONEq(t, `"7"`, string(byStage[DeadLetterStageWrite].Record))
+	assert.Equal(t, "rejected 7", byStage[DeadLetterStageWrite].Error)
+
+	// A retried batch dead-letters the same records under the same IDs.
+	_, err = mapper.Map(ctx, []int{-2})
+	require.NoError(t, err)
+	letters, err = queue.ListDeadLetters(context.Background(), "orders")
+	require.NoError(t, err)
+	assert.Len(t, letters, 2)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) PutDeadLetters(context.Context, []DeadLetter) error {
+	return errors.New("queue down")
+}
+
+func TestWithDeadLetters_QueueFailureFailsBatch(t *testing.T) {
+	mapper, _ := WithDeadLetters[int, string]("orders", positiveMapper(), &rejectingSink{}, failingWriter{})
+	_, err := mapper.Map(context.Background(), []int{-1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "queue down")
+}
+
+func TestWithDeadLetters_WholeBatchErrorsPassThrough(t *testing.T) {
+	sink := &mockSink[string]{name: "dst", err: errors.New("connection refused")}
+	_, wrapped := WithDeadLetters[int, string]("orders", positiveMapper(), sink, newMemoryDeadLetterQueue())
+	_, err := wrapped.Write(context.Background(), []string{"1"})
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestDeadLettersToSink(t *testing.T) {
+	sink := &mockSink[DeadLetter]{name: "dlq-table", result: WriteResult{Inserted: 1}}
+	writer := DeadLettersToSink(sink)
+
+	letter, err := NewDeadLetter("orders", "", DeadLetterStageWrite, "7", "rejected")
+	require.NoError(t, err)
+	require.NoError(t, writer.PutDeadLetters(context.Background(), []DeadLetter{letter}))
+	assert.Equal(t, []DeadLetter{letter}, sink.written)
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	queue := newMemoryDeadLetterQueue()
+	sink := &rejectingSink{reject: map[string]bool{"7": true, "9": true}}
+	mapper, wrapped := WithDeadLetters[int, string]("orders", positiveMapper(), sink, queue)
+
+	mapped, err := mapper.Map(context.Background(), []int{-2, 7, 9})
+	require.NoError(t, err)
+	_, err = wrapped.Write(context.Background(), mapped)
+	require.NoError(t, err)
+
+	// Fix the sink for 7 and the mapper for negatives; 9 still fails.
+	delete(sink.reject, "7")
+	sink.written = nil
+	fixedMapper := NewRecordMapper[int, string]("abs", func(r *int) (string, error) {
+		return strconv.Itoa(max(*r, -*r)), nil
+	})
+
+	result, err := ReplayDeadLetters[int, string](context.Background(), queue, "orders", fixedMapper, sink, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Total: 3, Replayed: 2, Failed: 1}, result)
+	assert.ElementsMatch(t, []string{"2", "7"}, sink.written)
+
+	letters, err := queue.ListDeadLetters(context.Background(), "orders")
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.JSONEq(t, `"9"`, string(letters[0].Record))
+	assert.Equal(t, 2, letters[0].Attempts)
+	assert.Equal(t, "rejected 9", letters[0].Error)
+}
+
+func TestReplayDeadLetters_UpdatesQueuePerBatch(t *testing.T) {
+	queue := newMemoryDeadLetterQueue()
+	var letters []DeadLetter
+	for i := range ReplayBatchSize*2 + 50 {
+		l, err := NewDeadLetter("orders", "", DeadLetterStageWrite, strconv.Itoa(i), "boom")
+		require.NoError(t, err)
+		letters = append(letters, l)
+	}
+	require.NoError(t, queue.PutDeadLetters(context.Background(), letters))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var batches []ReplayResult
+	result, err := ReplayDeadLetters[string, string](ctx, queue, "orders", IdentityMapper[string](), &rejectingSink{}, 0,
+		func(r ReplayResult) {
+			batches = append(batches, r)
+			cancel()
+		})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []ReplayResult{{Total: ReplayBatchSize*2 + 50, Replayed: ReplayBatchSize}}, batches)
+	assert.Equal(t, ReplayBatchSize, result.Replayed)
+
+	left, err := queue.ListDeadLetters(context.Background(), "orders")
+	require.NoError(t, err)
+	assert.Len(t, left, ReplayBatchSize+50, "the finished batch is deleted before the replay stops")
+
+	batches = nil
+	result, err = ReplayDeadLetters[string, string](context.Background(), queue, "orders", IdentityMapper[string](), &rejectingSink{}, 0,
+		func(r ReplayResult) { batches = append(batches, r) })
+	require.NoError(t, err)
+	assert.Len(t, batches, 2)
+	assert.Equal(t, ReplayResult{Total: ReplayBatchSize + 50, Replayed: ReplayBatchSize + 50}, result)
+}
@@ -0,0 +1,295 @@
+package datasync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	pkgotel "github.com/jasoet/pkg/v2/otel"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
+
+// FanOutPolicy decides what a FanOutSink does when one of its sinks fails.
+type FanOutPolicy string
+
+const (
+	// FanOutAllOrNothing fails the write when any sink fails. Sequential
+	// fan-outs stop at the failed sink and parallel ones cancel the rest;
+	// sinks already written are not rolled back, so the retried batch is
+	// written to them again and they should be idempotent.
+	FanOutAllOrNothing FanOutPolicy = "all-or-nothing"
+	// FanOutBestEffort writes to every sink and reports the failures in
+	// WriteResult.Sinks. The write fails only when every sink fails.
+	FanOutBestEffort FanOutPolicy = "best-effort"
+)
+
+// FanOutTarget is one sink of a FanOutSink together with the mapper that
+// turns the fan-out's records into the sink's. Create one with FanOutTo or
+// FanOutMapped.
+type FanOutTarget[U any] struct {
+	name string
+	// write returns how many records the mapper skipped alongside the result.
+	write   func(ctx context.Context, records []U) (WriteResult, int, error)
+	plan    func(ctx context.Context, records []U) (payload.SyncPlan, error)
+	finish  func(ctx context.Context) (WriteResult, error)
+	canPlan bool
+}
+
+// FanOutTo targets sink with the records as they are.
+func FanOutTo[U any](sink Sink[U]) FanOutTarget[U] {
+	return FanOutMapped(IdentityMapper[U](), sink)
+}
+
+// FanOutMapped targets sink with the records mapped by mapper. To
+// dead-letter the records the sink or mapper rejects, wrap them with
+// WithDeadLetters first: a FanOutSink treats a PartialWriteError as a failure
+// of the whole sink, since its record indices are the sink's. Records the
+// mapper skips keep the sink from being finished, as failures do.
+func FanOutMapped[U, V any](mapper Mapper[U, V], sink Sink[V]) FanOutTarget[U] {
+	return FanOutTarget[U]{
+		name: sink.Name(),
+		write: func(ctx context.Context, records []U) (WriteResult, int, error) {
+			mr, err := mapWithSkips(ctx, mapper, records)
+			if err != nil {
+				return WriteResult{}, 0, fmt.Errorf("map: %w", err)
+			}
+			if len(mr.Records) == 0 {
+				return WriteResult{}, mr.Skipped, nil
+			}
+			wr, err := sink.Write(ctx, mr.Records)
+			var partial *PartialWriteError
+			if errors.As(err, &partial) {
+				err = errors.New(partial.Error())
+			}
+			return wr, mr.Skipped, err
+		},
+		plan: func(ctx context.Context, records []U) (payload.SyncPlan, error) {
+			mapped, err := mapper.Map(ctx, records)
+			if err != nil {
+				return payload.SyncPlan{}, fmt.Errorf("map: %w", err)
+			}
+			return PlanWrite(ctx, sink, mapped)
+		},
+		finish: func(ctx context.Context) (WriteResult, error) {
+			return FinishSink(ctx, sink)
+		},
+		canPlan: CanPlan(sink),
+	}
+}
+
+// FanOutError is returned when a FanOutSink write fails. It lists the
+// failed sinks in target order.
+type FanOutError struct {
+	Failures []SinkFailure
+}
+
+// SinkFailure is the error one sink of a FanOutSink returned.
+type SinkFailure struct {
+	Sink string
+	Err  error
+}
+
+func (e *FanOutError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		msgs[i] = fmt.Sprintf("sink %s: %v", f.Sink, f.Err)
+	}
+	return "fan-out failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *FanOutError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// FanOutSink writes every batch to several sinks, each through its own
+// mapper. Targets are written in order unless Parallel is set, and a
+// failing target fails the write unless BestEffort is set. The
+// WriteResult counts add up every target's and WriteResult.Sinks breaks
+// them down by sink name.
+//
+// A FanOutSink is a FinishingSink that finishes the targets that received
+// every record of the run, and a Planner when every target is one.
+type FanOutSink[U any] struct {
+	name     string
+	targets  []FanOutTarget[U]
+	parallel bool
+	policy   FanOutPolicy
+}
+
+// fanOutRun tracks, per SyncRun, the targets that missed records.
+type fanOutRun struct {
+	mu     sync.Mutex
+	missed map[string]struct{}
+}
+
+func (r *fanOutRun) miss(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.missed[name] = struct{}{}
+}
+
+func (r *fanOutRun) hasMissed(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.missed[name]
+	return ok
+}
+
+// NewFanOutSink creates a FanOutSink. It panics if there are no targets or
+// two targets share a sink name, since results are keyed by it.
+func NewFanOutSink[U any](name string, targets ...FanOutTarget[U]) *FanOutSink[U] {
+	if len(targets) == 0 {
+		panic(fmt.Sprintf("datasync.NewFanOutSink(%q): at least one target is required", name))
+	}
+	seen := make(map[string]struct{}, len(targets))
+	for _, t := range targets {
+		if _, dup := seen[t.name]; dup {
+			panic(fmt.Sprintf("datasync.NewFanOutSink(%q): duplicate sink name %q", name, t.name))
+		}
+		seen[t.name] = struct{}{}
+	}
+	return &FanOutSink[U]{name: name, targets: targets, policy: FanOutAllOrNothing}
+}
+
+// Parallel writes to every target at once instead of one after another.
+func (s *FanOutSink[U]) Parallel() *FanOutSink[U] {
+	s.parallel = true
+	return s
+}
+
+// BestEffort switches the failure policy to FanOutBestEffort.
+func (s *FanOutSink[U]) BestEffort() *FanOutSink[U] {
+	s.policy = FanOutBestEffort
+	return s
+}
+
+// Name returns the fan-out's name.
+func (s *FanOutSink[U]) Name() string { return s.name }
+
+func (s *FanOutSink[U]) run(ctx context.Context) (*fanOutRun, bool) {
+	return runState(ctx, s, func() *fanOutRun { return &fanOutRun{missed: make(map[string]struct{})} })
+}
+
+// Write writes records to every target. Under a SyncRun, it records the
+// targets that missed records because their write failed or their mapper
+// skipped some.
+func (s *FanOutSink[U]) Write(ctx context.Context, records []U) (WriteResult, error) {
+	run, tracked := s.run(ctx)
+	return s.each(ctx, func(ctx context.Context, t FanOutTarget[U]) (WriteResult, error) {
+		wr, skipped, err := t.write(ctx, records)
+		if tracked && (err != nil || skipped > 0) {
+			run.miss(t.name)
+		}
+		return wr, err
+	})
+}
+
+// Finish finishes every target that is a FinishingSink, except those that
+// missed records in the context's SyncRun: UpsertSink.DeleteMissing must
+// not run on them. Outside a SyncRun every target is finished.
+func (s *FanOutSink[U]) Finish(ctx context.Context) (WriteResult, error) {
+	run, tracked := s.run(ctx)
+	return s.each(ctx, func(ctx context.Context, t FanOutTarget[U]) (WriteResult, error) {
+		if tracked && run.hasMissed(t.name) {
+			return WriteResult{}, nil
+		}
+		return t.finish(ctx)
+	})
+}
+
+// Plan plans the write to every target, one after another. Diffs are
+// tagged with their sink's name.
+func (s *FanOutSink[U]) Plan(ctx context.Context, records []U) (payload.SyncPlan, error) {
+	var plan payload.SyncPlan
+	for _, t := range s.targets {
+		p, err := t.plan(ctx, records)
+		if err != nil {
+			return plan, fmt.Errorf("sink %s: %w", t.name, err)
+		}
+		for i := range p.Diffs {
+			p.Diffs[i].Sink = t.name
+		}
+		plan.Add(p)
+	}
+	return plan, nil
+}
+
+func (s *FanOutSink[U]) canPlan() bool {
+	for _, t := range s.targets {
+		if !t.canPlan {
+			return false
+		}
+	}
+	return true
+}
+
+// each runs op against every target according to the ordering and failure
+// policy, and combines the results.
+func (s *FanOutSink[U]) each(ctx context.Context, op func(ctx context.Context, t FanOutTarget[U]) (WriteResult, error)) (WriteResult, error) {
+	results := make([]WriteResult, len(s.targets))
+	errs := make([]error, len(s.targets))
+	attempted := make([]bool, len(s.targets))
+
+	if s.parallel {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var wg sync.WaitGroup
+		for i, t := range s.targets {
+			attempted[i] = true
+			wg.Go(func() {
+				results[i], errs[i] = op(ctx, t)
+				if errs[i] != nil && s.policy == FanOutAllOrNothing {
+					cancel()
+				}
+			})
+		}
+		wg.Wait()
+	} else {
+		for i, t := range s.targets {
+			attempted[i] = true
+			results[i], errs[i] = op(ctx, t)
+			if errs[i] != nil && s.policy == FanOutAllOrNothing {
+				break
+			}
+		}
+	}
+
+	var wr WriteResult
+	var failures []SinkFailure
+	for i, t := range s.targets {
+		if !attempted[i] {
+			continue
+		}
+		r := results[i]
+		sr := payload.SinkResult{Inserted: r.Inserted, Updated: r.Updated, Skipped: r.Skipped, Deleted: r.Deleted}
+		if errs[i] != nil {
+			sr.Error = errs[i].Error()
+			failures = append(failures, SinkFailure{Sink: t.name, Err: errs[i]})
+		}
+		r.Sinks = map[string]payload.SinkResult{t.name: sr}
+		wr.Add(r)
+	}
+
+	if len(failures) == 0 {
+		return wr, nil
+	}
+	if s.policy == FanOutBestEffort && len(failures) < len(s.targets) {
+		s.logFailures(ctx, failures)
+		return wr, nil
+	}
+	return wr, &FanOutError{Failures: failures}
+}
+
+func (s *FanOutSink[U]) logFailures(ctx context.Context, failures []SinkFailure) {
+	logger := pkgotel.NewLogHelper(ctx, pkgotel.ConfigFromContext(ctx), "datasync.fanout", s.name)
+	for _, f := range failures {
+		logger.Warn("fan-out sink failed", pkgotel.F("sink", f.Sink), pkgotel.F("error", f.Err.Error()))
+	}
+}
@@ -0,0 +1,217 @@
+package datasync
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
+
+// recordingSink records what it was written and can fail every write.
+type recordingSink[U any] struct {
+	name     string
+	err      error
+	mu       sync.Mutex
+	written  []U
+	finished int
+}
+
+func (s *recordingSink[U]) Name() string { return s.name }
+func (s *recordingSink[U]) Write(_ context.Context, records []U) (WriteResult, error) {
+	if s.err != nil {
+		return WriteResult{}, s.err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.written = append(s.written, records...)
+	return WriteResult{Inserted: len(records)}, nil
+}
+
+func (s *recordingSink[U]) Finish(context.Context) (WriteResult, error) {
+	s.finished++
+	return WriteResult{Deleted: 1}, nil
+}
+
+func itoaMapper() Mapper[int, string] {
+	return MapperFunc[int, string](func(_ context.Context, records []int) ([]string, error) {
+		out := make([]string, len(records))
+		for i, r := range records {
+			out[i] = strconv.Itoa(r)
+		}
+		return out, nil
+	})
+}
+
+func TestFanOutSink_WritesEveryTargetWithItsMapper(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		db := &recordingSink[int]{name: "postgres"}
+		events := &recordingSink[string]{name: "events"}
+		fanOut := NewFanOutSink("fan-out", FanOutTo[int](db), FanOutMapped(itoaMapper(), Sink[string](events)))
+		if parallel {
+			fanOut.Parallel()
+		}
+
+		wr, err := fanOut.Write(context.Background(), []int{1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, db.written)
+		assert.Equal(t, []string{"1", "2"}, events.written)
+		assert.Equal(t, 4, wr.Inserted, "counts add up every target's")
+		assert.Equal(t, map[string]payload.SinkResult{
+			"postgres": {Inserted: 2},
+			"events":   {Inserted: 2},
+		}, wr.Sinks)
+	}
+}
+
+func TestFanOutSink_AllOrNothingStopsAtFailure(t *testing.T) {
+	failing := &recordingSink[int]{name: "search", err: errors.New("index down")}
+	later := &recordingSink[int]{name: "events"}
+	fanOut := NewFanOutSink("fan-out", FanOutTo[int](&recordingSink[int]{name: "postgres"}), FanOutTo[int](failing), FanOutTo[int](later))
+
+	wr, err := fanOut.Write(context.Background(), []int{1})
+	var fe *FanOutError
+	require.ErrorAs(t, err, &fe)
+	require.Len(t, fe.Failures, 1)
+	assert.Equal(t, "search", fe.Failures[0].Sink)
+	assert.Contains(t, err.Error(), "sink search: index down")
+	assert.Empty(t, later.written, "targets after the failure are not written")
+	assert.Equal(t, 1, wr.Sinks["postgres"].Inserted)
+	assert.NotContains(t, wr.Sinks, "events")
+}
+
+func TestFanOutSink_ParallelAllOrNothingFails(t *testing.T) {
+	cause := errors.New("index down")
+	fanOut := NewFanOutSink("fan-out",
+		FanOutTo[int](&recordingSink[int]{name: "postgres"}),
+		FanOutTo[int](&recordingSink[int]{name: "search", err: cause})).Parallel()
+
+	_, err := fanOut.Write(context.Background(), []int{1})
+	require.ErrorIs(t, err, cause)
+}
+
+func TestFanOutSink_BestEffort(t *testing.T) {
+	db := &recordingSink[int]{name: "postgres"}
+	search := &recordingSink[int]{name: "search", err: errors.New("index down")}
+	fanOut := NewFanOutSink("fan-out", FanOutTo[int](db), FanOutTo[int](search)).BestEffort()
+	ctx := WithSyncRun(context.Background(), NewSyncRun())
+
+	wr, err := fanOut.Write(ctx, []int{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, wr.Inserted)
+	assert.Equal(t, "index down", wr.Sinks["search"].Error)
+
+	fr, err := FinishSink(ctx, fanOut)
+	require.NoError(t, err)
+	assert.Equal(t, 1, db.finished)
+	assert.Zero(t, search.finished, "a target that missed records is not finished")
+	assert.Equal(t, 1, fr.Deleted)
+
+	db.err = errors.New("db down")
+	_, err = fanOut.Write(ctx, []int{3})
+	var fe *FanOutError
+	require.ErrorAs(t, err, &fe, "best effort fails when every target fails")
+	assert.Len(t, fe.Failures, 2)
+}
+
+func TestFanOutSink_DoesNotFinishTargetsWhoseMapperSkipped(t *testing.T) {
+	db := &recordingSink[int]{name: "postgres"}
+	events := &recordingSink[string]{name: "events"}
+	evensOnly := MapperFunc[int, string](func(_ context.Context, records []int) ([]string, error) {
+		var out []string
+		for _, r := range records {
+			if r%2 == 0 {
+				out = append(out, strconv.Itoa(r))
+			}
+		}
+		return out, nil
+	})
+	fanOut := NewFanOutSink("fan-out", FanOutTo[int](db), FanOutMapped(evensOnly, Sink[string](events)))
+
+	ctx := WithSyncRun(context.Background(), NewSyncRun())
+	_, err := fanOut.Write(ctx, []int{1, 3})
+	require.NoError(t, err)
+	_, err = FinishSink(ctx, fanOut)
+	require.NoError(t, err)
+	assert.Equal(t, 1, db.finished)
+	assert.Zero(t, events.finished, "a target that received no records is not finished")
+
+	// A later run that reaches every target finishes it.
+	ctx = WithSyncRun(context.Background(), NewSyncRun())
+	_, err = fanOut.Write(ctx, []int{2})
+	require.NoError(t, err)
+	_, err = FinishSink(ctx, fanOut)
+	require.NoError(t, err)
+	assert.Equal(t, 1, events.finished)
+}
+
+func TestFanOutSink_PartialWriteErrorFailsTarget(t *testing.T) {
+	sink := &rejectingSink{reject: map[string]bool{"2": true}}
+	fanOut := NewFanOutSink("fan-out", FanOutMapped(itoaMapper(), Sink[string](sink)))
+
+	_, err := fanOut.Write(context.Background(), []int{1, 2})
+	require.Error(t, err)
+	var partial *PartialWriteError
+	assert.False(t, errors.As(err, &partial), "target record indices must not reach a dead-letter wrapper")
+}
+
+func TestFanOutSink_Plan(t *testing.T) {
+	fanOut := NewFanOutSink("fan-out", FanOutTo[int](&planningSink{}), FanOutTo[int](&diffingPlanner{name: "search"}))
+	require.True(t, CanPlan[int](fanOut))
+
+	plan, err := PlanWrite[int](context.Background(), fanOut, []int{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, 4, plan.WouldInsert)
+	require.Len(t, plan.Diffs, 2)
+	assert.Equal(t, "search", plan.Diffs[0].Sink)
+
+	mixed := NewFanOutSink("fan-out", FanOutTo[int](&planningSink{}), FanOutTo[int](&recordingSink[int]{name: "events"}))
+	assert.False(t, CanPlan[int](mixed))
+}
+
+func TestNewFanOutSink_DuplicateNamePanics(t *testing.T) {
+	assert.Panics(t, func() {
+		NewFanOutSink("fan-out", FanOutTo[int](&batchSink{}), FanOutTo[int](&batchSink{}))
+	})
+	assert.Panics(t, func() { NewFanOutSink[int]("fan-out") })
+}
+
+func TestRunner_FanOutReportsPerSink(t *testing.T) {
+	fanOut := NewFanOutSink("fan-out",
+		FanOutTo[int](&recordingSink[int]{name: "postgres"}),
+		FanOutMapped(itoaMapper(), Sink[string](&recordingSink[string]{name: "events"})))
+	source := &mockSource[int]{name: "src", records: []int{1, 2, 3}}
+
+	result, err := NewRunner[int, int](source, IdentityMapper[int](), fanOut).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, payload.SinkResult{Inserted: 3, Deleted: 1}, result.WriteResult.Sinks["postgres"])
+	assert.Equal(t, payload.SinkResult{Inserted: 3, Deleted: 1}, result.WriteResult.Sinks["events"])
+}
+
+// diffingPlanner plans every record as an insert with a diff.
+type diffingPlanner struct {
+	name string
+}
+
+func (p *diffingPlanner) Name() string { return p.name }
+func (p *diffingPlanner) Write(_ context.Context, records []int) (WriteResult, error) {
+	return WriteResult{Inserted: len(records)}, nil
+}
+
+func (p *diffingPlanner) Plan(_ context.Context, records []int) (payload.SyncPlan, error) {
+	var plan payload.SyncPlan
+	for _, r := range records {
+		diff, err := NewRecordDiff(r, nil, r)
+		if err != nil {
+			return plan, err
+		}
+		plan.WouldInsert++
+		plan.Diffs = append(plan.Diffs, diff)
+	}
+	return plan, nil
+}
@@ -0,0 +1,239 @@
+package datasync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+// Names of the objects a run records under RunKey.
+const (
+	RunSummaryName = "summary.json"
+	RunSkipsName   = "skips.json"
+	RunSampleName  = "sample.json"
+)
+
+// RunSummary is the record of one sync run.
+type RunSummary struct {
+	JobName    string                      `json:"jobName"`
+	WorkflowID string                      `json:"workflowId"`
+	RunID      string                      `json:"runId"`
+	StartedAt  time.Time                   `json:"startedAt"`
+	Output     payload.SyncExecutionOutput `json:"output"`
+}
+
+// RunKey returns the key prefix a run's records are stored under:
+// "<job>/<runID>".
+func RunKey(jobName, runID string) *store.KeyBuilder {
+	return store.NewKeyBuilder().WithWorkflow(jobName).WithRun(runID)
+}
+
+// RunHistory stores the summary, mapper skip reasons and record sample of
+// each sync run in a RawStore, giving a job a run history without another
+// database. It is what Job.Store is used for.
+type RunHistory struct {
+	raw store.RawStore
+}
+
+// NewRunHistory creates a RunHistory over raw.
+func NewRunHistory(raw store.RawStore) *RunHistory {
+	return &RunHistory{raw: raw}
+}
+
+// SaveSummary stores the summary of a run.
+func (h *RunHistory) SaveSummary(ctx context.Context, s RunSummary) error {
+	return h.save(ctx, RunKey(s.JobName, s.RunID).WithName(RunSummaryName).Build(), s)
+}
+
+// SaveRecorder stores the skip reasons and sample a RunRecorder collected
+// during a run. Empty parts are not stored.
+func (h *RunHistory) SaveRecorder(ctx context.Context, jobName, runID string, r *RunRecorder) error {
+	skips, sample := r.SkipReasons(), r.Sample()
+	if len(skips) > 0 {
+		if err := h.save(ctx, RunKey(jobName, runID).WithName(RunSkipsName).Build(), skips); err != nil {
+			return err
+		}
+	}
+	if len(sample) > 0 {
+		if err := h.save(ctx, RunKey(jobName, runID).WithName(RunSampleName).Build(), sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary loads the summary of a run.
+func (h *RunHistory) Summary(ctx context.Context, jobName, runID string) (RunSummary, error) {
+	var s RunSummary
+	err := h.load(ctx, RunKey(jobName, runID).WithName(RunSummaryName).Build(), &s)
+	return s, err
+}
+
+// SkipReasons loads the mapper skip reasons of a run. A run without skips
+// returns an empty slice.
+func (h *RunHistory) SkipReasons(ctx context.Context, jobName, runID string) ([]string, error) {
+	var skips []string
+	err := h.load(ctx, RunKey(jobName, runID).WithName(RunSkipsName).Build(), &skips)
+	if errors.Is(err, store.ErrNotFound) {
+		return []string{}, nil
+	}
+	return skips, err
+}
+
+// Sample loads the sample of fetched records of a run, one JSON value per
+// record. A run without a sample returns an empty slice.
+func (h *RunHistory) Sample(ctx context.Context, jobName, runID string) ([]json.RawMessage, error) {
+	var sample []json.RawMessage
+	err := h.load(ctx, RunKey(jobName, runID).WithName(RunSampleName).Build(), &sample)
+	if errors.Is(err, store.ErrNotFound) {
+		return []json.RawMessage{}, nil
+	}
+	return sample, err
+}
+
+// ListRuns returns the summaries of jobName's runs, newest first. A positive
+// limit caps how many are returned.
+func (h *RunHistory) ListRuns(ctx context.Context, jobName string, limit int) ([]RunSummary, error) {
+	keys, err := h.raw.List(ctx, store.NewKeyBuilder().WithWorkflow(jobName).WithName("").Build())
+	if err != nil {
+		return nil, fmt.Errorf("list runs of %s: %w", jobName, err)
+	}
+
+	var runs []RunSummary
+	for _, key := range keys {
+		if !strings.HasSuffix(key, "/"+RunSummaryName) {
+			continue
+		}
+		var s RunSummary
+		if err := h.load(ctx, key, &s); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue // deleted since List
+			}
+			return nil, err
+		}
+		runs = append(runs, s)
+	}
+	slices.SortFunc(runs, func(a, b RunSummary) int { return b.StartedAt.Compare(a.StartedAt) })
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func (h *RunHistory) save(ctx context.Context, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", key, err)
+	}
+	if err := store.NewBytesStore(h.raw).Save(ctx, key, data); err != nil {
+		return fmt.Errorf("save %s: %w", key, err)
+	}
+	return nil
+}
+
+func (h *RunHistory) load(ctx context.Context, key string, v any) error {
+	data, err := store.NewBytesStore(h.raw).Load(ctx, key)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", key, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s: %w", key, err)
+	}
+	return nil
+}
+
+// RunRecorder collects the mapper skip reasons and a sample of the fetched
+// records during one sync run. The SyncData activity puts one in the
+// context when the job has a Store; RecordSkips and RecordSample add to it.
+type RunRecorder struct {
+	mu          sync.Mutex
+	sampleSize  int
+	skipReasons []string
+	sample      []json.RawMessage
+}
+
+// NewRunRecorder creates a RunRecorder keeping up to sampleSize records.
+func NewRunRecorder(sampleSize int) *RunRecorder {
+	return &RunRecorder{sampleSize: sampleSize}
+}
+
+// SkipReasons returns the skip reasons recorded so far, at most
+// MaxSkipReasons of them.
+func (r *RunRecorder) SkipReasons() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.skipReasons)
+}
+
+// Sample returns the records sampled so far.
+func (r *RunRecorder) Sample() []json.RawMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.sample)
+}
+
+type runRecorderKey struct{}
+
+// WithRunRecorder returns a context carrying r.
+func WithRunRecorder(ctx context.Context, r *RunRecorder) context.Context {
+	return context.WithValue(ctx, runRecorderKey{}, r)
+}
+
+func runRecorder(ctx context.Context) *RunRecorder {
+	r, _ := ctx.Value(runRecorderKey{}).(*RunRecorder)
+	return r
+}
+
+// RecordSample adds the first records to the context's RunRecorder until
+// its sample is full. Without a recorder it does nothing.
+func RecordSample[T any](ctx context.Context, records []T) {
+	r := runRecorder(ctx)
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := 0; i < len(records) && len(r.sample) < r.sampleSize; i++ {
+		data, err := json.Marshal(records[i])
+		if err != nil {
+			continue
+		}
+		r.sample = append(r.sample, data)
+	}
+}
+
+// RecordSkips wraps mapper so the skip reasons of a DetailedMapper are added
+// to the context's RunRecorder, up to MaxSkipReasons per run. The result is a DetailedMapper when mapper
+// is one, so it can still be wrapped with WithDeadLetters.
+func RecordSkips[T, U any](mapper Mapper[T, U]) Mapper[T, U] {
+	if detailed, ok := mapper.(DetailedMapper[T, U]); ok {
+		return &skipRecordingMapper[T, U]{inner: detailed}
+	}
+	return mapper
+}
+
+type skipRecordingMapper[T, U any] struct {
+	inner DetailedMapper[T, U]
+}
+
+func (m *skipRecordingMapper[T, U]) Map(ctx context.Context, records []T) ([]U, error) {
+	return m.MapDetailed(ctx, records).Records, nil
+}
+
+func (m *skipRecordingMapper[T, U]) MapDetailed(ctx context.Context, records []T) MapResult[U] {
+	result := m.inner.MapDetailed(ctx, records)
+	if r := runRecorder(ctx); r != nil && len(result.SkipReasons) > 0 {
+		r.mu.Lock()
+		r.skipReasons = AddSkipReasons(r.skipReasons, result.SkipReasons)
+		r.mu.Unlock()
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package datasync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+func TestRunKey(t *testing.T) {
+	assert.Equal(t, "orders/run-1/summary.json", RunKey("orders", "run-1").WithName(RunSummaryName).Build())
+}
+
+func TestRunHistory_ListRuns(t *testing.T) {
+	ctx := context.Background()
+	history := NewRunHistory(store.NewMemoryStore())
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, runID := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, history.SaveSummary(ctx, RunSummary{
+			JobName:   "orders",
+			RunID:     runID,
+			StartedAt: start.Add(time.Duration(i) * time.Hour),
+			Output:    payload.SyncExecutionOutput{JobName: "orders", Inserted: i, Success: true},
+		}))
+	}
+	require.NoError(t, history.SaveSummary(ctx, RunSummary{JobName: "orders-eu", RunID: "other", StartedAt: start}))
+	require.NoError(t, history.SaveRecorder(ctx, "orders", "r1", recorderWith([]string{"bad date"}, nil)))
+
+	runs, err := history.ListRuns(ctx, "orders", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 3, "other jobs sharing the prefix are not listed")
+	assert.Equal(t, []string{"r3", "r2", "r1"}, []string{runs[0].RunID, runs[1].RunID, runs[2].RunID})
+	assert.Equal(t, 2, runs[0].Output.Inserted)
+
+	runs, err = history.ListRuns(ctx, "orders", 2)
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+
+	runs, err = history.ListRuns(ctx, "unknown", 0)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestRunHistory_Summary(t *testing.T) {
+	ctx := context.Background()
+	history := NewRunHistory(store.NewMemoryStore())
+	require.NoError(t, history.SaveSummary(ctx, RunSummary{JobName: "orders", WorkflowID: "wf", RunID: "r1"}))
+
+	s, err := history.Summary(ctx, "orders", "r1")
+	require.NoError(t, err)
+	assert.Equal(t, "wf", s.WorkflowID)
+
+	_, err = history.Summary(ctx, "orders", "missing")
+	assert.True(t, errors.Is(err, store.ErrNotFound))
+}
+
+func recorderWith(skips []string, sample []int) *RunRecorder {
+	r := NewRunRecorder(len(sample))
+	r.skipReasons = skips
+	RecordSample(WithRunRecorder(context.Background(), r), sample)
+	return r
+}
+
+func TestRunHistory_SkipsAndSample(t *testing.T) {
+	ctx := context.Background()
+	history := NewRunHistory(store.NewMemoryStore())
+	require.NoError(t, history.SaveRecorder(ctx, "orders", "r1", recorderWith([]string{"record 0: bad"}, []int{7, 8})))
+
+	skips, err := history.SkipReasons(ctx, "orders", "r1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"record 0: bad"}, skips)
+
+	sample, err := history.Sample(ctx, "orders", "r1")
+	require.NoError(t, err)
+	assert.Equal(t, []json.RawMessage{json.RawMessage("7"), json.RawMessage("8")}, sample)
+
+	skips, err = history.SkipReasons(ctx, "orders", "r2")
+	require.NoError(t, err)
+	assert.Empty(t, skips)
+	sample, err = history.Sample(ctx, "orders", "r2")
+	require.NoError(t, err)
+	assert.Empty(t, sample)
+}
+
+func TestRecordSample_CapsAtSampleSize(t *testing.T) {
+	r := NewRunRecorder(3)
+	ctx := WithRunRecorder(context.Background(), r)
+
+	RecordSample(ctx, []int{1, 2})
+	RecordSample(ctx, []int{3, 4, 5})
+	RecordSample(context.Background(), []int{6}) // no recorder: no-op
+
+	assert.Len(t, r.Sample(), 3)
+	assert.Equal(t, json.RawMessage("3"), r.Sample()[2])
+}
+
+func TestRecordSkips(t *testing.T) {
+	mapper := RecordSkips[string, int](NewRecordMapper("parse", func(s *string) (int, error) {
+		if *s == "" {
+			return 0, errors.New("empty")
+		}
+		return len(*s), nil
+	}))
+	_, ok := mapper.(DetailedMapper[string, int])
+	require.True(t, ok, "a detailed mapper stays detailed")
+
+	r := NewRunRecorder(0)
+	out, err := mapper.Map(WithRunRecorder(context.Background(), r), []string{"ab", "", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, out)
+	require.Len(t, r.SkipReasons(), 1)
+	assert.Contains(t, r.SkipReasons()[0], "empty")
+
+	bad := make([]string, MaxSkipReasons+5)
+	_, err = mapper.Map(WithRunRecorder(context.Background(), r), bad)
+	require.NoError(t, err)
+	assert.Len(t, r.SkipReasons(), MaxSkipReasons, "skip reasons are capped per run")
+
+	plain := IdentityMapper[int]()
+	assert.NotNil(t, RecordSkips(plain), "plain mappers are returned as is")
+}
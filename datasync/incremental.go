@@ -0,0 +1,237 @@
+package datasync
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+// Watermark is how far an incremental sync has read: the highest watermark
+// value written and the IDs of the records at exactly that value. The
+// boundary IDs let the next run fetch inclusively, so records that tie with
+// the watermark are neither missed nor written twice.
+type Watermark[K any] struct {
+	Value    K        `json:"value"`
+	Boundary []string `json:"boundary,omitempty"`
+}
+
+// WatermarkTracker persists the watermark of an incremental job. It plays
+// the role chunk.ProgressTracker plays for partitioned syncs.
+type WatermarkTracker[K any] interface {
+	// Watermark returns the saved watermark for the named job. The bool
+	// reports whether one has been saved.
+	Watermark(ctx context.Context, jobName string) (Watermark[K], bool, error)
+	// SaveWatermark records the watermark after a successful write.
+	SaveWatermark(ctx context.Context, jobName string, w Watermark[K]) error
+}
+
+// WatermarkKeyPrefix is the key prefix StoreWatermarkTracker stores
+// watermarks under.
+const WatermarkKeyPrefix = "watermarks"
+
+// StoreWatermarkTracker keeps watermarks in a store.Store, one key per job
+// under "watermarks/<job>".
+type StoreWatermarkTracker[K any] struct {
+	store store.Store[Watermark[K]]
+}
+
+// NewStoreWatermarkTracker creates a WatermarkTracker backed by s. Use
+// store.NewJSONStore to build one from a RawStore.
+func NewStoreWatermarkTracker[K any](s store.Store[Watermark[K]]) *StoreWatermarkTracker[K] {
+	return &StoreWatermarkTracker[K]{store: s}
+}
+
+func (t *StoreWatermarkTracker[K]) key(jobName string) string {
+	return store.NewKeyBuilder().WithName(WatermarkKeyPrefix).WithWorkflow(jobName).Build()
+}
+
+// Watermark loads the job's watermark.
+func (t *StoreWatermarkTracker[K]) Watermark(ctx context.Context, jobName string) (Watermark[K], bool, error) {
+	w, err := t.store.Load(ctx, t.key(jobName))
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return Watermark[K]{}, false, nil
+	case err != nil:
+		return Watermark[K]{}, false, fmt.Errorf("load watermark for %s: %w", jobName, err)
+	}
+	return w, true, nil
+}
+
+// SaveWatermark stores the job's watermark.
+func (t *StoreWatermarkTracker[K]) SaveWatermark(ctx context.Context, jobName string, w Watermark[K]) error {
+	if err := t.store.Save(ctx, t.key(jobName), w); err != nil {
+		return fmt.Errorf("save watermark for %s: %w", jobName, err)
+	}
+	return nil
+}
+
+// SinceSource is a Source that can fetch only the records whose watermark
+// column is at or after since, for example
+// "WHERE updated_at >= $1". The comparison must be inclusive.
+type SinceSource[T, K any] interface {
+	Source[T]
+	FetchSince(ctx context.Context, since K) ([]T, error)
+}
+
+// IncrementalSource is a Source that fetches only what changed since the
+// job's last successful run. Runner and the SyncData activity detect it and
+// commit the returned ChangeSet once the sink has written its records.
+type IncrementalSource[T any] interface {
+	Source[T]
+	FetchIncremental(ctx context.Context, jobName string) (ChangeSet[T], error)
+}
+
+// ChangeSet is the result of an incremental fetch.
+type ChangeSet[T any] struct {
+	Records []T
+	// Since describes the watermark the fetch started from; it is empty
+	// for the first, full fetch.
+	Since  string
+	commit func(ctx context.Context) error
+}
+
+// NewChangeSet creates a ChangeSet whose Commit calls commit. Custom
+// IncrementalSource implementations use it; commit may be nil.
+func NewChangeSet[T any](records []T, since string, commit func(ctx context.Context) error) ChangeSet[T] {
+	return ChangeSet[T]{Records: records, Since: since, commit: commit}
+}
+
+// Commit advances the job's watermark past Records. Call it only after
+// they have been written.
+func (c ChangeSet[T]) Commit(ctx context.Context) error {
+	if c.commit == nil {
+		return nil
+	}
+	return c.commit(ctx)
+}
+
+// Incremental turns a SinceSource into an IncrementalSource. The first run
+// fetches everything; later runs fetch from the saved watermark, rewound by
+// the overlap window, and drop the boundary records the previous run
+// already wrote. Records inside the overlap window are written again, so
+// the sink must be idempotent (see UpsertSink).
+type Incremental[T, K any] struct {
+	source    SinceSource[T, K]
+	tracker   WatermarkTracker[K]
+	watermark func(r *T) K
+	id        func(r *T) string
+	compare   func(a, b K) int
+	rewind    func(K) K
+}
+
+// NewIncremental creates an Incremental over an ordered watermark such as
+// an auto-increment ID. watermark reads a record's watermark value and id
+// its unique key.
+func NewIncremental[T any, K cmp.Ordered](
+	source SinceSource[T, K],
+	tracker WatermarkTracker[K],
+	watermark func(r *T) K,
+	id func(r *T) string,
+) *Incremental[T, K] {
+	return &Incremental[T, K]{source: source, tracker: tracker, watermark: watermark, id: id, compare: cmp.Compare[K]}
+}
+
+// NewTimeIncremental creates an Incremental over a timestamp such as an
+// updated_at column.
+func NewTimeIncremental[T any](
+	source SinceSource[T, time.Time],
+	tracker WatermarkTracker[time.Time],
+	watermark func(r *T) time.Time,
+	id func(r *T) string,
+) *Incremental[T, time.Time] {
+	return &Incremental[T, time.Time]{source: source, tracker: tracker, watermark: watermark, id: id, compare: time.Time.Compare}
+}
+
+// Overlap sets how far before the saved watermark each fetch starts, to pick
+// up late-arriving records committed with an older watermark value.
+func (s *Incremental[T, K]) Overlap(rewind func(K) K) *Incremental[T, K] {
+	s.rewind = rewind
+	return s
+}
+
+// TimeOverlap rewinds a timestamp watermark by d, for Incremental.Overlap.
+func TimeOverlap(d time.Duration) func(time.Time) time.Time {
+	return func(t time.Time) time.Time { return t.Add(-d) }
+}
+
+// Name returns the underlying source's name.
+func (s *Incremental[T, K]) Name() string {
+	return s.source.Name()
+}
+
+// Fetch fetches everything, ignoring the watermark.
+func (s *Incremental[T, K]) Fetch(ctx context.Context) ([]T, error) {
+	return s.source.Fetch(ctx)
+}
+
+// FetchIncremental fetches the records changed since jobName's watermark.
+func (s *Incremental[T, K]) FetchIncremental(ctx context.Context, jobName string) (ChangeSet[T], error) {
+	last, ok, err := s.tracker.Watermark(ctx, jobName)
+	if err != nil {
+		return ChangeSet[T]{}, err
+	}
+
+	var cs ChangeSet[T]
+	if !ok {
+		if cs.Records, err = s.source.Fetch(ctx); err != nil {
+			return ChangeSet[T]{}, err
+		}
+	} else {
+		since := last.Value
+		if s.rewind != nil {
+			since = s.rewind(since)
+		}
+		records, err := s.source.FetchSince(ctx, since)
+		if err != nil {
+			return ChangeSet[T]{}, err
+		}
+		cs.Since = fmt.Sprint(since)
+		cs.Records = slices.DeleteFunc(records, func(r T) bool {
+			return s.compare(s.watermark(&r), last.Value) == 0 && slices.Contains(last.Boundary, s.id(&r))
+		})
+	}
+
+	next, advanced := s.advance(last, ok, cs.Records)
+	if advanced {
+		cs.commit = func(ctx context.Context) error {
+			return s.tracker.SaveWatermark(ctx, jobName, next)
+		}
+	}
+	return cs, nil
+}
+
+// advance returns the watermark after records. It never moves backwards:
+// records older than last, such as late arrivals in the overlap window, do
+// not change it, and records tying with last join its boundary.
+func (s *Incremental[T, K]) advance(last Watermark[K], hasLast bool, records []T) (Watermark[K], bool) {
+	if len(records) == 0 {
+		return last, false
+	}
+	next := Watermark[K]{Value: s.watermark(&records[0])}
+	for i := range records {
+		v := s.watermark(&records[i])
+		switch c := s.compare(v, next.Value); {
+		case c > 0:
+			next = Watermark[K]{Value: v, Boundary: []string{s.id(&records[i])}}
+		case c == 0:
+			next.Boundary = append(next.Boundary, s.id(&records[i]))
+		}
+	}
+
+	if hasLast {
+		switch c := s.compare(next.Value, last.Value); {
+		case c < 0:
+			return last, false
+		case c == 0:
+			next.Boundary = append(slices.Clone(last.Boundary), next.Boundary...)
+		}
+	}
+	slices.Sort(next.Boundary)
+	next.Boundary = slices.Compact(next.Boundary)
+	return next, true
+}
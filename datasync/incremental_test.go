@@ -0,0 +1,215 @@
+package datasync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+type change struct {
+	ID string
+	At time.Time
+}
+
+// changeTable is a SinceSource over an in-memory table.
+type changeTable struct {
+	rows   []change
+	sinces []time.Time
+}
+
+func (c *changeTable) Name() string { return "changes" }
+func (c *changeTable) Fetch(_ context.Context) ([]change, error) {
+	return append([]change(nil), c.rows...), nil
+}
+
+func (c *changeTable) FetchSince(_ context.Context, since time.Time) ([]change, error) {
+	c.sinces = append(c.sinces, since)
+	var out []change
+	for _, r := range c.rows {
+		if !r.At.Before(since) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func newWatermarkTracker() *StoreWatermarkTracker[time.Time] {
+	return NewStoreWatermarkTracker(store.NewJSONStore[Watermark[time.Time]](store.NewMemoryStore()))
+}
+
+func newChangeIncremental(table *changeTable, tracker WatermarkTracker[time.Time]) *Incremental[change, time.Time] {
+	return NewTimeIncremental[change](table, tracker,
+		func(r *change) time.Time { return r.At },
+		func(r *change) string { return r.ID })
+}
+
+func changeIDs(records []change) []string {
+	ids := make([]string, len(records))
+	for i, r := range records {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+var changeEpoch = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestIncremental_FirstRunFetchesAll(t *testing.T) {
+	table := &changeTable{rows: []change{{"a", changeEpoch}, {"b", changeEpoch.Add(time.Minute)}}}
+	tracker := newWatermarkTracker()
+	inc := newChangeIncremental(table, tracker)
+	ctx := context.Background()
+
+	cs, err := inc.FetchIncremental(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, changeIDs(cs.Records))
+	assert.Empty(t, cs.Since)
+	assert.Empty(t, table.sinces, "first run is a full fetch")
+
+	_, ok, err := tracker.Watermark(ctx, "job")
+	require.NoError(t, err)
+	assert.False(t, ok, "nothing is saved before Commit")
+
+	require.NoError(t, cs.Commit(ctx))
+	wm, ok, err := tracker.Watermark(ctx, "job")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, wm.Value.Equal(changeEpoch.Add(time.Minute)))
+	assert.Equal(t, []string{"b"}, wm.Boundary)
+}
+
+func TestIncremental_BoundaryTies(t *testing.T) {
+	table := &changeTable{rows: []change{{"a", changeEpoch}, {"b", changeEpoch}}}
+	tracker := newWatermarkTracker()
+	inc := newChangeIncremental(table, tracker)
+	ctx := context.Background()
+
+	cs, err := inc.FetchIncremental(ctx, "job")
+	require.NoError(t, err)
+	require.NoError(t, cs.Commit(ctx))
+
+	// "c" arrives later with the same timestamp as the boundary.
+	table.rows = append(table.rows, change{"c", changeEpoch}, change{"d", changeEpoch.Add(time.Second)})
+	cs, err = inc.FetchIncremental(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, changeIDs(cs.Records), "boundary records already written are dropped")
+	assert.True(t, table.sinces[0].Equal(changeEpoch), "fetch is inclusive of the watermark")
+	require.NoError(t, cs.Commit(ctx))
+
+	cs, err = inc.FetchIncremental(ctx, "job")
+	require.NoError(t, err)
+	assert.Empty(t, cs.Records)
+	require.NoError(t, cs.Commit(ctx), "an empty change set commits nothing")
+
+	wm, _, err := tracker.Watermark(ctx, "job")
+	require.NoError(t, err)
+	assert.True(t, wm.Value.Equal(changeEpoch.Add(time.Second)))
+	assert.Equal(t, []string{"d"}, wm.Boundary)
+}
+
+func TestIncremental_SameValueExtendsBoundary(t *testing.T) {
+	table := &changeTable{rows: []change{{"a", changeEpoch}}}
+	tracker := newWatermarkTracker()
+	inc := newChangeIncremental(table, tracker)
+	ctx := context.Background()
+
+	cs, err := inc.FetchIncremental(ctx, "job")
+	require.NoError(t, err)
+	require.NoError(t, cs.Commit(ctx))
+
+	table.rows = append(table.rows, change{"b", changeEpoch})
+	cs, err = inc.FetchIncremental(ctx, "job")
+	require.NoError(t, err)
+	require.NoError(t, cs.Commit(ctx))
+
+	wm, _, err := tracker.Watermark(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, wm.Boundary)
+}
+
+func TestIncremental_OverlapPicksUpLateArrivals(t *testing.T) {
+	table := &changeTable{rows: []change{{"a", changeEpoch}}}
+	tracker := newWatermarkTracker()
+	inc := newChangeIncremental(table, tracker).Overlap(TimeOverlap(5 * time.Minute))
+	ctx := context.Background()
+
+	cs, err := inc.FetchIncremental(ctx, "job")
+	require.NoError(t, err)
+	require.NoError(t, cs.Commit(ctx))
+
+	// "late" was committed after the last run with an older timestamp.
+	table.rows = append(table.rows, change{"late", changeEpoch.Add(-2 * time.Minute)})
+	cs, err = inc.FetchIncremental(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"late"}, changeIDs(cs.Records))
+	assert.True(t, table.sinces[0].Equal(changeEpoch.Add(-5*time.Minute)))
+	require.NoError(t, cs.Commit(ctx))
+
+	wm, _, err := tracker.Watermark(ctx, "job")
+	require.NoError(t, err)
+	assert.True(t, wm.Value.Equal(changeEpoch), "late arrivals never move the watermark back")
+	assert.Equal(t, []string{"a"}, wm.Boundary)
+}
+
+func TestNewIncremental_OrderedID(t *testing.T) {
+	type row struct{ ID int64 }
+	tracker := NewStoreWatermarkTracker(store.NewJSONStore[Watermark[int64]](store.NewMemoryStore()))
+	require.NoError(t, tracker.SaveWatermark(context.Background(), "job", Watermark[int64]{Value: 2, Boundary: []string{"2"}}))
+
+	source := &sinceFunc[row, int64]{fetchSince: func(since int64) []row {
+		assert.Equal(t, int64(2), since)
+		return []row{{2}, {3}, {4}}
+	}}
+	inc := NewIncremental[row, int64](source, tracker,
+		func(r *row) int64 { return r.ID },
+		func(r *row) string { return fmt.Sprint(r.ID) })
+
+	cs, err := inc.FetchIncremental(context.Background(), "job")
+	require.NoError(t, err)
+	assert.Equal(t, []row{{3}, {4}}, cs.Records)
+	assert.Equal(t, "2", cs.Since)
+}
+
+type sinceFunc[T, K any] struct {
+	fetchSince func(since K) []T
+}
+
+func (s *sinceFunc[T, K]) Name() string                         { return "since" }
+func (s *sinceFunc[T, K]) Fetch(_ context.Context) ([]T, error) { return nil, nil }
+func (s *sinceFunc[T, K]) FetchSince(_ context.Context, since K) ([]T, error) {
+	return s.fetchSince(since), nil
+}
+
+func TestRunner_IncrementalAdvancesAfterWrite(t *testing.T) {
+	table := &changeTable{rows: []change{{"a", changeEpoch}}}
+	tracker := newWatermarkTracker()
+	inc := newChangeIncremental(table, tracker)
+	ctx := context.Background()
+
+	failing := &mockSink[change]{name: "dst", err: errors.New("write failed")}
+	_, err := NewRunner[change, change](inc, IdentityMapper[change](), failing).JobName("orders").Run(ctx)
+	require.Error(t, err)
+	_, ok, err := tracker.Watermark(ctx, "orders")
+	require.NoError(t, err)
+	assert.False(t, ok, "a failed write leaves the watermark alone")
+
+	sink := &mockSink[change]{name: "dst", result: WriteResult{Inserted: 1}}
+	_, err = NewRunner[change, change](inc, IdentityMapper[change](), sink).Run(ctx)
+	require.ErrorContains(t, err, "needs a JobName")
+
+	_, err = NewRunner[change, change](inc, IdentityMapper[change](), sink).JobName("orders").Run(ctx)
+	require.NoError(t, err)
+	wm, ok, err := tracker.Watermark(ctx, "orders")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, wm.Value.Equal(changeEpoch))
+	_, ok, err = tracker.Watermark(ctx, inc.Name())
+	require.NoError(t, err)
+	assert.False(t, ok, "the watermark is keyed by the job, not the source")
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	pkgotel "github.com/jasoet/pkg/v2/otel"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
 )
 
 // FindFunc looks up a record by its ID. It returns nil if the record does not exist.
@@ -19,6 +21,8 @@ type InsertIfAbsentSink[U any, ID comparable] struct {
 	getID  func(r *U) ID
 	find   FindFunc[U, ID]
 	create CreateFunc[U]
+
+	isRecordError func(error) bool
 }
 
 // NewInsertIfAbsentSink creates a new InsertIfAbsentSink.
@@ -41,10 +45,27 @@ func (s *InsertIfAbsentSink[U, ID]) Name() string {
 	return s.name
 }
 
+// WithRecordErrors sets how create errors are classified. Errors for which
+// isRecordError returns true reject only their record; any other error fails
+// the batch at once, so an outage is retried instead of dead-lettered.
+func (s *InsertIfAbsentSink[U, ID]) WithRecordErrors(isRecordError func(error) bool) *InsertIfAbsentSink[U, ID] {
+	s.isRecordError = isRecordError
+	return s
+}
+
 // Write iterates records, skipping those that already exist and creating the rest.
+// A record that fails to create does not stop the batch: the remaining records
+// are written and the failures are returned as a *PartialWriteError, which
+// WithDeadLetters turns into dead letters. A find error still fails the batch,
+// since it usually means the destination is unavailable. Without
+// WithRecordErrors, a batch in which every create failed is also treated as
+// unavailable and fails with the first error; retrying it is safe, since
+// records written by an earlier attempt are skipped.
 func (s *InsertIfAbsentSink[U, ID]) Write(ctx context.Context, records []U) (WriteResult, error) {
 	logger := pkgotel.NewLogHelper(ctx, pkgotel.ConfigFromContext(ctx), "datasync.sink", s.name)
 	var result WriteResult
+	var failures []RecordError
+	var firstErr error
 
 	for i := range records {
 		record := &records[i]
@@ -62,7 +83,18 @@ func (s *InsertIfAbsentSink[U, ID]) Write(ctx context.Context, records []U) (Wri
 		}
 
 		if err := s.create(ctx, record); err != nil {
-			return result, fmt.Errorf("%s: create record %v: %w", s.name, id, err)
+			if s.isRecordError != nil && !s.isRecordError(err) {
+				return result, fmt.Errorf("%s: create record %v: %w", s.name, id, err)
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: create record %v: %w", s.name, id, err)
+			}
+			failures = append(failures, RecordError{
+				Index: i,
+				Error: fmt.Sprintf("%s: create record %v: %v", s.name, id, err),
+			})
+			logger.Warn("create failed, continuing", pkgotel.F("id", id), pkgotel.F("error", err.Error()))
+			continue
 		}
 
 		result.Inserted++
@@ -71,7 +103,43 @@ func (s *InsertIfAbsentSink[U, ID]) Write(ctx context.Context, records []U) (Wri
 	logger.Debug("write complete",
 		pkgotel.F("inserted", result.Inserted),
 		pkgotel.F("skipped", result.Skipped),
-		pkgotel.F("total", result.Total()))
+		pkgotel.F("total", result.Total()),
+		pkgotel.F("failed", len(failures)))
 
+	if len(failures) > 0 && result.Inserted == 0 && s.isRecordError == nil {
+		return result, firstErr
+	}
+	if len(failures) > 0 {
+		return result, &PartialWriteError{Failures: failures}
+	}
 	return result, nil
 }
+
+// Plan reports what Write would do with records: existing records would be
+// skipped and the rest inserted. It looks records up but creates nothing.
+func (s *InsertIfAbsentSink[U, ID]) Plan(ctx context.Context, records []U) (payload.SyncPlan, error) {
+	var plan payload.SyncPlan
+	for i := range records {
+		record := &records[i]
+		id := s.getID(record)
+
+		existing, err := s.find(ctx, id)
+		if err != nil {
+			return plan, fmt.Errorf("%s: find record %v: %w", s.name, id, err)
+		}
+		if existing != nil {
+			plan.WouldSkip++
+			continue
+		}
+
+		plan.WouldInsert++
+		if len(plan.Diffs) < payload.MaxPlanDiffs {
+			diff, err := NewRecordDiff(id, nil, record)
+			if err != nil {
+				return plan, fmt.Errorf("%s: %w", s.name, err)
+			}
+			plan.Diffs = append(plan.Diffs, diff)
+		}
+	}
+	return plan, nil
+}
@@ -2,6 +2,7 @@ package datasync
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -108,7 +109,90 @@ func TestInsertIfAbsentSink_Write_CreateError(t *testing.T) {
 		func(_ context.Context, _ *testRecord) error { return fmt.Errorf("create failed") },
 	)
 
-	_, err := sink.Write(context.Background(), []testRecord{{ID: "1"}})
+	_, err := sink.Write(context.Background(), []testRecord{{ID: "1"}, {ID: "2"}})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "create record")
+	var partial *PartialWriteError
+	assert.False(t, errors.As(err, &partial), "a batch whose creates all fail is not dead-lettered")
+}
+
+func TestInsertIfAbsentSink_Write_RecordErrors(t *testing.T) {
+	errRejected := errors.New("constraint violation")
+	errDown := errors.New("connection refused")
+	var created []string
+	sink := NewInsertIfAbsentSink[testRecord, string](
+		"test-sink",
+		func(r *testRecord) string { return r.ID },
+		func(_ context.Context, _ string) (*testRecord, error) { return nil, nil },
+		func(_ context.Context, r *testRecord) error {
+			switch r.ID {
+			case "1":
+				return errRejected
+			case "3":
+				return errDown
+			}
+			created = append(created, r.ID)
+			return nil
+		},
+	).WithRecordErrors(func(err error) bool { return errors.Is(err, errRejected) })
+
+	_, err := sink.Write(context.Background(), []testRecord{{ID: "1"}})
+	var partial *PartialWriteError
+	require.ErrorAs(t, err, &partial, "a classified rejection is a record failure even when nothing was written")
+
+	result, err := sink.Write(context.Background(), []testRecord{{ID: "2"}, {ID: "3"}, {ID: "4"}})
+	require.ErrorIs(t, err, errDown)
+	assert.False(t, errors.As(err, &partial), "an unclassified error fails the batch")
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, []string{"2"}, created)
+}
+
+func TestInsertIfAbsentSink_Write_CreateErrorContinues(t *testing.T) {
+	var created []string
+	sink := NewInsertIfAbsentSink[testRecord, string](
+		"test-sink",
+		func(r *testRecord) string { return r.ID },
+		func(_ context.Context, _ string) (*testRecord, error) { return nil, nil },
+		func(_ context.Context, r *testRecord) error {
+			if r.ID == "2" {
+				return fmt.Errorf("constraint violation")
+			}
+			created = append(created, r.ID)
+			return nil
+		},
+	)
+
+	result, err := sink.Write(context.Background(), []testRecord{{ID: "1"}, {ID: "2"}, {ID: "3"}})
+	var partial *PartialWriteError
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, []RecordError{{Index: 1, Error: "test-sink: create record 2: constraint violation"}}, partial.Failures)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, []string{"1", "3"}, created)
+}
+
+func TestInsertIfAbsentSink_Plan(t *testing.T) {
+	var created int
+	sink := NewInsertIfAbsentSink[testRecord, string](
+		"test-sink",
+		func(r *testRecord) string { return r.ID },
+		func(_ context.Context, id string) (*testRecord, error) {
+			if id == "1" {
+				return &testRecord{ID: "1"}, nil
+			}
+			return nil, nil
+		},
+		func(_ context.Context, _ *testRecord) error {
+			created++
+			return nil
+		},
+	)
+
+	plan, err := sink.Plan(context.Background(), []testRecord{{ID: "1", Name: "a"}, {ID: "2", Name: "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.WouldInsert)
+	assert.Equal(t, 1, plan.WouldSkip)
+	assert.Zero(t, created)
+	require.Len(t, plan.Diffs, 1)
+	assert.Equal(t, "2", plan.Diffs[0].ID)
+	assert.JSONEq(t, `{"ID":"2","Name":"b"}`, string(plan.Diffs[0].After))
 }
@@ -22,5 +22,24 @@ type Job[T any, U any] struct {
 	RetryMaxInterval        time.Duration
 
 	Metadata any
-	Store    store.RawStore
+
+	// Store, when set, keeps a run history: each run's summary and mapper
+	// skip reasons, and a sample of up to SampleSize fetched records, under
+	// RunKey(Name, runID). Read it back with NewRunHistory(Store).
+	Store      store.RawStore
+	SampleSize int
+
+	// DryRun makes every run a dry run: records are fetched and mapped, and
+	// the sink, which must be a Planner, plans the write instead of doing it.
+	// A single run can also be a dry run through SyncExecutionInput.DryRun.
+	DryRun bool
+
+	// Quality checks mapped records before they are written; see
+	// WithQualityChecks. Quarantining checks send records to DeadLetters.
+	Quality *QualityChecks[U]
+
+	// DeadLetters receives records that fail individually; see
+	// WithDeadLetters. When it is a DeadLetterQueue, a replay workflow is
+	// registered with the job as well.
+	DeadLetters DeadLetterWriter
 }
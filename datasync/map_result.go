@@ -13,6 +13,10 @@ type MapResult[U any] struct {
 	Records     []U      `json:"records"`
 	Skipped     int      `json:"skipped"`
 	SkipReasons []string `json:"skipReasons,omitempty"`
+
+	// Failures identifies the skipped records by their index in the input,
+	// so they can be dead-lettered with the original record.
+	Failures []RecordError `json:"failures,omitempty"`
 }
 
 // DetailedMapper extends Mapper with a MapDetailed method that returns
@@ -28,7 +32,8 @@ type RecordMapFunc[T any, U any] func(record *T) (U, error)
 
 // RecordMapper implements both Mapper and DetailedMapper by applying a
 // per-record conversion function. Records that return an error are skipped
-// with a warning log instead of failing the entire batch.
+// with a warning log instead of failing the entire batch; wrap the mapper with
+// WithDeadLetters to keep them.
 type RecordMapper[T any, U any] struct {
 	name string
 	fn   RecordMapFunc[T, U]
@@ -63,6 +68,7 @@ func (m *RecordMapper[T, U]) MapDetailed(ctx context.Context, records []T) MapRe
 			result.Skipped++
 			reason := fmt.Sprintf("record %d: %s", i, err.Error())
 			result.SkipReasons = append(result.SkipReasons, reason)
+			result.Failures = append(result.Failures, RecordError{Index: i, Error: err.Error()})
 			logger.Warn("skipping record",
 				pkgotel.F("index", i),
 				pkgotel.F("error", err.Error()))
@@ -33,6 +33,10 @@ func TestRecordMapper_MapDetailed_WithSkips(t *testing.T) {
 	assert.Len(t, result.SkipReasons, 2)
 	assert.Contains(t, result.SkipReasons[0], "record 1")
 	assert.Contains(t, result.SkipReasons[1], "record 3")
+	assert.Equal(t, []RecordError{
+		{Index: 1, Error: "even numbers not allowed"},
+		{Index: 3, Error: "even numbers not allowed"},
+	}, result.Failures)
 }
 
 func TestRecordMapper_MapDetailed_AllSkipped(t *testing.T) {
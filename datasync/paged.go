@@ -0,0 +1,132 @@
+package datasync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
+
+// PagedSource is a Source that can also be read one page at a time. Runner
+// and the SyncData activity detect it and fetch, map and write page by page,
+// so only one page is held in memory.
+type PagedSource[T any] interface {
+	Source[T]
+	// FetchPage returns the records at cursor and the cursor of the next
+	// page. An empty cursor fetches the first page; an empty next cursor
+	// marks the last page.
+	FetchPage(ctx context.Context, cursor string) (records []T, next string, err error)
+}
+
+// FetchAll reads every page of source into one slice. PagedSource
+// implementations can use it to implement Fetch.
+func FetchAll[T any](ctx context.Context, source PagedSource[T]) ([]T, error) {
+	var all []T
+	cursor := ""
+	for {
+		records, next, err := source.FetchPage(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, records...)
+		if next == "" {
+			return all, nil
+		}
+		cursor = next
+	}
+}
+
+// PageProgress is the running state of a paged sync. The SyncData activity
+// heartbeats it after every page and resumes from it on retry.
+type PageProgress struct {
+	// Cursor is the cursor of the next page to fetch.
+	Cursor string `json:"cursor,omitempty"`
+	// Done is set once the last page has been written.
+	Done         bool        `json:"done,omitempty"`
+	Pages        int         `json:"pages"`
+	TotalFetched int         `json:"totalFetched"`
+	WriteResult  WriteResult `json:"writeResult"`
+	// Plan accumulates the pages of a dry run.
+	Plan *payload.SyncPlan `json:"plan,omitempty"`
+	// MapSkipped counts the records the mapper skipped; SkipReasons keeps
+	// the first MaxSkipReasons reasons a DetailedMapper gave.
+	MapSkipped  int      `json:"mapSkipped,omitempty"`
+	SkipReasons []string `json:"skipReasons,omitempty"`
+}
+
+// SyncPages fetches, maps (with MapRun) and writes the pages of source
+// starting at from, which is the zero value for a fresh run. onPage, if non-nil, is called with
+// the progress after each page is written. The returned progress covers
+// from plus every page written, also when an error stops the sync. Under a
+// dry-run context (WithDryRun) pages are planned instead of written.
+func SyncPages[T, U any](
+	ctx context.Context,
+	source PagedSource[T],
+	mapper Mapper[T, U],
+	sink Sink[U],
+	from PageProgress,
+	onPage func(PageProgress),
+) (PageProgress, error) {
+	progress := from
+	for !progress.Done {
+		ctx := WithDeadLetterPartition(ctx, fmt.Sprintf("page %d", progress.Pages+1))
+		records, next, err := source.FetchPage(ctx, progress.Cursor)
+		if err != nil {
+			return progress, fmt.Errorf("source %s fetch page %d failed: %w", source.Name(), progress.Pages+1, err)
+		}
+		RecordSample(ctx, records)
+
+		if len(records) > 0 {
+			mr, err := MapRun(ctx, mapper, records)
+			if err != nil {
+				return progress, fmt.Errorf("mapper failed on page %d: %w", progress.Pages+1, err)
+			}
+			mapped := mr.Records
+			progress.MapSkipped += mr.Skipped
+			progress.SkipReasons = AddSkipReasons(progress.SkipReasons, mr.SkipReasons)
+			if IsDryRun(ctx) {
+				plan, err := PlanWrite(ctx, sink, mapped)
+				if err != nil {
+					return progress, fmt.Errorf("sink %s plan page %d failed: %w", sink.Name(), progress.Pages+1, err)
+				}
+				if progress.Plan == nil {
+					progress.Plan = &payload.SyncPlan{}
+				}
+				progress.Plan.Add(plan)
+			} else {
+				wr, err := sink.Write(ctx, mapped)
+				if err != nil {
+					return progress, fmt.Errorf("sink %s write page %d failed: %w", sink.Name(), progress.Pages+1, err)
+				}
+				progress.WriteResult.Add(wr)
+			}
+		}
+
+		progress.Pages++
+		progress.TotalFetched += len(records)
+		progress.Cursor = next
+		progress.Done = next == ""
+		if onPage != nil {
+			onPage(progress)
+		}
+	}
+	return progress, nil
+}
+
+// CheckPages runs CheckRun for a paged run that is done, adding the count
+// checks to the progress's write result, or to its plan for a dry run. The
+// count covers the pages of earlier attempts of a resumed run too.
+func CheckPages[U any](ctx context.Context, sink Sink[U], progress *PageProgress) error {
+	n := progress.TotalFetched - progress.MapSkipped
+	if IsDryRun(ctx) {
+		if progress.Plan == nil {
+			progress.Plan = &payload.SyncPlan{}
+		}
+		quality, err := CheckRun(ctx, sink, n, progress.Plan.Quality)
+		progress.Plan.Quality = quality
+		return err
+	}
+	quality, err := CheckRun(ctx, sink, n, progress.WriteResult.Quality)
+	progress.WriteResult.Quality = quality
+	return err
+}
@@ -0,0 +1,130 @@
+package datasync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedSource serves pages of records keyed by the page index as cursor.
+type pagedSource struct {
+	pages   [][]int
+	failAt  int // page index that fails; -1 for none
+	cursors []string
+}
+
+func (p *pagedSource) Name() string { return "paged" }
+
+func (p *pagedSource) Fetch(ctx context.Context) ([]int, error) { return FetchAll[int](ctx, p) }
+
+func (p *pagedSource) FetchPage(_ context.Context, cursor string) ([]int, string, error) {
+	p.cursors = append(p.cursors, cursor)
+	i := 0
+	if cursor != "" {
+		i, _ = strconv.Atoi(cursor)
+	}
+	if i == p.failAt {
+		return nil, "", errors.New("page unavailable")
+	}
+	next := ""
+	if i+1 < len(p.pages) {
+		next = strconv.Itoa(i + 1)
+	}
+	return p.pages[i], next, nil
+}
+
+// batchSink records the size of every Write.
+type batchSink struct {
+	batches []int
+}
+
+func (b *batchSink) Name() string { return "batches" }
+func (b *batchSink) Write(_ context.Context, records []int) (WriteResult, error) {
+	b.batches = append(b.batches, len(records))
+	return WriteResult{Inserted: len(records)}, nil
+}
+
+func TestRunner_Run_PagedSource(t *testing.T) {
+	source := &pagedSource{pages: [][]int{{1, 2}, {3, 4}, {}, {5}}, failAt: -1}
+	sink := &batchSink{}
+
+	result, err := NewRunner[int, int](source, IdentityMapper[int](), sink).Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, result.TotalFetched)
+	assert.Equal(t, 4, result.Pages)
+	assert.Equal(t, 5, result.WriteResult.Inserted)
+	assert.Equal(t, []int{2, 2, 1}, sink.batches, "one write per non-empty page")
+	assert.Equal(t, []string{"", "1", "2", "3"}, source.cursors)
+}
+
+func TestSyncPages_ResumesFromProgress(t *testing.T) {
+	source := &pagedSource{pages: [][]int{{1, 2}, {3, 4}, {5}}, failAt: -1}
+	sink := &batchSink{}
+	from := PageProgress{Cursor: "2", Pages: 2, TotalFetched: 4, WriteResult: WriteResult{Inserted: 4}}
+
+	var seen []PageProgress
+	progress, err := SyncPages[int, int](context.Background(), source, IdentityMapper[int](), sink, from,
+		func(p PageProgress) { seen = append(seen, p) })
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"2"}, source.cursors)
+	assert.True(t, progress.Done)
+	assert.Equal(t, 3, progress.Pages)
+	assert.Equal(t, 5, progress.TotalFetched)
+	assert.Equal(t, 5, progress.WriteResult.Inserted)
+	assert.Len(t, seen, 1)
+
+	// A finished sync does not fetch again.
+	_, err = SyncPages[int, int](context.Background(), source, IdentityMapper[int](), sink, progress, nil)
+	require.NoError(t, err)
+	assert.Len(t, source.cursors, 1)
+}
+
+func TestSyncPages_ErrorKeepsProgress(t *testing.T) {
+	source := &pagedSource{pages: [][]int{{1}, {2}, {3}}, failAt: 1}
+
+	progress, err := SyncPages[int, int](context.Background(), source, IdentityMapper[int](), &batchSink{}, PageProgress{}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "page 2")
+	assert.Equal(t, "1", progress.Cursor)
+	assert.Equal(t, 1, progress.Pages)
+	assert.False(t, progress.Done)
+}
+
+func TestSyncPages_KeepsMapperSkips(t *testing.T) {
+	source := &pagedSource{pages: [][]int{{1, -2}, {-3, 4}}, failAt: -1}
+	mapper := NewRecordMapper[int, int]("positive", func(r *int) (int, error) {
+		if *r < 0 {
+			return 0, fmt.Errorf("negative value %d", *r)
+		}
+		return *r, nil
+	})
+	ctx := WithSyncRun(context.Background(), NewSyncRun())
+
+	progress, err := SyncPages[int, int](ctx, source, mapper, &batchSink{}, PageProgress{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.MapSkipped)
+	require.Len(t, progress.SkipReasons, 2)
+	assert.Contains(t, progress.SkipReasons[1], "negative value -3")
+	assert.Contains(t, syncRun(ctx).Incomplete(), "mapper skipped 1 records")
+}
+
+func TestAddSkipReasons_Caps(t *testing.T) {
+	reasons := make([]string, MaxSkipReasons+5)
+	kept := AddSkipReasons(nil, reasons[:3])
+	kept = AddSkipReasons(kept, reasons)
+	assert.Len(t, kept, MaxSkipReasons)
+}
+
+func TestFetchAll(t *testing.T) {
+	source := &pagedSource{pages: [][]int{{1}, {2, 3}}, failAt: -1}
+	records, err := source.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, records)
+}
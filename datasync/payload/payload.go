@@ -1,6 +1,8 @@
 package payload
 
 import (
+	"encoding/json"
+	"slices"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -22,6 +24,9 @@ type SyncExecutionInput struct {
 	SourceName string `json:"sourceName" validate:"required,max=255"`
 	SinkName   string `json:"sinkName" validate:"required,max=255"`
 	Metadata   any    `json:"metadata,omitempty"`
+	// DryRun fetches and maps as usual but only plans the write; see
+	// datasync.Planner.
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 func (s *SyncExecutionInput) Validate() error {
@@ -39,10 +44,169 @@ type SyncExecutionOutput struct {
 	Inserted       int           `json:"inserted"`
 	Updated        int           `json:"updated"`
 	Skipped        int           `json:"skipped"`
+	Deleted        int           `json:"deleted,omitempty"`
 	ProcessingTime time.Duration `json:"processingTime"`
 	Success        bool          `json:"success"`
 	Error          string        `json:"error,omitempty"`
+	// DryRun is set for a dry run, whose Plan replaces the write counts.
+	DryRun bool      `json:"dryRun,omitempty"`
+	Plan   *SyncPlan `json:"plan,omitempty"`
+	// Quality reports the data quality checks of the records written.
+	Quality *QualityReport `json:"quality,omitempty"`
+	// Sinks breaks the write counts down by sink name when the job writes
+	// to several sinks.
+	Sinks map[string]SinkResult `json:"sinks,omitempty"`
+	// MapSkipped counts the records the mapper skipped, and SkipReasons
+	// keeps the first reasons it gave.
+	MapSkipped  int      `json:"mapSkipped,omitempty"`
+	SkipReasons []string `json:"skipReasons,omitempty"`
+	// FinishSkipped says why the sink was not finished after the run, for
+	// example because records were skipped or dead-lettered.
+	FinishSkipped string `json:"finishSkipped,omitempty"`
 }
 
 func (s SyncExecutionOutput) IsSuccess() bool  { return s.Success }
 func (s SyncExecutionOutput) GetError() string { return s.Error }
+
+// MaxPlanDiffs is the number of sample diffs a SyncPlan keeps.
+const MaxPlanDiffs = 20
+
+// Record diff actions.
+const (
+	DiffInsert = "insert"
+	DiffUpdate = "update"
+)
+
+// SyncPlan is what a dry run would have written.
+type SyncPlan struct {
+	WouldInsert int `json:"wouldInsert"`
+	WouldUpdate int `json:"wouldUpdate"`
+	WouldSkip   int `json:"wouldSkip"`
+	// Diffs samples up to MaxPlanDiffs of the planned inserts and updates.
+	Diffs []RecordDiff `json:"diffs,omitempty"`
+	// Quality reports the data quality checks of the planned records.
+	Quality *QualityReport `json:"quality,omitempty"`
+}
+
+// Add merges another plan into this one, keeping at most MaxPlanDiffs diffs.
+func (p *SyncPlan) Add(other SyncPlan) {
+	p.WouldInsert += other.WouldInsert
+	p.WouldUpdate += other.WouldUpdate
+	p.WouldSkip += other.WouldSkip
+	if room := MaxPlanDiffs - len(p.Diffs); room > 0 {
+		p.Diffs = append(p.Diffs, other.Diffs[:min(room, len(other.Diffs))]...)
+	}
+	p.Quality = MergeQuality(p.Quality, other.Quality)
+}
+
+// RecordDiff is one planned change. Before is empty for an insert; Fields
+// lists the top-level fields an update changes.
+type RecordDiff struct {
+	ID     string          `json:"id"`
+	Action string          `json:"action"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after"`
+	Fields []string        `json:"fields,omitempty"`
+	// Sink names the sink of a multi-sink plan.
+	Sink string `json:"sink,omitempty"`
+}
+
+// QualityReport is the outcome of the data quality checks of a run.
+type QualityReport struct {
+	Checks []CheckResult `json:"checks"`
+	// Quarantined counts the records held back from the sink.
+	Quarantined int `json:"quarantined,omitempty"`
+}
+
+// CheckResult is the outcome of one data quality check. A check passed
+// when Violations is zero.
+type CheckResult struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	// Violations counts the offending records, or the failed batches of a
+	// batch-level assertion such as a record count.
+	Violations int `json:"violations"`
+	// Message describes the first violation.
+	Message string `json:"message,omitempty"`
+}
+
+// Add merges another report into this one, adding up checks by name.
+func (r *QualityReport) Add(other QualityReport) {
+	r.Quarantined += other.Quarantined
+	for _, c := range other.Checks {
+		i := slices.IndexFunc(r.Checks, func(have CheckResult) bool { return have.Name == c.Name })
+		if i < 0 {
+			r.Checks = append(r.Checks, c)
+			continue
+		}
+		r.Checks[i].Violations += c.Violations
+		if r.Checks[i].Message == "" {
+			r.Checks[i].Message = c.Message
+		}
+	}
+}
+
+// MergeQuality returns a merged with b, either of which may be nil.
+func MergeQuality(a, b *QualityReport) *QualityReport {
+	if b == nil {
+		return a
+	}
+	if a == nil {
+		a = &QualityReport{}
+	}
+	a.Add(*b)
+	return a
+}
+
+// SinkResult is one sink's share of a multi-sink write.
+type SinkResult struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+	Deleted  int `json:"deleted,omitempty"`
+	// Error is set when the sink failed and the write went on without it.
+	Error string `json:"error,omitempty"`
+}
+
+// Add merges another result of the same sink into this one, keeping the
+// first error.
+func (r *SinkResult) Add(other SinkResult) {
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Skipped += other.Skipped
+	r.Deleted += other.Deleted
+	if r.Error == "" {
+		r.Error = other.Error
+	}
+}
+
+// MergeSinkResults adds b's per-sink results into a, allocating a when it
+// is nil.
+func MergeSinkResults(a, b map[string]SinkResult) map[string]SinkResult {
+	if len(b) == 0 {
+		return a
+	}
+	if a == nil {
+		a = make(map[string]SinkResult, len(b))
+	}
+	for name, r := range b {
+		merged := a[name]
+		merged.Add(r)
+		a[name] = merged
+	}
+	return a
+}
+
+// ReplayDeadLettersInput defines input for the dead-letter replay workflow.
+type ReplayDeadLettersInput struct {
+	// Limit caps how many letters one run replays; zero replays all.
+	Limit int `json:"limit,omitempty" validate:"gte=0"`
+}
+
+// ReplayDeadLettersOutput defines output from the dead-letter replay workflow.
+type ReplayDeadLettersOutput struct {
+	JobName  string `json:"jobName"`
+	Total    int    `json:"total"`
+	Replayed int    `json:"replayed"`
+	Failed   int    `json:"failed"`
+}
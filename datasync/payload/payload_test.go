@@ -60,3 +60,42 @@ func TestSyncExecutionOutput_GetError(t *testing.T) {
 	assert.Equal(t, "", SyncExecutionOutput{}.GetError())
 	assert.Equal(t, "something failed", SyncExecutionOutput{Error: "something failed"}.GetError())
 }
+
+func TestSyncPlan_Add(t *testing.T) {
+	diffs := make([]RecordDiff, MaxPlanDiffs-1)
+	plan := SyncPlan{WouldInsert: 1, Diffs: diffs}
+	plan.Add(SyncPlan{WouldInsert: 2, WouldUpdate: 3, WouldSkip: 4, Diffs: []RecordDiff{{ID: "a"}, {ID: "b"}}})
+
+	assert.Equal(t, 3, plan.WouldInsert)
+	assert.Equal(t, 3, plan.WouldUpdate)
+	assert.Equal(t, 4, plan.WouldSkip)
+	require.Len(t, plan.Diffs, MaxPlanDiffs)
+	assert.Equal(t, "a", plan.Diffs[MaxPlanDiffs-1].ID)
+}
+
+func TestQualityReport_Add(t *testing.T) {
+	report := &QualityReport{Checks: []CheckResult{{Name: "unique", Action: "warn", Violations: 1, Message: "duplicate key \"a\""}}, Quarantined: 1}
+	merged := MergeQuality(report, &QualityReport{
+		Checks:      []CheckResult{{Name: "unique", Action: "warn", Violations: 2, Message: "duplicate key \"b\""}, {Name: "min-count", Action: "fail"}},
+		Quarantined: 2,
+	})
+
+	require.Len(t, merged.Checks, 2)
+	assert.Equal(t, 3, merged.Checks[0].Violations)
+	assert.Equal(t, "duplicate key \"a\"", merged.Checks[0].Message, "the first message is kept")
+	assert.Equal(t, "min-count", merged.Checks[1].Name)
+	assert.Equal(t, 3, merged.Quarantined)
+
+	assert.Nil(t, MergeQuality(nil, nil))
+}
+
+func TestMergeSinkResults(t *testing.T) {
+	merged := MergeSinkResults(nil, map[string]SinkResult{"postgres": {Inserted: 1}, "search": {Error: "index down"}})
+	merged = MergeSinkResults(merged, map[string]SinkResult{"postgres": {Updated: 2}, "search": {Inserted: 1, Error: "timeout"}})
+
+	assert.Equal(t, map[string]SinkResult{
+		"postgres": {Inserted: 1, Updated: 2},
+		"search":   {Inserted: 1, Error: "index down"},
+	}, merged)
+	assert.Nil(t, MergeSinkResults(nil, nil))
+}
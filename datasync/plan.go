@@ -0,0 +1,93 @@
+package datasync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
+
+// ErrDryRunUnsupported is returned when a dry run meets a sink that is not a
+// Planner.
+var ErrDryRunUnsupported = errors.New("sink does not support dry runs")
+
+// Planner is a Sink that can report what a Write would do without writing.
+// A dry run calls Plan in place of Write; FinishingSink.Finish and
+// ChangeSet.Commit are not called.
+type Planner[U any] interface {
+	Sink[U]
+	Plan(ctx context.Context, records []U) (payload.SyncPlan, error)
+}
+
+// CanPlan reports whether sink supports dry runs. It sees through the sink
+// WithDeadLetters returns.
+func CanPlan[U any](sink Sink[U]) bool {
+	if w, ok := sink.(interface{ canPlan() bool }); ok {
+		return w.canPlan()
+	}
+	_, ok := sink.(Planner[U])
+	return ok
+}
+
+// PlanWrite plans writing records to sink. It fails with
+// ErrDryRunUnsupported if sink is not a Planner.
+func PlanWrite[U any](ctx context.Context, sink Sink[U], records []U) (payload.SyncPlan, error) {
+	planner, ok := sink.(Planner[U])
+	if !ok {
+		return payload.SyncPlan{}, fmt.Errorf("%s: %w", sink.Name(), ErrDryRunUnsupported)
+	}
+	return planner.Plan(ctx, records)
+}
+
+type dryRunKey struct{}
+
+// WithDryRun returns a context marking a dry run. SyncPages plans instead of
+// writing under it, and WithDeadLetters does not store dead letters.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether ctx marks a dry run.
+func IsDryRun(ctx context.Context) bool {
+	dry, _ := ctx.Value(dryRunKey{}).(bool)
+	return dry
+}
+
+// NewRecordDiff builds the diff of a planned insert (before is nil) or
+// update, for Planner implementations. Fields lists the top-level JSON
+// fields whose values differ.
+func NewRecordDiff(id any, before, after any) (payload.RecordDiff, error) {
+	diff := payload.RecordDiff{ID: fmt.Sprint(id), Action: payload.DiffInsert}
+	var err error
+	if diff.After, err = json.Marshal(after); err != nil {
+		return payload.RecordDiff{}, fmt.Errorf("diff %v: encode record: %w", id, err)
+	}
+	if before == nil {
+		return diff, nil
+	}
+
+	diff.Action = payload.DiffUpdate
+	if diff.Before, err = json.Marshal(before); err != nil {
+		return payload.RecordDiff{}, fmt.Errorf("diff %v: encode existing record: %w", id, err)
+	}
+	var b, a map[string]json.RawMessage
+	if json.Unmarshal(diff.Before, &b) != nil || json.Unmarshal(diff.After, &a) != nil {
+		return diff, nil // not objects: no field breakdown
+	}
+	for name, value := range a {
+		if old, ok := b[name]; !ok || !bytes.Equal(old, value) {
+			diff.Fields = append(diff.Fields, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			diff.Fields = append(diff.Fields, name)
+		}
+	}
+	slices.Sort(diff.Fields)
+	return diff, nil
+}
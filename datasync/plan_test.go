@@ -0,0 +1,116 @@
+package datasync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
+
+// planningSink is a batchSink that plans every record as an insert.
+type planningSink struct {
+	batchSink
+	finished int
+}
+
+func (p *planningSink) Plan(_ context.Context, records []int) (payload.SyncPlan, error) {
+	return payload.SyncPlan{WouldInsert: len(records)}, nil
+}
+
+func (p *planningSink) Finish(context.Context) (WriteResult, error) {
+	p.finished++
+	return WriteResult{}, nil
+}
+
+func TestNewRecordDiff(t *testing.T) {
+	insert, err := NewRecordDiff(7, nil, testRecord{ID: "7", Name: "a"})
+	require.NoError(t, err)
+	assert.Equal(t, payload.RecordDiff{ID: "7", Action: payload.DiffInsert, After: []byte(`{"ID":"7","Name":"a"}`)}, insert)
+
+	update, err := NewRecordDiff("7", map[string]any{"ID": "7", "Name": "a", "Gone": 1}, testRecord{ID: "7", Name: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, payload.DiffUpdate, update.Action)
+	assert.Equal(t, []string{"Gone", "Name"}, update.Fields)
+
+	scalar, err := NewRecordDiff("x", 1, 2)
+	require.NoError(t, err)
+	assert.Empty(t, scalar.Fields, "non-objects have no field breakdown")
+}
+
+func TestCanPlan(t *testing.T) {
+	assert.True(t, CanPlan[int](&planningSink{}))
+	assert.False(t, CanPlan[int](&batchSink{}))
+
+	_, wrapped := WithDeadLetters[int, int]("job", IdentityMapper[int](), &batchSink{}, newMemoryDeadLetterQueue())
+	assert.False(t, CanPlan(wrapped), "the dead-letter wrapper plans only if its sink does")
+	_, err := PlanWrite(context.Background(), wrapped, []int{1})
+	require.ErrorIs(t, err, ErrDryRunUnsupported)
+}
+
+func TestRunner_DryRun(t *testing.T) {
+	sink := &planningSink{}
+	source := &mockSource[int]{name: "src", records: []int{1, 2, 3}}
+
+	result, err := NewRunner[int, int](source, IdentityMapper[int](), sink).DryRun().Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.TotalFetched)
+	require.NotNil(t, result.Plan)
+	assert.Equal(t, 3, result.Plan.WouldInsert)
+	assert.Zero(t, result.WriteResult.Total())
+	assert.Empty(t, sink.batches, "a dry run writes nothing")
+	assert.Zero(t, sink.finished, "a dry run does not finish the sink")
+}
+
+func TestRunner_DryRun_PagedSource(t *testing.T) {
+	sink := &planningSink{}
+	source := &pagedSource{pages: [][]int{{1, 2}, {3}}, failAt: -1}
+
+	result, err := NewRunner[int, int](source, IdentityMapper[int](), sink).DryRun().Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Pages)
+	require.NotNil(t, result.Plan)
+	assert.Equal(t, 3, result.Plan.WouldInsert)
+	assert.Empty(t, sink.batches)
+	assert.Zero(t, sink.finished)
+}
+
+func TestRunner_DryRun_RequiresPlanner(t *testing.T) {
+	source := &mockSource[int]{name: "src", records: []int{1}}
+	_, err := NewRunner[int, int](source, IdentityMapper[int](), &batchSink{}).DryRun().Run(context.Background())
+	require.ErrorIs(t, err, ErrDryRunUnsupported)
+}
+
+func TestRunner_DryRun_LeavesWatermark(t *testing.T) {
+	table := &changeTable{rows: []change{{"a", changeEpoch}}}
+	tracker := newWatermarkTracker()
+	inc := newChangeIncremental(table, tracker)
+	ctx := context.Background()
+
+	sink := NewUpsertSink[change, string]("dst",
+		func(r *change) string { return r.ID },
+		func(context.Context, []string) (map[string]change, error) { return nil, nil },
+		func(context.Context, UpsertBatch[change, string]) error { return nil })
+	result, err := NewRunner[change, change](inc, IdentityMapper[change](), sink).JobName("orders").DryRun().Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Plan.WouldInsert)
+
+	_, ok, err := tracker.Watermark(ctx, "orders")
+	require.NoError(t, err)
+	assert.False(t, ok, "a dry run does not advance the watermark")
+}
+
+func TestWithDeadLetters_DryRunStoresNothing(t *testing.T) {
+	queue := newMemoryDeadLetterQueue()
+	mapper, _ := WithDeadLetters[int, string]("orders", positiveMapper(), &rejectingSink{}, queue)
+
+	mapped, err := mapper.Map(WithDryRun(context.Background()), []int{1, -2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, mapped)
+
+	letters, err := queue.ListDeadLetters(context.Background(), "orders")
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+}
@@ -0,0 +1,567 @@
+package datasync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	pkgotel "github.com/jasoet/pkg/v2/otel"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+// qualityValidator runs the struct-tag rules of QualityChecks.ValidateStruct.
+var qualityValidator = validator.New()
+
+// DeadLetterStageQuality marks a mapped record a data quality check
+// quarantined.
+const DeadLetterStageQuality = "quality"
+
+// QualityAction is what a failed data quality check does.
+type QualityAction string
+
+const (
+	// QualityFail fails the batch, or for a count check the run, before it
+	// is written, except for the count checks of a PagedSource run, which
+	// fail it after its last page is written; see CheckRun.
+	QualityFail QualityAction = "fail"
+	// QualityQuarantine holds the offending records back from the sink and
+	// dead-letters them. Count checks have no offending records and cannot
+	// quarantine.
+	QualityQuarantine QualityAction = "quarantine"
+	// QualityWarn logs the violation and writes the records anyway.
+	QualityWarn QualityAction = "warn"
+)
+
+// QualityError is returned when a check with QualityFail fails.
+type QualityError struct {
+	Report payload.QualityReport
+}
+
+func (e *QualityError) Error() string {
+	var failed []string
+	for _, c := range e.Report.Checks {
+		if c.Action == string(QualityFail) && c.Violations > 0 {
+			failed = append(failed, c.Name+": "+c.Message)
+		}
+	}
+	return "data quality checks failed: " + strings.Join(failed, "; ")
+}
+
+// CountBaseline remembers a job's last record count for
+// QualityChecks.CountDrift.
+type CountBaseline interface {
+	// LastCount returns the named job's last count. The bool reports
+	// whether one has been saved.
+	LastCount(ctx context.Context, jobName string) (int, bool, error)
+	// SaveCount records the count of a successful run.
+	SaveCount(ctx context.Context, jobName string, n int) error
+}
+
+// CountBaselineKeyPrefix is the key prefix StoreCountBaseline stores counts
+// under.
+const CountBaselineKeyPrefix = "quality-baselines"
+
+// StoreCountBaseline keeps counts in a store.Store, one key per job under
+// "quality-baselines/<job>".
+type StoreCountBaseline struct {
+	store store.Store[int]
+}
+
+// NewStoreCountBaseline creates a CountBaseline backed by s. Use
+// store.NewJSONStore to build one from a RawStore.
+func NewStoreCountBaseline(s store.Store[int]) *StoreCountBaseline {
+	return &StoreCountBaseline{store: s}
+}
+
+func (b *StoreCountBaseline) key(jobName string) string {
+	return store.NewKeyBuilder().WithName(CountBaselineKeyPrefix).WithWorkflow(jobName).Build()
+}
+
+// LastCount loads the job's last count.
+func (b *StoreCountBaseline) LastCount(ctx context.Context, jobName string) (int, bool, error) {
+	n, err := b.store.Load(ctx, b.key(jobName))
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("load count baseline for %s: %w", jobName, err)
+	}
+	return n, true, nil
+}
+
+// SaveCount stores the job's count.
+func (b *StoreCountBaseline) SaveCount(ctx context.Context, jobName string, n int) error {
+	if err := b.store.Save(ctx, b.key(jobName), n); err != nil {
+		return fmt.Errorf("save count baseline for %s: %w", jobName, err)
+	}
+	return nil
+}
+
+// QualityChecks is a set of data quality rules applied to mapped records;
+// see WithQualityChecks. Record rules check each record on its own and
+// batch assertions each batch passed to Write as a whole, which is the
+// whole run for a plain Source, a page for a PagedSource and a partition
+// for a chunked sync; both run before the batch is written. Count checks
+// (MinCount, MaxCount, CountDrift) check the number of records of a run:
+// for a plain or incremental Source, which writes one batch, before it is
+// written (CheckCount); for a PagedSource, whose count is known only at
+// the end, once its last page is written (CheckRun); and for a chunked
+// sync, per partition.
+type QualityChecks[U any] struct {
+	records []recordRule[U]
+	batches []batchRule[U]
+	counts  []countRule
+	drift   *driftRule
+}
+
+type recordRule[U any] struct {
+	name   string
+	action QualityAction
+	check  func(r *U) error
+}
+
+type batchRule[U any] struct {
+	name   string
+	action QualityAction
+	check  func(records []U) batchViolation
+}
+
+type countRule struct {
+	name   string
+	action QualityAction
+	check  func(n int) batchViolation
+}
+
+// batchViolation is a failed batch assertion. Indices lists the offending
+// records; nil means the batch as a whole.
+type batchViolation struct {
+	failed  bool
+	count   int
+	message string
+	indices []int
+}
+
+type driftRule struct {
+	baseline CountBaseline
+	maxRatio float64
+	action   QualityAction
+}
+
+// NewQualityChecks creates an empty set of checks.
+func NewQualityChecks[U any]() *QualityChecks[U] {
+	return &QualityChecks[U]{}
+}
+
+// ValidateStruct checks every record against its `validate` struct tags
+// with go-playground/validator, as check "validate".
+func (q *QualityChecks[U]) ValidateStruct(action QualityAction) *QualityChecks[U] {
+	return q.Record("validate", func(r *U) error { return qualityValidator.Struct(r) }, action)
+}
+
+// Record adds a per-record rule: check returns an error for a bad record.
+func (q *QualityChecks[U]) Record(name string, check func(r *U) error, action QualityAction) *QualityChecks[U] {
+	q.records = append(q.records, recordRule[U]{name: name, action: action, check: check})
+	return q
+}
+
+// MinCount asserts that a run has at least n records, as check
+// "min-count". It panics if action is QualityQuarantine.
+func (q *QualityChecks[U]) MinCount(n int, action QualityAction) *QualityChecks[U] {
+	return q.count("min-count", action, func(got int) batchViolation {
+		if got >= n {
+			return batchViolation{}
+		}
+		return batchViolation{failed: true, count: 1, message: fmt.Sprintf("%d records, want at least %d", got, n)}
+	})
+}
+
+// MaxCount asserts that a run has at most n records, as check "max-count".
+// It panics if action is QualityQuarantine.
+func (q *QualityChecks[U]) MaxCount(n int, action QualityAction) *QualityChecks[U] {
+	return q.count("max-count", action, func(got int) batchViolation {
+		if got <= n {
+			return batchViolation{}
+		}
+		return batchViolation{failed: true, count: 1, message: fmt.Sprintf("%d records, want at most %d", got, n)}
+	})
+}
+
+// NullRate asserts that at most maxRate (0 to 1) of a batch's records are
+// null by isNull. When the rate is exceeded, the null records are the
+// offending ones.
+func (q *QualityChecks[U]) NullRate(name string, isNull func(r *U) bool, maxRate float64, action QualityAction) *QualityChecks[U] {
+	return q.batch(name, action, func(records []U) batchViolation {
+		var nulls []int
+		for i := range records {
+			if isNull(&records[i]) {
+				nulls = append(nulls, i)
+			}
+		}
+		rate := float64(len(nulls)) / float64(max(len(records), 1))
+		if rate <= maxRate {
+			return batchViolation{}
+		}
+		return batchViolation{
+			failed:  true,
+			count:   len(nulls),
+			message: fmt.Sprintf("null rate %.3f exceeds %.3f", rate, maxRate),
+			indices: nulls,
+		}
+	})
+}
+
+// Unique asserts that key is unique within a batch. Every record repeating
+// an earlier record's key is an offending one.
+func (q *QualityChecks[U]) Unique(name string, key func(r *U) string, action QualityAction) *QualityChecks[U] {
+	return q.batch(name, action, func(records []U) batchViolation {
+		seen := make(map[string]struct{}, len(records))
+		var v batchViolation
+		for i := range records {
+			k := key(&records[i])
+			if _, dup := seen[k]; !dup {
+				seen[k] = struct{}{}
+				continue
+			}
+			if !v.failed {
+				v = batchViolation{failed: true, message: fmt.Sprintf("duplicate key %q", k)}
+			}
+			v.indices = append(v.indices, i)
+		}
+		v.count = len(v.indices)
+		return v
+	})
+}
+
+// CountDrift asserts, as check "count-drift", that a run's record count is
+// within maxRatio times the count of the last successful run, in either
+// direction: with maxRatio 3, 100 records followed by 301 or 33 fail. The
+// count is kept in baseline under the job name after every non-empty run
+// that passes its count checks, quarantines nothing and is not a dry run;
+// the first run always passes. It panics if action is QualityQuarantine.
+func (q *QualityChecks[U]) CountDrift(baseline CountBaseline, maxRatio float64, action QualityAction) *QualityChecks[U] {
+	mustNotQuarantineCount("count-drift", action)
+	q.drift = &driftRule{baseline: baseline, maxRatio: maxRatio, action: action}
+	return q
+}
+
+// HasCountChecks reports whether any count check is set.
+func (q *QualityChecks[U]) HasCountChecks() bool {
+	return len(q.counts) > 0 || q.drift != nil
+}
+
+func (q *QualityChecks[U]) count(name string, action QualityAction, check func(n int) batchViolation) *QualityChecks[U] {
+	mustNotQuarantineCount(name, action)
+	q.counts = append(q.counts, countRule{name: name, action: action, check: check})
+	return q
+}
+
+func mustNotQuarantineCount(name string, action QualityAction) {
+	if action == QualityQuarantine {
+		panic(fmt.Sprintf("datasync.QualityChecks: count check %q cannot quarantine: a count has no offending records", name))
+	}
+}
+
+func (q *QualityChecks[U]) batch(name string, action QualityAction, check func(records []U) batchViolation) *QualityChecks[U] {
+	q.batches = append(q.batches, batchRule[U]{name: name, action: action, check: check})
+	return q
+}
+
+// Quarantines reports whether any check quarantines records, which
+// requires a DeadLetterWriter.
+func (q *QualityChecks[U]) Quarantines() bool {
+	for _, r := range q.records {
+		if r.action == QualityQuarantine {
+			return true
+		}
+	}
+	for _, b := range q.batches {
+		if b.action == QualityQuarantine {
+			return true
+		}
+	}
+	return false
+}
+
+// qualityOutcome is the result of checking one batch or run.
+type qualityOutcome struct {
+	report payload.QualityReport
+	failed bool
+	// reasons maps the index of each quarantined record to the check that
+	// quarantined it.
+	reasons map[int]string
+}
+
+// apply adds the result of a check to the outcome.
+func (out *qualityOutcome) apply(name string, action QualityAction, v batchViolation) {
+	out.report.Checks = append(out.report.Checks, payload.CheckResult{
+		Name: name, Action: string(action), Violations: v.count, Message: v.message,
+	})
+	if !v.failed {
+		return
+	}
+	switch action {
+	case QualityFail:
+		out.failed = true
+	case QualityQuarantine:
+		for _, i := range v.indices {
+			if _, ok := out.reasons[i]; !ok {
+				out.reasons[i] = name + ": " + v.message
+			}
+		}
+	}
+}
+
+// evaluate runs the record rules and batch assertions against records.
+func (q *QualityChecks[U]) evaluate(records []U) qualityOutcome {
+	out := qualityOutcome{reasons: make(map[int]string)}
+	for _, rule := range q.records {
+		var v batchViolation
+		for i := range records {
+			if err := rule.check(&records[i]); err != nil {
+				if !v.failed {
+					v = batchViolation{failed: true, message: err.Error()}
+				}
+				v.indices = append(v.indices, i)
+			}
+		}
+		v.count = len(v.indices)
+		out.apply(rule.name, rule.action, v)
+	}
+	for _, rule := range q.batches {
+		out.apply(rule.name, rule.action, rule.check(records))
+	}
+	out.report.Quarantined = len(out.reasons)
+	return out
+}
+
+// evaluateCounts runs the count checks against a run of n records.
+func (q *QualityChecks[U]) evaluateCounts(ctx context.Context, jobName string, n int) (qualityOutcome, error) {
+	var out qualityOutcome
+	for _, rule := range q.counts {
+		out.apply(rule.name, rule.action, rule.check(n))
+	}
+	if q.drift != nil {
+		v, err := q.drift.check(ctx, jobName, n)
+		if err != nil {
+			return out, err
+		}
+		out.apply("count-drift", q.drift.action, v)
+	}
+	return out, nil
+}
+
+func (d *driftRule) check(ctx context.Context, jobName string, n int) (batchViolation, error) {
+	last, ok, err := d.baseline.LastCount(ctx, jobName)
+	if err != nil || !ok || last == 0 {
+		return batchViolation{}, err
+	}
+	ratio := float64(n) / float64(last)
+	if ratio <= d.maxRatio && ratio*d.maxRatio >= 1 {
+		return batchViolation{}, nil
+	}
+	return batchViolation{
+		failed:  true,
+		count:   1,
+		message: fmt.Sprintf("%d records is %.2fx the last count of %d, beyond %.2fx", n, ratio, last, d.maxRatio),
+	}, nil
+}
+
+// WithQualityChecks wraps sink so every batch is checked before it is
+// written. Checks with QualityFail fail the batch with a *QualityError;
+// records quarantined by QualityQuarantine are sent to dlq and left out of
+// the write; QualityWarn violations are logged. The report is returned in
+// WriteResult.Quality, or in SyncPlan.Quality for a dry run, which does not
+// quarantine. Count checks run once per run, in CheckCount or CheckRun.
+//
+// dlq may be nil when no check quarantines. Quarantining marks the run
+// incomplete (MarkIncomplete), so FinishRun does not finish the sink and
+// UpsertSink.DeleteMissing never deletes the rows of records held back.
+func WithQualityChecks[U any](jobName string, sink Sink[U], checks *QualityChecks[U], dlq DeadLetterWriter) Sink[U] {
+	return &qualitySink[U]{job: jobName, inner: sink, checks: checks, dlq: dlq}
+}
+
+type qualitySink[U any] struct {
+	job    string
+	inner  Sink[U]
+	checks *QualityChecks[U]
+	dlq    DeadLetterWriter
+}
+
+func (s *qualitySink[U]) Name() string { return s.inner.Name() }
+
+func (s *qualitySink[U]) canPlan() bool { return CanPlan(s.inner) }
+
+func (s *qualitySink[U]) Write(ctx context.Context, records []U) (WriteResult, error) {
+	keep, out, err := s.check(ctx, records)
+	if err != nil {
+		return WriteResult{}, err
+	}
+	if len(out.reasons) > 0 {
+		if err := s.quarantine(ctx, records, out.reasons); err != nil {
+			return WriteResult{}, err
+		}
+		MarkIncomplete(ctx, fmt.Sprintf("%d records quarantined", len(out.reasons)))
+	}
+
+	var wr WriteResult
+	if len(keep) > 0 {
+		if wr, err = s.inner.Write(ctx, keep); err != nil {
+			return wr, err
+		}
+	}
+	wr.Quality = payload.MergeQuality(wr.Quality, &out.report)
+	return wr, nil
+}
+
+// Plan checks records and plans the records that would be written.
+func (s *qualitySink[U]) Plan(ctx context.Context, records []U) (payload.SyncPlan, error) {
+	keep, out, err := s.check(ctx, records)
+	if err != nil {
+		return payload.SyncPlan{}, err
+	}
+	plan, err := PlanWrite(ctx, s.inner, keep)
+	if err != nil {
+		return plan, err
+	}
+	plan.Quality = payload.MergeQuality(plan.Quality, &out.report)
+	return plan, nil
+}
+
+// checkCount runs the count checks against a run of n records.
+func (s *qualitySink[U]) checkCount(ctx context.Context, n int) (*payload.QualityReport, error) {
+	out, err := s.checks.evaluateCounts(ctx, s.job, n)
+	if err != nil {
+		return nil, fmt.Errorf("%s: quality checks: %w", s.inner.Name(), err)
+	}
+	s.logWarnings(ctx, out.report)
+	if out.failed {
+		return nil, &QualityError{Report: out.report}
+	}
+	if len(out.report.Checks) == 0 {
+		return nil, nil
+	}
+	return &out.report, nil
+}
+
+// saveCount saves the count baseline of a written run of n records when
+// it quarantined nothing.
+func (s *qualitySink[U]) saveCount(ctx context.Context, n int, report *payload.QualityReport) error {
+	quarantined := report != nil && report.Quarantined > 0
+	if s.checks.drift == nil || n == 0 || quarantined || IsDryRun(ctx) {
+		return nil
+	}
+	return s.checks.drift.baseline.SaveCount(ctx, s.job, n)
+}
+
+// runCounter is a sink with count checks; see WithQualityChecks.
+type runCounter interface {
+	checkCount(ctx context.Context, n int) (*payload.QualityReport, error)
+	saveCount(ctx context.Context, n int, report *payload.QualityReport) error
+}
+
+// CheckCount runs the count checks of a sink wrapped with WithQualityChecks
+// against a run of n mapped records before they are written, or planned
+// for a dry run. It returns the count checks' report, to be merged into
+// the run's, and a *QualityError when a QualityFail check fails. Once the
+// run is written, call SaveRunCount. For other sinks it returns nil.
+func CheckCount[U any](ctx context.Context, sink Sink[U], n int) (*payload.QualityReport, error) {
+	if rc, ok := sink.(runCounter); ok {
+		return rc.checkCount(ctx, n)
+	}
+	return nil, nil
+}
+
+// SaveRunCount saves the count baseline of CountDrift once a run of n
+// records is written. report is the run's quality report; a run that
+// quarantined records, an empty run and a dry run are not saved.
+func SaveRunCount[U any](ctx context.Context, sink Sink[U], n int, report *payload.QualityReport) error {
+	if rc, ok := sink.(runCounter); ok {
+		return rc.saveCount(ctx, n, report)
+	}
+	return nil
+}
+
+// CheckRun runs the count checks of a sink wrapped with WithQualityChecks
+// once the last page of a PagedSource run is written, or planned for a dry
+// run, and then saves the count baseline as SaveRunCount does. n is the
+// number of mapped records the run passed to the sink and report the
+// quality report of its pages; the count checks are added to it. A failed
+// QualityFail check returns a *QualityError: the pages are already
+// written, but the run fails before its sink is finished. Runs that write
+// one batch use CheckCount instead, which fails them before the write. For
+// other sinks it returns report unchanged.
+func CheckRun[U any](ctx context.Context, sink Sink[U], n int, report *payload.QualityReport) (*payload.QualityReport, error) {
+	counts, err := CheckCount(ctx, sink, n)
+	if err != nil {
+		return nil, err
+	}
+	report = payload.MergeQuality(report, counts)
+	if err := SaveRunCount(ctx, sink, n, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *qualitySink[U]) Finish(ctx context.Context) (WriteResult, error) {
+	return FinishSink(ctx, s.inner)
+}
+
+// check evaluates the checks and returns the records to write.
+func (s *qualitySink[U]) check(ctx context.Context, records []U) ([]U, qualityOutcome, error) {
+	out := s.checks.evaluate(records)
+	s.logWarnings(ctx, out.report)
+	if out.failed {
+		return nil, out, &QualityError{Report: out.report}
+	}
+	if len(out.reasons) > 0 && s.dlq == nil {
+		return nil, out, fmt.Errorf("%s: %d records quarantined but no dead-letter writer is configured", s.inner.Name(), len(out.reasons))
+	}
+
+	keep := records
+	if len(out.reasons) > 0 {
+		keep = make([]U, 0, len(records)-len(out.reasons))
+		for i := range records {
+			if _, ok := out.reasons[i]; !ok {
+				keep = append(keep, records[i])
+			}
+		}
+	}
+	return keep, out, nil
+}
+
+func (s *qualitySink[U]) quarantine(ctx context.Context, records []U, reasons map[int]string) error {
+	partition := deadLetterPartition(ctx)
+	letters := make([]DeadLetter, 0, len(reasons))
+	for i := range records {
+		reason, ok := reasons[i]
+		if !ok {
+			continue
+		}
+		l, err := NewDeadLetter(s.job, partition, DeadLetterStageQuality, records[i], reason)
+		if err != nil {
+			return err
+		}
+		letters = append(letters, l)
+	}
+	if err := s.dlq.PutDeadLetters(ctx, letters); err != nil {
+		return fmt.Errorf("quarantine %d records: %w", len(letters), err)
+	}
+	logDeadLettered(ctx, s.job, DeadLetterStageQuality, len(letters))
+	return nil
+}
+
+func (s *qualitySink[U]) logWarnings(ctx context.Context, report payload.QualityReport) {
+	logger := pkgotel.NewLogHelper(ctx, pkgotel.ConfigFromContext(ctx), "datasync.quality", s.job)
+	for _, c := range report.Checks {
+		if c.Action == string(QualityWarn) && c.Violations > 0 {
+			logger.Warn("data quality check failed",
+				pkgotel.F("check", c.Name),
+				pkgotel.F("violations", c.Violations),
+				pkgotel.F("message", c.Message))
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package datasync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+type reading struct {
+	ID    string `validate:"required"`
+	Value *int
+}
+
+func intPtr(n int) *int { return &n }
+
+func readingIDs(records []reading) []string {
+	ids := make([]string, len(records))
+	for i, r := range records {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func findCheck(t *testing.T, report *payload.QualityReport, name string) payload.CheckResult {
+	t.Helper()
+	require.NotNil(t, report)
+	for _, c := range report.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no check %q in report", name)
+	return payload.CheckResult{}
+}
+
+func TestQualityChecks_FailBlocksWrite(t *testing.T) {
+	sink := &mockSink[reading]{name: "dst"}
+	checks := NewQualityChecks[reading]().ValidateStruct(QualityFail)
+	wrapped := WithQualityChecks[reading]("job", sink, checks, nil)
+
+	_, err := wrapped.Write(context.Background(), []reading{{ID: "a"}, {ID: ""}})
+	var qe *QualityError
+	require.ErrorAs(t, err, &qe)
+	assert.Contains(t, err.Error(), "validate")
+	assert.Equal(t, 1, findCheck(t, &qe.Report, "validate").Violations)
+	assert.Nil(t, sink.written, "a failed check writes nothing")
+}
+
+func TestQualityChecks_QuarantineDeadLettersOffenders(t *testing.T) {
+	queue := newMemoryDeadLetterQueue()
+	sink := &mockSink[reading]{name: "dst", result: WriteResult{Inserted: 2}}
+	checks := NewQualityChecks[reading]().
+		Unique("unique-id", func(r *reading) string { return r.ID }, QualityQuarantine).
+		Record("positive", func(r *reading) error {
+			if r.Value != nil && *r.Value < 0 {
+				return errors.New("negative value")
+			}
+			return nil
+		}, QualityQuarantine)
+	wrapped := WithQualityChecks[reading]("job", sink, checks, queue)
+
+	ctx := context.Background()
+	wr, err := wrapped.Write(ctx, []reading{
+		{ID: "a", Value: intPtr(1)},
+		{ID: "b", Value: intPtr(-1)},
+		{ID: "a", Value: intPtr(2)},
+		{ID: "c"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, readingIDs(sink.written))
+	require.NotNil(t, wr.Quality)
+	assert.Equal(t, 2, wr.Quality.Quarantined)
+	assert.Equal(t, 1, findCheck(t, wr.Quality, "unique-id").Violations)
+	assert.Equal(t, 1, findCheck(t, wr.Quality, "positive").Violations)
+
+	letters, err := queue.ListDeadLetters(ctx, "job")
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+	for _, l := range letters {
+		assert.Equal(t, DeadLetterStageQuality, l.Stage)
+	}
+}
+
+func TestQualityChecks_QuarantineRequiresDeadLetters(t *testing.T) {
+	checks := NewQualityChecks[reading]().ValidateStruct(QualityQuarantine)
+	assert.True(t, checks.Quarantines())
+	assert.False(t, NewQualityChecks[reading]().ValidateStruct(QualityWarn).Quarantines())
+
+	wrapped := WithQualityChecks[reading]("job", &mockSink[reading]{name: "dst"}, checks, nil)
+	_, err := wrapped.Write(context.Background(), []reading{{}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no dead-letter writer")
+}
+
+func TestQualityChecks_WarnWritesEverything(t *testing.T) {
+	sink := &mockSink[reading]{name: "dst"}
+	checks := NewQualityChecks[reading]().
+		NullRate("value-nulls", func(r *reading) bool { return r.Value == nil }, 0.25, QualityWarn).
+		MaxCount(1, QualityWarn)
+	wrapped := WithQualityChecks[reading]("job", sink, checks, nil)
+	ctx := context.Background()
+
+	wr, err := wrapped.Write(ctx, []reading{{ID: "a"}, {ID: "b", Value: intPtr(1)}})
+	require.NoError(t, err)
+	assert.Len(t, sink.written, 2)
+	assert.Equal(t, 0, wr.Quality.Quarantined)
+	nulls := findCheck(t, wr.Quality, "value-nulls")
+	assert.Equal(t, 1, nulls.Violations)
+	assert.Contains(t, nulls.Message, "0.500")
+
+	report, err := CheckRun(ctx, wrapped, 2, wr.Quality)
+	require.NoError(t, err)
+	assert.Equal(t, 1, findCheck(t, report, "max-count").Violations)
+	assert.Equal(t, 1, findCheck(t, report, "value-nulls").Violations, "the batch checks are kept")
+}
+
+func TestQualityChecks_QuarantineSkipsFinish(t *testing.T) {
+	queue := newMemoryDeadLetterQueue()
+	sink := &planningSink{}
+	checks := NewQualityChecks[int]().Record("positive", func(r *int) error {
+		if *r < 0 {
+			return errors.New("negative")
+		}
+		return nil
+	}, QualityQuarantine)
+	wrapped := WithQualityChecks[int]("job", sink, checks, queue)
+	ctx := WithSyncRun(context.Background(), NewSyncRun())
+
+	_, err := wrapped.Write(ctx, []int{1, -2})
+	require.NoError(t, err)
+	_, skipped, err := FinishRun(ctx, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sink.finished, "finish is skipped after a quarantine")
+	assert.Contains(t, skipped, "1 records quarantined")
+
+	ctx = WithSyncRun(context.Background(), NewSyncRun())
+	_, err = wrapped.Write(ctx, []int{1, 2, 3})
+	require.NoError(t, err)
+	_, _, err = FinishRun(ctx, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sink.finished)
+}
+
+func TestQualityChecks_CountChecksCannotQuarantine(t *testing.T) {
+	checks := NewQualityChecks[int]()
+	assert.Panics(t, func() { checks.MinCount(1, QualityQuarantine) })
+	assert.Panics(t, func() { checks.MaxCount(1, QualityQuarantine) })
+	assert.Panics(t, func() { checks.CountDrift(nil, 2, QualityQuarantine) })
+	assert.False(t, checks.HasCountChecks())
+}
+
+func TestRunner_CountChecksSeeTheWholeRun(t *testing.T) {
+	checks := NewQualityChecks[int]().MinCount(3, QualityFail).MaxCount(3, QualityFail)
+	sink := &batchSink{}
+	source := &pagedSource{pages: [][]int{{1, 2}, {3}}, failAt: -1}
+
+	result, err := NewRunner[int, int](source, IdentityMapper[int](), WithQualityChecks[int]("job", sink, checks, nil)).Run(context.Background())
+	require.NoError(t, err, "no page alone has 3 records")
+	assert.Equal(t, []int{2, 1}, sink.batches)
+	assert.Zero(t, findCheck(t, result.WriteResult.Quality, "min-count").Violations)
+}
+
+func TestRunner_CountChecksFailBeforeWrite(t *testing.T) {
+	checks := NewQualityChecks[int]().MaxCount(3, QualityFail)
+	sink := &batchSink{}
+	source := &mockSource[int]{name: "src", records: []int{1, 2, 3, 4}}
+
+	_, err := NewRunner[int, int](source, IdentityMapper[int](), WithQualityChecks[int]("job", sink, checks, nil)).Run(context.Background())
+	var qe *QualityError
+	require.ErrorAs(t, err, &qe)
+	assert.Empty(t, sink.batches, "a one-batch run is counted before it is written")
+}
+
+func TestRunner_CountDrift(t *testing.T) {
+	baseline := NewStoreCountBaseline(store.NewJSONStore[int](store.NewMemoryStore()))
+	checks := NewQualityChecks[int]().CountDrift(baseline, 2, QualityFail)
+	sink := WithQualityChecks[int]("job", &batchSink{}, checks, nil)
+	ctx := context.Background()
+	run := func(pages ...[]int) error {
+		_, err := NewRunner[int, int](&pagedSource{pages: pages, failAt: -1}, IdentityMapper[int](), sink).Run(ctx)
+		return err
+	}
+
+	require.NoError(t, run(make([]int, 5), make([]int, 5)), "the first run always passes")
+	last, ok, err := baseline.LastCount(ctx, "job")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 10, last, "the baseline is the run's count, not a page's")
+
+	require.NoError(t, run(make([]int, 20)))
+	err = run(make([]int, 9))
+	var qe *QualityError
+	require.ErrorAs(t, err, &qe)
+	assert.Contains(t, err.Error(), "count-drift")
+
+	last, _, err = baseline.LastCount(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, 20, last, "a failed run does not move the baseline")
+}
+
+func TestQualityChecks_QuarantinedRunKeepsBaseline(t *testing.T) {
+	baseline := NewStoreCountBaseline(store.NewJSONStore[int](store.NewMemoryStore()))
+	checks := NewQualityChecks[int]().
+		CountDrift(baseline, 2, QualityFail).
+		Record("positive", func(r *int) error {
+			if *r < 0 {
+				return errors.New("negative")
+			}
+			return nil
+		}, QualityQuarantine)
+	sink := WithQualityChecks[int]("job", &batchSink{}, checks, newMemoryDeadLetterQueue())
+	source := &mockSource[int]{name: "src", records: []int{-1, -2}}
+
+	_, err := NewRunner[int, int](source, IdentityMapper[int](), sink).Run(context.Background())
+	require.NoError(t, err)
+	_, ok, err := baseline.LastCount(context.Background(), "job")
+	require.NoError(t, err)
+	assert.False(t, ok, "a run that quarantined records does not save its count")
+}
+
+func TestQualityChecks_PlanReportsWithoutQuarantining(t *testing.T) {
+	queue := newMemoryDeadLetterQueue()
+	checks := NewQualityChecks[int]().Record("positive", func(r *int) error {
+		if *r < 0 {
+			return errors.New("negative")
+		}
+		return nil
+	}, QualityQuarantine)
+	wrapped := WithQualityChecks[int]("job", &planningSink{}, checks, queue)
+	require.True(t, CanPlan(wrapped))
+
+	plan, err := PlanWrite(context.Background(), wrapped, []int{1, -1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, plan.WouldInsert)
+	require.NotNil(t, plan.Quality)
+	assert.Equal(t, 1, plan.Quality.Quarantined)
+
+	letters, err := queue.ListDeadLetters(context.Background(), "job")
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+
+	assert.False(t, CanPlan(WithQualityChecks[int]("job", &batchSink{}, checks, queue)))
+}
+
+func TestRunner_QualityChecksEmptyFetch(t *testing.T) {
+	checks := NewQualityChecks[int]().MinCount(1, QualityFail)
+	sink := WithQualityChecks[int]("job", &batchSink{}, checks, nil)
+	source := &mockSource[int]{name: "src", records: []int{}}
+
+	_, err := NewRunner[int, int](source, IdentityMapper[int](), sink).Run(context.Background())
+	var qe *QualityError
+	require.ErrorAs(t, err, &qe)
+	assert.Contains(t, err.Error(), "0 records, want at least 1")
+
+	report, err := CheckRun[int](context.Background(), &batchSink{}, 0, nil)
+	require.NoError(t, err)
+	assert.Nil(t, report, "unchecked sinks have no report")
+}
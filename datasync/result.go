@@ -1,10 +1,23 @@
 package datasync
 
-import "time"
+import (
+	"time"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
 
 // Result contains the outcome of a sync run.
 type Result struct {
 	TotalFetched   int           `json:"totalFetched"`
+	Pages          int           `json:"pages,omitempty"` // Pages read from a PagedSource.
 	WriteResult    WriteResult   `json:"writeResult"`
 	ProcessingTime time.Duration `json:"processingTime"`
+	// Plan is set by a dry run, which writes nothing.
+	Plan *payload.SyncPlan `json:"plan,omitempty"`
+	// MapSkipped counts the records the mapper skipped; SkipReasons keeps
+	// the first MaxSkipReasons reasons a DetailedMapper gave.
+	MapSkipped  int      `json:"mapSkipped,omitempty"`
+	SkipReasons []string `json:"skipReasons,omitempty"`
+	// FinishSkipped says why a FinishingSink was not finished; see FinishRun.
+	FinishSkipped string `json:"finishSkipped,omitempty"`
 }
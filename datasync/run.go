@@ -0,0 +1,135 @@
+package datasync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SyncRun is the state of one sync run, shared by the mapper and sinks
+// that run under it. Runner and the SyncData activity start one per run
+// with WithSyncRun, so a sink instance shared by concurrent runs keeps
+// their state apart. A chunked sync has no SyncRun and never finishes its
+// sink.
+type SyncRun struct {
+	mu         sync.Mutex
+	state      map[any]any
+	incomplete []string
+}
+
+// NewSyncRun starts the state of a sync run.
+func NewSyncRun() *SyncRun {
+	return &SyncRun{state: make(map[any]any)}
+}
+
+type syncRunKey struct{}
+
+// WithSyncRun returns a context carrying run.
+func WithSyncRun(ctx context.Context, run *SyncRun) context.Context {
+	return context.WithValue(ctx, syncRunKey{}, run)
+}
+
+func syncRun(ctx context.Context) *SyncRun {
+	r, _ := ctx.Value(syncRunKey{}).(*SyncRun)
+	return r
+}
+
+// MarkIncomplete records in the context's SyncRun that some records of the
+// run did not reach the sink, for example because they were skipped or
+// dead-lettered. FinishRun does not finish the sink of an incomplete run.
+// Without a SyncRun it does nothing.
+func MarkIncomplete(ctx context.Context, reason string) {
+	r := syncRun(ctx)
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.incomplete = append(r.incomplete, reason)
+}
+
+// Incomplete returns why the run is incomplete, or "" if it is not.
+func (r *SyncRun) Incomplete() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.incomplete, "; ")
+}
+
+// runState returns the value key holds in the context's SyncRun, creating
+// it with init on first use. The bool is false when there is no SyncRun.
+func runState[V any](ctx context.Context, key any, init func() V) (V, bool) {
+	r := syncRun(ctx)
+	if r == nil {
+		var zero V
+		return zero, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.state[key].(V)
+	if !ok {
+		v = init()
+		r.state[key] = v
+	}
+	return v, true
+}
+
+// FinishRun finishes sink after the last write of a run, unless the
+// context's SyncRun is incomplete. It returns why the finish was skipped,
+// or "" if sink was finished.
+func FinishRun[U any](ctx context.Context, sink Sink[U]) (WriteResult, string, error) {
+	if r := syncRun(ctx); r != nil {
+		if reason := r.Incomplete(); reason != "" {
+			return WriteResult{}, reason, nil
+		}
+	}
+	wr, err := FinishSink(ctx, sink)
+	return wr, "", err
+}
+
+// MaxSkipReasons is the number of mapper skip reasons a run result keeps.
+const MaxSkipReasons = 20
+
+// resultMapper is a mapper wrapper that can report what it skipped, such
+// as the mapper WithDeadLetters returns.
+type resultMapper[T, U any] interface {
+	mapResult(ctx context.Context, records []T) (MapResult[U], error)
+}
+
+// MapRun maps records with MapDetailed when mapper is a DetailedMapper, and
+// with Map otherwise, counting the records Map drops as skipped. When
+// records are skipped it marks the run incomplete.
+func MapRun[T, U any](ctx context.Context, mapper Mapper[T, U], records []T) (MapResult[U], error) {
+	result, err := mapWithSkips(ctx, mapper, records)
+	if err != nil {
+		return result, err
+	}
+	if result.Skipped > 0 {
+		MarkIncomplete(ctx, fmt.Sprintf("mapper skipped %d records", result.Skipped))
+	}
+	return result, nil
+}
+
+// mapWithSkips is MapRun without marking the run incomplete.
+func mapWithSkips[T, U any](ctx context.Context, mapper Mapper[T, U], records []T) (MapResult[U], error) {
+	switch m := mapper.(type) {
+	case resultMapper[T, U]:
+		return m.mapResult(ctx, records)
+	case DetailedMapper[T, U]:
+		return m.MapDetailed(ctx, records), nil
+	}
+	mapped, err := mapper.Map(ctx, records)
+	if err != nil {
+		return MapResult[U]{}, err
+	}
+	return MapResult[U]{Records: mapped, Skipped: max(len(records)-len(mapped), 0)}, nil
+}
+
+// AddSkipReasons appends reasons to kept up to MaxSkipReasons.
+func AddSkipReasons(kept, reasons []string) []string {
+	n := min(len(reasons), MaxSkipReasons-len(kept))
+	if n <= 0 {
+		return kept
+	}
+	return append(kept, reasons[:n]...)
+}
@@ -1,47 +1,178 @@
 package datasync
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
 )
 
 // Runner orchestrates a single fetch-map-write cycle.
 // Use for testing and simple in-process sync without Temporal.
 type Runner[T any, U any] struct {
-	source Source[T]
-	mapper Mapper[T, U]
-	sink   Sink[U]
+	source  Source[T]
+	mapper  Mapper[T, U]
+	sink    Sink[U]
+	jobName string
+	dryRun  bool
 }
 
 func NewRunner[T, U any](source Source[T], mapper Mapper[T, U], sink Sink[U]) *Runner[T, U] {
 	return &Runner[T, U]{source: source, mapper: mapper, sink: sink}
 }
 
+// JobName sets the name of the job the runner syncs. It keys the watermark
+// of an IncrementalSource, as the SyncData activity does with its
+// ActivityInput.JobName, and is required for one.
+func (r *Runner[T, U]) JobName(name string) *Runner[T, U] {
+	r.jobName = name
+	return r
+}
+
+// DryRun makes Run fetch and map as usual but plan the write with the
+// sink's Planner instead of writing. Result.Plan reports the plan; the sink
+// is not finished and an incremental watermark does not advance.
+func (r *Runner[T, U]) DryRun() *Runner[T, U] {
+	r.dryRun = true
+	return r
+}
+
+// Run fetches, maps and writes all records under a new SyncRun. A
+// PagedSource is processed one page at a time. An IncrementalSource fetches
+// only what changed, keyed by JobName, and its watermark advances
+// after the write. A FinishingSink is finished after the last write with
+// FinishRun, unless the source returned no records or is incremental.
 func (r *Runner[T, U]) Run(ctx context.Context) (*Result, error) {
 	start := time.Now()
+	ctx = WithSyncRun(ctx, NewSyncRun())
+	if r.dryRun {
+		if !CanPlan(r.sink) {
+			return nil, fmt.Errorf("sink %s: %w", r.sink.Name(), ErrDryRunUnsupported)
+		}
+		ctx = WithDryRun(ctx)
+	}
+
+	if paged, ok := r.source.(PagedSource[T]); ok {
+		progress, err := SyncPages(ctx, paged, r.mapper, r.sink, PageProgress{}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := CheckPages(ctx, r.sink, &progress); err != nil {
+			return nil, fmt.Errorf("sink %s: %w", r.sink.Name(), err)
+		}
+		if r.dryRun {
+			return &Result{
+				TotalFetched:   progress.TotalFetched,
+				Pages:          progress.Pages,
+				ProcessingTime: time.Since(start),
+				Plan:           cmp.Or(progress.Plan, &payload.SyncPlan{}),
+				MapSkipped:     progress.MapSkipped,
+				SkipReasons:    progress.SkipReasons,
+			}, nil
+		}
+		var skipped string
+		if progress.TotalFetched > 0 {
+			fr, reason, err := FinishRun(ctx, r.sink)
+			if err != nil {
+				return nil, fmt.Errorf("sink %s finish failed: %w", r.sink.Name(), err)
+			}
+			progress.WriteResult.Add(fr)
+			skipped = reason
+		}
+		return &Result{
+			TotalFetched:   progress.TotalFetched,
+			Pages:          progress.Pages,
+			WriteResult:    progress.WriteResult,
+			ProcessingTime: time.Since(start),
+			MapSkipped:     progress.MapSkipped,
+			SkipReasons:    progress.SkipReasons,
+			FinishSkipped:  skipped,
+		}, nil
+	}
 
-	records, err := r.source.Fetch(ctx)
+	var changes *ChangeSet[T]
+	var records []T
+	var err error
+	if inc, ok := r.source.(IncrementalSource[T]); ok {
+		if r.jobName == "" {
+			return nil, fmt.Errorf("source %s is incremental: the runner needs a JobName", r.source.Name())
+		}
+		var cs ChangeSet[T]
+		cs, err = inc.FetchIncremental(ctx, r.jobName)
+		records, changes = cs.Records, &cs
+	} else {
+		records, err = r.source.Fetch(ctx)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("source %s fetch failed: %w", r.source.Name(), err)
 	}
 
 	result := &Result{TotalFetched: len(records)}
+	if r.dryRun {
+		result.Plan = &payload.SyncPlan{}
+	}
 
 	if len(records) == 0 {
+		quality, err := CheckRun(ctx, r.sink, 0, nil)
+		if err != nil {
+			return nil, fmt.Errorf("sink %s: %w", r.sink.Name(), err)
+		}
+		if r.dryRun {
+			result.Plan.Quality = quality
+		} else {
+			result.WriteResult.Quality = quality
+		}
 		result.ProcessingTime = time.Since(start)
 		return result, nil
 	}
 
-	mapped, err := r.mapper.Map(ctx, records)
+	mr, err := MapRun(ctx, r.mapper, records)
 	if err != nil {
 		return nil, fmt.Errorf("mapper failed: %w", err)
 	}
+	mapped := mr.Records
+	result.MapSkipped = mr.Skipped
+	result.SkipReasons = AddSkipReasons(nil, mr.SkipReasons)
+
+	// The run is one batch, so its count checks run before it is written.
+	counts, err := CheckCount(ctx, r.sink, len(mapped))
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: %w", r.sink.Name(), err)
+	}
+
+	if r.dryRun {
+		plan, err := PlanWrite(ctx, r.sink, mapped)
+		if err != nil {
+			return nil, fmt.Errorf("sink %s plan failed: %w", r.sink.Name(), err)
+		}
+		plan.Quality = payload.MergeQuality(plan.Quality, counts)
+		result.Plan = &plan
+		result.ProcessingTime = time.Since(start)
+		return result, nil
+	}
 
 	wr, err := r.sink.Write(ctx, mapped)
 	if err != nil {
 		return nil, fmt.Errorf("sink %s write failed: %w", r.sink.Name(), err)
 	}
+	wr.Quality = payload.MergeQuality(wr.Quality, counts)
+	if err := SaveRunCount(ctx, r.sink, len(mapped), wr.Quality); err != nil {
+		return nil, fmt.Errorf("sink %s: %w", r.sink.Name(), err)
+	}
+	if changes != nil {
+		if err := changes.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("source %s commit watermark failed: %w", r.source.Name(), err)
+		}
+	} else {
+		fr, reason, err := FinishRun(ctx, r.sink)
+		if err != nil {
+			return nil, fmt.Errorf("sink %s finish failed: %w", r.sink.Name(), err)
+		}
+		wr.Add(fr)
+		result.FinishSkipped = reason
+	}
 
 	result.WriteResult = wr
 	result.ProcessingTime = time.Since(start)
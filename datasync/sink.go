@@ -1,12 +1,23 @@
 package datasync
 
-import "context"
+import (
+	"context"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
 
 // WriteResult contains statistics from a sink write operation.
 type WriteResult struct {
 	Inserted int `json:"inserted"`
 	Updated  int `json:"updated"`
 	Skipped  int `json:"skipped"`
+	// Deleted counts destination records removed by a FinishingSink. They
+	// are not part of Total.
+	Deleted int `json:"deleted,omitempty"`
+	// Quality is reported by a sink wrapped with WithQualityChecks.
+	Quality *payload.QualityReport `json:"quality,omitempty"`
+	// Sinks breaks the counts down by sink name for a FanOutSink.
+	Sinks map[string]payload.SinkResult `json:"sinks,omitempty"`
 }
 
 // Add merges another WriteResult into this one.
@@ -14,6 +25,9 @@ func (r *WriteResult) Add(other WriteResult) {
 	r.Inserted += other.Inserted
 	r.Updated += other.Updated
 	r.Skipped += other.Skipped
+	r.Deleted += other.Deleted
+	r.Quality = payload.MergeQuality(r.Quality, other.Quality)
+	r.Sinks = payload.MergeSinkResults(r.Sinks, other.Sinks)
 }
 
 // Total returns the total number of records processed.
@@ -0,0 +1,119 @@
+package sqlsync
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeCall is a statement the fake database received.
+type fakeCall struct {
+	query string
+	args  []driver.Value
+}
+
+// fakeDB is a database/sql driver that records statements and answers them
+// from functions, so statement building and scanning are tested without a
+// database. Behaviour against real servers is covered by the integration
+// tests.
+type fakeDB struct {
+	mu      sync.Mutex
+	calls   []fakeCall
+	commits int
+
+	// query answers QueryContext with columns and rows.
+	query func(q string, args []driver.Value) ([]string, [][]driver.Value, error)
+	// exec answers ExecContext with the rows affected.
+	exec func(q string, args []driver.Value) (int64, error)
+}
+
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, f
+}
+
+func (f *fakeDB) record(q string, args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fakeCall{query: q, args: values})
+	return values
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, errors.New("use sql.OpenDB") }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return &fakeTx{db: c.db}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	values := c.db.record(q, args)
+	if c.db.query == nil {
+		return nil, errors.New("unexpected query")
+	}
+	columns, rows, err := c.db.query(q, values)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	values := c.db.record(q, args)
+	if c.db.exec == nil {
+		return nil, errors.New("unexpected exec")
+	}
+	n, err := c.db.exec(q, values)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(n), nil
+}
+
+type fakeTx struct{ db *fakeDB }
+
+func (t *fakeTx) Commit() error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+	t.db.commits++
+	return nil
+}
+
+func (t *fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
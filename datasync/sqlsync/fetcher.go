@@ -0,0 +1,54 @@
+package sqlsync
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jasoet/go-wf/v2/datasync/chunk"
+
+	"github.com/jasoet/go-wf/v2/internal/sqlident"
+)
+
+// RangeClause returns "column >= p1 AND column < p2" with the dialect's
+// placeholders for the first two arguments, for the WHERE clause of a
+// RangeFetcher or TimeRangeFetcher query.
+func RangeClause(d Dialect, column string) (string, error) {
+	if err := d.validate(); err != nil {
+		return "", err
+	}
+	if !sqlident.Valid(column) {
+		return "", fmt.Errorf("sqlsync: unsafe column name %q", column)
+	}
+	return fmt.Sprintf("%s >= %s AND %s < %s", column, d.placeholder(1), column, d.placeholder(2)), nil
+}
+
+// RangeFetcher returns a chunk.PartitionFetcher that runs query with the
+// partition's start and end as its first two arguments, followed by args:
+//
+//	SELECT id, name FROM orders WHERE id >= $1 AND id < $2
+func RangeFetcher[T any, K cmp.Ordered](db *sql.DB, query string, args ...any) chunk.PartitionFetcher[T, K] {
+	return func(ctx context.Context, start, end K) ([]T, error) {
+		records, err := Query[T](ctx, db, query, append([]any{start, end}, args...)...)
+		if err != nil {
+			return nil, fmt.Errorf("range [%v, %v): %w", start, end, err)
+		}
+		return records, nil
+	}
+}
+
+// TimeRangeFetcher is RangeFetcher for chunk.DateChunkedSync. The bounds
+// are passed in UTC:
+//
+//	SELECT id, ts FROM events WHERE ts >= $1 AND ts < $2
+func TimeRangeFetcher[T any](db *sql.DB, query string, args ...any) chunk.TimeFetcher[T] {
+	return func(ctx context.Context, start, end time.Time) ([]T, error) {
+		records, err := Query[T](ctx, db, query, append([]any{start.UTC(), end.UTC()}, args...)...)
+		if err != nil {
+			return nil, fmt.Errorf("range [%s, %s): %w", start.Format(time.RFC3339), end.Format(time.RFC3339), err)
+		}
+		return records, nil
+	}
+}
@@ -0,0 +1,55 @@
+package sqlsync
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeClause(t *testing.T) {
+	clause, err := RangeClause(Postgres, "created_at")
+	require.NoError(t, err)
+	assert.Equal(t, "created_at >= $1 AND created_at < $2", clause)
+
+	clause, err = RangeClause(SQLite, "o.id")
+	require.NoError(t, err)
+	assert.Equal(t, "o.id >= ? AND o.id < ?", clause)
+
+	_, err = RangeClause(Postgres, "id; --")
+	require.Error(t, err)
+}
+
+func TestRangeFetcher(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.query = func(_ string, _ []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(10)}, {int64(11)}}, nil
+	}
+
+	fetch := RangeFetcher[order, int64](db, "SELECT id FROM orders WHERE id >= $1 AND id < $2 AND customer = $3", "acme")
+	records, err := fetch(context.Background(), 10, 20)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, []driver.Value{int64(10), int64(20), "acme"}, fake.calls[0].args)
+}
+
+func TestTimeRangeFetcher(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.query = func(_ string, _ []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, nil, nil
+	}
+
+	loc := time.FixedZone("UTC+7", 7*3600)
+	start := time.Date(2026, 1, 2, 7, 0, 0, 0, loc)
+	fetch := TimeRangeFetcher[order](db, "SELECT id FROM orders WHERE ts >= $1 AND ts < $2")
+	_, err := fetch(context.Background(), start, start.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	args := fake.calls[0].args
+	require.Len(t, args, 2)
+	assert.Equal(t, time.UTC, args[0].(time.Time).Location())
+	assert.True(t, args[0].(time.Time).Equal(start))
+}
@@ -0,0 +1,325 @@
+package sqlsync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+
+	"github.com/jasoet/go-wf/v2/internal/sqlident"
+)
+
+// DefaultBatchSize is the number of rows per INSERT statement unless
+// WithBatchSize is set. Batches are also capped by the dialect's bind
+// parameter limit.
+const DefaultBatchSize = 500
+
+// TableSink is a datasync.Sink that writes records to a table with
+// multi-row INSERT statements, all batches of one Write in a transaction.
+//
+// Without conflict columns every row is inserted. WithConflictColumns adds
+// ON CONFLICT ... DO NOTHING, so existing rows count as skipped;
+// WithUpdateOnConflict turns it into DO UPDATE, where rows whose updated
+// columns are unchanged are left alone and also count as skipped. The rows
+// of one Write must not repeat a conflict key.
+//
+// TableSink is not a datasync.Planner: its counts come from the database,
+// so it cannot take part in a dry run.
+type TableSink[U any] struct {
+	name      string
+	db        *sql.DB
+	dialect   Dialect
+	table     string
+	fields    []field
+	conflict  []string
+	update    []string
+	upsert    bool
+	batchSize int
+}
+
+// SinkOption configures a TableSink.
+type SinkOption func(*sinkConfig)
+
+type sinkConfig struct {
+	name      string
+	conflict  []string
+	update    []string
+	upsert    bool
+	batchSize int
+}
+
+// WithSinkName sets the sink's name (default: the table name).
+func WithSinkName(name string) SinkOption {
+	return func(c *sinkConfig) { c.name = name }
+}
+
+// WithConflictColumns sets the columns of the unique constraint checked by
+// ON CONFLICT. Alone it skips rows that already exist.
+func WithConflictColumns(columns ...string) SinkOption {
+	return func(c *sinkConfig) { c.conflict = columns }
+}
+
+// WithUpdateOnConflict updates columns of rows that already exist. No
+// columns updates every column outside the conflict columns. It requires
+// WithConflictColumns.
+func WithUpdateOnConflict(columns ...string) SinkOption {
+	return func(c *sinkConfig) {
+		c.upsert = true
+		c.update = columns
+	}
+}
+
+// WithBatchSize sets the rows per INSERT statement.
+func WithBatchSize(n int) SinkOption {
+	return func(c *sinkConfig) {
+		if n > 0 {
+			c.batchSize = n
+		}
+	}
+}
+
+// NewTableSink returns a sink writing U's `db`-tagged fields to table. The
+// table and column names are validated like pgtracker's, because they are
+// interpolated into the statement.
+func NewTableSink[U any](db *sql.DB, dialect Dialect, table string, opts ...SinkOption) (*TableSink[U], error) {
+	if db == nil {
+		return nil, errors.New("sqlsync: db is required")
+	}
+	if err := dialect.validate(); err != nil {
+		return nil, err
+	}
+	if !sqlident.Valid(table) {
+		return nil, fmt.Errorf("sqlsync: unsafe table name %q", table)
+	}
+	fields, err := fieldsOf[U]()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := sinkConfig{name: table, batchSize: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.column
+	}
+	for _, c := range append(slices.Clone(cfg.conflict), cfg.update...) {
+		if !slices.Contains(columns, c) {
+			return nil, fmt.Errorf("sqlsync: column %q is not mapped by %s", c, reflect.TypeFor[U]())
+		}
+	}
+	if cfg.upsert && len(cfg.conflict) == 0 {
+		return nil, errors.New("sqlsync: WithUpdateOnConflict requires WithConflictColumns")
+	}
+	update := cfg.update
+	if cfg.upsert && len(update) == 0 {
+		for _, c := range columns {
+			if !slices.Contains(cfg.conflict, c) {
+				update = append(update, c)
+			}
+		}
+		if len(update) == 0 {
+			return nil, errors.New("sqlsync: every column is a conflict column; nothing to update")
+		}
+	}
+
+	batchSize := min(cfg.batchSize, dialect.maxParams()/len(fields))
+	return &TableSink[U]{
+		name:      cfg.name,
+		db:        db,
+		dialect:   dialect,
+		table:     table,
+		fields:    fields,
+		conflict:  cfg.conflict,
+		update:    update,
+		upsert:    cfg.upsert,
+		batchSize: batchSize,
+	}, nil
+}
+
+// Name returns the sink's identifier.
+func (s *TableSink[U]) Name() string {
+	return s.name
+}
+
+// Write inserts records in batches inside one transaction.
+func (s *TableSink[U]) Write(ctx context.Context, records []U) (datasync.WriteResult, error) {
+	var result datasync.WriteResult
+	if len(records) == 0 {
+		return result, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("%s: begin: %w", s.name, err)
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	for start := 0; start < len(records); start += s.batchSize {
+		end := min(start+s.batchSize, len(records))
+		br, err := s.writeBatch(ctx, tx, records[start:end])
+		if err != nil {
+			return datasync.WriteResult{}, fmt.Errorf("%s: rows %d-%d: %w", s.name, start+1, end, err)
+		}
+		result.Add(br)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return datasync.WriteResult{}, fmt.Errorf("%s: commit: %w", s.name, err)
+	}
+	return result, nil
+}
+
+func (s *TableSink[U]) writeBatch(ctx context.Context, tx *sql.Tx, records []U) (datasync.WriteResult, error) {
+	n := len(records)
+	query, args := s.insertStatement(records)
+
+	if !s.upsert {
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return datasync.WriteResult{}, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return datasync.WriteResult{}, err
+		}
+		return datasync.WriteResult{Inserted: int(affected), Skipped: n - int(affected)}, nil
+	}
+
+	if s.dialect == Postgres {
+		return s.upsertPostgres(ctx, tx, query, args, n)
+	}
+	return s.upsertSQLite(ctx, tx, query, args, records)
+}
+
+// upsertPostgres tells inserts from updates by RETURNING (xmax = 0), which
+// is true for freshly inserted rows.
+func (s *TableSink[U]) upsertPostgres(ctx context.Context, tx *sql.Tx, query string, args []any, n int) (datasync.WriteResult, error) {
+	rows, err := tx.QueryContext(ctx, query+" RETURNING (xmax = 0)", args...)
+	if err != nil {
+		return datasync.WriteResult{}, err
+	}
+	defer rows.Close()
+
+	var result datasync.WriteResult
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return datasync.WriteResult{}, err
+		}
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return datasync.WriteResult{}, err
+	}
+	result.Skipped = n - result.Inserted - result.Updated
+	return result, nil
+}
+
+// upsertSQLite counts the rows that already exist before the upsert,
+// because SQLite reports inserts and updates alike in RowsAffected.
+func (s *TableSink[U]) upsertSQLite(ctx context.Context, tx *sql.Tx, query string, args []any, records []U) (datasync.WriteResult, error) {
+	countQuery, countArgs := s.existingStatement(records)
+	var existing int
+	if err := tx.QueryRowContext(ctx, countQuery, countArgs...).Scan(&existing); err != nil {
+		return datasync.WriteResult{}, fmt.Errorf("count existing rows: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return datasync.WriteResult{}, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return datasync.WriteResult{}, err
+	}
+	inserted := len(records) - existing
+	return datasync.WriteResult{
+		Inserted: inserted,
+		Updated:  int(affected) - inserted,
+		Skipped:  len(records) - int(affected),
+	}, nil
+}
+
+// insertStatement builds the INSERT for records and its arguments.
+func (s *TableSink[U]) insertStatement(records []U) (string, []any) {
+	columns := make([]string, len(s.fields))
+	for i, f := range s.fields {
+		columns[i] = f.column
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s AS target (%s) VALUES ", s.table, strings.Join(columns, ", "))
+	args := s.appendValues(&b, records, s.fields, nil)
+
+	if len(s.conflict) > 0 {
+		fmt.Fprintf(&b, " ON CONFLICT (%s) DO ", strings.Join(s.conflict, ", "))
+		if !s.upsert {
+			b.WriteString("NOTHING")
+		} else {
+			sets := make([]string, len(s.update))
+			changed := make([]string, len(s.update))
+			distinct := "IS DISTINCT FROM"
+			if s.dialect == SQLite {
+				distinct = "IS NOT"
+			}
+			for i, c := range s.update {
+				sets[i] = fmt.Sprintf("%s = excluded.%s", c, c)
+				changed[i] = fmt.Sprintf("target.%s %s excluded.%s", c, distinct, c)
+			}
+			fmt.Fprintf(&b, "UPDATE SET %s WHERE %s", strings.Join(sets, ", "), strings.Join(changed, " OR "))
+		}
+	}
+	return b.String(), args
+}
+
+// existingStatement counts the rows of the table whose conflict key appears
+// in records.
+func (s *TableSink[U]) existingStatement(records []U) (string, []any) {
+	keys := make([]field, 0, len(s.conflict))
+	for _, c := range s.conflict {
+		for _, f := range s.fields {
+			if f.column == c {
+				keys = append(keys, f)
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "SELECT count(*) FROM %s WHERE (%s) IN (VALUES ", s.table, strings.Join(s.conflict, ", "))
+	args := s.appendValues(&b, records, keys, nil)
+	b.WriteString(")")
+	return b.String(), args
+}
+
+// appendValues writes "(p1, p2), (p3, p4)" for the given fields of records
+// and returns args extended with their values.
+func (s *TableSink[U]) appendValues(b *strings.Builder, records []U, fields []field, args []any) []any {
+	for i := range records {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		v := reflect.ValueOf(&records[i]).Elem()
+		for j, f := range fields {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			args = append(args, v.FieldByIndex(f.index).Interface())
+			b.WriteString(s.dialect.placeholder(len(args)))
+		}
+		b.WriteString(")")
+	}
+	return args
+}
@@ -0,0 +1,157 @@
+package sqlsync
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+)
+
+type customer struct {
+	ID    int64  `db:"id"`
+	Name  string `db:"name"`
+	Email string `db:"email"`
+}
+
+var _ datasync.Sink[customer] = (*TableSink[customer])(nil)
+
+func TestNewTableSink_Validates(t *testing.T) {
+	db, _ := newFakeDB(t)
+
+	tests := []struct {
+		name    string
+		dialect Dialect
+		table   string
+		opts    []SinkOption
+	}{
+		{"unsafe table", Postgres, "customers; DROP TABLE x", nil},
+		{"uppercase table", Postgres, "Customers", nil},
+		{"unknown dialect", "mysql", "customers", nil},
+		{"unmapped conflict column", Postgres, "customers", []SinkOption{WithConflictColumns("nope")}},
+		{"unmapped update column", Postgres, "customers", []SinkOption{WithConflictColumns("id"), WithUpdateOnConflict("nope")}},
+		{"update without conflict", Postgres, "customers", []SinkOption{WithUpdateOnConflict()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTableSink[customer](db, tt.dialect, tt.table, tt.opts...)
+			require.Error(t, err)
+		})
+	}
+
+	_, err := NewTableSink[customer](nil, Postgres, "customers")
+	require.Error(t, err)
+}
+
+func TestTableSink_Name(t *testing.T) {
+	db, _ := newFakeDB(t)
+
+	sink, err := NewTableSink[customer](db, Postgres, "crm.customers")
+	require.NoError(t, err)
+	assert.Equal(t, "crm.customers", sink.Name())
+
+	sink, err = NewTableSink[customer](db, Postgres, "crm.customers", WithSinkName("customer-sink"))
+	require.NoError(t, err)
+	assert.Equal(t, "customer-sink", sink.Name())
+}
+
+func TestTableSink_Insert(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.exec = func(_ string, args []driver.Value) (int64, error) { return int64(len(args) / 3), nil }
+
+	sink, err := NewTableSink[customer](db, Postgres, "customers", WithBatchSize(2))
+	require.NoError(t, err)
+
+	result, err := sink.Write(context.Background(), []customer{
+		{ID: 1, Name: "a", Email: "a@x"},
+		{ID: 2, Name: "b", Email: "b@x"},
+		{ID: 3, Name: "c", Email: "c@x"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{Inserted: 3}, result)
+	require.Len(t, fake.calls, 2)
+	assert.Equal(t,
+		"INSERT INTO customers AS target (id, name, email) VALUES ($1, $2, $3), ($4, $5, $6)",
+		fake.calls[0].query)
+	assert.Equal(t, []driver.Value{int64(1), "a", "a@x", int64(2), "b", "b@x"}, fake.calls[0].args)
+	assert.Equal(t, 1, fake.commits, "all batches share one transaction")
+}
+
+func TestTableSink_DoNothing(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.exec = func(string, []driver.Value) (int64, error) { return 1, nil }
+
+	sink, err := NewTableSink[customer](db, SQLite, "customers", WithConflictColumns("id"))
+	require.NoError(t, err)
+
+	result, err := sink.Write(context.Background(), []customer{{ID: 1}, {ID: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{Inserted: 1, Skipped: 1}, result)
+	assert.Equal(t,
+		"INSERT INTO customers AS target (id, name, email) VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT (id) DO NOTHING",
+		fake.calls[0].query)
+}
+
+func TestTableSink_UpsertPostgres(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.query = func(_ string, _ []driver.Value) ([]string, [][]driver.Value, error) {
+		// One insert and one update; the third row was unchanged.
+		return []string{"?column?"}, [][]driver.Value{{true}, {false}}, nil
+	}
+
+	sink, err := NewTableSink[customer](db, Postgres, "customers",
+		WithConflictColumns("id"), WithUpdateOnConflict())
+	require.NoError(t, err)
+
+	result, err := sink.Write(context.Background(), []customer{{ID: 1}, {ID: 2}, {ID: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{Inserted: 1, Updated: 1, Skipped: 1}, result)
+	query := fake.calls[0].query
+	assert.True(t, strings.HasSuffix(query,
+		" ON CONFLICT (id) DO UPDATE SET name = excluded.name, email = excluded.email"+
+			" WHERE target.name IS DISTINCT FROM excluded.name OR target.email IS DISTINCT FROM excluded.email"+
+			" RETURNING (xmax = 0)"), query)
+}
+
+func TestTableSink_UpsertSQLite(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.query = func(q string, _ []driver.Value) ([]string, [][]driver.Value, error) {
+		assert.Equal(t, "SELECT count(*) FROM customers WHERE (id) IN (VALUES (?), (?), (?))", q)
+		return []string{"count"}, [][]driver.Value{{int64(2)}}, nil
+	}
+	fake.exec = func(string, []driver.Value) (int64, error) { return 2, nil }
+
+	sink, err := NewTableSink[customer](db, SQLite, "customers",
+		WithConflictColumns("id"), WithUpdateOnConflict("email"))
+	require.NoError(t, err)
+
+	result, err := sink.Write(context.Background(), []customer{{ID: 1}, {ID: 2}, {ID: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{Inserted: 1, Updated: 1, Skipped: 1}, result)
+	assert.True(t, strings.HasSuffix(fake.calls[1].query,
+		" ON CONFLICT (id) DO UPDATE SET email = excluded.email WHERE target.email IS NOT excluded.email"),
+		fake.calls[1].query)
+}
+
+func TestTableSink_BatchCappedByParameterLimit(t *testing.T) {
+	db, _ := newFakeDB(t)
+
+	sink, err := NewTableSink[customer](db, SQLite, "customers", WithBatchSize(100000))
+	require.NoError(t, err)
+	assert.Equal(t, SQLite.maxParams()/3, sink.batchSize)
+}
+
+func TestTableSink_WriteEmpty(t *testing.T) {
+	db, fake := newFakeDB(t)
+
+	sink, err := NewTableSink[customer](db, Postgres, "customers")
+	require.NoError(t, err)
+	result, err := sink.Write(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{}, result)
+	assert.Empty(t, fake.calls)
+}
@@ -0,0 +1,114 @@
+package sqlsync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// QueryParams are the parameters of a QuerySource, shown in the Temporal UI
+// through datasync.ParamSource.
+type QueryParams struct {
+	Query string `json:"query"`
+	Args  []any  `json:"args,omitempty"`
+}
+
+// QuerySource is a datasync.Source that runs a query and scans every row
+// into a T. It implements datasync.ParamSource[T, QueryParams].
+type QuerySource[T any] struct {
+	name  string
+	db    *sql.DB
+	query string
+	args  []any
+}
+
+// NewQuerySource returns a source that runs query with args on db. Every
+// column the query returns must map to a field of T.
+func NewQuerySource[T any](name string, db *sql.DB, query string, args ...any) (*QuerySource[T], error) {
+	if db == nil {
+		return nil, errors.New("sqlsync: db is required")
+	}
+	if query == "" {
+		return nil, errors.New("sqlsync: query is required")
+	}
+	if _, err := fieldsOf[T](); err != nil {
+		return nil, err
+	}
+	return &QuerySource[T]{name: name, db: db, query: query, args: args}, nil
+}
+
+// Name returns the source's identifier.
+func (s *QuerySource[T]) Name() string {
+	return s.name
+}
+
+// Params returns the query and its arguments.
+func (s *QuerySource[T]) Params() QueryParams {
+	return QueryParams{Query: s.query, Args: s.args}
+}
+
+// Fetch runs the query and returns every row.
+func (s *QuerySource[T]) Fetch(ctx context.Context) ([]T, error) {
+	records, err := Query[T](ctx, s.db, s.query, s.args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.name, err)
+	}
+	return records, nil
+}
+
+// Query runs query with args on db and scans the rows into T.
+func Query[T any](ctx context.Context, db *sql.DB, query string, args ...any) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlsync: query: %w", err)
+	}
+	defer rows.Close()
+	return ScanRows[T](rows)
+}
+
+// ScanRows scans every remaining row into a T, matching columns to fields by
+// their `db` tag. A column without a matching field is an error. It does
+// not close rows.
+func ScanRows[T any](rows *sql.Rows) ([]T, error) {
+	fields, err := fieldsOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	byColumn := make(map[string][]int, len(fields))
+	for _, f := range fields {
+		byColumn[f.column] = f.index
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("sqlsync: read columns: %w", err)
+	}
+	indexes := make([][]int, len(columns))
+	for i, column := range columns {
+		index, ok := byColumn[column]
+		if !ok {
+			return nil, fmt.Errorf("sqlsync: column %q has no field in %s", column, reflect.TypeFor[T]())
+		}
+		indexes[i] = index
+	}
+
+	records := make([]T, 0)
+	dest := make([]any, len(columns))
+	for rows.Next() {
+		var record T
+		v := reflect.ValueOf(&record).Elem()
+		for i, index := range indexes {
+			dest[i] = v.FieldByIndex(index).Addr().Interface()
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("sqlsync: scan row %d: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlsync: read rows: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,87 @@
+package sqlsync
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+)
+
+func TestNewQuerySource_Validates(t *testing.T) {
+	db, _ := newFakeDB(t)
+
+	_, err := NewQuerySource[order]("orders", nil, "SELECT 1")
+	require.Error(t, err)
+	_, err = NewQuerySource[order]("orders", db, "")
+	require.Error(t, err)
+	_, err = NewQuerySource[int]("orders", db, "SELECT 1")
+	require.Error(t, err)
+}
+
+func TestQuerySource_Fetch(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.query = func(_ string, _ []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"id", "customer", "created_by"}, [][]driver.Value{
+			{int64(1), "acme", "alice"},
+			{int64(2), "globex", "bob"},
+		}, nil
+	}
+
+	source, err := NewQuerySource[order]("orders", db, "SELECT id, customer, created_by FROM orders WHERE customer <> $1", "initech")
+	require.NoError(t, err)
+	var _ datasync.ParamSource[order, QueryParams] = source
+
+	records, err := source.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []order{
+		{Audit: Audit{CreatedBy: "alice"}, ID: 1, Customer: "acme"},
+		{Audit: Audit{CreatedBy: "bob"}, ID: 2, Customer: "globex"},
+	}, records)
+	assert.Equal(t, []driver.Value{"initech"}, fake.calls[0].args)
+	assert.Equal(t, QueryParams{Query: fake.calls[0].query, Args: []any{"initech"}}, source.Params())
+}
+
+func TestQuerySource_Fetch_Empty(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.query = func(_ string, _ []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, nil, nil
+	}
+
+	source, err := NewQuerySource[order]("orders", db, "SELECT id FROM orders")
+	require.NoError(t, err)
+	records, err := source.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.NotNil(t, records)
+	assert.Empty(t, records)
+}
+
+func TestQuerySource_Fetch_UnmappedColumn(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.query = func(_ string, _ []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"id", "region"}, [][]driver.Value{{int64(1), "eu"}}, nil
+	}
+
+	source, err := NewQuerySource[order]("orders", db, "SELECT id, region FROM orders")
+	require.NoError(t, err)
+	_, err = source.Fetch(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `column "region" has no field`)
+}
+
+func TestQuerySource_Fetch_QueryError(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.query = func(_ string, _ []driver.Value) ([]string, [][]driver.Value, error) {
+		return nil, nil, errors.New("relation does not exist")
+	}
+
+	source, err := NewQuerySource[order]("orders", db, "SELECT id FROM orders")
+	require.NoError(t, err)
+	_, err = source.Fetch(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "orders: sqlsync: query: relation does not exist")
+}
@@ -0,0 +1,94 @@
+//go:build integration
+
+package sqlsync_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+	"github.com/jasoet/go-wf/v2/datasync/sqlsync"
+)
+
+type stock struct {
+	Warehouse string `db:"warehouse"`
+	SKU       string `db:"sku"`
+	Quantity  int64  `db:"quantity"`
+}
+
+func newSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sqlsync.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.ExecContext(context.Background(), `CREATE TABLE stock (
+		warehouse TEXT NOT NULL,
+		sku       TEXT NOT NULL,
+		quantity  INTEGER NOT NULL,
+		PRIMARY KEY (warehouse, sku)
+	)`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestTableSink_SQLiteUpsert(t *testing.T) {
+	db := newSQLite(t)
+	ctx := context.Background()
+
+	sink, err := sqlsync.NewTableSink[stock](db, sqlsync.SQLite, "stock",
+		sqlsync.WithConflictColumns("warehouse", "sku"), sqlsync.WithUpdateOnConflict("quantity"))
+	require.NoError(t, err)
+
+	result, err := sink.Write(ctx, []stock{
+		{Warehouse: "ams", SKU: "a", Quantity: 1},
+		{Warehouse: "ams", SKU: "b", Quantity: 2},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{Inserted: 2}, result)
+
+	// One changed row, one unchanged row and one new row; the composite key
+	// is counted as a row value.
+	result, err = sink.Write(ctx, []stock{
+		{Warehouse: "ams", SKU: "a", Quantity: 5},
+		{Warehouse: "ams", SKU: "b", Quantity: 2},
+		{Warehouse: "rtm", SKU: "a", Quantity: 7},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{Inserted: 1, Updated: 1, Skipped: 1}, result)
+
+	var quantity int64
+	require.NoError(t, db.QueryRowContext(ctx,
+		`SELECT quantity FROM stock WHERE warehouse = 'ams' AND sku = 'a'`).Scan(&quantity))
+	assert.Equal(t, int64(5), quantity)
+}
+
+func TestTableSink_SQLiteDoNothing(t *testing.T) {
+	db := newSQLite(t)
+	ctx := context.Background()
+
+	sink, err := sqlsync.NewTableSink[stock](db, sqlsync.SQLite, "stock",
+		sqlsync.WithConflictColumns("warehouse", "sku"))
+	require.NoError(t, err)
+
+	_, err = sink.Write(ctx, []stock{{Warehouse: "ams", SKU: "a", Quantity: 1}})
+	require.NoError(t, err)
+
+	result, err := sink.Write(ctx, []stock{
+		{Warehouse: "ams", SKU: "a", Quantity: 9},
+		{Warehouse: "ams", SKU: "b", Quantity: 2},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{Inserted: 1, Skipped: 1}, result)
+
+	var quantity int64
+	require.NoError(t, db.QueryRowContext(ctx,
+		`SELECT quantity FROM stock WHERE warehouse = 'ams' AND sku = 'a'`).Scan(&quantity))
+	assert.Equal(t, int64(1), quantity, "existing rows are left alone")
+}
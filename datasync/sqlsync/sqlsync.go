@@ -0,0 +1,122 @@
+// Package sqlsync provides database/sql-backed datasync sources and sinks.
+//
+// QuerySource scans the rows of a query into structs, TableSink writes
+// structs to a table with multi-row INSERT ... ON CONFLICT statements, and
+// RangeFetcher and TimeRangeFetcher adapt range queries to the chunk
+// package's fetchers. Struct fields map to columns through `db` tags.
+//
+// Like pgtracker it depends only on database/sql: the caller supplies the
+// driver and the pool.
+package sqlsync
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jasoet/go-wf/v2/internal/sqlident"
+)
+
+// Dialect selects the placeholder syntax and upsert details of a database.
+type Dialect string
+
+const (
+	// Postgres uses $1, $2, ... placeholders.
+	Postgres Dialect = "postgres"
+	// SQLite uses ? placeholders and needs SQLite 3.35 or later.
+	SQLite Dialect = "sqlite"
+)
+
+func (d Dialect) validate() error {
+	switch d {
+	case Postgres, SQLite:
+		return nil
+	default:
+		return fmt.Errorf("sqlsync: unknown dialect %q", d)
+	}
+}
+
+// placeholder returns the placeholder for the n-th argument, counted from 1.
+func (d Dialect) placeholder(n int) string {
+	if d == SQLite {
+		return "?"
+	}
+	return "$" + strconv.Itoa(n)
+}
+
+// maxParams is the number of bind parameters one statement may carry.
+func (d Dialect) maxParams() int {
+	if d == SQLite {
+		return 32766
+	}
+	return 65535
+}
+
+// field is a struct field mapped to a column.
+type field struct {
+	column string
+	index  []int
+}
+
+// fieldsOf maps the exported fields of struct type T to columns. A field's
+// column is its `db` tag, or its lowercased name when untagged; `db:"-"`
+// skips it. Embedded structs without a tag are flattened.
+func fieldsOf[T any]() ([]field, error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sqlsync: %s is not a struct", t)
+	}
+	var fields []field
+	if err := collectFields(t, nil, &fields); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("sqlsync: %s has no mapped fields", t)
+	}
+	return fields, nil
+}
+
+func collectFields(t reflect.Type, parent []int, fields *[]field) error {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("db")
+		if tag == "-" || !sf.IsExported() {
+			continue
+		}
+		index := append(append([]int(nil), parent...), i)
+		if sf.Anonymous && !hasTag && sf.Type.Kind() == reflect.Struct {
+			if err := collectFields(sf.Type, index, fields); err != nil {
+				return err
+			}
+			continue
+		}
+		column := tag
+		if column == "" {
+			column = strings.ToLower(sf.Name)
+		}
+		if !sqlident.ValidUnqualified(column) {
+			return fmt.Errorf("sqlsync: field %s has unsafe column name %q", sf.Name, column)
+		}
+		for _, f := range *fields {
+			if f.column == column {
+				return fmt.Errorf("sqlsync: column %q is mapped by more than one field", column)
+			}
+		}
+		*fields = append(*fields, field{column: column, index: index})
+	}
+	return nil
+}
+
+// Columns returns the column names T's fields map to, in field order.
+func Columns[T any]() ([]string, error) {
+	fields, err := fieldsOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.column
+	}
+	return columns, nil
+}
@@ -0,0 +1,100 @@
+//go:build integration
+
+package sqlsync_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+	"github.com/jasoet/go-wf/v2/datasync/sqlsync"
+)
+
+type event struct {
+	ID    int64     `db:"id"`
+	Kind  string    `db:"kind"`
+	At    time.Time `db:"at"`
+	Count int64     `db:"count"`
+}
+
+func newPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	container, err := tcpostgres.Run(ctx, "postgres:18-alpine",
+		tcpostgres.WithDatabase("sqlsync"),
+		tcpostgres.WithUsername("test"),
+		tcpostgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(2*time.Minute),
+		),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = testcontainers.TerminateContainer(container) })
+
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE events (
+		id    BIGINT PRIMARY KEY,
+		kind  TEXT NOT NULL,
+		at    TIMESTAMPTZ NOT NULL,
+		count BIGINT NOT NULL
+	)`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestTableSinkAndQuerySource_Postgres(t *testing.T) {
+	db := newPostgres(t)
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	sink, err := sqlsync.NewTableSink[event](db, sqlsync.Postgres, "events",
+		sqlsync.WithConflictColumns("id"), sqlsync.WithUpdateOnConflict("count"))
+	require.NoError(t, err)
+
+	result, err := sink.Write(ctx, []event{
+		{ID: 1, Kind: "click", At: day, Count: 1},
+		{ID: 2, Kind: "view", At: day.Add(time.Hour), Count: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{Inserted: 2}, result)
+
+	result, err = sink.Write(ctx, []event{
+		{ID: 1, Kind: "click", At: day, Count: 5},
+		{ID: 2, Kind: "view", At: day.Add(time.Hour), Count: 1},
+		{ID: 3, Kind: "click", At: day.Add(25 * time.Hour), Count: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, datasync.WriteResult{Inserted: 1, Updated: 1, Skipped: 1}, result)
+
+	source, err := sqlsync.NewQuerySource[event]("events", db,
+		"SELECT id, kind, at, count FROM events WHERE kind = $1 ORDER BY id", "click")
+	require.NoError(t, err)
+	records, err := source.Fetch(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, int64(5), records[0].Count)
+
+	clause, err := sqlsync.RangeClause(sqlsync.Postgres, "at")
+	require.NoError(t, err)
+	fetch := sqlsync.TimeRangeFetcher[event](db, "SELECT id, kind, at, count FROM events WHERE "+clause+" ORDER BY id")
+	inDay, err := fetch(ctx, day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, inDay, 2)
+}
@@ -0,0 +1,56 @@
+package sqlsync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Audit struct {
+	CreatedBy string `db:"created_by"`
+}
+
+type order struct {
+	Audit
+	ID       int64  `db:"id"`
+	Customer string `db:"customer"`
+	Total    float64
+	Internal string `db:"-"`
+	hidden   string
+}
+
+func TestColumns(t *testing.T) {
+	columns, err := Columns[order]()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"created_by", "id", "customer", "total"}, columns)
+}
+
+func TestColumns_RejectsUnsafeTag(t *testing.T) {
+	type bad struct {
+		Name string `db:"name; DROP TABLE users"`
+	}
+	_, err := Columns[bad]()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsafe column name")
+}
+
+func TestColumns_RejectsDuplicateColumn(t *testing.T) {
+	type dup struct {
+		A string `db:"name"`
+		B string `db:"name"`
+	}
+	_, err := Columns[dup]()
+	require.Error(t, err)
+}
+
+func TestColumns_RequiresStruct(t *testing.T) {
+	_, err := Columns[int]()
+	require.Error(t, err)
+}
+
+func TestDialect_Placeholder(t *testing.T) {
+	assert.Equal(t, "$3", Postgres.placeholder(3))
+	assert.Equal(t, "?", SQLite.placeholder(3))
+	require.Error(t, Dialect("mysql").validate())
+}
@@ -0,0 +1,353 @@
+package datasync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	pkgotel "github.com/jasoet/pkg/v2/otel"
+
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
+
+// DefaultUpsertBatchSize is the number of records UpsertSink looks up and
+// writes per round trip unless BatchSize is set.
+const DefaultUpsertBatchSize = 500
+
+// UpsertStrategy decides what UpsertSink does with a record whose ID already
+// exists in the destination.
+type UpsertStrategy string
+
+const (
+	// UpsertInsertOnly skips existing records.
+	UpsertInsertOnly UpsertStrategy = "insert-only"
+	// UpsertUpdateIfChanged updates existing records whose content differs.
+	UpsertUpdateIfChanged UpsertStrategy = "update-if-changed"
+	// UpsertLastWriterWins updates existing records with a lower version.
+	UpsertLastWriterWins UpsertStrategy = "last-writer-wins"
+)
+
+// FindManyFunc looks up the records with the given IDs. IDs that do not
+// exist are left out of the returned map.
+type FindManyFunc[U any, ID comparable] func(ctx context.Context, ids []ID) (map[ID]U, error)
+
+// UpsertBatch is one round of changes for WriteBatchFunc.
+type UpsertBatch[U any, ID comparable] struct {
+	Inserts []U
+	Updates []U
+	Deletes []ID
+}
+
+// WriteBatchFunc applies a batch of inserts, updates and deletes, ideally in
+// one transaction.
+type WriteBatchFunc[U any, ID comparable] func(ctx context.Context, batch UpsertBatch[U, ID]) error
+
+// ListIDsFunc returns the IDs of every record in the destination, for
+// DeleteMissing.
+type ListIDsFunc[ID comparable] func(ctx context.Context) ([]ID, error)
+
+// FinishingSink is a Sink with work to do after the last batch of a sync
+// run. Runner and the SyncData activity call Finish once every record has
+// been written.
+type FinishingSink[U any] interface {
+	Sink[U]
+	Finish(ctx context.Context) (WriteResult, error)
+}
+
+// FinishSink calls sink.Finish if sink is a FinishingSink.
+func FinishSink[U any](ctx context.Context, sink Sink[U]) (WriteResult, error) {
+	if fs, ok := sink.(FinishingSink[U]); ok {
+		return fs.Finish(ctx)
+	}
+	return WriteResult{}, nil
+}
+
+// UpsertSink implements Sink[U] with batched lookups and writes: each batch
+// of records is looked up with one FindManyFunc call, classified as insert,
+// update or skip by the strategy, and written with one WriteBatchFunc call.
+// The default strategy is UpsertUpdateIfChanged with a content hash.
+type UpsertSink[U any, ID comparable] struct {
+	name      string
+	getID     func(r *U) ID
+	find      FindManyFunc[U, ID]
+	write     WriteBatchFunc[U, ID]
+	batchSize int
+
+	strategy UpsertStrategy
+	equal    func(existing, incoming *U) bool
+	version  func(r *U) int64
+
+	listIDs ListIDsFunc[ID]
+}
+
+// upsertSeen is the IDs an UpsertSink wrote during one SyncRun.
+type upsertSeen[ID comparable] struct {
+	mu  sync.Mutex
+	ids map[ID]struct{}
+}
+
+// NewUpsertSink creates an UpsertSink.
+func NewUpsertSink[U any, ID comparable](
+	name string,
+	getID func(r *U) ID,
+	find FindManyFunc[U, ID],
+	write WriteBatchFunc[U, ID],
+) *UpsertSink[U, ID] {
+	return &UpsertSink[U, ID]{
+		name:      name,
+		getID:     getID,
+		find:      find,
+		write:     write,
+		batchSize: DefaultUpsertBatchSize,
+		strategy:  UpsertUpdateIfChanged,
+	}
+}
+
+// BatchSize sets how many records are looked up and written per round trip.
+func (s *UpsertSink[U, ID]) BatchSize(n int) *UpsertSink[U, ID] {
+	if n > 0 {
+		s.batchSize = n
+	}
+	return s
+}
+
+// InsertOnly skips records that already exist.
+func (s *UpsertSink[U, ID]) InsertOnly() *UpsertSink[U, ID] {
+	s.strategy = UpsertInsertOnly
+	return s
+}
+
+// UpdateIfChanged updates existing records unless equal reports them
+// unchanged. A nil equal compares a SHA-256 hash of the JSON encoding.
+func (s *UpsertSink[U, ID]) UpdateIfChanged(equal func(existing, incoming *U) bool) *UpsertSink[U, ID] {
+	s.strategy = UpsertUpdateIfChanged
+	s.equal = equal
+	return s
+}
+
+// LastWriterWins updates an existing record only when the incoming record's
+// version is greater, for example an updated_at or revision column.
+func (s *UpsertSink[U, ID]) LastWriterWins(version func(r *U) int64) *UpsertSink[U, ID] {
+	s.strategy = UpsertLastWriterWins
+	s.version = version
+	return s
+}
+
+// DeleteMissing deletes, in Finish, every destination record listed by
+// listIDs that the sync run did not write. The IDs written are kept in the
+// context's SyncRun, so runs sharing the sink do not see each other's, and
+// Finish deletes nothing when the run wrote no records or has no SyncRun.
+// Runner and the SyncData activity do not finish a run that dropped,
+// skipped or dead-lettered records (see FinishRun). A chunked sync never
+// finishes its sink, so DeleteMissing has no effect there.
+func (s *UpsertSink[U, ID]) DeleteMissing(listIDs ListIDsFunc[ID]) *UpsertSink[U, ID] {
+	s.listIDs = listIDs
+	return s
+}
+
+// Name returns the sink's identifier.
+func (s *UpsertSink[U, ID]) Name() string {
+	return s.name
+}
+
+// Write upserts records in batches. Inserted, Updated and Skipped count the
+// records of batches that were written; a failed batch returns the counts of
+// the batches before it.
+func (s *UpsertSink[U, ID]) Write(ctx context.Context, records []U) (WriteResult, error) {
+	logger := pkgotel.NewLogHelper(ctx, pkgotel.ConfigFromContext(ctx), "datasync.sink", s.name)
+	var result WriteResult
+
+	for start := 0; start < len(records); start += s.batchSize {
+		end := min(start+s.batchSize, len(records))
+		br, err := s.writeBatch(ctx, records[start:end])
+		if err != nil {
+			return result, err
+		}
+		result.Add(br)
+	}
+
+	logger.Debug("upsert complete",
+		pkgotel.F("inserted", result.Inserted),
+		pkgotel.F("updated", result.Updated),
+		pkgotel.F("skipped", result.Skipped))
+	return result, nil
+}
+
+func (s *UpsertSink[U, ID]) writeBatch(ctx context.Context, records []U) (WriteResult, error) {
+	c, err := s.classify(ctx, records)
+	if err != nil {
+		return WriteResult{}, err
+	}
+	batch := c.batch
+	if len(batch.Inserts)+len(batch.Updates) > 0 {
+		if err := s.write(ctx, batch); err != nil {
+			return WriteResult{}, fmt.Errorf("%s: write %d inserts and %d updates: %w",
+				s.name, len(batch.Inserts), len(batch.Updates), err)
+		}
+	}
+
+	if s.listIDs != nil {
+		if seen, ok := s.runSeen(ctx); ok {
+			seen.mu.Lock()
+			for _, id := range c.ids {
+				seen.ids[id] = struct{}{}
+			}
+			seen.mu.Unlock()
+		}
+	}
+	return WriteResult{Inserted: len(batch.Inserts), Updated: len(batch.Updates), Skipped: c.skipped}, nil
+}
+
+// upsertClassification is a batch sorted into inserts, updates and skips.
+type upsertClassification[U any, ID comparable] struct {
+	ids      []ID
+	existing map[ID]U
+	batch    UpsertBatch[U, ID]
+	skipped  int
+}
+
+// classify looks records up and sorts them by the strategy. The last record
+// wins when an ID repeats within the batch.
+func (s *UpsertSink[U, ID]) classify(ctx context.Context, records []U) (upsertClassification[U, ID], error) {
+	var c upsertClassification[U, ID]
+
+	c.ids = make([]ID, 0, len(records))
+	last := make(map[ID]int, len(records))
+	for i := range records {
+		id := s.getID(&records[i])
+		if _, dup := last[id]; !dup {
+			c.ids = append(c.ids, id)
+		}
+		last[id] = i
+	}
+	c.skipped = len(records) - len(c.ids)
+
+	var err error
+	if c.existing, err = s.find(ctx, c.ids); err != nil {
+		return c, fmt.Errorf("%s: find %d records: %w", s.name, len(c.ids), err)
+	}
+
+	for _, id := range c.ids {
+		incoming := &records[last[id]]
+		current, ok := c.existing[id]
+		switch {
+		case !ok:
+			c.batch.Inserts = append(c.batch.Inserts, *incoming)
+		case s.shouldUpdate(&current, incoming):
+			c.batch.Updates = append(c.batch.Updates, *incoming)
+		default:
+			c.skipped++
+		}
+	}
+	return c, nil
+}
+
+// Plan reports what Write would do with records, looking them up in
+// batches but writing nothing. The records DeleteMissing would delete are
+// not planned.
+func (s *UpsertSink[U, ID]) Plan(ctx context.Context, records []U) (payload.SyncPlan, error) {
+	var plan payload.SyncPlan
+	for start := 0; start < len(records); start += s.batchSize {
+		end := min(start+s.batchSize, len(records))
+		c, err := s.classify(ctx, records[start:end])
+		if err != nil {
+			return plan, err
+		}
+		bp := payload.SyncPlan{
+			WouldInsert: len(c.batch.Inserts),
+			WouldUpdate: len(c.batch.Updates),
+			WouldSkip:   c.skipped,
+		}
+		for i := 0; i < len(c.batch.Inserts) && len(plan.Diffs)+len(bp.Diffs) < payload.MaxPlanDiffs; i++ {
+			r := &c.batch.Inserts[i]
+			diff, err := NewRecordDiff(s.getID(r), nil, r)
+			if err != nil {
+				return plan, fmt.Errorf("%s: %w", s.name, err)
+			}
+			bp.Diffs = append(bp.Diffs, diff)
+		}
+		for i := 0; i < len(c.batch.Updates) && len(plan.Diffs)+len(bp.Diffs) < payload.MaxPlanDiffs; i++ {
+			r := &c.batch.Updates[i]
+			id := s.getID(r)
+			existing := c.existing[id]
+			diff, err := NewRecordDiff(id, &existing, r)
+			if err != nil {
+				return plan, fmt.Errorf("%s: %w", s.name, err)
+			}
+			bp.Diffs = append(bp.Diffs, diff)
+		}
+		plan.Add(bp)
+	}
+	return plan, nil
+}
+
+func (s *UpsertSink[U, ID]) shouldUpdate(existing, incoming *U) bool {
+	switch s.strategy {
+	case UpsertInsertOnly:
+		return false
+	case UpsertLastWriterWins:
+		return s.version(incoming) > s.version(existing)
+	default:
+		if s.equal != nil {
+			return !s.equal(existing, incoming)
+		}
+		return contentHash(existing) != contentHash(incoming)
+	}
+}
+
+// contentHash hashes the JSON encoding of v. A value that cannot be encoded
+// hashes as an empty encoding.
+func contentHash(v any) [sha256.Size]byte {
+	var buf bytes.Buffer
+	_ = json.NewEncoder(&buf).Encode(v) //nolint:errcheck // unencodable values hash as empty
+	return sha256.Sum256(buf.Bytes())
+}
+
+func (s *UpsertSink[U, ID]) runSeen(ctx context.Context) (*upsertSeen[ID], bool) {
+	return runState(ctx, s, func() *upsertSeen[ID] {
+		return &upsertSeen[ID]{ids: make(map[ID]struct{})}
+	})
+}
+
+// Finish deletes, in batches, the records DeleteMissing lists that the
+// context's SyncRun did not write. Without DeleteMissing, a SyncRun or any
+// record written in it, it does nothing.
+func (s *UpsertSink[U, ID]) Finish(ctx context.Context) (WriteResult, error) {
+	var result WriteResult
+	if s.listIDs == nil {
+		return result, nil
+	}
+	seen, ok := s.runSeen(ctx)
+	if !ok {
+		return result, nil
+	}
+	seen.mu.Lock()
+	written := len(seen.ids)
+	seen.mu.Unlock()
+	if written == 0 {
+		return result, nil
+	}
+
+	all, err := s.listIDs(ctx)
+	if err != nil {
+		return result, fmt.Errorf("%s: list ids for delete-missing: %w", s.name, err)
+	}
+	var missing []ID
+	for _, id := range all {
+		if _, ok := seen.ids[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+
+	for start := 0; start < len(missing); start += s.batchSize {
+		end := min(start+s.batchSize, len(missing))
+		if err := s.write(ctx, UpsertBatch[U, ID]{Deletes: missing[start:end]}); err != nil {
+			return result, fmt.Errorf("%s: delete %d missing records: %w", s.name, end-start, err)
+		}
+		result.Deleted += end - start
+	}
+	return result, nil
+}
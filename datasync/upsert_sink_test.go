@@ -0,0 +1,337 @@
+package datasync
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type versionedRecord struct {
+	ID      string
+	Name    string
+	Version int64
+}
+
+// memTable is an in-memory destination for UpsertSink tests.
+type memTable struct {
+	rows    map[string]versionedRecord
+	finds   int
+	writes  int
+	deleted []string
+}
+
+func newMemTable(rows ...versionedRecord) *memTable {
+	t := &memTable{rows: make(map[string]versionedRecord)}
+	for _, r := range rows {
+		t.rows[r.ID] = r
+	}
+	return t
+}
+
+func (t *memTable) sink() *UpsertSink[versionedRecord, string] {
+	return NewUpsertSink[versionedRecord, string](
+		"mem",
+		func(r *versionedRecord) string { return r.ID },
+		func(_ context.Context, ids []string) (map[string]versionedRecord, error) {
+			t.finds++
+			found := make(map[string]versionedRecord)
+			for _, id := range ids {
+				if r, ok := t.rows[id]; ok {
+					found[id] = r
+				}
+			}
+			return found, nil
+		},
+		func(_ context.Context, batch UpsertBatch[versionedRecord, string]) error {
+			t.writes++
+			for _, r := range batch.Inserts {
+				t.rows[r.ID] = r
+			}
+			for _, r := range batch.Updates {
+				t.rows[r.ID] = r
+			}
+			for _, id := range batch.Deletes {
+				delete(t.rows, id)
+				t.deleted = append(t.deleted, id)
+			}
+			return nil
+		},
+	)
+}
+
+func (t *memTable) listIDs(_ context.Context) ([]string, error) {
+	ids := make([]string, 0, len(t.rows))
+	for id := range t.rows {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func TestUpsertSink_UpdateIfChanged_ContentHash(t *testing.T) {
+	table := newMemTable(
+		versionedRecord{ID: "1", Name: "same"},
+		versionedRecord{ID: "2", Name: "old"},
+	)
+
+	result, err := table.sink().Write(context.Background(), []versionedRecord{
+		{ID: "1", Name: "same"},
+		{ID: "2", Name: "new"},
+		{ID: "3", Name: "added"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, WriteResult{Inserted: 1, Updated: 1, Skipped: 1}, result)
+	assert.Equal(t, "new", table.rows["2"].Name)
+	assert.Equal(t, "added", table.rows["3"].Name)
+}
+
+func TestUpsertSink_UpdateIfChanged_Comparator(t *testing.T) {
+	table := newMemTable(versionedRecord{ID: "1", Name: "a", Version: 1})
+	sink := table.sink().UpdateIfChanged(func(existing, incoming *versionedRecord) bool {
+		return existing.Name == incoming.Name
+	})
+
+	result, err := sink.Write(context.Background(), []versionedRecord{{ID: "1", Name: "a", Version: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, WriteResult{Skipped: 1}, result)
+	assert.Equal(t, 0, table.writes, "nothing to write")
+}
+
+func TestUpsertSink_InsertOnly(t *testing.T) {
+	table := newMemTable(versionedRecord{ID: "1", Name: "old"})
+
+	result, err := table.sink().InsertOnly().Write(context.Background(), []versionedRecord{
+		{ID: "1", Name: "new"},
+		{ID: "2", Name: "added"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, WriteResult{Inserted: 1, Skipped: 1}, result)
+	assert.Equal(t, "old", table.rows["1"].Name)
+}
+
+func TestUpsertSink_LastWriterWins(t *testing.T) {
+	table := newMemTable(
+		versionedRecord{ID: "1", Name: "stored", Version: 5},
+		versionedRecord{ID: "2", Name: "stored", Version: 5},
+		versionedRecord{ID: "3", Name: "stored", Version: 5},
+	)
+	sink := table.sink().LastWriterWins(func(r *versionedRecord) int64 { return r.Version })
+
+	result, err := sink.Write(context.Background(), []versionedRecord{
+		{ID: "1", Name: "older", Version: 4},
+		{ID: "2", Name: "same", Version: 5},
+		{ID: "3", Name: "newer", Version: 6},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, WriteResult{Updated: 1, Skipped: 2}, result)
+	assert.Equal(t, "stored", table.rows["1"].Name)
+	assert.Equal(t, "stored", table.rows["2"].Name)
+	assert.Equal(t, "newer", table.rows["3"].Name)
+}
+
+func TestUpsertSink_BatchSize(t *testing.T) {
+	table := newMemTable()
+	records := make([]versionedRecord, 5)
+	for i := range records {
+		records[i] = versionedRecord{ID: string(rune('a' + i))}
+	}
+
+	result, err := table.sink().BatchSize(2).Write(context.Background(), records)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Inserted)
+	assert.Equal(t, 3, table.finds)
+	assert.Equal(t, 3, table.writes)
+}
+
+func TestUpsertSink_DuplicateIDsLastWins(t *testing.T) {
+	table := newMemTable()
+
+	result, err := table.sink().Write(context.Background(), []versionedRecord{
+		{ID: "1", Name: "first"},
+		{ID: "1", Name: "second"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, WriteResult{Inserted: 1, Skipped: 1}, result)
+	assert.Equal(t, "second", table.rows["1"].Name)
+}
+
+func TestUpsertSink_WriteError(t *testing.T) {
+	calls := 0
+	sink := NewUpsertSink[versionedRecord, string](
+		"failing",
+		func(r *versionedRecord) string { return r.ID },
+		func(_ context.Context, _ []string) (map[string]versionedRecord, error) { return nil, nil },
+		func(_ context.Context, _ UpsertBatch[versionedRecord, string]) error {
+			calls++
+			if calls == 2 {
+				return errors.New("db down")
+			}
+			return nil
+		},
+	).BatchSize(1)
+
+	result, err := sink.Write(context.Background(), []versionedRecord{{ID: "1"}, {ID: "2"}, {ID: "3"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "db down")
+	assert.Equal(t, WriteResult{Inserted: 1}, result, "counts cover the batches written before the failure")
+}
+
+func TestUpsertSink_DeleteMissing(t *testing.T) {
+	table := newMemTable(
+		versionedRecord{ID: "1"},
+		versionedRecord{ID: "2"},
+		versionedRecord{ID: "3"},
+	)
+	sink := table.sink().BatchSize(1).DeleteMissing(table.listIDs)
+	ctx := WithSyncRun(context.Background(), NewSyncRun())
+
+	_, err := sink.Write(ctx, []versionedRecord{{ID: "1"}})
+	require.NoError(t, err)
+	_, err = sink.Write(ctx, []versionedRecord{{ID: "4"}})
+	require.NoError(t, err)
+
+	result, err := FinishSink[versionedRecord](ctx, sink)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Deleted)
+	assert.Equal(t, []string{"2", "3"}, table.deleted)
+	assert.Len(t, table.rows, 2)
+
+	// The seen IDs belong to the run, so the next run starts fresh.
+	ctx = WithSyncRun(context.Background(), NewSyncRun())
+	_, err = sink.Write(ctx, []versionedRecord{{ID: "4"}})
+	require.NoError(t, err)
+	result, err = sink.Finish(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Deleted)
+	assert.NotContains(t, table.rows, "1")
+}
+
+func TestUpsertSink_DeleteMissingKeepsOverlappingRunsApart(t *testing.T) {
+	table := newMemTable(versionedRecord{ID: "1"}, versionedRecord{ID: "2"})
+	sink := table.sink().DeleteMissing(table.listIDs)
+	first := WithSyncRun(context.Background(), NewSyncRun())
+	second := WithSyncRun(context.Background(), NewSyncRun())
+
+	_, err := sink.Write(first, []versionedRecord{{ID: "1"}, {ID: "2"}})
+	require.NoError(t, err)
+	_, err = sink.Write(second, []versionedRecord{{ID: "1"}, {ID: "2"}})
+	require.NoError(t, err)
+
+	result, err := sink.Finish(first)
+	require.NoError(t, err)
+	assert.Zero(t, result.Deleted)
+	result, err = sink.Finish(second)
+	require.NoError(t, err)
+	assert.Zero(t, result.Deleted, "the first finish does not reset the second run")
+	assert.Len(t, table.rows, 2)
+}
+
+func TestUpsertSink_DeleteMissingNeedsWrittenRecords(t *testing.T) {
+	table := newMemTable(versionedRecord{ID: "1"})
+	sink := table.sink().DeleteMissing(table.listIDs)
+
+	result, err := sink.Finish(WithSyncRun(context.Background(), NewSyncRun()))
+	require.NoError(t, err)
+	assert.Zero(t, result.Deleted, "a run that wrote nothing deletes nothing")
+
+	_, err = sink.Write(context.Background(), []versionedRecord{{ID: "2"}})
+	require.NoError(t, err)
+	result, err = sink.Finish(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, result.Deleted, "without a SyncRun nothing is tracked or deleted")
+	assert.Contains(t, table.rows, "1")
+}
+
+func TestUpsertSink_FinishWithoutDeleteMissing(t *testing.T) {
+	table := newMemTable(versionedRecord{ID: "1"})
+
+	result, err := table.sink().Finish(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, WriteResult{}, result)
+	assert.Contains(t, table.rows, "1")
+}
+
+func TestRunner_FinishesUpsertSink(t *testing.T) {
+	table := newMemTable(versionedRecord{ID: "stale"})
+	sink := table.sink().DeleteMissing(table.listIDs)
+	source := &mockSource[versionedRecord]{name: "src", records: []versionedRecord{{ID: "1"}}}
+	mapper := IdentityMapper[versionedRecord]()
+
+	result, err := NewRunner[versionedRecord, versionedRecord](source, mapper, sink).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.WriteResult.Inserted)
+	assert.Equal(t, 1, result.WriteResult.Deleted)
+	assert.NotContains(t, table.rows, "stale")
+}
+
+func TestRunner_DoesNotFinishIncompleteRun(t *testing.T) {
+	table := newMemTable(versionedRecord{ID: "kept"}, versionedRecord{ID: "skipped"})
+	sink := table.sink().DeleteMissing(table.listIDs)
+	source := &mockSource[versionedRecord]{name: "src", records: []versionedRecord{{ID: "kept"}, {ID: "skipped"}}}
+	mapper := NewRecordMapper[versionedRecord, versionedRecord]("skip", func(r *versionedRecord) (versionedRecord, error) {
+		if r.ID == "skipped" {
+			return versionedRecord{}, errors.New("bad record")
+		}
+		return *r, nil
+	})
+
+	result, err := NewRunner[versionedRecord, versionedRecord](source, mapper, sink).Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, result.WriteResult.Deleted)
+	assert.Contains(t, result.FinishSkipped, "mapper skipped 1 records")
+	assert.Contains(t, table.rows, "skipped", "a skipped record's row is not deleted")
+
+	empty := MapperFunc[versionedRecord, versionedRecord](func(context.Context, []versionedRecord) ([]versionedRecord, error) {
+		return nil, nil
+	})
+	_, err = NewRunner[versionedRecord, versionedRecord](source, empty, sink).Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, table.rows, 2, "a run whose mapper keeps nothing does not empty the table")
+}
+
+func TestUpsertSink_Plan(t *testing.T) {
+	table := newMemTable(
+		versionedRecord{ID: "1", Name: "same"},
+		versionedRecord{ID: "2", Name: "old", Version: 1},
+	)
+	sink := table.sink().BatchSize(2)
+
+	plan, err := sink.Plan(context.Background(), []versionedRecord{
+		{ID: "1", Name: "same"},
+		{ID: "2", Name: "new", Version: 1},
+		{ID: "3", Name: "fresh"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.WouldInsert)
+	assert.Equal(t, 1, plan.WouldUpdate)
+	assert.Equal(t, 1, plan.WouldSkip)
+	assert.Zero(t, table.writes, "a plan writes nothing")
+	assert.Equal(t, "old", table.rows["2"].Name)
+
+	require.Len(t, plan.Diffs, 2)
+	assert.Equal(t, "2", plan.Diffs[0].ID)
+	assert.Equal(t, "update", plan.Diffs[0].Action)
+	assert.Equal(t, []string{"Name"}, plan.Diffs[0].Fields)
+	assert.JSONEq(t, `{"ID":"2","Name":"old","Version":1}`, string(plan.Diffs[0].Before))
+	assert.Equal(t, "3", plan.Diffs[1].ID)
+	assert.Equal(t, "insert", plan.Diffs[1].Action)
+	assert.Empty(t, plan.Diffs[1].Before)
+}
+
+func TestUpsertSink_PlanDoesNotTrackDeleteMissing(t *testing.T) {
+	table := newMemTable(versionedRecord{ID: "1"}, versionedRecord{ID: "2"})
+	sink := table.sink().DeleteMissing(table.listIDs)
+	ctx := WithSyncRun(context.Background(), NewSyncRun())
+
+	_, err := sink.Plan(ctx, []versionedRecord{{ID: "1"}, {ID: "2"}})
+	require.NoError(t, err)
+	_, err = sink.Write(ctx, []versionedRecord{{ID: "1"}})
+	require.NoError(t, err)
+	fr, err := sink.Finish(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fr.Deleted, "planned IDs do not count as seen")
+}
@@ -0,0 +1,91 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkactivity "go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+	"github.com/jasoet/go-wf/v2/datasync/activity"
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+func TestSyncWorkflow_RecordsRunHistory(t *testing.T) {
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestWorkflowEnvironment()
+
+	raw := store.NewMemoryStore()
+	job := datasync.Job[string, int]{
+		Name:   "history-job",
+		Source: &mockSource[string]{name: "src", records: []string{"a", "", "abc"}},
+		Mapper: datasync.NewRecordMapper("len", func(s *string) (int, error) {
+			if *s == "" {
+				return 0, errors.New("empty input")
+			}
+			return len(*s), nil
+		}),
+		Sink:       &mockSink[int]{name: "dst", result: datasync.WriteResult{Inserted: 2}},
+		Store:      raw,
+		SampleSize: 2,
+	}
+
+	// Mirror RegisterJob's wiring on the test environment.
+	history := datasync.NewRunHistory(raw)
+	activities := activity.NewActivities(job.Source, datasync.RecordSkips(job.Mapper), job.Sink).WithHistory(history, job.SampleSize)
+	env.RegisterActivityWithOptions(activities.SyncData, sdkactivity.RegisterOptions{Name: job.Name + ".SyncData"})
+	env.RegisterActivityWithOptions(activity.NewRecordRunActivity(history),
+		sdkactivity.RegisterOptions{Name: recordRunActivityName(job.Name)})
+
+	env.ExecuteWorkflow(newSyncWorkflow(job, BuildActivityInput(job)), payload.SyncExecutionInput{JobName: job.Name})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	ctx := context.Background()
+	runs, err := history.ListRuns(ctx, job.Name, 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	run := runs[0]
+	assert.NotEmpty(t, run.RunID)
+	assert.True(t, run.Output.Success)
+	assert.Equal(t, 2, run.Output.Inserted)
+
+	skips, err := history.SkipReasons(ctx, job.Name, run.RunID)
+	require.NoError(t, err)
+	require.Len(t, skips, 1)
+	assert.Contains(t, skips[0], "empty input")
+
+	sample, err := history.Sample(ctx, job.Name, run.RunID)
+	require.NoError(t, err)
+	assert.Len(t, sample, 2)
+}
+
+func TestSyncWorkflow_HistoryFailureDoesNotFailRun(t *testing.T) {
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestWorkflowEnvironment()
+
+	job := datasync.Job[int, int]{
+		Name:   "history-job",
+		Source: &mockSource[int]{name: "src", records: []int{1}},
+		Mapper: datasync.IdentityMapper[int](),
+		Sink:   &mockSink[int]{name: "dst", result: datasync.WriteResult{Inserted: 1}},
+		Store:  store.NewMemoryStore(),
+	}
+	env.RegisterActivityWithOptions(activity.NewActivities(job.Source, job.Mapper, job.Sink).SyncData,
+		sdkactivity.RegisterOptions{Name: job.Name + ".SyncData"})
+	env.RegisterActivityWithOptions(func(context.Context, datasync.RunSummary) error { return errors.New("store down") },
+		sdkactivity.RegisterOptions{Name: recordRunActivityName(job.Name)})
+
+	env.ExecuteWorkflow(newSyncWorkflow(job, BuildActivityInput(job)), payload.SyncExecutionInput{JobName: job.Name})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var output payload.SyncExecutionOutput
+	require.NoError(t, env.GetWorkflowResult(&output))
+	assert.True(t, output.Success)
+}
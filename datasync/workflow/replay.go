@@ -0,0 +1,62 @@
+package workflow
+
+import (
+	"time"
+
+	sdkactivity "go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+	"github.com/jasoet/go-wf/v2/datasync/activity"
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+)
+
+// ReplayWorkflowName returns the workflow type that replays a job's dead
+// letters. Start it on TaskQueue(jobName) with a
+// payload.ReplayDeadLettersInput once the cause of the failures is fixed.
+func ReplayWorkflowName(jobName string) string {
+	return jobName + ".ReplayDeadLetters"
+}
+
+// replayActivityName returns the activity type behind ReplayWorkflowName.
+func replayActivityName(jobName string) string {
+	return jobName + ".ReplayDeadLettersActivity"
+}
+
+// RegisterReplay registers the dead-letter replay workflow and activity of
+// jobName. mapper and sink are the job's own, unwrapped mapper and sink.
+func RegisterReplay[T, U any](w worker.Worker, jobName string, queue datasync.DeadLetterQueue, mapper datasync.Mapper[T, U], sink datasync.Sink[U]) {
+	w.RegisterWorkflowWithOptions(newReplayWorkflow(jobName), workflow.RegisterOptions{Name: ReplayWorkflowName(jobName)})
+	w.RegisterActivityWithOptions(activity.NewReplayActivity(jobName, queue, mapper, sink),
+		sdkactivity.RegisterOptions{Name: replayActivityName(jobName)})
+}
+
+// replayActivityTimeout bounds one replay attempt. The activity heartbeats
+// after every batch, so a stuck replay is caught by the heartbeat timeout
+// long before this.
+const replayActivityTimeout = time.Hour
+
+// newReplayWorkflow returns a workflow function that runs one replay activity.
+func newReplayWorkflow(jobName string) func(workflow.Context, payload.ReplayDeadLettersInput) (*payload.ReplayDeadLettersOutput, error) {
+	return func(ctx workflow.Context, input payload.ReplayDeadLettersInput) (*payload.ReplayDeadLettersOutput, error) {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			TaskQueue:           TaskQueue(jobName),
+			StartToCloseTimeout: replayActivityTimeout,
+			HeartbeatTimeout:    defaultHeartbeatTimeout,
+			RetryPolicy: &temporal.RetryPolicy{
+				MaximumAttempts:    defaultMaxRetries,
+				InitialInterval:    defaultRetryInitialInterval,
+				BackoffCoefficient: defaultRetryBackoffCoeff,
+				MaximumInterval:    defaultRetryMaxInterval,
+			},
+		})
+
+		var output payload.ReplayDeadLettersOutput
+		if err := workflow.ExecuteActivity(ctx, replayActivityName(jobName), input).Get(ctx, &output); err != nil {
+			return nil, err
+		}
+		return &output, nil
+	}
+}
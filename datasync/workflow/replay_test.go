@@ -0,0 +1,47 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkactivity "go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/datasync"
+	"github.com/jasoet/go-wf/v2/datasync/activity"
+	"github.com/jasoet/go-wf/v2/datasync/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+func TestReplayWorkflow(t *testing.T) {
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestWorkflowEnvironment()
+
+	queue := datasync.NewStoreDeadLetterQueue(
+		store.NewTypedStore[datasync.DeadLetter](store.NewMemoryStore(), &store.JSONCodec[datasync.DeadLetter]{}))
+	for _, r := range []int{1, 2} {
+		l, err := datasync.NewDeadLetter("replay-job", "", datasync.DeadLetterStageMap, r, "boom")
+		require.NoError(t, err)
+		require.NoError(t, queue.PutDeadLetters(context.Background(), []datasync.DeadLetter{l}))
+	}
+
+	sink := &mockSink[int]{name: "dst", result: datasync.WriteResult{Inserted: 1}}
+	env.RegisterWorkflowWithOptions(newReplayWorkflow("replay-job"), workflow.RegisterOptions{Name: ReplayWorkflowName("replay-job")})
+	env.RegisterActivityWithOptions(activity.NewReplayActivity("replay-job", queue, datasync.IdentityMapper[int](), sink),
+		sdkactivity.RegisterOptions{Name: replayActivityName("replay-job")})
+
+	env.ExecuteWorkflow(ReplayWorkflowName("replay-job"), payload.ReplayDeadLettersInput{Limit: 1})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var output payload.ReplayDeadLettersOutput
+	require.NoError(t, env.GetWorkflowResult(&output))
+	assert.Equal(t, payload.ReplayDeadLettersOutput{JobName: "replay-job", Total: 1, Replayed: 1}, output)
+
+	letters, err := queue.ListDeadLetters(context.Background(), "replay-job")
+	require.NoError(t, err)
+	assert.Len(t, letters, 1)
+}
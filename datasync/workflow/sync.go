@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"fmt"
 	"time"
 
 	sdkactivity "go.temporal.io/sdk/activity"
@@ -20,6 +21,7 @@ const (
 	defaultRetryInitialInterval = 30 * time.Second
 	defaultRetryBackoffCoeff    = 2.0
 	defaultRetryMaxInterval     = 5 * time.Minute
+	recordRunTimeout            = 30 * time.Second
 )
 
 // TaskQueue returns the Temporal task queue name for a sync job.
@@ -65,8 +67,32 @@ func BuildActivityInput[T, U any](job datasync.Job[T, U]) activity.ActivityInput
 }
 
 // RegisterJob registers a sync job's workflow and activities with a Temporal worker.
+// When job.DeadLetters is set, per-record failures are dead-lettered, and a
+// datasync.DeadLetterQueue also gets the replay workflow (RegisterReplay).
+// When job.Store is set, every run is recorded in a datasync.RunHistory.
+// A run is a dry run when job.DryRun or the input's DryRun is set. When
+// job.Quality is set, mapped records are checked before they are written.
 func RegisterJob[T, U any](w worker.Worker, job datasync.Job[T, U]) {
-	activities := activity.NewActivities(job.Source, job.Mapper, job.Sink)
+	mapper, sink := job.Mapper, job.Sink
+	if job.Store != nil {
+		mapper = datasync.RecordSkips(mapper)
+	}
+	if job.DeadLetters != nil {
+		mapper, sink = datasync.WithDeadLetters(job.Name, mapper, job.Sink, job.DeadLetters)
+		if queue, ok := job.DeadLetters.(datasync.DeadLetterQueue); ok {
+			RegisterReplay(w, job.Name, queue, job.Mapper, job.Sink)
+		}
+	}
+	if job.Quality != nil {
+		sink = datasync.WithQualityChecks(job.Name, sink, job.Quality, job.DeadLetters)
+	}
+	activities := activity.NewActivities(job.Source, mapper, sink)
+	if job.Store != nil {
+		history := datasync.NewRunHistory(job.Store)
+		activities.WithHistory(history, job.SampleSize)
+		w.RegisterActivityWithOptions(activity.NewRecordRunActivity(history),
+			sdkactivity.RegisterOptions{Name: recordRunActivityName(job.Name)})
+	}
 
 	activityInput := BuildActivityInput(job)
 	wf := newSyncWorkflow(job, activityInput)
@@ -90,7 +116,18 @@ func newSyncWorkflow[T, U any](job datasync.Job[T, U], activityInput activity.Ac
 	}
 	retryMaxInterval := withDefault(job.RetryMaxInterval, defaultRetryMaxInterval)
 
-	return func(ctx workflow.Context, _ payload.SyncExecutionInput) (*payload.SyncExecutionOutput, error) {
+	canPlan := datasync.CanPlan(job.Sink)
+
+	return func(ctx workflow.Context, input payload.SyncExecutionInput) (*payload.SyncExecutionOutput, error) {
+		activityInput := activityInput
+		activityInput.DryRun = job.DryRun || input.DryRun
+		if activityInput.DryRun && !canPlan {
+			err := fmt.Errorf("dry run of %s: sink %s: %w", job.Name, job.Sink.Name(), datasync.ErrDryRunUnsupported)
+			output := activity.ToSyncExecutionOutput(job.Name, nil, 0, err)
+			output.DryRun = true
+			return &output, err
+		}
+
 		ao := workflow.ActivityOptions{
 			TaskQueue:           TaskQueue(job.Name),
 			StartToCloseTimeout: activityTimeout,
@@ -111,6 +148,10 @@ func newSyncWorkflow[T, U any](job datasync.Job[T, U], activityInput activity.Ac
 
 		processingTime := workflow.Now(ctx).Sub(startTime)
 		output := activity.ToSyncExecutionOutput(job.Name, &actOutput, processingTime, err)
+		output.DryRun = activityInput.DryRun
+		if job.Store != nil {
+			recordRun(ctx, job.Name, startTime, output)
+		}
 
 		if err != nil {
 			return &output, err
@@ -119,6 +160,33 @@ func newSyncWorkflow[T, U any](job datasync.Job[T, U], activityInput activity.Ac
 	}
 }
 
+// recordRunActivityName returns the activity type that stores a run's
+// summary.
+func recordRunActivityName(jobName string) string {
+	return jobName + ".RecordRun"
+}
+
+// recordRun stores the run's summary. The history is best effort, so a
+// failure is logged and does not fail the run.
+func recordRun(ctx workflow.Context, jobName string, startedAt time.Time, output payload.SyncExecutionOutput) {
+	info := workflow.GetInfo(ctx)
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskQueue:           TaskQueue(jobName),
+		StartToCloseTimeout: recordRunTimeout,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: defaultMaxRetries},
+	})
+	summary := datasync.RunSummary{
+		JobName:    jobName,
+		WorkflowID: info.WorkflowExecution.ID,
+		RunID:      info.WorkflowExecution.RunID,
+		StartedAt:  startedAt,
+		Output:     output,
+	}
+	if err := workflow.ExecuteActivity(ctx, recordRunActivityName(jobName), summary).Get(ctx, nil); err != nil {
+		workflow.GetLogger(ctx).Warn("Run history not saved", "job", jobName, "error", err)
+	}
+}
+
 func withDefault(val, def time.Duration) time.Duration {
 	if val == 0 {
 		return def
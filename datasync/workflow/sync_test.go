@@ -134,3 +134,68 @@ func TestSyncWorkflow_ActivityError(t *testing.T) {
 	require.True(t, env.IsWorkflowCompleted())
 	assert.Error(t, env.GetWorkflowError())
 }
+
+// planningMockSink is a mockSink that implements datasync.Planner.
+type planningMockSink[U any] struct {
+	mockSink[U]
+}
+
+func (p *planningMockSink[U]) Plan(_ context.Context, records []U) (payload.SyncPlan, error) {
+	return payload.SyncPlan{WouldInsert: len(records)}, nil
+}
+
+func TestSyncWorkflow_DryRunFromInput(t *testing.T) {
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestWorkflowEnvironment()
+
+	jobName := "test-job"
+	env.RegisterActivityWithOptions(stubSyncDataActivity, sdkactivity.RegisterOptions{Name: jobName + ".SyncData"})
+
+	var gotDryRun bool
+	env.OnActivity(jobName+".SyncData", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activity.ActivityInput) (*activity.ActivityOutput, error) {
+			gotDryRun = in.DryRun
+			return &activity.ActivityOutput{TotalFetched: 4, Plan: &payload.SyncPlan{WouldInsert: 4}}, nil
+		})
+
+	job := datasync.Job[int, int]{
+		Name:   jobName,
+		Source: &mockSource[int]{name: "src"},
+		Mapper: datasync.IdentityMapper[int](),
+		Sink:   &planningMockSink[int]{mockSink[int]{name: "dst"}},
+	}
+	wf := newSyncWorkflow(job, BuildActivityInput(job))
+
+	env.ExecuteWorkflow(wf, payload.SyncExecutionInput{JobName: jobName, SourceName: "src", SinkName: "dst", DryRun: true})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var output payload.SyncExecutionOutput
+	require.NoError(t, env.GetWorkflowResult(&output))
+	assert.True(t, gotDryRun)
+	assert.True(t, output.DryRun)
+	require.NotNil(t, output.Plan)
+	assert.Equal(t, 4, output.Plan.WouldInsert)
+}
+
+func TestSyncWorkflow_DryRunRequiresPlanner(t *testing.T) {
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestWorkflowEnvironment()
+
+	jobName := "test-job"
+	env.RegisterActivityWithOptions(stubSyncDataActivity, sdkactivity.RegisterOptions{Name: jobName + ".SyncData"})
+
+	job := datasync.Job[int, int]{
+		Name:   jobName,
+		Source: &mockSource[int]{name: "src"},
+		Mapper: datasync.IdentityMapper[int](),
+		Sink:   &mockSink[int]{name: "dst"},
+		DryRun: true,
+	}
+	wf := newSyncWorkflow(job, BuildActivityInput(job))
+
+	env.ExecuteWorkflow(wf, payload.SyncExecutionInput{JobName: jobName, SourceName: "src", SinkName: "dst"})
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), datasync.ErrDryRunUnsupported.Error())
+}
@@ -24,6 +24,7 @@ import (
 	fnpayload "github.com/jasoet/go-wf/v2/function/payload"
 	fnwf "github.com/jasoet/go-wf/v2/function/workflow"
 	gowfworker "github.com/jasoet/go-wf/v2/worker"
+	generic "github.com/jasoet/go-wf/v2/workflow"
 	"github.com/jasoet/go-wf/v2/workflow/store"
 )
 
@@ -73,6 +74,15 @@ func main() {
 		)
 	}
 
+	// File and directory output artifacts are uploaded by one activity bound
+	// to one store; these demos only pass inline bytes artifacts, which each
+	// workflow stores in its own store.
+	if s3Store != nil {
+		generic.RegisterArtifactActivities(w, s3Store)
+	} else if localStore != nil {
+		generic.RegisterArtifactActivities(w, localStore)
+	}
+
 	// Register workflows and activity
 	fn.RegisterWorkflows(w)
 	fn.RegisterActivity(w, fnactivity.NewExecuteFunctionActivity(registry))
@@ -93,6 +103,9 @@ func main() {
 	log.Println()
 	log.Println("Registered activities:")
 	log.Println("  - ExecuteFunctionActivity")
+	if s3Store != nil || localStore != nil {
+		log.Println("  - UploadArtifactActivity")
+	}
 	log.Println()
 	log.Println("Worker listening on task queue: function-tasks")
 
@@ -2,21 +2,89 @@ package activity
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"go.temporal.io/sdk/activity"
+
 	fn "github.com/jasoet/go-wf/v2/function"
 	"github.com/jasoet/go-wf/v2/function/payload"
+	"github.com/jasoet/go-wf/v2/internal/heartbeat"
+	wferrors "github.com/jasoet/go-wf/v2/workflow/errors"
 	"github.com/jasoet/go-wf/v2/workflow/secrets"
+	"github.com/jasoet/go-wf/v2/workflow/store"
 )
 
+// Option configures NewExecuteFunctionActivity.
+type Option func(*config)
+
+type config struct {
+	autoHeartbeat     bool
+	heartbeatInterval time.Duration
+	claimCheck        *fn.ClaimCheck
+}
+
+// WithAutoHeartbeat heartbeats in the background while a handler runs, so
+// activities can use a HeartbeatTimeout even when handlers never call
+// function.Progress.Report. Each heartbeat re-sends the latest reported
+// details. A zero interval derives max(1s, HeartbeatTimeout/3), or 10s when
+// the activity has no HeartbeatTimeout.
+func WithAutoHeartbeat(interval time.Duration) Option {
+	return func(c *config) {
+		c.autoHeartbeat = true
+		c.heartbeatInterval = interval
+	}
+}
+
+// WithClaimCheck resolves input DataRefs from the claim check's store and
+// offloads output Data above its threshold, so large payloads stay out of
+// Temporal history. Workflows and clients must use the same store.
+func WithClaimCheck(cc *fn.ClaimCheck) Option {
+	return func(c *config) { c.claimCheck = cc }
+}
+
+// TimeoutError reports that a handler exceeded FunctionExecutionInput.Timeout.
+// It matches errors.ErrTimeout from the workflow/errors package via errors.Is.
+// Across the Temporal boundary it arrives as an ApplicationError of type
+// errors.TimeoutErrorType; the workflows map it back with errors.FromTemporal.
+type TimeoutError struct {
+	Function string
+	Timeout  time.Duration
+}
+
+// Error implements error interface.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("function %s timed out after %s", e.Function, e.Timeout)
+}
+
+// Is reports whether target is errors.ErrTimeout.
+func (e *TimeoutError) Is(target error) bool {
+	return target == wferrors.ErrTimeout
+}
+
 // NewExecuteFunctionActivity creates a Temporal activity that dispatches to registered handlers.
 //
 // Error handling semantics:
 //   - Validation errors and registry lookup failures return an error, causing Temporal retries.
 //   - Handler execution errors are captured in the output (Success=false, Error set) but return nil
 //     error, so Temporal does NOT retry. This treats handler failures as business logic results.
-func NewExecuteFunctionActivity(registry *fn.Registry) func(ctx context.Context, input payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+//   - When input.Timeout is set, the handler runs under a context with that deadline. A handler that
+//     overruns it is abandoned and the activity returns a *TimeoutError (retried per the retry
+//     policy), with the output also marking the failure.
+//
+// Every handler context carries a function.Progress for heartbeats and checkpoints; pass
+// WithAutoHeartbeat to also heartbeat in the background while handlers run.
+//
+// With WithClaimCheck, an input DataRef is loaded before the handler runs and output Data above
+// the threshold is replaced by a DataRef. Store failures return an error, causing Temporal retries;
+// the output upload heartbeats its progress (store.HeartbeatUploads) so a retry resumes it.
+func NewExecuteFunctionActivity(registry *fn.Registry, opts ...Option) func(ctx context.Context, input payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	return func(ctx context.Context, input payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
 		startTime := time.Now()
 
@@ -31,8 +99,9 @@ func NewExecuteFunctionActivity(registry *fn.Registry) func(ctx context.Context,
 			}, err
 		}
 
-		// Look up handler
-		handler, err := registry.Get(input.Name)
+		// Look up handler; an unversioned name runs the worker's default
+		// version, recorded in the output.
+		handler, version, err := registry.ResolveVersion(fn.VersionedName(input.Name, input.Version))
 		if err != nil {
 			return &payload.FunctionExecutionOutput{
 				Name:       input.Name,
@@ -56,6 +125,24 @@ func NewExecuteFunctionActivity(registry *fn.Registry) func(ctx context.Context,
 			}, err
 		}
 
+		// Load claim-checked input data worker-side.
+		if input.DataRef != "" {
+			if cfg.claimCheck == nil {
+				err = fmt.Errorf("input for %s has a data_ref but the activity has no claim-check store", input.Name)
+			} else {
+				err = cfg.claimCheck.ResolveInput(ctx, &input)
+			}
+			if err != nil {
+				return &payload.FunctionExecutionOutput{
+					Name:       input.Name,
+					StartedAt:  startTime,
+					FinishedAt: time.Now(),
+					Success:    false,
+					Error:      err.Error(),
+				}, err
+			}
+		}
+
 		// Build function input from payload
 		fnInput := fn.FunctionInput{
 			Args:    input.Args,
@@ -64,26 +151,45 @@ func NewExecuteFunctionActivity(registry *fn.Registry) func(ctx context.Context,
 			WorkDir: input.WorkDir,
 		}
 
-		// Call handler with panic recovery.
-		var fnOutput *fn.FunctionOutput
-		var handlerErr error
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					handlerErr = fmt.Errorf("handler panic: %v", r)
-				}
-			}()
-			fnOutput, handlerErr = handler(ctx, fnInput)
-		}()
+		progress := fn.NewProgress(ctx)
+		stopHeartbeats := func() {}
+		if cfg.autoHeartbeat && activity.IsActivity(ctx) {
+			interval := cfg.heartbeatInterval
+			if interval <= 0 {
+				interval = heartbeat.Interval(activity.GetInfo(ctx).HeartbeatTimeout)
+			}
+			stopHeartbeats = progress.StartHeartbeats(interval)
+			defer stopHeartbeats()
+		}
+
+		handlerCtx := fn.WithProgress(ctx, progress)
+		if input.Timeout > 0 {
+			var cancel context.CancelFunc
+			handlerCtx, cancel = context.WithTimeout(handlerCtx, input.Timeout)
+			defer cancel()
+		}
+
+		fnOutput, handlerErr := callHandler(handlerCtx, handler, fnInput)
 		finishTime := time.Now()
 
 		output := &payload.FunctionExecutionOutput{
 			Name:       input.Name,
+			Version:    version,
 			StartedAt:  startTime,
 			FinishedAt: finishTime,
 			Duration:   finishTime.Sub(startTime),
 		}
 
+		// Only our own deadline counts as a function timeout; cancellation or
+		// expiry of the activity context itself is reported as before.
+		if handlerErr != nil && input.Timeout > 0 && ctx.Err() == nil &&
+			errors.Is(handlerCtx.Err(), context.DeadlineExceeded) {
+			timeoutErr := &TimeoutError{Function: input.Name, Timeout: input.Timeout}
+			output.Success = false
+			output.Error = timeoutErr.Error()
+			return output, timeoutErr
+		}
+
 		if handlerErr != nil {
 			output.Success = false
 			output.Error = handlerErr.Error()
@@ -96,6 +202,54 @@ func NewExecuteFunctionActivity(registry *fn.Registry) func(ctx context.Context,
 			output.Data = fnOutput.Data
 		}
 
+		if cfg.claimCheck != nil {
+			// The offload heartbeats its upload progress next to the
+			// handler's checkpoint, so a retry resumes a large upload.
+			stopHeartbeats()
+			uploadCtx := store.HeartbeatUploads(ctx, progress.Latest)
+			if err := cfg.claimCheck.OffloadOutput(uploadCtx, output); err != nil {
+				output.Success = false
+				output.Error = err.Error()
+				output.Data = nil
+				return output, err
+			}
+		}
+
 		return output, nil
 	}
 }
+
+// callHandler runs handler with panic recovery. It returns as soon as ctx is
+// done, abandoning a handler that ignores cancellation so it cannot hold the
+// activity slot.
+func callHandler(ctx context.Context, handler fn.Handler, input fn.FunctionInput) (*fn.FunctionOutput, error) {
+	type result struct {
+		output *fn.FunctionOutput
+		err    error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		defer func() {
+			if p := recover(); p != nil {
+				r = result{err: fmt.Errorf("handler panic: %v", p)}
+			}
+			done <- r
+		}()
+		r.output, r.err = handler(ctx, input)
+	}()
+
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-ctx.Done():
+		// Prefer a result that raced with cancellation.
+		select {
+		case r := <-done:
+			return r.output, r.err
+		default:
+		}
+		return nil, ctx.Err()
+	}
+}
@@ -2,14 +2,18 @@ package activity
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	fn "github.com/jasoet/go-wf/v2/function"
 	"github.com/jasoet/go-wf/v2/function/payload"
+	wferrors "github.com/jasoet/go-wf/v2/workflow/errors"
+	"github.com/jasoet/go-wf/v2/workflow/store"
 )
 
 func TestExecuteFunctionActivity_Success(t *testing.T) {
@@ -170,3 +174,156 @@ func TestExecuteFunctionActivity_SecretRefMissing(t *testing.T) {
 	assert.False(t, output.Success)
 	assert.Contains(t, output.Error, "DEFINITELY_NOT_SET")
 }
+
+func TestExecuteFunctionActivity_Timeout(t *testing.T) {
+	registry := fn.NewRegistry()
+	release := make(chan struct{})
+	defer close(release)
+	// Ignores its context, as a hung handler would.
+	_ = registry.Register("hang", func(_ context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+		<-release
+		return &fn.FunctionOutput{}, nil
+	})
+
+	activity := NewExecuteFunctionActivity(registry)
+
+	start := time.Now()
+	output, err := activity(context.Background(), payload.FunctionExecutionInput{
+		Name:    "hang",
+		Timeout: 50 * time.Millisecond,
+	})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second, "hung handler must be abandoned at the deadline")
+
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, "hang", timeoutErr.Function)
+	assert.True(t, errors.Is(err, wferrors.ErrTimeout))
+
+	require.NotNil(t, output)
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Error, "timed out after 50ms")
+}
+
+func TestExecuteFunctionActivity_TimeoutNotReached(t *testing.T) {
+	registry := fn.NewRegistry()
+	_ = registry.Register("quick", func(ctx context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+		_, hasDeadline := ctx.Deadline()
+		return &fn.FunctionOutput{Result: map[string]string{"deadline": fmt.Sprint(hasDeadline)}}, nil
+	})
+
+	activity := NewExecuteFunctionActivity(registry)
+
+	output, err := activity(context.Background(), payload.FunctionExecutionInput{
+		Name:    "quick",
+		Timeout: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.True(t, output.Success)
+	assert.Equal(t, "true", output.Result["deadline"])
+}
+
+func TestExecuteFunctionActivity_ParentCancelIsNotTimeout(t *testing.T) {
+	registry := fn.NewRegistry()
+	_ = registry.Register("wait", func(ctx context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	activity := NewExecuteFunctionActivity(registry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	output, err := activity(ctx, payload.FunctionExecutionInput{
+		Name:    "wait",
+		Timeout: time.Minute,
+	})
+	require.NoError(t, err)
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Error, "context canceled")
+}
+
+func TestExecuteFunctionActivity_RunsMiddlewareChain(t *testing.T) {
+	registry := fn.NewRegistry()
+	registry.Use(func(next fn.Handler) fn.Handler {
+		return func(ctx context.Context, input fn.FunctionInput) (*fn.FunctionOutput, error) {
+			out, err := next(ctx, input)
+			if out != nil {
+				out.Result["handler"] = fn.HandlerName(ctx)
+			}
+			return out, err
+		}
+	})
+	_ = registry.Register("wrapped", func(_ context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+		return &fn.FunctionOutput{Result: map[string]string{}}, nil
+	})
+
+	activity := NewExecuteFunctionActivity(registry)
+
+	output, err := activity(context.Background(), payload.FunctionExecutionInput{Name: "wrapped"})
+	require.NoError(t, err)
+	assert.True(t, output.Success)
+	assert.Equal(t, "wrapped", output.Result["handler"])
+}
+
+func TestExecuteFunctionActivity_ClaimCheck(t *testing.T) {
+	ctx := context.Background()
+	raw := store.NewMemoryStore()
+	cc := fn.NewClaimCheck(raw, 8)
+
+	registry := fn.NewRegistry()
+	_ = registry.Register("double", func(_ context.Context, input fn.FunctionInput) (*fn.FunctionOutput, error) {
+		return &fn.FunctionOutput{Data: append(input.Data, input.Data...)}, nil
+	})
+
+	ref, err := cc.Offload(ctx, []byte("0123456789"))
+	require.NoError(t, err)
+
+	activity := NewExecuteFunctionActivity(registry, WithClaimCheck(cc))
+	output, err := activity(ctx, payload.FunctionExecutionInput{Name: "double", DataRef: ref})
+	require.NoError(t, err)
+	require.True(t, output.Success)
+
+	// The 20-byte output is above the threshold and travels by reference.
+	assert.Nil(t, output.Data)
+	require.NotEmpty(t, output.DataRef)
+	data, err := cc.Load(ctx, output.DataRef)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("01234567890123456789"), data)
+}
+
+func TestExecuteFunctionActivity_DataRefWithoutClaimCheck(t *testing.T) {
+	registry := fn.NewRegistry()
+	_ = registry.Register("noop", func(_ context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+		return &fn.FunctionOutput{}, nil
+	})
+
+	activity := NewExecuteFunctionActivity(registry)
+	output, err := activity(context.Background(), payload.FunctionExecutionInput{Name: "noop", DataRef: "claim-check/sha256/abc"})
+	require.Error(t, err)
+	require.NotNil(t, output)
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Error, "no claim-check store")
+}
+
+func TestExecuteFunctionActivity_RecordsVersion(t *testing.T) {
+	registry := fn.NewRegistry()
+	for _, v := range []string{"v1", "v2"} {
+		_ = registry.Register("process-order@"+v, func(_ context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+			return &fn.FunctionOutput{Result: map[string]string{"served": v}}, nil
+		})
+	}
+	require.NoError(t, registry.SetDefaultVersion("process-order", "v1"))
+	activity := NewExecuteFunctionActivity(registry)
+
+	output, err := activity(context.Background(), payload.FunctionExecutionInput{Name: "process-order"})
+	require.NoError(t, err)
+	assert.Equal(t, "v1", output.Version)
+	assert.Equal(t, "v1", output.Result["served"])
+
+	output, err = activity(context.Background(), payload.FunctionExecutionInput{Name: "process-order", Version: "v2"})
+	require.NoError(t, err)
+	assert.Equal(t, "v2", output.Version)
+	assert.Equal(t, "v2", output.Result["served"])
+}
@@ -0,0 +1,168 @@
+package activity
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkactivity "go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
+
+	fn "github.com/jasoet/go-wf/v2/function"
+	"github.com/jasoet/go-wf/v2/function/payload"
+)
+
+// heartbeatRecorder collects the int details of every heartbeat.
+type heartbeatRecorder struct {
+	mu     sync.Mutex
+	values []int
+	empty  int
+}
+
+func (r *heartbeatRecorder) listen(_ *sdkactivity.Info, details converter.EncodedValues) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !details.HasValues() {
+		r.empty++
+		return
+	}
+	var v int
+	if err := details.Get(&v); err == nil {
+		r.values = append(r.values, v)
+	}
+}
+
+func (r *heartbeatRecorder) snapshot() ([]int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.values...), r.empty
+}
+
+func TestExecuteFunctionActivity_ProgressResumesFromCheckpoint(t *testing.T) {
+	registry := fn.NewRegistry()
+	var resumedFrom int
+	_ = registry.Register("count", func(ctx context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+		p := fn.ProgressFrom(ctx)
+		if _, err := p.LastCheckpoint(&resumedFrom); err != nil {
+			return nil, err
+		}
+		for i := resumedFrom; i < 5; i++ {
+			p.Report(i + 1)
+		}
+		return &fn.FunctionOutput{}, nil
+	})
+
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestActivityEnvironment()
+	recorder := &heartbeatRecorder{}
+	env.SetOnActivityHeartbeatListener(recorder.listen)
+	env.SetHeartbeatDetails(3) // a previous attempt got through three items
+
+	activityFn := NewExecuteFunctionActivity(registry)
+	env.RegisterActivityWithOptions(activityFn, sdkactivity.RegisterOptions{Name: "ExecuteFunctionActivity"})
+
+	result, err := env.ExecuteActivity("ExecuteFunctionActivity", payload.FunctionExecutionInput{Name: "count"})
+	require.NoError(t, err)
+
+	var output payload.FunctionExecutionOutput
+	require.NoError(t, result.Get(&output))
+	assert.True(t, output.Success)
+	assert.Equal(t, 3, resumedFrom)
+
+	// Temporal throttles heartbeats, so only the first report is guaranteed
+	// to be sent before the activity completes.
+	values, _ := recorder.snapshot()
+	require.NotEmpty(t, values)
+	assert.Equal(t, 4, values[0])
+}
+
+func TestExecuteFunctionActivity_AutoHeartbeat(t *testing.T) {
+	registry := fn.NewRegistry()
+	_ = registry.Register("slow", func(_ context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+		time.Sleep(50 * time.Millisecond)
+		return &fn.FunctionOutput{}, nil
+	})
+
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestActivityEnvironment()
+	recorder := &heartbeatRecorder{}
+	env.SetOnActivityHeartbeatListener(recorder.listen)
+
+	activityFn := NewExecuteFunctionActivity(registry, WithAutoHeartbeat(5*time.Millisecond))
+	env.RegisterActivityWithOptions(activityFn, sdkactivity.RegisterOptions{Name: "ExecuteFunctionActivity"})
+
+	_, err := env.ExecuteActivity("ExecuteFunctionActivity", payload.FunctionExecutionInput{Name: "slow"})
+	require.NoError(t, err)
+
+	_, empty := recorder.snapshot()
+	assert.Positive(t, empty, "handler never reported, yet the activity heartbeated")
+}
+
+func TestExecuteFunctionActivity_NoAutoHeartbeatByDefault(t *testing.T) {
+	registry := fn.NewRegistry()
+	_ = registry.Register("slow", func(_ context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+		time.Sleep(20 * time.Millisecond)
+		return &fn.FunctionOutput{}, nil
+	})
+
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestActivityEnvironment()
+	recorder := &heartbeatRecorder{}
+	env.SetOnActivityHeartbeatListener(recorder.listen)
+
+	activityFn := NewExecuteFunctionActivity(registry)
+	env.RegisterActivityWithOptions(activityFn, sdkactivity.RegisterOptions{Name: "ExecuteFunctionActivity"})
+
+	_, err := env.ExecuteActivity("ExecuteFunctionActivity", payload.FunctionExecutionInput{Name: "slow"})
+	require.NoError(t, err)
+
+	values, empty := recorder.snapshot()
+	assert.Empty(t, values)
+	assert.Zero(t, empty)
+}
+
+func TestProgress_OutsideActivity(t *testing.T) {
+	p := fn.ProgressFrom(context.Background())
+	require.NotNil(t, p)
+
+	p.Report("step-1")
+	assert.Equal(t, "step-1", p.Latest())
+
+	var checkpoint string
+	found, err := p.LastCheckpoint(&checkpoint)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	stop := p.StartHeartbeats(time.Millisecond)
+	stop()
+	stop() // idempotent
+}
+
+func TestExecuteFunctionActivity_AutoHeartbeatKeepsPreviousCheckpoint(t *testing.T) {
+	registry := fn.NewRegistry()
+	_ = registry.Register("slow", func(_ context.Context, _ fn.FunctionInput) (*fn.FunctionOutput, error) {
+		time.Sleep(50 * time.Millisecond)
+		return &fn.FunctionOutput{}, nil
+	})
+
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestActivityEnvironment()
+	recorder := &heartbeatRecorder{}
+	env.SetOnActivityHeartbeatListener(recorder.listen)
+	env.SetHeartbeatDetails(3)
+
+	activityFn := NewExecuteFunctionActivity(registry, WithAutoHeartbeat(5*time.Millisecond))
+	env.RegisterActivityWithOptions(activityFn, sdkactivity.RegisterOptions{Name: "ExecuteFunctionActivity"})
+
+	_, err := env.ExecuteActivity("ExecuteFunctionActivity", payload.FunctionExecutionInput{Name: "slow"})
+	require.NoError(t, err)
+
+	values, empty := recorder.snapshot()
+	assert.Zero(t, empty, "a detail-less heartbeat would erase the previous checkpoint")
+	require.NotEmpty(t, values)
+	assert.Equal(t, 3, values[0])
+}
@@ -287,6 +287,7 @@ func (b *WorkflowBuilder[I, O]) Build() (*job.Definition, error) {
 		job.WithExecute(func(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in any) (client.WorkflowRun, error) {
 			return c.ExecuteWorkflow(ctx, opts, wfType, in)
 		}),
+		workflow.WithWorkflowType(wfType),
 		job.WithNewInput(newInputFn),
 	)
 }
@@ -553,6 +554,7 @@ func (lb *LoopBuilder[I, O]) Build() (*job.Definition, error) {
 		job.WithExecute(func(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in any) (client.WorkflowRun, error) {
 			return c.ExecuteWorkflow(ctx, opts, wfType, in)
 		}),
+		workflow.WithWorkflowType(wfType),
 		job.WithNewInput(newInputFn),
 	)
 }
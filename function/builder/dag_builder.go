@@ -1,22 +1,35 @@
 package builder
 
 import (
+	"context"
 	"fmt"
+	"slices"
 
+	"github.com/jasoet/pkg/v2/temporal/job"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+
+	fn "github.com/jasoet/go-wf/v2/function"
 	"github.com/jasoet/go-wf/v2/function/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
 )
 
-// DAGBuilder provides a fluent API for constructing function DAG workflow inputs.
+// DAGBuilder provides a fluent API for constructing function DAG workflow inputs
+// and producing a *job.Definition that runs the function DAG workflow.
 type DAGBuilder struct {
 	name        string
+	taskQueue   string
+	activityFn  any
 	nodes       []payload.FunctionDAGNode
 	nodeIndex   map[string]int // name -> index in nodes slice
 	failFast    bool
 	maxParallel int
+	claimCheck  bool
 	errors      []error
 }
 
-// NewDAGBuilder creates a new DAG builder with the specified name.
+// NewDAGBuilder creates a new DAG builder with the specified name. The name is
+// used as the job name by Build.
 func NewDAGBuilder(name string) *DAGBuilder {
 	return &DAGBuilder{
 		name:      name,
@@ -25,6 +38,18 @@ func NewDAGBuilder(name string) *DAGBuilder {
 	}
 }
 
+// TaskQueue overrides the default task queue (which is "function-<name>").
+func (b *DAGBuilder) TaskQueue(tq string) *DAGBuilder {
+	b.taskQueue = tq
+	return b
+}
+
+// Activity sets the activity function to register with the worker. Required before calling Build.
+func (b *DAGBuilder) Activity(activityFn any) *DAGBuilder {
+	b.activityFn = activityFn
+	return b
+}
+
 // AddNode adds a node from a WorkflowSource with optional dependencies.
 func (b *DAGBuilder) AddNode(name string, source WorkflowSource, deps ...string) *DAGBuilder {
 	if source == nil {
@@ -91,6 +116,65 @@ func (b *DAGBuilder) WithDataMapping(nodeName, fromNode string) *DAGBuilder {
 	return b
 }
 
+// WithMap turns the named node into a map node that runs its function once per
+// item of spec.FromNode's output. spec.FromNode is added as a dependency.
+func (b *DAGBuilder) WithMap(nodeName string, spec payload.MapSpec) *DAGBuilder {
+	idx, exists := b.nodeIndex[nodeName]
+	if !exists {
+		b.errors = append(b.errors, fmt.Errorf("unknown node for map: %s", nodeName))
+		return b
+	}
+
+	node := &b.nodes[idx]
+	node.Map = &spec
+	if !slices.Contains(node.Dependencies, spec.FromNode) {
+		node.Dependencies = append(node.Dependencies, spec.FromNode)
+	}
+	return b
+}
+
+// AddSubWorkflowNode adds a node that runs sub, a registered workflow or an
+// inline nested DAG or pipeline, as a child workflow.
+func (b *DAGBuilder) AddSubWorkflowNode(name string, sub payload.SubWorkflowNode, deps ...string) *DAGBuilder {
+	if _, exists := b.nodeIndex[name]; exists {
+		b.errors = append(b.errors, fmt.Errorf("duplicate node name: %s", name))
+		return b
+	}
+
+	node := payload.FunctionDAGNode{Name: name, SubWorkflow: &sub, Dependencies: deps}
+	b.nodeIndex[name] = len(b.nodes)
+	b.nodes = append(b.nodes, node)
+	return b
+}
+
+// AddDefinitionNode adds a node that runs def, with its own input snapshot,
+// as a child workflow.
+func (b *DAGBuilder) AddDefinitionNode(name string, def *job.Definition, deps ...string) *DAGBuilder {
+	sub, err := generic.ResolveDefinition(def, nil)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Errorf("node %s: %w", name, err))
+		return b
+	}
+	return b.AddSubWorkflowNode(name, payload.SubWorkflowNode{SubWorkflow: sub}, deps...)
+}
+
+// WithSubWorkflowOptions applies child workflow options, such as the ID
+// template, parent-close policy and output mappings, to a sub-workflow node.
+func (b *DAGBuilder) WithSubWorkflowOptions(nodeName string, opts ...generic.SubWorkflowOption) *DAGBuilder {
+	idx, exists := b.nodeIndex[nodeName]
+	if !exists {
+		b.errors = append(b.errors, fmt.Errorf("unknown node for sub-workflow options: %s", nodeName))
+		return b
+	}
+	node := &b.nodes[idx]
+	if node.SubWorkflow == nil {
+		b.errors = append(b.errors, fmt.Errorf("node %s is not a sub-workflow node", nodeName))
+		return b
+	}
+	node.SubWorkflow.Apply(opts...)
+	return b
+}
+
 // FailFast configures fail-fast behavior for the DAG workflow.
 func (b *DAGBuilder) FailFast(ff bool) *DAGBuilder {
 	b.failFast = ff
@@ -103,6 +187,13 @@ func (b *DAGBuilder) MaxParallel(max int) *DAGBuilder {
 	return b
 }
 
+// ClaimCheck passes "bytes" input artifacts to activities by reference. The
+// activity must use a claim check on the same store as the artifact store.
+func (b *DAGBuilder) ClaimCheck(enabled bool) *DAGBuilder {
+	b.claimCheck = enabled
+	return b
+}
+
 // BuildDAG creates the DAG workflow input, returning an error if validation fails.
 func (b *DAGBuilder) BuildDAG() (*payload.DAGWorkflowInput, error) {
 	if len(b.errors) > 0 {
@@ -117,11 +208,55 @@ func (b *DAGBuilder) BuildDAG() (*payload.DAGWorkflowInput, error) {
 		Nodes:       b.nodes,
 		FailFast:    b.failFast,
 		MaxParallel: b.maxParallel,
+		ClaimCheck:  b.claimCheck,
 	}
 
-	if err := input.Validate(); err != nil {
+	if err := input.ValidateAs(b.name); err != nil {
 		return nil, fmt.Errorf("DAG validation failed: %w", err)
 	}
 
 	return input, nil
 }
+
+// Build validates the DAG and returns a *job.Definition ready for
+// registration with a Temporal worker and execution via the job registry.
+// The workflow input carries no ArtifactStore: a store cannot cross the
+// Temporal boundary, so artifacts are skipped for jobs started this way.
+//
+// Required before calling Build:
+//   - a non-empty name passed to NewDAGBuilder
+//   - Activity(...) — sets the activity function
+func (b *DAGBuilder) Build() (*job.Definition, error) {
+	if b.name == "" {
+		return nil, fmt.Errorf("function.DAGBuilder: Name is required")
+	}
+	if b.activityFn == nil {
+		return nil, fmt.Errorf("function.DAGBuilder: Activity is required")
+	}
+
+	input, err := b.BuildDAG()
+	if err != nil {
+		return nil, err
+	}
+
+	tq := b.taskQueue
+	if tq == "" {
+		tq = "function-" + b.name
+	}
+
+	snapshot := *input
+	activityFn := b.activityFn
+	return job.New(b.name, tq,
+		job.WithRegister(func(w worker.Worker) {
+			fn.RegisterAll(w, activityFn)
+		}),
+		job.WithExecute(func(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in any) (client.WorkflowRun, error) {
+			return c.ExecuteWorkflow(ctx, opts, "InstrumentedDAGWorkflow", in)
+		}),
+		generic.WithWorkflowType("InstrumentedDAGWorkflow"),
+		job.WithNewInput(func() any {
+			cp := snapshot
+			return &cp
+		}),
+	)
+}
@@ -1,12 +1,14 @@
 package builder
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/jasoet/go-wf/v2/function/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
 )
 
 func TestDAGBuilder_SimpleTwoNodeDAG(t *testing.T) {
@@ -88,6 +90,7 @@ func TestDAGBuilder_FailFastAndMaxParallel(t *testing.T) {
 		AddNodeWithInput("node2", payload.FunctionExecutionInput{Name: "f2"}).
 		FailFast(true).
 		MaxParallel(3).
+		ClaimCheck(true).
 		BuildDAG()
 
 	require.NoError(t, err)
@@ -95,6 +98,7 @@ func TestDAGBuilder_FailFastAndMaxParallel(t *testing.T) {
 
 	assert.True(t, dag.FailFast)
 	assert.Equal(t, 3, dag.MaxParallel)
+	assert.True(t, dag.ClaimCheck)
 }
 
 func TestDAGBuilder_EmptyDAGError(t *testing.T) {
@@ -143,3 +147,148 @@ func TestDAGBuilder_MappingToUnknownNodeError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unknown node")
 }
+
+func TestDAGBuilder_Build(t *testing.T) {
+	t.Run("produces definition", func(t *testing.T) {
+		def, err := NewDAGBuilder("etl").
+			Activity(dummyActivity).
+			AddNodeWithInput("extract", payload.FunctionExecutionInput{Name: "extract"}).
+			AddNodeWithInput("load", payload.FunctionExecutionInput{Name: "load"}, "extract").
+			FailFast(true).
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, "etl", def.Name)
+		assert.Equal(t, "function-etl", def.TaskQueue)
+
+		in, ok := def.NewInput().(*payload.DAGWorkflowInput)
+		require.True(t, ok)
+		assert.Len(t, in.Nodes, 2)
+		assert.True(t, in.FailFast)
+	})
+
+	t.Run("custom task queue is used", func(t *testing.T) {
+		def, err := NewDAGBuilder("etl").
+			TaskQueue("custom-queue").
+			Activity(dummyActivity).
+			AddNodeWithInput("extract", payload.FunctionExecutionInput{Name: "extract"}).
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, "custom-queue", def.TaskQueue)
+	})
+
+	t.Run("requires name", func(t *testing.T) {
+		_, err := NewDAGBuilder("").
+			Activity(dummyActivity).
+			AddNodeWithInput("extract", payload.FunctionExecutionInput{Name: "extract"}).
+			Build()
+		assert.ErrorContains(t, err, "Name is required")
+	})
+
+	t.Run("requires activity", func(t *testing.T) {
+		_, err := NewDAGBuilder("etl").
+			AddNodeWithInput("extract", payload.FunctionExecutionInput{Name: "extract"}).
+			Build()
+		assert.ErrorContains(t, err, "Activity is required")
+	})
+
+	t.Run("rejects cycles", func(t *testing.T) {
+		_, err := NewDAGBuilder("cyclic").
+			Activity(dummyActivity).
+			AddNodeWithInput("a", payload.FunctionExecutionInput{Name: "a"}, "b").
+			AddNodeWithInput("b", payload.FunctionExecutionInput{Name: "b"}, "a").
+			Build()
+		assert.ErrorContains(t, err, "circular dependency")
+	})
+}
+
+func TestDAGBuilder_WithMap(t *testing.T) {
+	dag, err := NewDAGBuilder("map-dag").
+		AddNodeWithInput("list", payload.FunctionExecutionInput{Name: "list-partitions"}).
+		AddNodeWithInput("process", payload.FunctionExecutionInput{
+			Name: "process-partition",
+			Args: map[string]string{"partition": "{{item}}"},
+		}).
+		WithMap("process", payload.MapSpec{FromNode: "list", ResultKey: "partitions", MaxConcurrency: 4}).
+		BuildDAG()
+
+	require.NoError(t, err)
+	process := dag.Nodes[1]
+	require.NotNil(t, process.Map)
+	assert.Equal(t, "partitions", process.Map.ResultKey)
+	assert.Equal(t, 4, process.Map.MaxConcurrency)
+	assert.Equal(t, []string{"list"}, process.Dependencies)
+}
+
+func TestDAGBuilder_WithMapUnknownNode(t *testing.T) {
+	_, err := NewDAGBuilder("map-dag").
+		AddNodeWithInput("list", payload.FunctionExecutionInput{Name: "list-partitions"}).
+		WithMap("missing", payload.MapSpec{FromNode: "list"}).
+		BuildDAG()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown node for map: missing")
+}
+
+func TestDAGBuilder_AddDefinitionNode(t *testing.T) {
+	child, err := NewDAGBuilder("child").
+		Activity(dummyActivity).
+		AddNodeWithInput("step", payload.FunctionExecutionInput{Name: "step"}).
+		Build()
+	require.NoError(t, err)
+
+	dag, err := NewDAGBuilder("parent").
+		AddDefinitionNode("run-child", child).
+		WithSubWorkflowOptions("run-child",
+			generic.WithChildWorkflowID("{{parent_id}}-child"),
+			generic.WithParentClosePolicy(generic.ParentCloseAbandon),
+			generic.WithChildOutputs(generic.SubWorkflowOutput{Name: "ok", Path: "$.total_success"}),
+		).
+		AddNodeWithInput("after", payload.FunctionExecutionInput{Name: "after"}, "run-child").
+		BuildDAG()
+	require.NoError(t, err)
+
+	sub := dag.Nodes[0].SubWorkflow
+	require.NotNil(t, sub)
+	assert.Equal(t, "child", sub.Definition)
+	assert.Equal(t, payload.DAGWorkflowType, sub.WorkflowType)
+	assert.Equal(t, "function-child", sub.TaskQueue)
+	assert.Equal(t, "{{parent_id}}-child", sub.WorkflowID)
+	assert.Equal(t, generic.ParentCloseAbandon, sub.ParentClosePolicy)
+	assert.Len(t, sub.Outputs, 1)
+
+	var nested payload.DAGWorkflowInput
+	require.NoError(t, json.Unmarshal(sub.Input, &nested))
+	assert.Equal(t, "step", nested.Nodes[0].Name)
+}
+
+func TestDAGBuilder_SubWorkflowCycle(t *testing.T) {
+	a, err := NewDAGBuilder("a").
+		Activity(dummyActivity).
+		AddNodeWithInput("step", payload.FunctionExecutionInput{Name: "step"}).
+		Build()
+	require.NoError(t, err)
+
+	b, err := NewDAGBuilder("b").
+		Activity(dummyActivity).
+		AddDefinitionNode("run-a", a).
+		Build()
+	require.NoError(t, err)
+
+	_, err = NewDAGBuilder("a").
+		AddDefinitionNode("run-b", b).
+		BuildDAG()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sub-workflow cycle: a -> b -> a")
+}
+
+func TestDAGBuilder_SubWorkflowOptionsErrors(t *testing.T) {
+	_, err := NewDAGBuilder("parent").
+		AddNodeWithInput("plain", payload.FunctionExecutionInput{Name: "plain"}).
+		WithSubWorkflowOptions("plain", generic.WithChildWorkflowID("x")).
+		BuildDAG()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a sub-workflow node")
+
+	_, err = NewDAGBuilder("parent").AddDefinitionNode("nil", nil).BuildDAG()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "definition is nil")
+}
@@ -0,0 +1,142 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/jasoet/go-wf/v2/function/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+// DefaultClaimCheckThreshold is the Data size above which NewClaimCheck
+// offloads payloads when no threshold is given. Temporal rejects payloads of
+// about 2MB, and a workflow history holds many of them.
+const DefaultClaimCheckThreshold = 256 << 10
+
+// ClaimCheckPrefix is the key prefix of offloaded payloads, for example to
+// attach a store lifecycle rule.
+const ClaimCheckPrefix = "claim-check/"
+
+// ClaimCheck moves large Data payloads out of Temporal history. Data above
+// the threshold is written to a store and replaced by a reference (DataRef)
+// that is resolved on the worker.
+//
+// Payloads are stored content-addressed under ClaimCheckPrefix, so retries
+// and identical payloads reuse one object. The store does not delete them;
+// expire the prefix with a lifecycle rule if needed.
+type ClaimCheck struct {
+	store     store.RawStore
+	threshold int
+}
+
+// NewClaimCheck creates a ClaimCheck backed by raw. A threshold <= 0 uses
+// DefaultClaimCheckThreshold.
+func NewClaimCheck(raw store.RawStore, threshold int) *ClaimCheck {
+	if threshold <= 0 {
+		threshold = DefaultClaimCheckThreshold
+	}
+	return &ClaimCheck{store: raw, threshold: threshold}
+}
+
+// Store returns the backing store.
+func (c *ClaimCheck) Store() store.RawStore {
+	return c.store
+}
+
+// Threshold returns the size above which Data is offloaded.
+func (c *ClaimCheck) Threshold() int {
+	return c.threshold
+}
+
+// Offload writes data to the store and returns its reference.
+func (c *ClaimCheck) Offload(ctx context.Context, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	ref := ClaimCheckPrefix + "sha256/" + hex.EncodeToString(sum[:])
+
+	exists, err := c.store.Exists(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("claim check %s: %w", ref, err)
+	}
+	if exists {
+		return ref, nil
+	}
+	if err := c.store.Upload(ctx, ref, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("claim check %s: %w", ref, err)
+	}
+	return ref, nil
+}
+
+// Load reads the data stored under ref.
+func (c *ClaimCheck) Load(ctx context.Context, ref string) ([]byte, error) {
+	reader, err := c.store.Download(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("resolve data ref %s: %w", ref, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("resolve data ref %s: %w", ref, err)
+	}
+	return data, nil
+}
+
+// offload returns the reference for data when it exceeds the threshold.
+func (c *ClaimCheck) offload(ctx context.Context, data []byte) (string, bool, error) {
+	if len(data) <= c.threshold {
+		return "", false, nil
+	}
+	ref, err := c.Offload(ctx, data)
+	return ref, err == nil, err
+}
+
+// OffloadInput replaces input.Data with a DataRef when it exceeds the
+// threshold. Call it before starting a workflow with large inputs.
+func (c *ClaimCheck) OffloadInput(ctx context.Context, input *payload.FunctionExecutionInput) error {
+	ref, ok, err := c.offload(ctx, input.Data)
+	if ok {
+		input.Data, input.DataRef = nil, ref
+	}
+	return err
+}
+
+// OffloadOutput replaces output.Data with a DataRef when it exceeds the
+// threshold.
+func (c *ClaimCheck) OffloadOutput(ctx context.Context, output *payload.FunctionExecutionOutput) error {
+	ref, ok, err := c.offload(ctx, output.Data)
+	if ok {
+		output.Data, output.DataRef = nil, ref
+	}
+	return err
+}
+
+// ResolveInput loads input.DataRef into input.Data.
+func (c *ClaimCheck) ResolveInput(ctx context.Context, input *payload.FunctionExecutionInput) error {
+	if input.DataRef == "" {
+		return nil
+	}
+	data, err := c.Load(ctx, input.DataRef)
+	if err != nil {
+		return err
+	}
+	input.Data, input.DataRef = data, ""
+	return nil
+}
+
+// ResolveOutput loads output.DataRef into output.Data, for callers reading
+// workflow results.
+func (c *ClaimCheck) ResolveOutput(ctx context.Context, output *payload.FunctionExecutionOutput) error {
+	if output.DataRef == "" {
+		return nil
+	}
+	data, err := c.Load(ctx, output.DataRef)
+	if err != nil {
+		return err
+	}
+	output.Data, output.DataRef = data, ""
+	return nil
+}
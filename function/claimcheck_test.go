@@ -0,0 +1,82 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/function/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+func TestClaimCheck_DefaultThreshold(t *testing.T) {
+	cc := NewClaimCheck(store.NewMemoryStore(), 0)
+	assert.Equal(t, DefaultClaimCheckThreshold, cc.Threshold())
+}
+
+func TestClaimCheck_OffloadAndResolve(t *testing.T) {
+	ctx := context.Background()
+	raw := store.NewMemoryStore()
+	cc := NewClaimCheck(raw, 8)
+
+	large := bytes.Repeat([]byte("x"), 16)
+	input := &payload.FunctionExecutionInput{Name: "f", Data: large}
+	require.NoError(t, cc.OffloadInput(ctx, input))
+	assert.Nil(t, input.Data)
+	assert.True(t, strings.HasPrefix(input.DataRef, ClaimCheckPrefix))
+
+	exists, err := raw.Exists(ctx, input.DataRef)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, cc.ResolveInput(ctx, input))
+	assert.Equal(t, large, input.Data)
+	assert.Empty(t, input.DataRef)
+}
+
+func TestClaimCheck_SmallDataStaysInline(t *testing.T) {
+	ctx := context.Background()
+	raw := store.NewMemoryStore()
+	cc := NewClaimCheck(raw, 8)
+
+	output := &payload.FunctionExecutionOutput{Data: []byte("small")}
+	require.NoError(t, cc.OffloadOutput(ctx, output))
+	assert.Equal(t, []byte("small"), output.Data)
+	assert.Empty(t, output.DataRef)
+
+	keys, err := raw.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestClaimCheck_ContentAddressed(t *testing.T) {
+	ctx := context.Background()
+	raw := store.NewMemoryStore()
+	cc := NewClaimCheck(raw, 1)
+
+	first, err := cc.Offload(ctx, []byte("same payload"))
+	require.NoError(t, err)
+	second, err := cc.Offload(ctx, []byte("same payload"))
+	require.NoError(t, err)
+	other, err := cc.Offload(ctx, []byte("other payload"))
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+
+	keys, err := raw.List(ctx, ClaimCheckPrefix)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestClaimCheck_ResolveMissingRef(t *testing.T) {
+	cc := NewClaimCheck(store.NewMemoryStore(), 0)
+	output := &payload.FunctionExecutionOutput{DataRef: "claim-check/sha256/missing"}
+	err := cc.ResolveOutput(context.Background(), output)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "claim-check/sha256/missing")
+}
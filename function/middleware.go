@@ -0,0 +1,232 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	pkgotel "github.com/jasoet/pkg/v2/otel"
+	"golang.org/x/time/rate"
+)
+
+// Middleware wraps a Handler with cross-cutting behavior such as logging,
+// metrics, auth checks or argument redaction.
+//
+// Middlewares are registered on a Registry globally (Use), for handler names
+// matching a pattern (UseFor), or for a single handler (Register). Resolve
+// composes them around the handler, outermost first: global, then pattern,
+// then per-handler, each group in registration order. The handler name is
+// available to middlewares through HandlerName.
+type Middleware func(next Handler) Handler
+
+type handlerNameKey struct{}
+
+// HandlerName returns the registered name of the handler being executed, as
+// set by Registry.Resolve. It returns "" outside a resolved handler.
+func HandlerName(ctx context.Context) string {
+	name, _ := ctx.Value(handlerNameKey{}).(string) //nolint:errcheck // missing value yields ""
+	return name
+}
+
+func withHandlerName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, handlerNameKey{}, name)
+}
+
+// patternMiddleware is a middleware applied to handler names matching pattern.
+type patternMiddleware struct {
+	pattern     string
+	middlewares []Middleware
+}
+
+// chain composes middlewares so that middlewares[0] is the outermost.
+func chain(handler Handler, middlewares []Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Use adds middlewares applied to every handler.
+func (r *Registry) Use(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.global = append(r.global, middlewares...)
+}
+
+// UseFor adds middlewares applied to handlers whose name matches pattern, in
+// path.Match syntax (for example "billing-*").
+func (r *Registry) UseFor(pattern string, middlewares ...Middleware) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid middleware pattern %q: %w", pattern, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patterns = append(r.patterns, patternMiddleware{pattern: pattern, middlewares: middlewares})
+	return nil
+}
+
+// Resolve returns the named handler wrapped in its middleware chain. This is
+// what the function activity executes; Get returns the bare handler.
+func (r *Registry) Resolve(name string) (Handler, error) {
+	handler, _, err := r.ResolveVersion(name)
+	return handler, err
+}
+
+// ResolveVersion is Resolve that also returns the version that will serve
+// the call ("" for an unversioned handler). An unversioned name resolves to
+// its default version (SetDefaultVersion), else to a handler registered
+// without a version, else to its only version.
+func (r *Registry) ResolveVersion(name string) (Handler, string, error) {
+	r.mu.RLock()
+	name = r.keyLocked(name)
+	handler, ok := r.handlers[name]
+	var middlewares []Middleware
+	if ok {
+		middlewares = append(middlewares, r.global...)
+		for _, pm := range r.patterns {
+			if matchesPattern(pm.pattern, name) {
+				middlewares = append(middlewares, pm.middlewares...)
+			}
+		}
+		middlewares = append(middlewares, r.local[name]...)
+	}
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, "", r.notFound(name)
+	}
+
+	wrapped := chain(handler, middlewares)
+	_, version := SplitVersion(name)
+	return func(ctx context.Context, input FunctionInput) (*FunctionOutput, error) {
+		return wrapped(withHandlerName(ctx, name), input)
+	}, version, nil
+}
+
+// Tracing returns a middleware that opens an OTel span per handler call,
+// named after the handler. It is a pass-through when no OTel config is in
+// the context.
+func Tracing() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, input FunctionInput) (*FunctionOutput, error) {
+			if pkgotel.ConfigFromContext(ctx) == nil {
+				return next(ctx, input)
+			}
+
+			name := HandlerName(ctx)
+			lc := pkgotel.Layers.StartService(ctx, "function.handler", name,
+				pkgotel.F("function.name", name),
+				pkgotel.F("function.args", len(input.Args)),
+				pkgotel.F("function.data_bytes", len(input.Data)),
+			)
+			defer lc.End()
+
+			output, err := next(lc.Context(), input)
+			if err != nil {
+				//nolint:errcheck,gosec // we return the original err, not lc.Error's return
+				lc.Error(err, "handler failed")
+				return output, err
+			}
+			lc.Success("handler completed")
+			return output, nil
+		}
+	}
+}
+
+// perHandler lazily creates one value per handler name, so a single
+// middleware instance registered globally still limits each handler
+// independently.
+type perHandler[T any] struct {
+	mu     sync.Mutex
+	values map[string]T
+	create func() T
+}
+
+func newPerHandler[T any](create func() T) *perHandler[T] {
+	return &perHandler[T]{values: make(map[string]T), create: create}
+}
+
+func (p *perHandler[T]) get(name string) T {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.values[name]
+	if !ok {
+		v = p.create()
+		p.values[name] = v
+	}
+	return v
+}
+
+// RateLimit returns a middleware that allows each handler at most rps calls
+// per second with the given burst. Calls over the limit wait for a token or
+// fail when the context ends first.
+func RateLimit(rps float64, burst int) Middleware {
+	limiters := newPerHandler(func() *rate.Limiter {
+		return rate.NewLimiter(rate.Limit(rps), burst)
+	})
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, input FunctionInput) (*FunctionOutput, error) {
+			name := HandlerName(ctx)
+			if err := limiters.get(name).Wait(ctx); err != nil {
+				return nil, fmt.Errorf("rate limit for %s: %w", name, err)
+			}
+			return next(ctx, input)
+		}
+	}
+}
+
+// ConcurrencyLimit returns a middleware that runs at most n calls of each
+// handler at once within this worker. Further calls wait for a slot or fail
+// when the context ends first.
+func ConcurrencyLimit(n int) Middleware {
+	slots := newPerHandler(func() chan struct{} {
+		return make(chan struct{}, max(n, 1))
+	})
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, input FunctionInput) (*FunctionOutput, error) {
+			name := HandlerName(ctx)
+			sem := slots.get(name)
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return nil, fmt.Errorf("concurrency limit for %s: %w", name, ctx.Err())
+			}
+			defer func() { <-sem }()
+			return next(ctx, input)
+		}
+	}
+}
+
+// Validate returns a middleware that rejects inputs for which validate
+// returns an error, before the handler runs. Registry.ValidateInput fits
+// the signature and checks typed handlers against their input types:
+//
+//	registry.Use(function.Validate(registry.ValidateInput))
+func Validate(validate func(name string, input FunctionInput) error) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, input FunctionInput) (*FunctionOutput, error) {
+			if err := validate(HandlerName(ctx), input); err != nil {
+				return nil, err
+			}
+			return next(ctx, input)
+		}
+	}
+}
+
+// matchesPattern reports whether pattern matches the registry key or, for a
+// versioned key, the bare handler name.
+func matchesPattern(pattern, key string) bool {
+	if matched, _ := path.Match(pattern, key); matched { //nolint:errcheck // validated in UseFor
+		return true
+	}
+	base, version := SplitVersion(key)
+	if version == "" {
+		return false
+	}
+	matched, _ := path.Match(pattern, base) //nolint:errcheck // validated in UseFor
+	return matched
+}
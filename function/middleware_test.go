@@ -0,0 +1,221 @@
+package function
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pkgotel "github.com/jasoet/pkg/v2/otel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func okHandler(_ context.Context, _ FunctionInput) (*FunctionOutput, error) {
+	return &FunctionOutput{}, nil
+}
+
+// recordingMiddleware appends label to *calls when invoked.
+func recordingMiddleware(mu *sync.Mutex, calls *[]string, label string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, input FunctionInput) (*FunctionOutput, error) {
+			mu.Lock()
+			*calls = append(*calls, label+":"+HandlerName(ctx))
+			mu.Unlock()
+			return next(ctx, input)
+		}
+	}
+}
+
+func TestRegistry_ResolveChainOrder(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+
+	r := NewRegistry()
+	r.Use(recordingMiddleware(&mu, &calls, "global1"), recordingMiddleware(&mu, &calls, "global2"))
+	require.NoError(t, r.UseFor("billing-*", recordingMiddleware(&mu, &calls, "pattern")))
+	require.NoError(t, r.Register("billing-charge", okHandler, recordingMiddleware(&mu, &calls, "local")))
+	require.NoError(t, r.Register("report", okHandler))
+
+	handler, err := r.Resolve("billing-charge")
+	require.NoError(t, err)
+	_, err = handler(context.Background(), FunctionInput{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"global1:billing-charge",
+		"global2:billing-charge",
+		"pattern:billing-charge",
+		"local:billing-charge",
+	}, calls)
+
+	calls = nil
+	handler, err = r.Resolve("report")
+	require.NoError(t, err)
+	_, err = handler(context.Background(), FunctionInput{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"global1:report", "global2:report"}, calls)
+}
+
+func TestRegistry_GetBypassesMiddleware(t *testing.T) {
+	r := NewRegistry()
+	r.Use(func(Handler) Handler {
+		return func(context.Context, FunctionInput) (*FunctionOutput, error) {
+			return nil, errors.New("blocked")
+		}
+	})
+	require.NoError(t, r.Register("f", okHandler))
+
+	bare, err := r.Get("f")
+	require.NoError(t, err)
+	_, err = bare(context.Background(), FunctionInput{})
+	assert.NoError(t, err)
+
+	resolved, err := r.Resolve("f")
+	require.NoError(t, err)
+	_, err = resolved(context.Background(), FunctionInput{})
+	assert.EqualError(t, err, "blocked")
+}
+
+func TestRegistry_ResolveErrors(t *testing.T) {
+	r := NewRegistry()
+	_, err := r.Resolve("missing")
+	assert.ErrorContains(t, err, "not found")
+
+	assert.ErrorContains(t, r.UseFor("[bad", Validate(nil)), "invalid middleware pattern")
+}
+
+func TestRegisterTyped_WithMiddleware(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+
+	r := NewRegistry()
+	require.NoError(t, RegisterTyped(r, "typed", func(_ context.Context, in string) (string, error) {
+		return in, nil
+	}, WithMiddleware(recordingMiddleware(&mu, &calls, "local"))))
+
+	handler, err := r.Resolve("typed")
+	require.NoError(t, err)
+	_, err = handler(context.Background(), FunctionInput{Data: []byte(`"x"`)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"local:typed"}, calls)
+}
+
+func TestConcurrencyLimit_PerHandler(t *testing.T) {
+	var active, peak atomic.Int32
+	release := make(chan struct{})
+	slow := func(_ context.Context, _ FunctionInput) (*FunctionOutput, error) {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		active.Add(-1)
+		return &FunctionOutput{}, nil
+	}
+
+	r := NewRegistry()
+	r.Use(ConcurrencyLimit(2))
+	require.NoError(t, r.Register("slow", slow))
+	require.NoError(t, r.Register("other", okHandler))
+
+	handler, err := r.Resolve("slow")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = handler(context.Background(), FunctionInput{}) //nolint:errcheck // result irrelevant
+		}()
+	}
+
+	require.Eventually(t, func() bool { return active.Load() == 2 }, time.Second, time.Millisecond)
+
+	// Another handler has its own slots and is not blocked.
+	other, err := r.Resolve("other")
+	require.NoError(t, err)
+	_, err = other(context.Background(), FunctionInput{})
+	require.NoError(t, err)
+
+	// A waiting call gives up when its context ends.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = handler(ctx, FunctionInput{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestRateLimit(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register("limited", okHandler, RateLimit(1, 1)))
+
+	handler, err := r.Resolve("limited")
+	require.NoError(t, err)
+
+	_, err = handler(context.Background(), FunctionInput{})
+	require.NoError(t, err, "burst token is available immediately")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = handler(ctx, FunctionInput{})
+	assert.ErrorContains(t, err, "rate limit for limited")
+}
+
+func TestValidateMiddleware(t *testing.T) {
+	r := NewRegistry()
+	r.Use(Validate(r.ValidateInput))
+	require.NoError(t, RegisterTyped(r, "resize", func(_ context.Context, in resizeInput) (resizeOutput, error) {
+		return resizeOutput{}, nil
+	}))
+
+	handler, err := r.Resolve("resize")
+	require.NoError(t, err)
+
+	_, err = handler(context.Background(), FunctionInput{})
+	assert.ErrorContains(t, err, "invalid input for resize")
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	cfg := pkgotel.NewConfig("test").WithTracerProvider(tp).WithoutLogging()
+
+	r := NewRegistry()
+	r.Use(Tracing())
+	require.NoError(t, r.Register("traced", okHandler))
+	require.NoError(t, r.Register("failing", func(context.Context, FunctionInput) (*FunctionOutput, error) {
+		return nil, errors.New("boom")
+	}))
+
+	// Without OTel config the middleware is a pass-through.
+	handler, err := r.Resolve("traced")
+	require.NoError(t, err)
+	_, err = handler(context.Background(), FunctionInput{})
+	require.NoError(t, err)
+	assert.Empty(t, recorder.Ended())
+
+	ctx := pkgotel.ContextWithConfig(context.Background(), cfg)
+	_, err = handler(ctx, FunctionInput{})
+	require.NoError(t, err)
+
+	failing, err := r.Resolve("failing")
+	require.NoError(t, err)
+	_, err = failing(ctx, FunctionInput{})
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Contains(t, spans[0].Name(), "traced")
+	assert.Contains(t, spans[1].Name(), "failing")
+}
@@ -13,15 +13,21 @@ import (
 
 // Compile-time interface checks.
 var (
-	_ workflow.TaskInput  = (*FunctionExecutionInput)(nil)
-	_ workflow.TaskOutput = FunctionExecutionOutput{}
+	_ workflow.TaskInput       = (*FunctionExecutionInput)(nil)
+	_ workflow.TaskOutput      = FunctionExecutionOutput{}
+	_ workflow.TimedTaskInput  = (*FunctionExecutionInput)(nil)
+	_ workflow.RoutedTaskInput = (*FunctionExecutionInput)(nil)
 )
 
 // pkgValidator is a package-level validator instance to avoid repeated instantiation.
 var pkgValidator = validator.New()
 
-// safeFunctionName restricts function names to safe characters.
-var safeFunctionName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+// safeFunctionName restricts function names to safe characters, with an
+// optional "@version" suffix.
+var safeFunctionName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*(@[a-zA-Z0-9][a-zA-Z0-9._-]*)?$`)
+
+// safeVersion restricts handler versions to safe characters.
+var safeVersion = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
 
 const functionActivityName = "ExecuteFunctionActivity"
 
@@ -30,10 +36,19 @@ type FunctionExecutionInput struct {
 	Name    string            `json:"name" validate:"required,max=255"`
 	Args    map[string]string `json:"args,omitempty"`
 	Data    []byte            `json:"data,omitempty"`
+	DataRef string            `json:"data_ref,omitempty"` // Claim-check reference to Data offloaded to a store.
 	Env     map[string]string `json:"env,omitempty"`
 	WorkDir string            `json:"work_dir,omitempty"`
-	Timeout time.Duration     `json:"timeout,omitempty"` // Reserved for future use; not enforced by the activity.
+	Timeout time.Duration     `json:"timeout,omitempty" validate:"gte=0"` // Per-attempt handler deadline; zero means none.
 	Labels  map[string]string `json:"labels,omitempty"`
+
+	// Version pins the handler version to run. Empty runs the worker's
+	// default version, unless Routes pick one.
+	Version string `json:"version,omitempty"`
+
+	// Routes pick a version by label match or weight when Version is empty;
+	// see RouteTask.
+	Routes []VersionRoute `json:"routes,omitempty" validate:"dive"`
 }
 
 // FunctionExecutionOutput defines output from function execution.
@@ -43,6 +58,8 @@ type FunctionExecutionOutput struct {
 	Error      string            `json:"error,omitempty"`
 	Result     map[string]string `json:"result,omitempty"`
 	Data       []byte            `json:"data,omitempty"`
+	DataRef    string            `json:"data_ref,omitempty"` // Claim-check reference to Data offloaded to a store.
+	Version    string            `json:"version,omitempty"`  // Handler version that served the call.
 	Duration   time.Duration     `json:"duration"`
 	StartedAt  time.Time         `json:"started_at"`
 	FinishedAt time.Time         `json:"finished_at"`
@@ -116,7 +133,16 @@ func (i *FunctionExecutionInput) Validate() error {
 	// Skip regex check for template names (containing {{...}} placeholders);
 	// the substituted name will be validated when the activity executes.
 	if !strings.Contains(i.Name, "{{") && !safeFunctionName.MatchString(i.Name) {
-		return fmt.Errorf("invalid function name: must match [a-zA-Z][a-zA-Z0-9_-]*")
+		return fmt.Errorf("invalid function name: must match [a-zA-Z][a-zA-Z0-9_-]*, optionally followed by @version")
+	}
+	if i.Version != "" && !safeVersion.MatchString(i.Version) {
+		return fmt.Errorf("invalid function version %q", i.Version)
+	}
+	if i.Version != "" && strings.Contains(i.Name, "@") {
+		return fmt.Errorf("function %s already names a version; do not also set version", i.Name)
+	}
+	if len(i.Data) > 0 && i.DataRef != "" {
+		return fmt.Errorf("data and data_ref are mutually exclusive")
 	}
 	return nil
 }
@@ -126,6 +152,11 @@ func (i *FunctionExecutionInput) ActivityName() string {
 	return functionActivityName
 }
 
+// TaskTimeout returns the per-function timeout enforced by the activity.
+func (i *FunctionExecutionInput) TaskTimeout() time.Duration {
+	return i.Timeout
+}
+
 // IsSuccess returns whether the function executed successfully.
 func (o FunctionExecutionOutput) IsSuccess() bool {
 	return o.Success
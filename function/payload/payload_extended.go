@@ -3,6 +3,7 @@ package payload
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"time"
 
 	"github.com/jasoet/go-wf/v2/workflow/errors"
@@ -47,6 +48,34 @@ type DataMapping struct {
 	Optional bool `json:"optional"`
 }
 
+// MapSpec fans a DAG node out over a JSON array produced by a previous node.
+// The node's Function is the template run once per item, with {{item}} and
+// {{index}} substituted in its name, args, env and work dir.
+type MapSpec struct {
+	// FromNode is the node whose output supplies the items. It must be one
+	// of the map node's dependencies.
+	FromNode string `json:"from_node" validate:"required"`
+
+	// ResultKey is the result key holding the JSON array of items. When
+	// empty, the source node's Data is parsed as the array.
+	ResultKey string `json:"result_key,omitempty"`
+
+	// MaxConcurrency limits how many items run at once. Zero falls back to
+	// the DAG's MaxParallel, and runs all items at once if that is unset.
+	MaxConcurrency int `json:"max_concurrency,omitempty" validate:"gte=0"`
+}
+
+// MapItemResult is one item's entry in a map node's aggregated Data.
+type MapItemResult struct {
+	Index   int               `json:"index"`
+	Item    string            `json:"item"`
+	Success bool              `json:"success"`
+	Error   string            `json:"error,omitempty"`
+	Result  map[string]string `json:"result,omitempty"`
+	Data    []byte            `json:"data,omitempty"`
+	DataRef string            `json:"data_ref,omitempty"`
+}
+
 // ArtifactRef defines a reference to an artifact on a workflow node.
 // Used by DAG nodes to declare input/output artifacts.
 type ArtifactRef struct {
@@ -61,6 +90,34 @@ type ArtifactRef struct {
 
 	// Optional indicates if the artifact is optional
 	Optional bool `json:"optional"`
+
+	// Include limits a directory artifact to paths matching these
+	// gitignore-style patterns. On inputs it selects which entries are
+	// extracted from the stored archive.
+	Include []string `json:"include,omitempty"`
+
+	// Exclude drops paths matching these gitignore-style patterns from a
+	// directory artifact (for example "node_modules/" or "*.log").
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Format is the archive format for directory artifacts: "tar.gz"
+	// (default), "tar" or "zip". Inputs detect the format when unset.
+	Format string `json:"format,omitempty" validate:"omitempty,oneof=tar.gz tar zip"`
+}
+
+// ArchiveOptions returns the store archive options for this artifact.
+func (a ArtifactRef) ArchiveOptions() []store.ArchiveOption {
+	var opts []store.ArchiveOption
+	if a.Format != "" {
+		opts = append(opts, store.WithArchiveFormat(a.Format))
+	}
+	if len(a.Include) > 0 {
+		opts = append(opts, store.WithInclude(a.Include...))
+	}
+	if len(a.Exclude) > 0 {
+		opts = append(opts, store.WithExclude(a.Exclude...))
+	}
+	return opts
 }
 
 // FunctionDAGNode represents a node in a function DAG workflow.
@@ -88,6 +145,13 @@ type FunctionDAGNode struct {
 
 	// OutputArtifacts defines artifacts to upload after execution.
 	OutputArtifacts []ArtifactRef `json:"output_artifacts,omitempty"`
+
+	// Map, when set, runs Function once per item of a previous node's
+	// output instead of once.
+	Map *MapSpec `json:"map,omitempty"`
+	// SubWorkflow, when set, runs the node as a child workflow instead of
+	// Function.
+	SubWorkflow *SubWorkflowNode `json:"sub_workflow,omitempty"`
 }
 
 // DAGWorkflowInput defines a DAG (Directed Acyclic Graph) workflow for functions.
@@ -104,10 +168,20 @@ type DAGWorkflowInput struct {
 	// ArtifactStore is the artifact storage backend (optional).
 	// If nil, artifact operations are skipped.
 	ArtifactStore store.RawStore `json:"-"`
+
+	// ClaimCheck passes "bytes" input artifacts to activities as a DataRef
+	// instead of loading them into workflow history. The activity must be
+	// configured with a claim check on the same store as ArtifactStore.
+	ClaimCheck bool `json:"claim_check,omitempty"`
 }
 
 // Validate validates DAG workflow input including structural integrity checks.
+// Nested sub-workflows are validated recursively.
 func (i *DAGWorkflowInput) Validate() error {
+	return i.validate(nil)
+}
+
+func (i *DAGWorkflowInput) validate(chain []string) error {
 	if len(i.Nodes) == 0 {
 		return errors.ErrInvalidInput.Wrap("at least one node is required")
 	}
@@ -136,11 +210,35 @@ func (i *DAGWorkflowInput) Validate() error {
 		}
 	}
 
+	for _, node := range i.Nodes {
+		if err := validateMapSpec(node); err != nil {
+			return err
+		}
+	}
+
 	// DFS-based cycle detection.
 	if err := detectCycles(i.Nodes); err != nil {
 		return err
 	}
 
+	return validateSubWorkflowNodes(i.Nodes, chain)
+}
+
+// validateMapSpec checks that a map node reads its items from one of its
+// dependencies.
+func validateMapSpec(node FunctionDAGNode) error {
+	if node.Map == nil {
+		return nil
+	}
+	if node.Map.FromNode == "" {
+		return errors.ErrInvalidInput.Wrap(fmt.Sprintf("map node %s: from_node is required", node.Name))
+	}
+	if node.Map.MaxConcurrency < 0 {
+		return errors.ErrInvalidInput.Wrap(fmt.Sprintf("map node %s: max_concurrency must be >= 0", node.Name))
+	}
+	if !slices.Contains(node.Dependencies, node.Map.FromNode) {
+		return errors.ErrInvalidInput.Wrap(fmt.Sprintf("map node %s must depend on %s", node.Name, node.Map.FromNode))
+	}
 	return nil
 }
 
@@ -228,3 +326,6 @@ type FunctionDAGWorkflowOutput struct {
 	// TotalDuration is the total execution time.
 	TotalDuration time.Duration `json:"total_duration"`
 }
+
+// FailedSteps implements workflow.ChildOutput.
+func (o *FunctionDAGWorkflowOutput) FailedSteps() int { return o.TotalFailed }
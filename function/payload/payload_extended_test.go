@@ -1,11 +1,14 @@
 package payload
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/workflow"
 )
 
 func TestOutputMapping_Fields(t *testing.T) {
@@ -94,6 +97,38 @@ func TestDAGWorkflowInput_MissingDependency(t *testing.T) {
 	assert.Contains(t, err.Error(), "dependency node not found")
 }
 
+func TestDAGWorkflowInput_MapSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    *MapSpec
+		deps    []string
+		wantErr string
+	}{
+		{name: "valid", spec: &MapSpec{FromNode: "list", ResultKey: "items"}, deps: []string{"list"}},
+		{name: "missing from node", spec: &MapSpec{}, deps: []string{"list"}, wantErr: "from_node is required"},
+		{name: "not a dependency", spec: &MapSpec{FromNode: "list"}, wantErr: "must depend on list"},
+		{name: "negative concurrency", spec: &MapSpec{FromNode: "list", MaxConcurrency: -1}, deps: []string{"list"}, wantErr: "max_concurrency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := DAGWorkflowInput{
+				Nodes: []FunctionDAGNode{
+					{Name: "list", Function: FunctionExecutionInput{Name: "list"}},
+					{Name: "process", Function: FunctionExecutionInput{Name: "process"}, Dependencies: tt.deps, Map: tt.spec},
+				},
+			}
+			err := input.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
 func TestDAGWorkflowInput_CircularDependency(t *testing.T) {
 	input := DAGWorkflowInput{
 		Nodes: []FunctionDAGNode{
@@ -273,3 +308,91 @@ func TestFunctionDAGWorkflowOutput_Fields(t *testing.T) {
 	assert.Equal(t, 0, output.TotalFailed)
 	assert.Equal(t, 5*time.Second, output.TotalDuration)
 }
+
+func TestDAGWorkflowInput_SubWorkflow(t *testing.T) {
+	nested := func(nodes ...FunctionDAGNode) json.RawMessage {
+		data, err := json.Marshal(DAGWorkflowInput{Nodes: nodes})
+		require.NoError(t, err)
+		return data
+	}
+	subNode := func(name string, sub SubWorkflowNode) FunctionDAGNode {
+		return FunctionDAGNode{Name: name, SubWorkflow: &sub}
+	}
+	leaf := FunctionDAGNode{Name: "leaf", Function: FunctionExecutionInput{Name: "leaf"}}
+
+	tests := []struct {
+		name    string
+		node    FunctionDAGNode
+		root    string
+		wantErr string
+	}{
+		{
+			name: "registered type",
+			node: subNode("child", SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{WorkflowType: "Other"}}),
+		},
+		{
+			name: "inline DAG",
+			node: subNode("child", SubWorkflowNode{DAG: &DAGWorkflowInput{Nodes: []FunctionDAGNode{leaf}}}),
+		},
+		{
+			name:    "no target",
+			node:    subNode("child", SubWorkflowNode{}),
+			wantErr: "exactly one of",
+		},
+		{
+			name: "two targets",
+			node: subNode("child", SubWorkflowNode{
+				SubWorkflow: workflow.SubWorkflow{WorkflowType: "Other"},
+				DAG:         &DAGWorkflowInput{Nodes: []FunctionDAGNode{leaf}},
+			}),
+			wantErr: "exactly one of",
+		},
+		{
+			name:    "invalid nested DAG",
+			node:    subNode("child", SubWorkflowNode{DAG: &DAGWorkflowInput{}}),
+			wantErr: "at least one node",
+		},
+		{
+			name: "invalid nested definition input",
+			node: subNode("child", SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{
+				Definition: "b", WorkflowType: DAGWorkflowType,
+				Input: nested(FunctionDAGNode{Name: "x", Function: FunctionExecutionInput{Name: "x"}, Dependencies: []string{"missing"}}),
+			}}),
+			wantErr: "dependency node not found",
+		},
+		{
+			name: "cycle across definitions",
+			root: "a",
+			node: subNode("child", SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{
+				Definition: "b", WorkflowType: DAGWorkflowType,
+				Input: nested(subNode("back", SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{
+					Definition: "a", WorkflowType: DAGWorkflowType,
+				}})),
+			}}),
+			wantErr: "sub-workflow cycle: a -> b -> a",
+		},
+		{
+			name: "map and sub-workflow",
+			node: func() FunctionDAGNode {
+				n := subNode("child", SubWorkflowNode{SubWorkflow: workflow.SubWorkflow{WorkflowType: "Other"}})
+				n.Map = &MapSpec{FromNode: "leaf"}
+				n.Dependencies = []string{"leaf"}
+				return n
+			}(),
+			wantErr: "both a map and a sub-workflow",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := DAGWorkflowInput{Nodes: []FunctionDAGNode{leaf, tt.node}}
+			err := input.ValidateAs(tt.root)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
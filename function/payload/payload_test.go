@@ -64,6 +64,16 @@ func TestFunctionExecutionInput_Validate(t *testing.T) {
 			input:   FunctionExecutionInput{Name: "a"},
 			wantErr: false,
 		},
+		{
+			name:    "valid - data ref",
+			input:   FunctionExecutionInput{Name: "my-func", DataRef: "claim-check/sha256/abc"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid - data and data ref",
+			input:   FunctionExecutionInput{Name: "my-func", Data: []byte("x"), DataRef: "claim-check/sha256/abc"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
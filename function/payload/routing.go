@@ -0,0 +1,70 @@
+package payload
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// VersionRoute sends a share of calls, or the calls whose labels match, to a
+// handler version. Routes let a new version be canaried next to the current
+// one on the same workers.
+type VersionRoute struct {
+	// Version is the handler version the route selects.
+	Version string `json:"version" validate:"required,max=64"`
+
+	// Weight is the route's relative share of calls that no label route
+	// matched. Routes with zero weight only take label matches.
+	Weight int `json:"weight,omitempty" validate:"gte=0"`
+
+	// Match selects the route for every call whose Labels contain all of
+	// these key/value pairs.
+	Match map[string]string `json:"match,omitempty"`
+}
+
+// RouteTask sets Version from Routes. Label routes are tried first, in
+// order; otherwise a weighted route is picked by hashing key with the
+// function name. The same key always picks the same version, so a workflow
+// passing its own ID and step routes identically on replay; see
+// workflow.PrepareTask for the key the workflows pass. RouteTask does nothing
+// when a version is already set or there are no routes; when no route
+// applies, the worker's default version serves the call.
+func (i *FunctionExecutionInput) RouteTask(key string) {
+	if i.Version != "" || strings.Contains(i.Name, "@") || len(i.Routes) == 0 {
+		return
+	}
+
+	for _, route := range i.Routes {
+		if len(route.Match) > 0 && labelsMatch(i.Labels, route.Match) {
+			i.Version = route.Version
+			return
+		}
+	}
+
+	total := 0
+	for _, route := range i.Routes {
+		total += route.Weight
+	}
+	if total == 0 {
+		return
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key + "/" + i.Name))
+	pick := int(h.Sum32() % uint32(total)) //nolint:gosec // total is a positive sum of small weights
+	for _, route := range i.Routes {
+		if pick < route.Weight {
+			i.Version = route.Version
+			return
+		}
+		pick -= route.Weight
+	}
+}
+
+func labelsMatch(labels, match map[string]string) bool {
+	for k, v := range match {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package payload
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func canaryInput() *FunctionExecutionInput {
+	return &FunctionExecutionInput{
+		Name: "process-order",
+		Routes: []VersionRoute{
+			{Version: "v1", Weight: 90},
+			{Version: "v2", Weight: 10},
+		},
+	}
+}
+
+func TestRouteTask_Deterministic(t *testing.T) {
+	first := canaryInput()
+	first.RouteTask("order-42")
+	require.NotEmpty(t, first.Version)
+
+	for range 10 {
+		again := canaryInput()
+		again.RouteTask("order-42")
+		assert.Equal(t, first.Version, again.Version)
+	}
+}
+
+func TestRouteTask_WeightsSplitKeys(t *testing.T) {
+	counts := map[string]int{}
+	for i := range 1000 {
+		in := canaryInput()
+		in.RouteTask(fmt.Sprintf("wf-%d", i))
+		counts[in.Version]++
+	}
+	assert.Equal(t, 1000, counts["v1"]+counts["v2"])
+	assert.Greater(t, counts["v1"], counts["v2"])
+	assert.Positive(t, counts["v2"])
+}
+
+func TestRouteTask_LabelMatchWins(t *testing.T) {
+	in := canaryInput()
+	in.Labels = map[string]string{"tenant": "beta"}
+	in.Routes = append(in.Routes, VersionRoute{Version: "v3", Match: map[string]string{"tenant": "beta"}})
+
+	in.RouteTask("any")
+	assert.Equal(t, "v3", in.Version)
+}
+
+func TestRouteTask_NoOp(t *testing.T) {
+	pinned := canaryInput()
+	pinned.Version = "v1"
+	pinned.RouteTask("k")
+	assert.Equal(t, "v1", pinned.Version)
+
+	named := canaryInput()
+	named.Name = "process-order@v2"
+	named.RouteTask("k")
+	assert.Empty(t, named.Version)
+
+	labelOnly := &FunctionExecutionInput{
+		Name:   "process-order",
+		Routes: []VersionRoute{{Version: "v2", Match: map[string]string{"tenant": "beta"}}},
+	}
+	labelOnly.RouteTask("k")
+	assert.Empty(t, labelOnly.Version, "unmatched label routes fall back to the worker default")
+}
+
+func TestFunctionExecutionInput_ValidateVersion(t *testing.T) {
+	assert.NoError(t, (&FunctionExecutionInput{Name: "process-order@v2"}).Validate())
+	assert.NoError(t, (&FunctionExecutionInput{Name: "process-order", Version: "v2.1"}).Validate())
+	assert.Error(t, (&FunctionExecutionInput{Name: "process-order@v2", Version: "v2"}).Validate())
+	assert.Error(t, (&FunctionExecutionInput{Name: "process-order", Version: "bad version"}).Validate())
+	assert.Error(t, (&FunctionExecutionInput{Name: "process-order", Routes: []VersionRoute{{Weight: 1}}}).Validate())
+}
@@ -0,0 +1,62 @@
+package payload
+
+import (
+	"fmt"
+
+	"github.com/jasoet/go-wf/v2/workflow"
+	"github.com/jasoet/go-wf/v2/workflow/errors"
+)
+
+// Workflow types started for inline sub-workflows.
+const (
+	DAGWorkflowType      = "InstrumentedDAGWorkflow"
+	PipelineWorkflowType = "FunctionPipelineWorkflow"
+)
+
+// InlineWorkflowTypes names the function workflows that run inline nested
+// DAGs and pipelines.
+var InlineWorkflowTypes = workflow.InlineWorkflowTypes{DAG: DAGWorkflowType, Pipeline: PipelineWorkflowType}
+
+// FunctionPipeline is the input of the function pipeline workflow.
+type FunctionPipeline = workflow.PipelineInput[*FunctionExecutionInput, FunctionExecutionOutput]
+
+// ChildOutputs maps the function workflow types to the outputs a
+// sub-workflow node decodes their results into.
+var ChildOutputs = workflow.ChildOutputs{
+	DAGWorkflowType:             func() workflow.ChildOutput { return &FunctionDAGWorkflowOutput{} },
+	PipelineWorkflowType:        func() workflow.ChildOutput { return &workflow.PipelineOutput[FunctionExecutionOutput]{} },
+	"ParallelFunctionsWorkflow": func() workflow.ChildOutput { return &workflow.ParallelOutput[FunctionExecutionOutput]{} },
+	"LoopWorkflow":              func() workflow.ChildOutput { return &workflow.LoopOutput[FunctionExecutionOutput]{} },
+	"ParameterizedLoopWorkflow": func() workflow.ChildOutput { return &workflow.LoopOutput[FunctionExecutionOutput]{} },
+}
+
+// SubWorkflowNode runs a DAG node as a child workflow instead of a function.
+type SubWorkflowNode = workflow.SubWorkflowNode[*DAGWorkflowInput, *FunctionPipeline]
+
+// ValidateWithin validates the input nested in chain, the enclosing
+// definitions, so a sub-workflow that references one of them is reported as
+// a cycle.
+func (i *DAGWorkflowInput) ValidateWithin(chain []string) error {
+	return i.validate(chain)
+}
+
+// ValidateAs validates the input as the input of the named definition, so a
+// nested sub-workflow that references that definition is reported as a cycle.
+func (i *DAGWorkflowInput) ValidateAs(definition string) error {
+	return i.validate([]string{definition})
+}
+
+func validateSubWorkflowNodes(nodes []FunctionDAGNode, chain []string) error {
+	for _, node := range nodes {
+		if node.SubWorkflow == nil {
+			continue
+		}
+		if node.Map != nil {
+			return errors.ErrInvalidInput.Wrap(fmt.Sprintf("node %s cannot be both a map and a sub-workflow node", node.Name))
+		}
+		if err := node.SubWorkflow.ValidateWithin(chain, InlineWorkflowTypes); err != nil {
+			return errors.ErrInvalidInput.Wrap(fmt.Sprintf("sub-workflow node %s: %v", node.Name, err))
+		}
+	}
+	return nil
+}
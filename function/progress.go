@@ -0,0 +1,136 @@
+package function
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// Progress lets a long-running handler heartbeat its Temporal activity and
+// resume from the last checkpoint after a retry. Obtain it with
+// ProgressFrom inside a handler:
+//
+//	p := function.ProgressFrom(ctx)
+//	var done int
+//	if _, err := p.LastCheckpoint(&done); err != nil {
+//		return nil, err
+//	}
+//	for i := done; i < total; i++ {
+//		process(i)
+//		p.Report(i + 1)
+//	}
+//
+// Outside an activity (for example in unit tests) Report only records the
+// details locally and LastCheckpoint finds nothing.
+type Progress struct {
+	ctx        context.Context
+	inActivity bool
+
+	mu      sync.Mutex
+	details any
+}
+
+type progressKey struct{}
+
+// NewProgress creates a Progress bound to ctx, which should be the activity
+// context. The function activity does this for every handler call. On a
+// retry, the previous attempt's checkpoint is the latest progress until the
+// first Report, so background heartbeats do not overwrite it.
+func NewProgress(ctx context.Context) *Progress {
+	p := &Progress{ctx: ctx, inActivity: activity.IsActivity(ctx)}
+	if p.inActivity && activity.HasHeartbeatDetails(ctx) {
+		var prev any
+		if err := activity.GetHeartbeatDetails(ctx, &prev); err == nil {
+			p.details = prev
+		}
+	}
+	return p
+}
+
+// WithProgress returns a copy of ctx carrying p.
+func WithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// ProgressFrom returns the Progress attached to ctx. When there is none, it
+// returns a Progress for ctx itself, so handlers never need a nil check.
+func ProgressFrom(ctx context.Context) *Progress {
+	if p, ok := ctx.Value(progressKey{}).(*Progress); ok {
+		return p
+	}
+	return NewProgress(ctx)
+}
+
+// Report records details as the latest progress and heartbeats them
+// immediately. The details must be serialisable by the Temporal data
+// converter; they become the checkpoint seen by LastCheckpoint if the
+// activity is retried.
+func (p *Progress) Report(details any) {
+	p.mu.Lock()
+	p.details = details
+	p.mu.Unlock()
+
+	if p.inActivity {
+		activity.RecordHeartbeat(p.ctx, details)
+	}
+}
+
+// Latest returns the details passed to the most recent Report, else the
+// previous attempt's checkpoint, or nil.
+func (p *Progress) Latest() any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.details
+}
+
+// LastCheckpoint decodes the details heartbeated by the previous attempt of
+// this activity into target. It reports false when there is no checkpoint,
+// such as on the first attempt or when the previous attempt only
+// heartbeated the progress of an upload (store.HeartbeatUploads).
+func (p *Progress) LastCheckpoint(target any) (bool, error) {
+	if !p.inActivity || !activity.HasHeartbeatDetails(p.ctx) {
+		return false, nil
+	}
+	var raw any
+	if err := activity.GetHeartbeatDetails(p.ctx, &raw); err != nil || raw == nil {
+		return false, err
+	}
+	if err := activity.GetHeartbeatDetails(p.ctx, target); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// StartHeartbeats heartbeats in the background at the given interval until
+// the returned stop function is called or the context ends. Each tick
+// re-sends the latest details (see Latest) so the checkpoint is preserved;
+// when there are none it sends no details. It is a no-op outside an activity.
+func (p *Progress) StartHeartbeats(interval time.Duration) (stop func()) {
+	if !p.inActivity {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				if details := p.Latest(); details != nil {
+					activity.RecordHeartbeat(p.ctx, details)
+				} else {
+					activity.RecordHeartbeat(p.ctx)
+				}
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
@@ -27,32 +27,66 @@ type Handler func(ctx context.Context, input FunctionInput) (*FunctionOutput, er
 type Registry struct {
 	mu       sync.RWMutex
 	handlers map[string]Handler
+	typed    map[string]*typedInfo
+	defaults map[string]string // name -> default version
+
+	global   []Middleware
+	patterns []patternMiddleware
+	local    map[string][]Middleware
+}
+
+// typedInfo holds what RegisterTyped knows about a handler beyond its Handler.
+type typedInfo struct {
+	schema   HandlerSchema
+	validate func(FunctionInput) error
 }
 
 // NewRegistry creates a new empty function registry.
 func NewRegistry() *Registry {
 	return &Registry{
 		handlers: make(map[string]Handler),
+		typed:    make(map[string]*typedInfo),
+		defaults: make(map[string]string),
+		local:    make(map[string][]Middleware),
 	}
 }
 
-// Register adds a named handler to the registry. Returns an error if a handler
-// with the same name is already registered.
-func (r *Registry) Register(name string, handler Handler) error {
+// Register adds a named handler to the registry, optionally with middlewares
+// that apply to this handler only. Returns an error if a handler with the
+// same name is already registered.
+//
+// A name may carry a version ("process-order@v2") so several versions of a
+// handler can be served side by side; see SetDefaultVersion.
+func (r *Registry) Register(name string, handler Handler, middlewares ...Middleware) error {
+	return r.register(name, handler, nil, middlewares)
+}
+
+func (r *Registry) register(name string, handler Handler, info *typedInfo, middlewares []Middleware) error {
+	if err := validateVersionedName(name); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if _, exists := r.handlers[name]; exists {
 		return fmt.Errorf("handler already registered: %s", name)
 	}
 	r.handlers[name] = handler
+	if info != nil {
+		r.typed[name] = info
+	}
+	if len(middlewares) > 0 {
+		r.local[name] = middlewares
+	}
 	return nil
 }
 
-// Get retrieves a handler by name.
+// Get retrieves a handler by name, without its middleware chain; see Resolve.
+// An unversioned name resolves as described in ResolveVersion.
 func (r *Registry) Get(name string) (Handler, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	h, ok := r.handlers[name]
+	h, ok := r.handlers[r.keyLocked(name)]
 	if !ok {
 		return nil, fmt.Errorf("function %q not found in registry", name)
 	}
@@ -63,6 +97,48 @@ func (r *Registry) Get(name string) (Handler, error) {
 func (r *Registry) Has(name string) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	_, ok := r.handlers[name]
+	_, ok := r.handlers[r.keyLocked(name)]
 	return ok
 }
+
+// Schema returns the input and output JSON schemas of a handler registered
+// with RegisterTyped. It returns false for unknown or untyped handlers.
+func (r *Registry) Schema(name string) (HandlerSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.typed[r.keyLocked(name)]
+	if !ok {
+		return HandlerSchema{}, false
+	}
+	return info.schema, true
+}
+
+// Schemas returns the schemas of all typed handlers, keyed by name.
+func (r *Registry) Schemas() map[string]HandlerSchema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schemas := make(map[string]HandlerSchema, len(r.typed))
+	for name, info := range r.typed {
+		schemas[name] = info.schema
+	}
+	return schemas
+}
+
+// ValidateInput decodes and validates input for a typed handler without
+// calling it, so callers can reject bad input before scheduling work.
+// Untyped handlers accept any input.
+func (r *Registry) ValidateInput(name string, input FunctionInput) error {
+	r.mu.RLock()
+	key := r.keyLocked(name)
+	_, exists := r.handlers[key]
+	info := r.typed[key]
+	r.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("function %q not found in registry", name)
+	}
+	if info == nil {
+		return nil
+	}
+	return info.validate(input)
+}
@@ -0,0 +1,135 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	fn "github.com/jasoet/go-wf/v2/function"
+)
+
+// DefaultGRPCMethod is the unary method called by GRPCHandler unless
+// WithGRPCMethod overrides it.
+const DefaultGRPCMethod = "/gowf.function.v1.FunctionService/Execute"
+
+// WithGRPCMethod sets the full gRPC method name ("/package.Service/Method").
+// The method must take and return google.protobuf.Struct.
+func WithGRPCMethod(method string) Option {
+	return func(c *config) { c.grpcMethod = method }
+}
+
+// WithDialOptions adds options passed to grpc.NewClient after the transport
+// credentials, for example interceptors or keepalive settings.
+func WithDialOptions(dialOpts ...grpc.DialOption) Option {
+	return func(c *config) { c.dialOpts = append(c.dialOpts, dialOpts...) }
+}
+
+// GRPCHandler calls a function served over gRPC. The connection is created
+// once and reused; call Close when the handler is no longer needed.
+type GRPCHandler struct {
+	cfg  *config
+	conn *grpc.ClientConn
+}
+
+// NewGRPCHandler creates a handler for the function service at target, in
+// grpc.NewClient syntax (for example "dns:///resize.internal:443"). It
+// requires WithTLSConfig or WithMTLS, or WithInsecure for a plaintext
+// connection.
+func NewGRPCHandler(target string, opts ...Option) (*GRPCHandler, error) {
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds credentials.TransportCredentials
+	switch {
+	case cfg.tlsConfig != nil:
+		creds = credentials.NewTLS(cfg.tlsConfig)
+	case cfg.insecure:
+		creds = insecure.NewCredentials()
+	default:
+		return nil, fmt.Errorf("gRPC target %s needs transport security: use WithTLSConfig, WithMTLS or WithInsecure", target)
+	}
+	conn, err := grpc.NewClient(target, append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, cfg.dialOpts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("create gRPC client for %s: %w", target, err)
+	}
+
+	return &GRPCHandler{cfg: cfg, conn: conn}, nil
+}
+
+// Close closes the underlying connection.
+func (h *GRPCHandler) Close() error {
+	return h.conn.Close()
+}
+
+// Handle implements function.Handler.
+func (h *GRPCHandler) Handle(ctx context.Context, input fn.FunctionInput) (*fn.FunctionOutput, error) {
+	headers, err := h.cfg.resolveHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := h.cfg.request(ctx, input)
+	msg, err := toStruct(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	return h.cfg.call(ctx, req, func(ctx context.Context, _ Request) (*Response, error) {
+		return h.invoke(ctx, msg, headers)
+	})
+}
+
+func (h *GRPCHandler) invoke(ctx context.Context, msg *structpb.Struct, headers map[string]string) (*Response, error) {
+	if len(headers) > 0 {
+		md := metadata.New(nil)
+		for name, value := range headers {
+			md.Set(name, value)
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	reply := &structpb.Struct{}
+	if err := h.conn.Invoke(ctx, h.cfg.grpcMethod, msg, reply); err != nil {
+		callErr := fmt.Errorf("call %s: %w", h.cfg.grpcMethod, err)
+		if h.cfg.retryCodes[status.Code(err)] {
+			return nil, &retryableError{err: callErr}
+		}
+		return nil, callErr
+	}
+	var out Response
+	if err := fromStruct(reply, &out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &out, nil
+}
+
+// toStruct converts a JSON-encodable value into the Struct sent over gRPC.
+func toStruct(v any) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	msg := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// fromStruct decodes a Struct received over gRPC into target.
+func fromStruct(msg *structpb.Struct, target any) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
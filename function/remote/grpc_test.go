@@ -0,0 +1,188 @@
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	fn "github.com/jasoet/go-wf/v2/function"
+)
+
+// startFunctionService serves DefaultGRPCMethod with execute over an
+// in-memory listener and returns a handler connected to it.
+func startFunctionService(t *testing.T, execute func(ctx context.Context, req Request) (*Response, error), opts ...Option) *GRPCHandler {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "gowf.function.v1.FunctionService",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Execute",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				in := &structpb.Struct{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				var req Request
+				if err := fromStruct(in, &req); err != nil {
+					return nil, err
+				}
+				resp, err := execute(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				return toStruct(resp)
+			},
+		}},
+	}, struct{}{})
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	opts = append(opts, WithInsecure(), WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})))
+	h, err := NewGRPCHandler("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = h.Close() })
+	return h
+}
+
+func TestGRPCHandler_RoundTrip(t *testing.T) {
+	t.Setenv("SECRET_GRPC_TOKEN", "tok")
+	h := startFunctionService(t, func(ctx context.Context, req Request) (*Response, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return &Response{FunctionOutput: fn.FunctionOutput{
+			Result: map[string]string{
+				"function": req.Function,
+				"name":     req.Args["name"],
+				"auth":     first(md.Get("authorization")),
+			},
+			Data: append([]byte("echo:"), req.Data...),
+		}}, nil
+	}, WithBearerToken("secret://GRPC_TOKEN"))
+
+	registry := fn.NewRegistry()
+	require.NoError(t, registry.Register("py-score", h.Handle))
+	handler, err := registry.Resolve("py-score")
+	require.NoError(t, err)
+
+	out, err := handler(context.Background(), fn.FunctionInput{
+		Args: map[string]string{"name": "model-a"},
+		Data: []byte{0, 1, 2},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "py-score", out.Result["function"])
+	assert.Equal(t, "model-a", out.Result["name"])
+	assert.Equal(t, "Bearer tok", out.Result["auth"])
+	assert.Equal(t, []byte{'e', 'c', 'h', 'o', ':', 0, 1, 2}, out.Data)
+}
+
+func TestNewGRPCHandler_RequiresTransportSecurity(t *testing.T) {
+	_, err := NewGRPCHandler("passthrough:///bufnet")
+	assert.ErrorContains(t, err, "needs transport security")
+
+	h, err := NewGRPCHandler("passthrough:///bufnet", WithInsecure())
+	require.NoError(t, err)
+	assert.NoError(t, h.Close())
+
+	h, err = NewGRPCHandler("passthrough:///bufnet", WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+	require.NoError(t, err)
+	assert.NoError(t, h.Close())
+}
+
+func TestGRPCHandler_RetryClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  []codes.Code
+		opts      []Option
+		wantCalls int32
+		wantCode  codes.Code
+	}{
+		{name: "retries unavailable", failures: []codes.Code{codes.Unavailable, codes.Unavailable}, wantCalls: 3},
+		{name: "retries resource exhausted", failures: []codes.Code{codes.ResourceExhausted}, wantCalls: 2},
+		{name: "does not retry invalid argument", failures: []codes.Code{codes.InvalidArgument}, wantCalls: 1, wantCode: codes.InvalidArgument},
+		{
+			name:      "gives up after max attempts",
+			failures:  []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable},
+			wantCalls: 3,
+			wantCode:  codes.Unavailable,
+		},
+		{
+			name:      "custom retryable codes",
+			failures:  []codes.Code{codes.Internal},
+			opts:      []Option{WithRetryableCodes(codes.Internal)},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			opts := append([]Option{WithRetry(3, time.Millisecond)}, tt.opts...)
+			h := startFunctionService(t, func(context.Context, Request) (*Response, error) {
+				n := int(calls.Add(1))
+				if n <= len(tt.failures) {
+					return nil, status.Error(tt.failures[n-1], "failed")
+				}
+				return &Response{FunctionOutput: fn.FunctionOutput{Result: map[string]string{"ok": "true"}}}, nil
+			}, opts...)
+
+			out, err := h.Handle(context.Background(), fn.FunctionInput{})
+			assert.Equal(t, tt.wantCalls, calls.Load())
+			if tt.wantCode != codes.OK {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantCode, status.Code(errorsUnwrapAll(err)))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "true", out.Result["ok"])
+		})
+	}
+}
+
+func TestGRPCHandler_RemoteError(t *testing.T) {
+	var calls atomic.Int32
+	h := startFunctionService(t, func(context.Context, Request) (*Response, error) {
+		calls.Add(1)
+		return &Response{Error: "model not found"}, nil
+	}, WithRetry(3, time.Millisecond))
+
+	_, err := h.Handle(context.Background(), fn.FunctionInput{})
+	var remoteErr *RemoteError
+	require.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, "model not found", remoteErr.Message)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// errorsUnwrapAll returns the innermost error in a chain of single wraps.
+func errorsUnwrapAll(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
@@ -0,0 +1,116 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	fn "github.com/jasoet/go-wf/v2/function"
+)
+
+// maxErrorBody caps how much of a non-2xx response body is kept in a
+// StatusError.
+const maxErrorBody = 4 << 10
+
+// httpDoer is the part of *http.Client used by HTTPHandler.
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// WithHTTPClient replaces the HTTP client, for example to add a proxy or
+// instrumentation. WithTLSConfig and WithMTLS have no effect on a custom
+// client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) { c.httpClient = client }
+}
+
+// HTTPHandler calls a function served over HTTP. It POSTs a JSON Request to
+// the endpoint and expects a 2xx JSON Response.
+type HTTPHandler struct {
+	endpoint string
+	cfg      *config
+	client   httpDoer
+}
+
+// NewHTTPHandler creates a handler for the function at endpoint. With
+// WithEnv, WithHeader or WithBearerToken, an http:// endpoint also needs
+// WithInsecure.
+func NewHTTPHandler(endpoint string, opts ...Option) (*HTTPHandler, error) {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("endpoint %q must be an http or https URL", endpoint)
+	}
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if (cfg.forwardEnv || len(cfg.headers) > 0) && !cfg.insecure && strings.HasPrefix(endpoint, "http://") {
+		return nil, fmt.Errorf("endpoint %q is plaintext: WithEnv and headers need https or WithInsecure", endpoint)
+	}
+
+	client := cfg.httpClient
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:errcheck,forcetypeassert // DefaultTransport is always *http.Transport
+		if cfg.tlsConfig != nil {
+			transport.TLSClientConfig = cfg.tlsConfig
+		}
+		client = &http.Client{Transport: transport}
+	}
+
+	return &HTTPHandler{endpoint: endpoint, cfg: cfg, client: client}, nil
+}
+
+// Handle implements function.Handler.
+func (h *HTTPHandler) Handle(ctx context.Context, input fn.FunctionInput) (*fn.FunctionOutput, error) {
+	headers, err := h.cfg.resolveHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := h.cfg.request(ctx, input)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	return h.cfg.call(ctx, req, func(ctx context.Context, _ Request) (*Response, error) {
+		return h.post(ctx, body, headers)
+	})
+}
+
+func (h *HTTPHandler) post(ctx context.Context, body []byte, headers map[string]string) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		// Network failures and attempt timeouts are transient.
+		return nil, &retryableError{err: fmt.Errorf("call %s: %w", h.endpoint, err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody)) //nolint:errcheck // best-effort error detail
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(snippet))}
+		if h.cfg.retryStatus[resp.StatusCode] {
+			return nil, &retryableError{err: statusErr}
+		}
+		return nil, statusErr
+	}
+
+	var out Response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &out, nil
+}
@@ -0,0 +1,381 @@
+package remote
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkactivity "go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+
+	fn "github.com/jasoet/go-wf/v2/function"
+	fnactivity "github.com/jasoet/go-wf/v2/function/activity"
+	"github.com/jasoet/go-wf/v2/function/payload"
+)
+
+// echoHandler answers every request with the decoded Request echoed into the
+// Result map.
+func echoHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_ = json.NewEncoder(w).Encode(Response{FunctionOutput: fn.FunctionOutput{
+			Result: map[string]string{
+				"function": req.Function,
+				"name":     req.Args["name"],
+				"auth":     r.Header.Get("Authorization"),
+				"tenant":   r.Header.Get("X-Tenant"),
+				"env":      req.Env["DB_PASSWORD"],
+			},
+			Data: append([]byte("echo:"), req.Data...),
+		}})
+	}
+}
+
+func TestHTTPHandler_RoundTrip(t *testing.T) {
+	t.Setenv("SECRET_RESIZE_TOKEN", "s3cr3t")
+	server := httptest.NewServer(echoHandler(t))
+	defer server.Close()
+
+	h, err := NewHTTPHandler(server.URL,
+		WithBearerToken("secret://RESIZE_TOKEN"),
+		WithHeader("X-Tenant", "acme"),
+		WithInsecure(),
+	)
+	require.NoError(t, err)
+
+	registry := fn.NewRegistry()
+	require.NoError(t, registry.Register("resize", h.Handle))
+	handler, err := registry.Resolve("resize")
+	require.NoError(t, err)
+
+	out, err := handler(context.Background(), fn.FunctionInput{
+		Args: map[string]string{"name": "cat.png"},
+		Data: []byte("pixels"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "resize", out.Result["function"])
+	assert.Equal(t, "cat.png", out.Result["name"])
+	assert.Equal(t, "Bearer s3cr3t", out.Result["auth"])
+	assert.Equal(t, "acme", out.Result["tenant"])
+	assert.Equal(t, []byte("echo:pixels"), out.Data)
+}
+
+func TestHTTPHandler_FunctionNameOverride(t *testing.T) {
+	server := httptest.NewServer(echoHandler(t))
+	defer server.Close()
+
+	h, err := NewHTTPHandler(server.URL, WithFunctionName("images.resize"))
+	require.NoError(t, err)
+
+	out, err := h.Handle(context.Background(), fn.FunctionInput{})
+	require.NoError(t, err)
+	assert.Equal(t, "images.resize", out.Result["function"])
+}
+
+func TestHTTPHandler_EnvOnlyWithOptIn(t *testing.T) {
+	server := httptest.NewServer(echoHandler(t))
+	defer server.Close()
+	input := fn.FunctionInput{Env: map[string]string{"DB_PASSWORD": "hunter2"}}
+
+	h, err := NewHTTPHandler(server.URL)
+	require.NoError(t, err)
+	out, err := h.Handle(context.Background(), input)
+	require.NoError(t, err)
+	assert.Empty(t, out.Result["env"])
+
+	_, err = NewHTTPHandler(server.URL, WithEnv())
+	require.ErrorContains(t, err, "need https or WithInsecure")
+
+	h, err = NewHTTPHandler(server.URL, WithEnv(), WithInsecure())
+	require.NoError(t, err)
+	out, err = h.Handle(context.Background(), input)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", out.Result["env"])
+}
+
+func TestHTTPHandler_HeadersNeedTransportSecurity(t *testing.T) {
+	_, err := NewHTTPHandler("http://example.com", WithBearerToken("secret://TOKEN"))
+	require.ErrorContains(t, err, "need https or WithInsecure")
+	_, err = NewHTTPHandler("http://example.com", WithHeader("X-Tenant", "acme"))
+	require.ErrorContains(t, err, "need https or WithInsecure")
+
+	_, err = NewHTTPHandler("https://example.com", WithBearerToken("secret://TOKEN"))
+	require.NoError(t, err)
+	_, err = NewHTTPHandler("http://example.com", WithBearerToken("secret://TOKEN"), WithInsecure())
+	require.NoError(t, err)
+}
+
+func TestHTTPHandler_MissingSecret(t *testing.T) {
+	h, err := NewHTTPHandler("http://127.0.0.1:1", WithBearerToken("secret://NOPE_MISSING_TOKEN"), WithInsecure())
+	require.NoError(t, err)
+
+	_, err = h.Handle(context.Background(), fn.FunctionInput{})
+	assert.ErrorContains(t, err, "NOPE_MISSING_TOKEN")
+}
+
+func TestHTTPHandler_RemoteErrorIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode(Response{Error: "image too large"})
+	}))
+	defer server.Close()
+
+	h, err := NewHTTPHandler(server.URL, WithRetry(3, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = h.Handle(context.Background(), fn.FunctionInput{})
+	var remoteErr *RemoteError
+	require.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, "image too large", remoteErr.Message)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestHTTPHandler_RetryClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		opts      []Option
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "retries 503 then succeeds", statuses: []int{503, 503, 200}, wantCalls: 3},
+		{name: "retries 429", statuses: []int{429, 200}, wantCalls: 2},
+		{name: "does not retry 400", statuses: []int{400, 200}, wantCalls: 1, wantErr: true},
+		{name: "gives up after max attempts", statuses: []int{502, 502, 502, 200}, wantCalls: 3, wantErr: true},
+		{
+			name:      "custom retryable statuses",
+			statuses:  []int{409, 200},
+			opts:      []Option{WithRetryableStatus(409)},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				n := calls.Add(1)
+				status := tt.statuses[n-1]
+				if status != http.StatusOK {
+					http.Error(w, "nope", status)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(Response{FunctionOutput: fn.FunctionOutput{Result: map[string]string{"ok": "true"}}})
+			}))
+			defer server.Close()
+
+			opts := append([]Option{WithRetry(3, time.Millisecond)}, tt.opts...)
+			h, err := NewHTTPHandler(server.URL, opts...)
+			require.NoError(t, err)
+
+			out, err := h.Handle(context.Background(), fn.FunctionInput{})
+			assert.Equal(t, tt.wantCalls, calls.Load())
+			if tt.wantErr {
+				var statusErr *StatusError
+				require.ErrorAs(t, err, &statusErr)
+				assert.Equal(t, "nope", statusErr.Body)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "true", out.Result["ok"])
+		})
+	}
+}
+
+func TestHTTPHandler_AttemptTimeoutIsRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Response{})
+	}))
+	defer server.Close()
+
+	h, err := NewHTTPHandler(server.URL, WithTimeout(50*time.Millisecond), WithRetry(2, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = h.Handle(context.Background(), fn.FunctionInput{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHTTPHandler_ParentCancelStopsRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	h, err := NewHTTPHandler(server.URL, WithRetry(10, time.Hour))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = h.Handle(ctx, fn.FunctionInput{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestNewHTTPHandler_Validation(t *testing.T) {
+	_, err := NewHTTPHandler("ftp://example.com")
+	assert.ErrorContains(t, err, "http or https")
+
+	_, err = NewHTTPHandler("http://example.com", WithTimeout(0))
+	assert.ErrorContains(t, err, "timeout must be positive")
+
+	_, err = NewHTTPHandler("https://example.com", WithMTLS("missing.crt", "missing.key", ""))
+	assert.ErrorContains(t, err, "load client certificate")
+}
+
+func TestHTTPHandler_MTLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	server := httptest.NewUnstartedServer(echoHandler(t))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pki.serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pki.pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	t.Run("client certificate accepted", func(t *testing.T) {
+		h, err := NewHTTPHandler(server.URL, WithMTLS(pki.clientCertFile, pki.clientKeyFile, pki.caFile))
+		require.NoError(t, err)
+
+		out, err := h.Handle(context.Background(), fn.FunctionInput{Args: map[string]string{"name": "x"}})
+		require.NoError(t, err)
+		assert.Equal(t, "x", out.Result["name"])
+	})
+
+	t.Run("missing client certificate rejected", func(t *testing.T) {
+		h, err := NewHTTPHandler(server.URL, WithTLSConfig(&tls.Config{RootCAs: pki.pool, MinVersion: tls.VersionTLS12}))
+		require.NoError(t, err)
+
+		_, err = h.Handle(context.Background(), fn.FunctionInput{})
+		assert.Error(t, err)
+	})
+}
+
+func TestHTTPHandler_InFunctionActivity(t *testing.T) {
+	server := httptest.NewServer(echoHandler(t))
+	defer server.Close()
+
+	h, err := NewHTTPHandler(server.URL)
+	require.NoError(t, err)
+	registry := fn.NewRegistry()
+	require.NoError(t, registry.Register("remote-echo", h.Handle))
+
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivityWithOptions(fnactivity.NewExecuteFunctionActivity(registry), sdkactivity.RegisterOptions{Name: "ExecuteFunctionActivity"})
+
+	val, err := env.ExecuteActivity("ExecuteFunctionActivity", payload.FunctionExecutionInput{
+		Name: "remote-echo",
+		Args: map[string]string{"name": "dag-node"},
+	})
+	require.NoError(t, err)
+
+	var out payload.FunctionExecutionOutput
+	require.NoError(t, val.Get(&out))
+	assert.True(t, out.Success)
+	assert.Equal(t, "remote-echo", out.Result["function"])
+	assert.Equal(t, "dag-node", out.Result["name"])
+}
+
+// testPKI is a throwaway CA with a server certificate for 127.0.0.1 and a
+// client certificate written to disk.
+type testPKI struct {
+	pool           *x509.CertPool
+	serverCert     tls.Certificate
+	caFile         string
+	clientCertFile string
+	clientKeyFile  string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "test"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		return der, key
+	}
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+		return path
+	}
+	keyDER := func(key *ecdsa.PrivateKey) []byte {
+		der, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		return der
+	}
+
+	serverDER, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	clientDER, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+
+	pki := &testPKI{
+		pool:           x509.NewCertPool(),
+		caFile:         writePEM("ca.crt", "CERTIFICATE", caDER),
+		clientCertFile: writePEM("client.crt", "CERTIFICATE", clientDER),
+		clientKeyFile:  writePEM("client.key", "EC PRIVATE KEY", keyDER(clientKey)),
+		serverCert:     tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey},
+	}
+	pki.pool.AddCert(caCert)
+	return pki
+}
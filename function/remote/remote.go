@@ -0,0 +1,311 @@
+// Package remote adapts functions served over HTTP or gRPC into
+// function.Handler values, so handlers written in other languages can take
+// part in function pipelines, loops and DAGs:
+//
+//	h, err := remote.NewHTTPHandler("https://resize.internal/run",
+//	    remote.WithTimeout(10*time.Second),
+//	    remote.WithRetry(3, 500*time.Millisecond),
+//	    remote.WithBearerToken("secret://RESIZE_TOKEN"),
+//	)
+//	if err != nil { ... }
+//	_ = registry.Register("resize", h.Handle)
+//
+// Both transports exchange the same JSON document. The request is a
+// [Request]: the FunctionInput fields plus the registered function name.
+// Env, which holds resolved secrets, is left out unless WithEnv is given. The
+// response is a [Response]: the FunctionOutput fields, or an "error" message
+// for business failures. Data travels as base64, as encoding/json encodes
+// []byte. Over gRPC the document is sent as a google.protobuf.Struct to a
+// unary method, by default /gowf.function.v1.FunctionService/Execute (see
+// function_service.proto).
+//
+// Transport failures are retried when classified as transient: for HTTP,
+// network errors and statuses 408, 425, 429, 500, 502, 503 and 504; for gRPC,
+// Unavailable, ResourceExhausted, Aborted and DeadlineExceeded. A response
+// carrying "error" is never retried.
+//
+// gRPC handlers need WithTLSConfig or WithMTLS; plaintext connections, and
+// sending Env or headers to an http:// endpoint, require an explicit
+// WithInsecure.
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	fn "github.com/jasoet/go-wf/v2/function"
+	"github.com/jasoet/go-wf/v2/workflow/secrets"
+)
+
+// DefaultTimeout bounds each attempt when WithTimeout is not given.
+const DefaultTimeout = 30 * time.Second
+
+// Request is the document sent to a remote function. Its Env is empty unless
+// the handler was created WithEnv.
+type Request struct {
+	// Function is the name the handler is registered under.
+	Function string `json:"function"`
+	fn.FunctionInput
+}
+
+// Response is the document a remote function returns. A non-empty Error
+// reports a business failure: it becomes the handler error and is not
+// retried.
+type Response struct {
+	fn.FunctionOutput
+	Error string `json:"error,omitempty"`
+}
+
+// RemoteError is a failure reported by the remote function in
+// Response.Error.
+type RemoteError struct {
+	Function string
+	Message  string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("remote function %s failed: %s", e.Function, e.Message)
+}
+
+// StatusError reports an HTTP response with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("remote function returned HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("remote function returned HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// Option configures a remote handler.
+type Option func(*config)
+
+type config struct {
+	timeout      time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	retryStatus  map[int]bool
+	retryCodes   map[codes.Code]bool
+	headers      map[string]headerValue
+	tlsConfig    *tls.Config
+	tlsErr       error
+	grpcMethod   string
+	dialOpts     []grpc.DialOption
+	httpClient   httpDoer
+	functionName string
+	forwardEnv   bool
+	insecure     bool
+}
+
+func newConfig(opts []Option) (*config, error) {
+	cfg := &config{
+		timeout:     DefaultTimeout,
+		maxAttempts: 1,
+		backoff:     time.Second,
+		retryStatus: setOf(408, 425, 429, 500, 502, 503, 504),
+		retryCodes:  setOf(codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded),
+		headers:     make(map[string]headerValue),
+		grpcMethod:  DefaultGRPCMethod,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.tlsErr != nil {
+		return nil, cfg.tlsErr
+	}
+	if cfg.timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be positive, got %s", cfg.timeout)
+	}
+	return cfg, nil
+}
+
+func setOf[T comparable](values ...T) map[T]bool {
+	set := make(map[T]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// WithTimeout bounds each attempt. The handler context still bounds the call
+// as a whole.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) { c.timeout = d }
+}
+
+// WithRetry makes up to maxAttempts attempts on transient failures, waiting
+// backoff before the second attempt and doubling the wait after each further
+// failure.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(c *config) {
+		c.maxAttempts = max(maxAttempts, 1)
+		c.backoff = backoff
+	}
+}
+
+// WithRetryableStatus replaces the HTTP statuses treated as transient.
+func WithRetryableStatus(statuses ...int) Option {
+	return func(c *config) { c.retryStatus = setOf(statuses...) }
+}
+
+// WithRetryableCodes replaces the gRPC codes treated as transient.
+func WithRetryableCodes(retryable ...codes.Code) Option {
+	return func(c *config) { c.retryCodes = setOf(retryable...) }
+}
+
+// WithHeader sets a request header (gRPC metadata for gRPC handlers). The
+// value may be a secret reference such as "secret://API_KEY"; it is resolved
+// through the secrets package on every call, so it never appears in workflow
+// history.
+func WithHeader(name, value string) Option {
+	return func(c *config) { c.headers[name] = headerValue{value: value} }
+}
+
+// WithBearerToken sets "Authorization: Bearer <token>". The token may be a
+// secret reference, as for WithHeader.
+func WithBearerToken(token string) Option {
+	return func(c *config) { c.headers["Authorization"] = headerValue{prefix: "Bearer ", value: token} }
+}
+
+// headerValue is a header whose value may be a secret reference; prefix is
+// prepended after resolution.
+type headerValue struct {
+	prefix string
+	value  string
+}
+
+// WithTLSConfig sets the TLS configuration used to reach the endpoint.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *config) { c.tlsConfig = cfg }
+}
+
+// WithMTLS loads a client certificate and key for mutual TLS. caFile, when
+// not empty, replaces the system roots used to verify the server.
+func WithMTLS(certFile, keyFile, caFile string) Option {
+	return func(c *config) {
+		cfg, err := loadMTLSConfig(certFile, keyFile, caFile)
+		if err != nil {
+			c.tlsErr = err
+			return
+		}
+		c.tlsConfig = cfg
+	}
+}
+
+// WithEnv sends FunctionInput.Env to the endpoint. Env values are resolved
+// secrets, so an http:// endpoint also needs WithInsecure.
+func WithEnv() Option {
+	return func(c *config) { c.forwardEnv = true }
+}
+
+// WithInsecure allows plaintext: a gRPC connection without transport
+// credentials, or WithEnv, WithHeader or WithBearerToken over an http://
+// endpoint. Use it only for local development or trusted networks.
+func WithInsecure() Option {
+	return func(c *config) { c.insecure = true }
+}
+
+// WithFunctionName sets Request.Function instead of the registered handler
+// name, for endpoints that expect a different name.
+func WithFunctionName(name string) Option {
+	return func(c *config) { c.functionName = name }
+}
+
+func loadMTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load client certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// resolveHeaders resolves secret references in the configured headers.
+func (c *config) resolveHeaders(ctx context.Context) (map[string]string, error) {
+	headers := make(map[string]string, len(c.headers))
+	for name, header := range c.headers {
+		resolved, err := secrets.Resolve(ctx, header.value)
+		if err != nil {
+			return nil, fmt.Errorf("resolve header %s: %w", name, err)
+		}
+		headers[name] = header.prefix + resolved
+	}
+	return headers, nil
+}
+
+func (c *config) request(ctx context.Context, input fn.FunctionInput) Request {
+	name := c.functionName
+	if name == "" {
+		name = fn.HandlerName(ctx)
+	}
+	if !c.forwardEnv {
+		input.Env = nil
+	}
+	return Request{Function: name, FunctionInput: input}
+}
+
+// retryableError marks a failure worth another attempt.
+type retryableError struct{ err error }
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// call sends req with attempt until it succeeds, fails permanently, or runs
+// out of attempts. Each attempt gets its own timeout.
+func (c *config) call(ctx context.Context, req Request, attempt func(ctx context.Context, req Request) (*Response, error)) (*fn.FunctionOutput, error) {
+	var lastErr error
+	for i := 0; i < c.maxAttempts; i++ {
+		if i > 0 {
+			wait := time.Duration(float64(c.backoff) * math.Pow(2, float64(i-1)))
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("remote call canceled after %d attempts: %w", i, errors.Join(ctx.Err(), lastErr))
+			case <-time.After(wait):
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		resp, err := attempt(attemptCtx, req)
+		cancel()
+		if err == nil {
+			if resp.Error != "" {
+				return nil, &RemoteError{Function: req.Function, Message: resp.Error}
+			}
+			output := resp.FunctionOutput
+			return &output, nil
+		}
+
+		var retryable *retryableError
+		if !errors.As(err, &retryable) || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = retryable.err
+	}
+	return nil, fmt.Errorf("remote call failed after %d attempts: %w", c.maxAttempts, lastErr)
+}
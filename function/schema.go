@@ -0,0 +1,200 @@
+package function
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HandlerSchema describes the input and output of a typed handler as JSON
+// Schema documents (draft 2020-12). Untyped handlers have no schema.
+type HandlerSchema struct {
+	Input  json.RawMessage `json:"input"`
+	Output json.RawMessage `json:"output"`
+}
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	timeType            = reflect.TypeFor[time.Time]()
+	rawMessageType      = reflect.TypeFor[json.RawMessage]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// SchemaOf returns the JSON Schema for values of type T as they appear in
+// encoded form: the JSON shape of Data for typed handlers. Struct fields follow
+// encoding/json naming, and `validate` tags contribute required, enum
+// (oneof), and min/max constraints.
+func SchemaOf[T any]() json.RawMessage {
+	return schemaFor(reflect.TypeFor[T]())
+}
+
+func schemaFor(t reflect.Type) json.RawMessage {
+	schema := newSchemaBuilder().build(t)
+	schema["$schema"] = jsonSchemaDialect
+
+	// Only maps, strings, numbers and bools are stored, so this cannot fail.
+	data, _ := json.Marshal(schema) //nolint:errcheck // see above
+	return data
+}
+
+type schemaBuilder struct {
+	// visiting guards against infinite recursion on self-referential types.
+	visiting map[reflect.Type]bool
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{visiting: make(map[reflect.Type]bool)}
+}
+
+func (b *schemaBuilder) build(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return map[string]any{"type": "integer", "description": "duration in nanoseconds"}
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": b.build(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.build(t.Elem())}
+	case reflect.Struct:
+		return b.buildStruct(t)
+	default:
+		// Interfaces and other dynamic types accept any value.
+		return map[string]any{}
+	}
+}
+
+func (b *schemaBuilder) buildStruct(t reflect.Type) map[string]any {
+	if b.visiting[t] {
+		return map[string]any{"type": "object"}
+	}
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+
+	properties := map[string]any{}
+	var required []string
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		prop := b.build(field.Type)
+		if applyValidateTag(prop, field.Tag.Get("validate")) {
+			required = append(required, name)
+		}
+		properties[name] = prop
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonFieldName returns the encoded name of a struct field and false when
+// encoding/json skips it.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// applyValidateTag adds constraints from a validator tag to prop and reports
+// whether the field is required. Rules after "dive" apply to elements and
+// are ignored.
+func applyValidateTag(prop map[string]any, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			return required
+		case "required":
+			required = true
+		case "oneof":
+			values := make([]any, 0)
+			for _, v := range strings.Fields(param) {
+				values = append(values, enumValue(prop, v))
+			}
+			prop["enum"] = values
+		case "min", "gte":
+			applyBound(prop, param, "minimum", "minLength", "minItems")
+		case "max", "lte":
+			applyBound(prop, param, "maximum", "maxLength", "maxItems")
+		}
+	}
+	return required
+}
+
+func enumValue(prop map[string]any, v string) any {
+	switch prop["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// applyBound maps a validator min/max onto the keyword matching the JSON type.
+func applyBound(prop map[string]any, param, numberKey, stringKey, arrayKey string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch prop["type"] {
+	case "integer", "number":
+		prop[numberKey] = n
+	case "string":
+		if _, encoded := prop["contentEncoding"]; !encoded {
+			prop[stringKey] = int(n)
+		}
+	case "array":
+		prop[arrayKey] = int(n)
+	case "object":
+		if _, isMap := prop["additionalProperties"]; isMap {
+			prop[strings.Replace(arrayKey, "Items", "Properties", 1)] = int(n)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package function
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaNode struct {
+	Name     string            `json:"name" validate:"required,max=64"`
+	Kind     string            `json:"kind" validate:"oneof=leaf branch"`
+	Priority int               `json:"priority,omitempty" validate:"oneof=1 2 3"`
+	Weight   float64           `json:"weight" validate:"gte=0,lte=1"`
+	Children []*schemaNode     `json:"children,omitempty" validate:"max=8,dive"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Payload  []byte            `json:"payload,omitempty"`
+	Created  time.Time         `json:"created"`
+	Wait     time.Duration     `json:"wait"`
+	Ignored  string            `json:"-"`
+	NoTag    bool
+	hidden   string //nolint:unused // verifies unexported fields are skipped
+}
+
+func decodeSchema(t *testing.T, raw json.RawMessage) map[string]any {
+	t.Helper()
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(raw, &schema))
+	return schema
+}
+
+func TestSchemaOf_Struct(t *testing.T) {
+	schema := decodeSchema(t, SchemaOf[schemaNode]())
+
+	assert.Equal(t, jsonSchemaDialect, schema["$schema"])
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, []any{"name"}, schema["required"])
+
+	props := schema["properties"].(map[string]any)
+	assert.NotContains(t, props, "Ignored")
+	assert.NotContains(t, props, "hidden")
+	assert.Contains(t, props, "NoTag")
+
+	assert.Equal(t, map[string]any{"type": "string", "maxLength": float64(64)}, props["name"])
+	assert.Equal(t, []any{"leaf", "branch"}, props["kind"].(map[string]any)["enum"])
+	assert.Equal(t, []any{float64(1), float64(2), float64(3)}, props["priority"].(map[string]any)["enum"])
+	assert.Equal(t, map[string]any{"type": "number", "minimum": float64(0), "maximum": float64(1)}, props["weight"])
+	assert.Equal(t, "base64", props["payload"].(map[string]any)["contentEncoding"])
+	assert.Equal(t, "date-time", props["created"].(map[string]any)["format"])
+	assert.Equal(t, "integer", props["wait"].(map[string]any)["type"])
+	assert.Equal(t, "string", props["labels"].(map[string]any)["additionalProperties"].(map[string]any)["type"])
+
+	children := props["children"].(map[string]any)
+	assert.Equal(t, "array", children["type"])
+	assert.Equal(t, float64(8), children["maxItems"])
+	// Recursive reference is cut off rather than expanded forever.
+	assert.Equal(t, map[string]any{"type": "object"}, children["items"])
+}
+
+func TestSchemaOf_Scalars(t *testing.T) {
+	assert.Equal(t, "string", decodeSchema(t, SchemaOf[string]())["type"])
+	assert.Equal(t, "integer", decodeSchema(t, SchemaOf[*int64]())["type"])
+	assert.Equal(t, "boolean", decodeSchema(t, SchemaOf[bool]())["type"])
+	assert.NotContains(t, decodeSchema(t, SchemaOf[any]()), "type")
+}
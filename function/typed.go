@@ -0,0 +1,278 @@
+package function
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// typedValidator validates decoded inputs of typed handlers.
+var typedValidator = validator.New()
+
+// Codec converts between the untyped FunctionInput/FunctionOutput and the
+// values handled by typed handlers registered with RegisterTyped.
+type Codec interface {
+	// Decode populates target, a non-nil pointer, from input.
+	Decode(input FunctionInput, target any) error
+	// Encode converts a handler result into a FunctionOutput.
+	Encode(value any) (*FunctionOutput, error)
+}
+
+// JSONCodec is the default Codec.
+//
+// Decode unmarshals Data as JSON into the target and then applies Args on
+// top: each arg is assigned to the struct field with the matching JSON name,
+// parsed according to the field type (strconv for scalars, time.ParseDuration
+// for durations, encoding.TextUnmarshaler, or JSON for anything else). Args
+// without a matching field are ignored. A map[string]string target receives
+// the args as-is.
+//
+// Encode marshals the value as JSON into Data. When it encodes to a JSON
+// object, each top-level property is also copied into Result — strings as-is,
+// other values as their JSON text — so DAG output mappings can use them.
+type JSONCodec struct{}
+
+// Decode implements Codec.
+func (JSONCodec) Decode(input FunctionInput, target any) error {
+	if len(input.Data) > 0 {
+		if err := json.Unmarshal(input.Data, target); err != nil {
+			return fmt.Errorf("decode data: %w", err)
+		}
+	}
+	if len(input.Args) == 0 {
+		return nil
+	}
+	return decodeArgs(input.Args, target)
+}
+
+// Encode implements Codec.
+func (JSONCodec) Encode(value any) (*FunctionOutput, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("encode output: %w", err)
+	}
+
+	output := &FunctionOutput{Data: data}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) == nil && len(fields) > 0 {
+		output.Result = make(map[string]string, len(fields))
+		for key, raw := range fields {
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				output.Result[key] = s
+			} else {
+				output.Result[key] = string(raw)
+			}
+		}
+	}
+	return output, nil
+}
+
+// TypedOption configures RegisterTyped.
+type TypedOption func(*typedConfig)
+
+type typedConfig struct {
+	codec       Codec
+	validate    bool
+	middlewares []Middleware
+}
+
+// WithCodec replaces the default JSONCodec.
+func WithCodec(codec Codec) TypedOption {
+	return func(c *typedConfig) { c.codec = codec }
+}
+
+// WithMiddleware adds middlewares that apply to this handler only.
+func WithMiddleware(middlewares ...Middleware) TypedOption {
+	return func(c *typedConfig) { c.middlewares = append(c.middlewares, middlewares...) }
+}
+
+// WithoutValidation skips struct-tag validation of decoded inputs.
+func WithoutValidation() TypedOption {
+	return func(c *typedConfig) { c.validate = false }
+}
+
+// RegisterTyped registers a handler that works with typed values instead of
+// raw args and bytes. The input is decoded into In by the codec (JSONCodec
+// by default) and validated with go-playground/validator struct tags; the
+// returned Out is encoded back into a FunctionOutput. Decode and validation
+// failures are reported as handler errors.
+//
+// JSON schemas for In and Out are recorded in the registry and available
+// from Registry.Schema.
+func RegisterTyped[In, Out any](r *Registry, name string, handler func(ctx context.Context, in In) (Out, error), opts ...TypedOption) error {
+	if handler == nil {
+		return fmt.Errorf("handler for %s must not be nil", name)
+	}
+
+	cfg := typedConfig{codec: JSONCodec{}, validate: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.codec == nil {
+		return fmt.Errorf("codec for %s must not be nil", name)
+	}
+
+	decode := func(input FunctionInput) (In, error) {
+		var in In
+		if err := cfg.codec.Decode(input, &in); err != nil {
+			return in, fmt.Errorf("decode input for %s: %w", name, err)
+		}
+		if cfg.validate && isStruct(reflect.TypeFor[In]()) {
+			if err := typedValidator.Struct(in); err != nil {
+				return in, fmt.Errorf("invalid input for %s: %w", name, err)
+			}
+		}
+		return in, nil
+	}
+
+	wrapped := func(ctx context.Context, input FunctionInput) (*FunctionOutput, error) {
+		in, err := decode(input)
+		if err != nil {
+			return nil, err
+		}
+		out, err := handler(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		output, err := cfg.codec.Encode(out)
+		if err != nil {
+			return nil, fmt.Errorf("encode output for %s: %w", name, err)
+		}
+		return output, nil
+	}
+
+	return r.register(name, wrapped, &typedInfo{
+		schema: HandlerSchema{
+			Input:  SchemaOf[In](),
+			Output: SchemaOf[Out](),
+		},
+		validate: func(input FunctionInput) error {
+			_, err := decode(input)
+			return err
+		},
+	}, cfg.middlewares)
+}
+
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// decodeArgs assigns string args to the fields of the struct target points to.
+func decodeArgs(args map[string]string, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("decode args: target must be a non-nil pointer, got %T", target)
+	}
+	v = v.Elem()
+
+	if m, ok := v.Addr().Interface().(*map[string]string); ok {
+		if *m == nil {
+			*m = make(map[string]string, len(args))
+		}
+		for key, value := range args {
+			(*m)[key] = value
+		}
+		return nil
+	}
+
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("decode args: cannot decode args into %s", v.Type())
+	}
+
+	fields := make(map[string]reflect.Value)
+	for _, field := range reflect.VisibleFields(v.Type()) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		if name, ok := jsonFieldName(field); ok {
+			if fv, err := v.FieldByIndexErr(field.Index); err == nil {
+				fields[name] = fv
+			}
+		}
+	}
+
+	for key, raw := range args {
+		field, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if err := setFromString(field, raw); err != nil {
+			return fmt.Errorf("decode arg %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// setFromString parses raw according to the type of field and assigns it.
+func setFromString(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFromString(ptr.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		// Slices, maps and nested structs are passed as JSON text.
+		return json.Unmarshal([]byte(raw), field.Addr().Interface())
+	}
+	return nil
+}
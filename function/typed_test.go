@@ -0,0 +1,191 @@
+package function
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type resizeInput struct {
+	URL     string        `json:"url" validate:"required"`
+	Width   int           `json:"width" validate:"min=1,max=4096"`
+	Quality float64       `json:"quality,omitempty"`
+	Crop    bool          `json:"crop,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty"`
+	Tags    []string      `json:"tags,omitempty"`
+	Mode    string        `json:"mode,omitempty" validate:"omitempty,oneof=fit fill"`
+}
+
+type resizeOutput struct {
+	Location string `json:"location"`
+	Bytes    int    `json:"bytes"`
+}
+
+func TestRegisterTyped_DecodesArgsAndData(t *testing.T) {
+	r := NewRegistry()
+
+	var got resizeInput
+	err := RegisterTyped(r, "resize", func(_ context.Context, in resizeInput) (resizeOutput, error) {
+		got = in
+		return resizeOutput{Location: "s3://out/" + in.URL, Bytes: in.Width * 10}, nil
+	})
+	require.NoError(t, err)
+
+	handler, err := r.Get("resize")
+	require.NoError(t, err)
+
+	out, err := handler(context.Background(), FunctionInput{
+		Data: []byte(`{"url":"cat.png","width":100,"tags":["a"]}`),
+		Args: map[string]string{
+			"width":   "640", // args override data
+			"quality": "0.8",
+			"crop":    "true",
+			"timeout": "5s",
+			"tags":    `["x","y"]`,
+			"unused":  "ignored",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, resizeInput{
+		URL:     "cat.png",
+		Width:   640,
+		Quality: 0.8,
+		Crop:    true,
+		Timeout: 5 * time.Second,
+		Tags:    []string{"x", "y"},
+	}, got)
+
+	assert.Equal(t, "s3://out/cat.png", out.Result["location"])
+	assert.Equal(t, "6400", out.Result["bytes"])
+
+	var decoded resizeOutput
+	require.NoError(t, json.Unmarshal(out.Data, &decoded))
+	assert.Equal(t, 6400, decoded.Bytes)
+}
+
+func TestRegisterTyped_ValidationFailure(t *testing.T) {
+	r := NewRegistry()
+	called := false
+	require.NoError(t, RegisterTyped(r, "resize", func(_ context.Context, in resizeInput) (resizeOutput, error) {
+		called = true
+		return resizeOutput{}, nil
+	}))
+
+	handler, err := r.Get("resize")
+	require.NoError(t, err)
+
+	_, err = handler(context.Background(), FunctionInput{Args: map[string]string{"width": "10"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid input for resize")
+	assert.False(t, called)
+
+	_, err = handler(context.Background(), FunctionInput{Args: map[string]string{"url": "x", "width": "ten"}})
+	assert.ErrorContains(t, err, `decode arg "width"`)
+}
+
+func TestRegisterTyped_WithoutValidation(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, RegisterTyped(r, "resize", func(_ context.Context, in resizeInput) (resizeOutput, error) {
+		return resizeOutput{}, nil
+	}, WithoutValidation()))
+
+	assert.NoError(t, r.ValidateInput("resize", FunctionInput{}))
+}
+
+func TestRegisterTyped_HandlerError(t *testing.T) {
+	r := NewRegistry()
+	boom := errors.New("boom")
+	require.NoError(t, RegisterTyped(r, "fail", func(_ context.Context, _ map[string]string) (string, error) {
+		return "", boom
+	}))
+
+	handler, err := r.Get("fail")
+	require.NoError(t, err)
+	_, err = handler(context.Background(), FunctionInput{})
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestRegisterTyped_MapInputAndScalarOutput(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, RegisterTyped(r, "echo", func(_ context.Context, in map[string]string) (string, error) {
+		return in["name"], nil
+	}))
+
+	handler, err := r.Get("echo")
+	require.NoError(t, err)
+
+	out, err := handler(context.Background(), FunctionInput{Args: map[string]string{"name": "go"}})
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`"go"`), out.Data)
+	assert.Empty(t, out.Result)
+}
+
+// upperCodec is a custom codec used to verify WithCodec.
+type upperCodec struct{}
+
+func (upperCodec) Decode(input FunctionInput, target any) error {
+	*(target.(*string)) = string(input.Data)
+	return nil
+}
+
+func (upperCodec) Encode(value any) (*FunctionOutput, error) {
+	return &FunctionOutput{Result: map[string]string{"value": value.(string)}}, nil
+}
+
+func TestRegisterTyped_WithCodec(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, RegisterTyped(r, "raw", func(_ context.Context, in string) (string, error) {
+		return in + "!", nil
+	}, WithCodec(upperCodec{})))
+
+	handler, err := r.Get("raw")
+	require.NoError(t, err)
+
+	out, err := handler(context.Background(), FunctionInput{Data: []byte("hi")})
+	require.NoError(t, err)
+	assert.Equal(t, "hi!", out.Result["value"])
+}
+
+func TestRegisterTyped_Errors(t *testing.T) {
+	r := NewRegistry()
+
+	err := RegisterTyped[string, string](r, "nil", nil)
+	assert.ErrorContains(t, err, "must not be nil")
+
+	err = RegisterTyped(r, "nil-codec", func(_ context.Context, in string) (string, error) { return in, nil }, WithCodec(nil))
+	assert.ErrorContains(t, err, "codec")
+
+	require.NoError(t, r.Register("dup", func(_ context.Context, _ FunctionInput) (*FunctionOutput, error) { return nil, nil }))
+	err = RegisterTyped(r, "dup", func(_ context.Context, in string) (string, error) { return in, nil })
+	assert.ErrorContains(t, err, "already registered")
+}
+
+func TestRegistry_SchemaAndValidateInput(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, RegisterTyped(r, "resize", func(_ context.Context, in resizeInput) (resizeOutput, error) {
+		return resizeOutput{}, nil
+	}))
+	require.NoError(t, r.Register("plain", func(_ context.Context, _ FunctionInput) (*FunctionOutput, error) { return nil, nil }))
+
+	schema, ok := r.Schema("resize")
+	require.True(t, ok)
+
+	var input map[string]any
+	require.NoError(t, json.Unmarshal(schema.Input, &input))
+	assert.Equal(t, []any{"url"}, input["required"])
+
+	_, ok = r.Schema("plain")
+	assert.False(t, ok)
+	assert.Len(t, r.Schemas(), 1)
+
+	assert.Error(t, r.ValidateInput("resize", FunctionInput{}))
+	assert.NoError(t, r.ValidateInput("resize", FunctionInput{Args: map[string]string{"url": "a", "width": "1"}}))
+	assert.NoError(t, r.ValidateInput("plain", FunctionInput{}))
+	assert.ErrorContains(t, r.ValidateInput("missing", FunctionInput{}), "not found")
+}
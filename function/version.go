@@ -0,0 +1,213 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// versionSeparator separates a handler name from its version, as in
+// "process-order@v2".
+const versionSeparator = "@"
+
+// safeVersionedName restricts registered names to a function name with an
+// optional version.
+var safeVersionedName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*(@[a-zA-Z0-9][a-zA-Z0-9._-]*)?$`)
+
+// HandlerVersion describes one versioned registration.
+type HandlerVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Default bool   `json:"default,omitempty"`
+}
+
+// String returns the versioned name, marked with "*" when it is the default.
+func (v HandlerVersion) String() string {
+	s := VersionedName(v.Name, v.Version)
+	if v.Default {
+		s += "*"
+	}
+	return s
+}
+
+// SplitVersion splits "name@version" into its parts. The version is empty
+// for an unversioned name.
+func SplitVersion(name string) (base, version string) {
+	base, version, _ = strings.Cut(name, versionSeparator)
+	return base, version
+}
+
+// VersionedName joins name and version. An empty version returns name.
+func VersionedName(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + versionSeparator + version
+}
+
+func validateVersionedName(name string) error {
+	if !safeVersionedName.MatchString(name) {
+		return fmt.Errorf("invalid handler name %q: must match name or name@version", name)
+	}
+	return nil
+}
+
+// SetDefaultVersion makes version the one an unversioned call to name
+// resolves to. The version must already be registered.
+func (r *Registry) SetDefaultVersion(name, version string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.handlers[VersionedName(name, version)]; !ok || version == "" {
+		return fmt.Errorf("set default version: %s is not registered", VersionedName(name, version))
+	}
+	r.defaults[name] = version
+	return nil
+}
+
+// DefaultVersion returns the default version of name set with
+// SetDefaultVersion.
+func (r *Registry) DefaultVersion(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	version, ok := r.defaults[name]
+	return version, ok
+}
+
+// Versions lists the versioned registrations, sorted by name and version.
+// Handlers registered without a version are not listed.
+func (r *Registry) Versions() []HandlerVersion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var versions []HandlerVersion
+	for key := range r.handlers {
+		base, version := SplitVersion(key)
+		if version == "" {
+			continue
+		}
+		versions = append(versions, HandlerVersion{
+			Name:    base,
+			Version: version,
+			Default: r.defaults[base] == version,
+		})
+	}
+	slices.SortFunc(versions, func(a, b HandlerVersion) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Version, b.Version)
+	})
+	return versions
+}
+
+// keyLocked maps a requested name to its registry key. A versioned name is
+// used as is; an unversioned one resolves to its default version, then to a
+// plain registration, then to its only version. The caller holds r.mu.
+func (r *Registry) keyLocked(name string) string {
+	if strings.Contains(name, versionSeparator) {
+		return name
+	}
+	if version, ok := r.defaults[name]; ok {
+		return VersionedName(name, version)
+	}
+	if _, ok := r.handlers[name]; ok {
+		return name
+	}
+	versions := r.versionsLocked(name)
+	if len(versions) == 1 {
+		return VersionedName(name, versions[0])
+	}
+	return name
+}
+
+// versionsLocked returns the registered versions of name, sorted. The
+// caller holds r.mu.
+func (r *Registry) versionsLocked(name string) []string {
+	prefix := name + versionSeparator
+	var versions []string
+	for key := range r.handlers {
+		if version, ok := strings.CutPrefix(key, prefix); ok {
+			versions = append(versions, version)
+		}
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// notFound explains why name did not resolve, listing its versions when an
+// unversioned name is ambiguous.
+func (r *Registry) notFound(name string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if base, version := SplitVersion(name); version == "" {
+		if versions := r.versionsLocked(base); len(versions) > 0 {
+			return fmt.Errorf("function %q has versions %s but no default version", name, strings.Join(versions, ", "))
+		}
+	}
+	return fmt.Errorf("function %q not found in registry", name)
+}
+
+// identityHandlersMarker separates the worker identity prefix from the list
+// of handler versions it serves.
+const identityHandlersMarker = " fn="
+
+// WorkerIdentity returns a worker identity that embeds the handler versions
+// the registry serves, for worker.Options.Identity. DescribeFleet reads it
+// back from the task queue's pollers, so mixed-version fleets are visible
+// during a rollout.
+func WorkerIdentity(prefix string, registry *Registry) string {
+	versions := registry.Versions()
+	if len(versions) == 0 {
+		return prefix
+	}
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = v.String()
+	}
+	return prefix + identityHandlersMarker + strings.Join(parts, ",")
+}
+
+// ParseWorkerIdentity splits an identity built by WorkerIdentity into its
+// prefix and handler versions. Other identities are returned as the prefix.
+func ParseWorkerIdentity(identity string) (prefix string, versions []HandlerVersion) {
+	prefix, list, ok := strings.Cut(identity, identityHandlersMarker)
+	if !ok {
+		return identity, nil
+	}
+	for _, part := range strings.Split(list, ",") {
+		part, isDefault := strings.CutSuffix(part, "*")
+		name, version := SplitVersion(part)
+		if name == "" || version == "" {
+			continue
+		}
+		versions = append(versions, HandlerVersion{Name: name, Version: version, Default: isDefault})
+	}
+	return prefix, versions
+}
+
+// FleetWorker is a worker polling a task queue and the handler versions it
+// advertises in its identity.
+type FleetWorker struct {
+	Identity string           `json:"identity"`
+	Versions []HandlerVersion `json:"versions,omitempty"`
+}
+
+// DescribeFleet lists the workers polling taskQueue for activities, with the
+// handler versions each one serves. Workers must set their identity with
+// WorkerIdentity for their versions to be listed.
+func DescribeFleet(ctx context.Context, c client.Client, taskQueue string) ([]FleetWorker, error) {
+	resp, err := c.DescribeTaskQueue(ctx, taskQueue, enumspb.TASK_QUEUE_TYPE_ACTIVITY)
+	if err != nil {
+		return nil, fmt.Errorf("describe task queue %s: %w", taskQueue, err)
+	}
+	workers := make([]FleetWorker, 0, len(resp.GetPollers()))
+	for _, poller := range resp.GetPollers() {
+		_, versions := ParseWorkerIdentity(poller.GetIdentity())
+		workers = append(workers, FleetWorker{Identity: poller.GetIdentity(), Versions: versions})
+	}
+	return workers, nil
+}
@@ -0,0 +1,170 @@
+package function
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+func versionHandler(version string) Handler {
+	return func(_ context.Context, _ FunctionInput) (*FunctionOutput, error) {
+		return &FunctionOutput{Result: map[string]string{"version": version}}, nil
+	}
+}
+
+func callVersion(t *testing.T, r *Registry, name string) (string, string) {
+	t.Helper()
+	h, version, err := r.ResolveVersion(name)
+	require.NoError(t, err)
+	out, err := h(context.Background(), FunctionInput{})
+	require.NoError(t, err)
+	return out.Result["version"], version
+}
+
+func TestSplitVersion(t *testing.T) {
+	base, version := SplitVersion("process-order@v2")
+	assert.Equal(t, "process-order", base)
+	assert.Equal(t, "v2", version)
+
+	base, version = SplitVersion("process-order")
+	assert.Equal(t, "process-order", base)
+	assert.Empty(t, version)
+
+	assert.Equal(t, "process-order@v2", VersionedName("process-order", "v2"))
+	assert.Equal(t, "process-order", VersionedName("process-order", ""))
+}
+
+func TestRegistry_Versions(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register("process-order@v1", versionHandler("v1")))
+	require.NoError(t, r.Register("process-order@v2", versionHandler("v2")))
+	require.NoError(t, r.Register("plain", versionHandler("")))
+
+	err := r.Register("process-order@v2", versionHandler("v2"))
+	assert.Error(t, err, "duplicate versions are still rejected")
+
+	got, version := callVersion(t, r, "process-order@v2")
+	assert.Equal(t, "v2", got)
+	assert.Equal(t, "v2", version)
+
+	// Two versions and no default: ambiguous.
+	_, _, err = r.ResolveVersion("process-order")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "v1, v2")
+	assert.False(t, r.Has("process-order"))
+
+	require.NoError(t, r.SetDefaultVersion("process-order", "v1"))
+	got, version = callVersion(t, r, "process-order")
+	assert.Equal(t, "v1", got)
+	assert.Equal(t, "v1", version)
+	assert.True(t, r.Has("process-order"))
+
+	def, ok := r.DefaultVersion("process-order")
+	assert.True(t, ok)
+	assert.Equal(t, "v1", def)
+
+	assert.Equal(t, []HandlerVersion{
+		{Name: "process-order", Version: "v1", Default: true},
+		{Name: "process-order", Version: "v2"},
+	}, r.Versions())
+
+	_, version = callVersion(t, r, "plain")
+	assert.Empty(t, version)
+}
+
+func TestRegistry_SingleVersionResolvesWithoutDefault(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register("resize@v3", versionHandler("v3")))
+
+	got, version := callVersion(t, r, "resize")
+	assert.Equal(t, "v3", got)
+	assert.Equal(t, "v3", version)
+}
+
+func TestRegistry_SetDefaultVersionUnknown(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register("resize@v1", versionHandler("v1")))
+
+	assert.Error(t, r.SetDefaultVersion("resize", "v9"))
+	assert.Error(t, r.SetDefaultVersion("resize", ""))
+}
+
+func TestRegistry_RegisterInvalidVersionedName(t *testing.T) {
+	r := NewRegistry()
+	assert.Error(t, r.Register("resize@", versionHandler("")))
+	assert.Error(t, r.Register("resize@v1@v2", versionHandler("")))
+}
+
+func TestRegistry_PatternMiddlewareMatchesVersions(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register("process-order@v2", versionHandler("v2")))
+
+	var seen string
+	require.NoError(t, r.UseFor("process-order", func(next Handler) Handler {
+		return func(ctx context.Context, input FunctionInput) (*FunctionOutput, error) {
+			seen = HandlerName(ctx)
+			return next(ctx, input)
+		}
+	}))
+
+	callVersion(t, r, "process-order")
+	assert.Equal(t, "process-order@v2", seen)
+}
+
+func TestWorkerIdentity(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register("process-order@v1", versionHandler("v1")))
+	require.NoError(t, r.Register("process-order@v2", versionHandler("v2")))
+	require.NoError(t, r.SetDefaultVersion("process-order", "v2"))
+
+	identity := WorkerIdentity("worker-1", r)
+	assert.Equal(t, "worker-1 fn=process-order@v1,process-order@v2*", identity)
+
+	prefix, versions := ParseWorkerIdentity(identity)
+	assert.Equal(t, "worker-1", prefix)
+	assert.Equal(t, r.Versions(), versions)
+
+	assert.Equal(t, "worker-2", WorkerIdentity("worker-2", NewRegistry()))
+	prefix, versions = ParseWorkerIdentity("1234@host")
+	assert.Equal(t, "1234@host", prefix)
+	assert.Empty(t, versions)
+}
+
+type fleetClient struct {
+	client.Client
+	identities []string
+}
+
+func (c *fleetClient) DescribeTaskQueue(_ context.Context, _ string, tqType enumspb.TaskQueueType) (*workflowservice.DescribeTaskQueueResponse, error) {
+	if tqType != enumspb.TASK_QUEUE_TYPE_ACTIVITY {
+		return &workflowservice.DescribeTaskQueueResponse{}, nil
+	}
+	resp := &workflowservice.DescribeTaskQueueResponse{}
+	for _, id := range c.identities {
+		resp.Pollers = append(resp.Pollers, &taskqueue.PollerInfo{Identity: id})
+	}
+	return resp, nil
+}
+
+func TestDescribeFleet(t *testing.T) {
+	c := &fleetClient{identities: []string{
+		"w1 fn=process-order@v1*",
+		"w2 fn=process-order@v1,process-order@v2*",
+		"legacy",
+	}}
+
+	fleet, err := DescribeFleet(context.Background(), c, "functions")
+	require.NoError(t, err)
+	require.Len(t, fleet, 3)
+
+	assert.Equal(t, []HandlerVersion{{Name: "process-order", Version: "v1", Default: true}}, fleet[0].Versions)
+	assert.Len(t, fleet[1].Versions, 2)
+	assert.Equal(t, "legacy", fleet[2].Identity)
+	assert.Empty(t, fleet[2].Versions)
+}
@@ -11,6 +11,8 @@ import (
 	wf "go.temporal.io/sdk/workflow"
 
 	"github.com/jasoet/go-wf/v2/function/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
+	wferrors "github.com/jasoet/go-wf/v2/workflow/errors"
 	"github.com/jasoet/go-wf/v2/workflow/store"
 )
 
@@ -20,7 +22,14 @@ type dagState struct {
 	executed    map[string]bool
 	results     map[string]*payload.FunctionExecutionOutput
 	stepOutputs map[string]map[string]string
-	stepData    map[string][]byte
+	stepData    map[string]nodeData
+}
+
+// nodeData is the Data a node produced, inline or as a claim-check
+// reference.
+type nodeData struct {
+	data []byte
+	ref  string
 }
 
 func newDagState() *dagState {
@@ -28,14 +37,16 @@ func newDagState() *dagState {
 		executed:    make(map[string]bool),
 		results:     make(map[string]*payload.FunctionExecutionOutput),
 		stepOutputs: make(map[string]map[string]string),
-		stepData:    make(map[string][]byte),
+		stepData:    make(map[string]nodeData),
 	}
 }
 
 // DAGWorkflow executes functions in a DAG (Directed Acyclic Graph) pattern.
 // Execution order is determined by the dependency graph, with support for
 // input mappings (passing outputs between nodes) and data mappings (passing
-// byte data between nodes).
+// byte data between nodes). Map nodes fan out over a JSON array produced by a
+// previous node and aggregate the item results. Sub-workflow nodes run a
+// registered workflow or a nested DAG or pipeline as a child workflow.
 func DAGWorkflow(ctx wf.Context, input payload.DAGWorkflowInput) (*payload.FunctionDAGWorkflowOutput, error) {
 	logger := wf.GetLogger(ctx)
 	logger.Info("Starting function DAG workflow", "nodes", len(input.Nodes))
@@ -115,26 +126,36 @@ func executeFnDAGNode(
 
 	logger.Info("Executing function node", "name", nodeName)
 
-	fnInput := node.Function
-	if err := applyFnInputMappings(logger, &fnInput, node, state); err != nil {
-		return err
-	}
-	applyFnDataMapping(&fnInput, node, state)
+	var result payload.FunctionExecutionOutput
+	var err error
+	if node.SubWorkflow != nil {
+		result, err = executeFnSubWorkflowNode(ctx, input, node, state)
+	} else {
+		fnInput := node.Function
+		if err := applyFnInputMappings(logger, &fnInput, node, state); err != nil {
+			return err
+		}
+		applyFnDataMapping(&fnInput, node, state)
 
-	if err := downloadFnInputArtifacts(ctx, input.ArtifactStore, node, &fnInput, input.Nodes); err != nil {
-		return err
-	}
+		if err := downloadFnInputArtifacts(ctx, input.ArtifactStore, input.ClaimCheck, node, &fnInput, input.Nodes); err != nil {
+			return err
+		}
 
-	var result payload.FunctionExecutionOutput
-	err := wf.ExecuteActivity(ctx, fnInput.ActivityName(), fnInput).Get(ctx, &result)
+		if node.Map != nil {
+			result, err = executeFnMapNode(ctx, node, fnInput, input, state)
+		} else {
+			actCtx := generic.PrepareTask(ctx, &fnInput, node.Name)
+			err = wferrors.FromTemporal(wf.ExecuteActivity(actCtx, fnInput.ActivityName(), fnInput).Get(ctx, &result))
+		}
 
-	extractFnOutputs(logger, node, &result, state)
-	uploadFnOutputArtifacts(ctx, logger, input.ArtifactStore, node, &result)
+		extractFnOutputs(logger, node, &result, state)
+		uploadFnOutputArtifacts(ctx, logger, input.ArtifactStore, node, &result)
+	}
 
 	state.mu.Lock()
 	state.results[nodeName] = &result
-	if result.Data != nil {
-		state.stepData[nodeName] = result.Data
+	if result.Data != nil || result.DataRef != "" {
+		state.stepData[nodeName] = nodeData{data: result.Data, ref: result.DataRef}
 	}
 	state.executed[nodeName] = true
 	state.mu.Unlock()
@@ -150,6 +171,39 @@ func executeFnDAGNode(
 	return nil
 }
 
+// executeFnSubWorkflowNode runs the node as a child workflow. The child's
+// mapped outputs become the node's Result and step outputs, and its JSON
+// result becomes the node's Data, offloaded like a map node's aggregate when
+// it is large and the DAG uses a claim check.
+func executeFnSubWorkflowNode(ctx wf.Context, input *payload.DAGWorkflowInput, node *payload.FunctionDAGNode, state *dagState) (payload.FunctionExecutionOutput, error) {
+	workflowType, childInput := node.SubWorkflow.Target(payload.InlineWorkflowTypes)
+	child, err := generic.ExecuteSubWorkflow(ctx, node.Name, node.SubWorkflow.SubWorkflow, workflowType, childInput, payload.ChildOutputs.New(workflowType))
+
+	result := payload.FunctionExecutionOutput{
+		Name:       node.Name,
+		Success:    child.Success,
+		Error:      child.Error,
+		Result:     child.Outputs,
+		Data:       child.Result,
+		StartedAt:  child.StartedAt,
+		FinishedAt: child.FinishedAt,
+		Duration:   child.FinishedAt.Sub(child.StartedAt),
+	}
+	if input.ClaimCheck && input.ArtifactStore != nil && len(result.Data) > nodeDataInlineLimit {
+		if offloadErr := offloadNodeData(ctx, input.ArtifactStore, node.Name, "child-result", &result); offloadErr != nil {
+			result.Success, result.Error = false, offloadErr.Error()
+			return result, fmt.Errorf("sub-workflow node %s: offload result: %w", node.Name, offloadErr)
+		}
+	}
+
+	if len(child.Outputs) > 0 {
+		state.mu.Lock()
+		state.stepOutputs[node.Name] = child.Outputs
+		state.mu.Unlock()
+	}
+	return result, err
+}
+
 func executeFnDependencies(
 	executeNode func(string) error,
 	node *payload.FunctionDAGNode,
@@ -237,7 +291,7 @@ func applyFnDataMapping(
 	defer state.mu.Unlock()
 
 	if data, ok := state.stepData[node.DataInput.FromNode]; ok {
-		fnInput.Data = data
+		fnInput.Data, fnInput.DataRef = data.data, data.ref
 	}
 }
 
@@ -318,7 +372,10 @@ func findFnArtifactProducer(artifactName string, allNodes []payload.FunctionDAGN
 	return ""
 }
 
-func downloadFnInputArtifacts(ctx wf.Context, raw store.RawStore, node *payload.FunctionDAGNode, fnInput *payload.FunctionExecutionInput, allNodes []payload.FunctionDAGNode) error {
+// downloadFnInputArtifacts makes input artifacts available to the node. With
+// claimCheck, "bytes" artifacts are passed by key as the input DataRef instead
+// of being loaded into the workflow.
+func downloadFnInputArtifacts(ctx wf.Context, raw store.RawStore, claimCheck bool, node *payload.FunctionDAGNode, fnInput *payload.FunctionExecutionInput, allNodes []payload.FunctionDAGNode) error {
 	if raw == nil || len(node.InputArtifacts) == 0 {
 		return nil
 	}
@@ -338,7 +395,22 @@ func downloadFnInputArtifacts(ctx wf.Context, raw store.RawStore, node *payload.
 			WithName(ref.Name).
 			Build()
 
-		if ref.Type == "bytes" {
+		if ref.Type == "bytes" && claimCheck {
+			var exists bool
+			err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) (bool, error) {
+				return raw.Exists(ctx, key)
+			}).Get(ctx, &exists)
+			if err == nil && !exists {
+				err = fmt.Errorf("%w: %s", store.ErrNotFound, key)
+			}
+			if err != nil {
+				if ref.Optional {
+					continue
+				}
+				return fmt.Errorf("failed to download artifact %s: %w", ref.Name, err)
+			}
+			fnInput.Data, fnInput.DataRef = nil, key
+		} else if ref.Type == "bytes" {
 			var data []byte
 			err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) ([]byte, error) {
 				return store.NewBytesStore(raw).Load(ctx, key)
@@ -349,10 +421,10 @@ func downloadFnInputArtifacts(ctx wf.Context, raw store.RawStore, node *payload.
 				}
 				return fmt.Errorf("failed to download artifact %s: %w", ref.Name, err)
 			}
-			fnInput.Data = data
+			fnInput.Data, fnInput.DataRef = data, ""
 		} else {
 			err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) error {
-				return store.DownloadFile(ctx, raw, key, ref.Path, ref.Type)
+				return store.DownloadFile(ctx, raw, key, ref.Path, ref.Type, ref.ArchiveOptions()...)
 			}).Get(ctx, nil)
 			if err != nil && !ref.Optional {
 				return fmt.Errorf("failed to download artifact %s: %w", ref.Name, err)
@@ -381,6 +453,7 @@ func uploadFnOutputArtifacts(
 		StartToCloseTimeout: 5 * time.Minute,
 	}
 	laCtx := wf.WithLocalActivityOptions(ctx, lao)
+	actCtx := generic.WithArtifactUploadOptions(ctx)
 
 	for _, ref := range node.OutputArtifacts {
 		key := store.NewKeyBuilder().
@@ -391,9 +464,18 @@ func uploadFnOutputArtifacts(
 			Build()
 
 		if ref.Type == "bytes" {
-			err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) error {
-				return store.NewBytesStore(raw).Save(ctx, key, result.Data)
-			}).Get(ctx, nil)
+			var err error
+			if result.DataRef != "" {
+				// Offloaded output: copy it within the store rather than
+				// through the workflow.
+				upload := generic.ArtifactUpload{Key: key, CopyFrom: result.DataRef}
+				err = wf.ExecuteActivity(actCtx, generic.UploadArtifactActivityName, upload).Get(ctx, nil)
+			} else {
+				data := result.Data
+				err = wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) error {
+					return store.NewBytesStore(raw).Save(ctx, key, data)
+				}).Get(ctx, nil)
+			}
 			if err != nil {
 				if ref.Optional {
 					logger.Info("Optional artifact upload skipped", "name", ref.Name, "error", err)
@@ -404,9 +486,15 @@ func uploadFnOutputArtifacts(
 				logger.Info("Uploaded bytes artifact", "name", ref.Name)
 			}
 		} else {
-			err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) error {
-				return store.UploadFile(ctx, raw, key, ref.Path, ref.Type)
-			}).Get(ctx, nil)
+			upload := generic.ArtifactUpload{
+				Key:     key,
+				Path:    ref.Path,
+				Type:    ref.Type,
+				Format:  ref.Format,
+				Include: ref.Include,
+				Exclude: ref.Exclude,
+			}
+			err := wf.ExecuteActivity(actCtx, generic.UploadArtifactActivityName, upload).Get(ctx, nil)
 			if err != nil {
 				if ref.Optional {
 					logger.Info("Optional artifact upload skipped", "name", ref.Name, "error", err)
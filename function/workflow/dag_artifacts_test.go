@@ -8,13 +8,21 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/testsuite"
 	wf "go.temporal.io/sdk/workflow"
 
 	"github.com/jasoet/go-wf/v2/function/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
 	"github.com/jasoet/go-wf/v2/workflow/store"
 )
 
+// registerArtifactActivity registers the artifact upload activity for raw,
+// as a worker running DAG workflows with an artifact store does.
+func registerArtifactActivity(env *testsuite.TestWorkflowEnvironment, raw store.RawStore) {
+	env.RegisterActivityWithOptions(generic.NewUploadArtifactActivity(raw), activity.RegisterOptions{Name: generic.UploadArtifactActivityName})
+}
+
 // withArtifactStore wraps DAGWorkflow to inject the artifact store after the
 // test environment deserializes the input (ArtifactStore is json:"-").
 func withArtifactStore(raw store.RawStore) func(wf.Context, payload.DAGWorkflowInput) (*payload.FunctionDAGWorkflowOutput, error) {
@@ -36,6 +44,7 @@ func TestDAGWorkflow_ArtifactBytesRoundTrip(t *testing.T) {
 	outputData := []byte("producer-artifact-bytes")
 
 	var consumerData []byte
+	registerArtifactActivity(env, raw)
 	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			in, ok := args.Get(1).(payload.FunctionExecutionInput)
@@ -93,6 +102,7 @@ func TestDAGWorkflow_ArtifactFileUpload(t *testing.T) {
 	require.NoError(t, err)
 	defer raw.Close() //nolint:errcheck // test cleanup
 
+	registerArtifactActivity(env, raw)
 	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).Return(
 		&payload.FunctionExecutionOutput{Name: "ok", Success: true, Duration: time.Second}, nil)
 
@@ -132,6 +142,7 @@ func TestDAGWorkflow_ArtifactDownloadFailure_NotOptional(t *testing.T) {
 	require.NoError(t, err)
 	defer raw.Close() //nolint:errcheck // test cleanup
 
+	registerArtifactActivity(env, raw)
 	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).Return(
 		&payload.FunctionExecutionOutput{Name: "ok", Success: true, Duration: time.Second}, nil)
 
@@ -164,6 +175,7 @@ func TestDAGWorkflow_ArtifactUploadFailure_NotOptional(t *testing.T) {
 	require.NoError(t, err)
 	defer raw.Close() //nolint:errcheck // test cleanup
 
+	registerArtifactActivity(env, raw)
 	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).Return(
 		&payload.FunctionExecutionOutput{Name: "ok", Success: true, Duration: time.Second}, nil)
 
@@ -190,3 +202,89 @@ func TestDAGWorkflow_ArtifactUploadFailure_NotOptional(t *testing.T) {
 	require.NoError(t, env.GetWorkflowResult(&result))
 	assert.Equal(t, 1, result.TotalSuccess)
 }
+
+func TestDAGWorkflow_DataRefPropagatesThroughDataInput(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	var transformInput payload.FunctionExecutionInput
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			if in.Name == "transform-func" {
+				transformInput = in
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true}, nil
+			}
+			return &payload.FunctionExecutionOutput{Name: in.Name, Success: true, DataRef: "claim-check/sha256/abc"}, nil
+		})
+
+	input := payload.DAGWorkflowInput{
+		Nodes: []payload.FunctionDAGNode{
+			{Name: "extract", Function: payload.FunctionExecutionInput{Name: "extract-func"}},
+			{
+				Name:         "transform",
+				Function:     payload.FunctionExecutionInput{Name: "transform-func"},
+				DataInput:    &payload.DataMapping{FromNode: "extract"},
+				Dependencies: []string{"extract"},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(DAGWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	assert.Equal(t, "claim-check/sha256/abc", transformInput.DataRef)
+	assert.Nil(t, transformInput.Data)
+}
+
+func TestDAGWorkflow_ClaimCheckArtifacts(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	raw := store.NewMemoryStore()
+	ctx := context.Background()
+	offloaded := []byte("large-offloaded-output")
+	require.NoError(t, store.NewBytesStore(raw).Save(ctx, "claim-check/sha256/out", offloaded))
+
+	var consumerInput payload.FunctionExecutionInput
+	registerArtifactActivity(env, raw)
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			if in.Name == "consumer-func" {
+				consumerInput = in
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true}, nil
+			}
+			return &payload.FunctionExecutionOutput{Name: in.Name, Success: true, DataRef: "claim-check/sha256/out"}, nil
+		})
+
+	input := payload.DAGWorkflowInput{
+		ClaimCheck: true,
+		Nodes: []payload.FunctionDAGNode{
+			{
+				Name:            "producer",
+				Function:        payload.FunctionExecutionInput{Name: "producer-func"},
+				OutputArtifacts: []payload.ArtifactRef{{Name: "shared-data", Type: "bytes"}},
+			},
+			{
+				Name:           "consumer",
+				Function:       payload.FunctionExecutionInput{Name: "consumer-func"},
+				Dependencies:   []string{"producer"},
+				InputArtifacts: []payload.ArtifactRef{{Name: "shared-data", Type: "bytes"}},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(withArtifactStore(raw), input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	// The producer's offloaded output is copied to the artifact key inside
+	// the store, and the consumer receives that key instead of the bytes.
+	require.NotEmpty(t, consumerInput.DataRef)
+	assert.Nil(t, consumerInput.Data)
+	data, err := store.NewBytesStore(raw).Load(ctx, consumerInput.DataRef)
+	require.NoError(t, err)
+	assert.Equal(t, offloaded, data)
+}
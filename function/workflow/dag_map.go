@@ -0,0 +1,241 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	wf "go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/function/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+// nodeDataInlineLimit is the size of a map node's aggregated output or a
+// sub-workflow node's child result above which, with
+// DAGWorkflowInput.ClaimCheck, the node's Data is offloaded to the artifact
+// store. It matches function.DefaultClaimCheckThreshold.
+const nodeDataInlineLimit = 256 << 10
+
+// executeFnMapNode runs the node's function template once per item of its
+// map source and aggregates the item outputs into a single output. Item
+// failures are reported through the aggregated output; the returned error is
+// only set when the items cannot be read.
+func executeFnMapNode(
+	ctx wf.Context,
+	node *payload.FunctionDAGNode,
+	template payload.FunctionExecutionInput,
+	input *payload.DAGWorkflowInput,
+	state *dagState,
+) (payload.FunctionExecutionOutput, error) {
+	startTime := wf.Now(ctx)
+
+	items, err := fnMapItems(node, state)
+	if err != nil {
+		return payload.FunctionExecutionOutput{
+			Name:       template.Name,
+			Error:      err.Error(),
+			StartedAt:  startTime,
+			FinishedAt: wf.Now(ctx),
+		}, err
+	}
+
+	limit := node.Map.MaxConcurrency
+	if limit == 0 {
+		limit = input.MaxParallel
+	}
+	if limit <= 0 || limit > len(items) {
+		limit = len(items)
+	}
+
+	results := make([]payload.MapItemResult, len(items))
+	selector := wf.NewSelector(ctx)
+	pending, next, failed := 0, 0, 0
+
+	launch := func(i int) {
+		itemInput := substituteFunctionInput(template, items[i], i, nil)
+		actCtx := generic.PrepareTask(ctx, &itemInput, node.Name+"/"+strconv.Itoa(i))
+		future := wf.ExecuteActivity(actCtx, itemInput.ActivityName(), itemInput)
+		pending++
+		selector.AddFuture(future, func(f wf.Future) {
+			pending--
+			var out payload.FunctionExecutionOutput
+			err := f.Get(ctx, &out)
+			results[i] = newMapItemResult(i, items[i], &out, err)
+			if !results[i].Success {
+				failed++
+			}
+		})
+	}
+
+	for {
+		// With FailFast, stop launching new items once one has failed.
+		for pending < limit && next < len(items) && (failed == 0 || !input.FailFast) {
+			launch(next)
+			next++
+		}
+		if pending == 0 {
+			break
+		}
+		selector.Select(ctx)
+	}
+
+	for i := next; i < len(items); i++ {
+		results[i] = payload.MapItemResult{Index: i, Item: items[i], Error: "skipped after an earlier item failed"}
+		failed++
+	}
+
+	output, err := aggregateMapResults(template.Name, results, failed)
+	if err != nil {
+		return output, fmt.Errorf("map node %s: %w", node.Name, err)
+	}
+	if input.ClaimCheck && input.ArtifactStore != nil && len(output.Data) > nodeDataInlineLimit {
+		if err := offloadMapData(ctx, input.ArtifactStore, node.Name, &output); err != nil {
+			output.Success, output.Error = false, err.Error()
+			return output, fmt.Errorf("map node %s: %w", node.Name, err)
+		}
+	}
+	output.StartedAt = startTime
+	output.FinishedAt = wf.Now(ctx)
+	output.Duration = output.FinishedAt.Sub(startTime)
+
+	wf.GetLogger(ctx).Info("Map node completed", "name", node.Name, "items", len(items), "failed", failed)
+	return output, nil
+}
+
+// fnMapItems reads the map node's items from its source node's output. String
+// elements are used as-is; other elements are passed as their JSON encoding.
+func fnMapItems(node *payload.FunctionDAGNode, state *dagState) ([]string, error) {
+	spec := node.Map
+
+	state.mu.Lock()
+	source := state.results[spec.FromNode]
+	state.mu.Unlock()
+
+	if source == nil {
+		return nil, fmt.Errorf("map node %s: no output from %s", node.Name, spec.FromNode)
+	}
+
+	var raw []byte
+	if spec.ResultKey != "" {
+		value, ok := source.Result[spec.ResultKey]
+		if !ok {
+			return nil, fmt.Errorf("map node %s: result key %q not found in %s output", node.Name, spec.ResultKey, spec.FromNode)
+		}
+		raw = []byte(value)
+	} else {
+		if source.DataRef != "" {
+			return nil, fmt.Errorf("map node %s: data of %s is offloaded and cannot be read by the workflow; use a result key", node.Name, spec.FromNode)
+		}
+		raw = source.Data
+	}
+
+	var elems []json.RawMessage
+	if err := json.Unmarshal(raw, &elems); err != nil {
+		return nil, fmt.Errorf("map node %s: items from %s are not a JSON array: %w", node.Name, spec.FromNode, err)
+	}
+
+	items := make([]string, len(elems))
+	for i, elem := range elems {
+		var s string
+		if err := json.Unmarshal(elem, &s); err == nil {
+			items[i] = s
+		} else {
+			items[i] = string(elem)
+		}
+	}
+	return items, nil
+}
+
+func newMapItemResult(index int, item string, out *payload.FunctionExecutionOutput, err error) payload.MapItemResult {
+	result := payload.MapItemResult{
+		Index:   index,
+		Item:    item,
+		Success: err == nil && out.Success,
+		Error:   out.Error,
+		Result:  out.Result,
+		Data:    out.Data,
+		DataRef: out.DataRef,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// aggregateMapResults builds a map node's output. Result holds the item counts
+// and "results", a JSON array of each item's result map in item order; Data
+// holds the JSON array of MapItemResult.
+func aggregateMapResults(name string, results []payload.MapItemResult, failed int) (payload.FunctionExecutionOutput, error) {
+	resultMaps := make([]map[string]string, len(results))
+	for i := range results {
+		resultMaps[i] = results[i].Result
+	}
+	resultsJSON, err := json.Marshal(resultMaps)
+	if err != nil {
+		return payload.FunctionExecutionOutput{Name: name}, err
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return payload.FunctionExecutionOutput{Name: name}, err
+	}
+
+	output := payload.FunctionExecutionOutput{
+		Name:    name,
+		Success: failed == 0,
+		Result: map[string]string{
+			"count":     strconv.Itoa(len(results)),
+			"succeeded": strconv.Itoa(len(results) - failed),
+			"failed":    strconv.Itoa(failed),
+			"results":   string(resultsJSON),
+		},
+		Data: data,
+	}
+	if failed > 0 {
+		output.Error = fmt.Sprintf("%d of %d items failed", failed, len(results))
+	}
+	return output, nil
+}
+
+// offloadMapData moves a map node's aggregated Data to the artifact store and
+// drops the inline "results", which repeat it, so a large fan-out stays out
+// of workflow history. Data and "results" are dropped even when the upload
+// fails.
+func offloadMapData(ctx wf.Context, raw store.RawStore, nodeName string, output *payload.FunctionExecutionOutput) error {
+	delete(output.Result, "results")
+	if err := offloadNodeData(ctx, raw, nodeName, "map-results", output); err != nil {
+		return fmt.Errorf("offload results: %w", err)
+	}
+	return nil
+}
+
+// offloadNodeData moves output.Data to the artifact store under name and
+// sets output.DataRef. Activities resolve the DataRef through a claim check
+// on the same store. Data is dropped even when the upload fails.
+func offloadNodeData(ctx wf.Context, raw store.RawStore, nodeName, name string, output *payload.FunctionExecutionOutput) error {
+	info := wf.GetInfo(ctx)
+	key := store.NewKeyBuilder().
+		WithWorkflow(info.WorkflowExecution.ID).
+		WithRun(info.WorkflowExecution.RunID).
+		WithStep(nodeName).
+		WithName(name).
+		Build()
+	laCtx := wf.WithLocalActivityOptions(ctx, wf.LocalActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+
+	data := output.Data
+	output.Data = nil
+	err := wf.ExecuteLocalActivity(laCtx, func(ctx context.Context) error {
+		return raw.Upload(ctx, key, bytes.NewReader(data))
+	}).Get(ctx, nil)
+	if err != nil {
+		return err
+	}
+	output.DataRef = key
+	return nil
+}
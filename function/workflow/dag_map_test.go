@@ -0,0 +1,262 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/jasoet/go-wf/v2/function/payload"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+func mapDAGInput(spec payload.MapSpec) payload.DAGWorkflowInput {
+	return payload.DAGWorkflowInput{
+		Nodes: []payload.FunctionDAGNode{
+			{Name: "list", Function: payload.FunctionExecutionInput{Name: "list-partitions"}},
+			{
+				Name: "process",
+				Function: payload.FunctionExecutionInput{
+					Name: "process-partition",
+					Args: map[string]string{"partition": "{{item}}", "index": "{{index}}"},
+				},
+				Dependencies: []string{"list"},
+				Map:          &spec,
+			},
+			{
+				Name:         "report",
+				Function:     payload.FunctionExecutionInput{Name: "report"},
+				Dependencies: []string{"process"},
+				DataInput:    &payload.DataMapping{FromNode: "process"},
+				Inputs:       []payload.FunctionInputMapping{{Name: "results", From: "process.results"}},
+			},
+		},
+	}
+}
+
+func TestDAGWorkflow_MapOverResultKey(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	var report payload.FunctionExecutionInput
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			switch in.Name {
+			case "list-partitions":
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true,
+					Result: map[string]string{"partitions": `["p0","p1",{"id":2}]`}}, nil
+			case "process-partition":
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true,
+					Result: map[string]string{"partition": in.Args["partition"], "index": in.Args["index"]}}, nil
+			default:
+				report = in
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true}, nil
+			}
+		})
+
+	input := mapDAGInput(payload.MapSpec{FromNode: "list", ResultKey: "partitions", MaxConcurrency: 2})
+	input.Nodes[1].Outputs = []payload.OutputMapping{{Name: "results", ResultKey: "results"}}
+
+	env.ExecuteWorkflow(DAGWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.FunctionDAGWorkflowOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, 3, result.TotalSuccess)
+	assert.Equal(t, "3", result.Results["process"].Result["count"])
+
+	// Results are aggregated in item order, with non-string items passed as JSON.
+	var results []map[string]string
+	require.NoError(t, json.Unmarshal([]byte(report.Args["results"]), &results))
+	assert.Equal(t, []map[string]string{
+		{"partition": "p0", "index": "0"},
+		{"partition": "p1", "index": "1"},
+		{"partition": `{"id":2}`, "index": "2"},
+	}, results)
+
+	var items []payload.MapItemResult
+	require.NoError(t, json.Unmarshal(report.Data, &items))
+	require.Len(t, items, 3)
+	assert.Equal(t, "p1", items[1].Item)
+	assert.True(t, items[1].Success)
+}
+
+func TestDAGWorkflow_MapOverData(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	var processed []string
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			if in.Name == "list-partitions" {
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true, Data: []byte(`["a","b"]`)}, nil
+			}
+			if in.Name == "process-partition" {
+				processed = append(processed, in.Args["partition"])
+			}
+			return &payload.FunctionExecutionOutput{Name: in.Name, Success: true}, nil
+		})
+
+	env.ExecuteWorkflow(DAGWorkflow, mapDAGInput(payload.MapSpec{FromNode: "list"}))
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	assert.ElementsMatch(t, []string{"a", "b"}, processed)
+}
+
+func TestDAGWorkflow_MapEmptyItems(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			return &payload.FunctionExecutionOutput{Name: in.Name, Success: true, Data: []byte(`[]`)}, nil
+		})
+
+	env.ExecuteWorkflow(DAGWorkflow, mapDAGInput(payload.MapSpec{FromNode: "list"}))
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.FunctionDAGWorkflowOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, "0", result.Results["process"].Result["count"])
+	assert.Equal(t, "[]", result.Results["process"].Result["results"])
+}
+
+func TestDAGWorkflow_MapItemFailureFailFast(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	var processed []string
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			if in.Name == "process-partition" {
+				processed = append(processed, in.Args["partition"])
+			}
+			switch {
+			case in.Name == "list-partitions":
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true, Data: []byte(`["ok","bad","later"]`)}, nil
+			case in.Args["partition"] == "bad":
+				return nil, errors.New("partition unreadable")
+			default:
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true}, nil
+			}
+		})
+
+	input := mapDAGInput(payload.MapSpec{FromNode: "list", MaxConcurrency: 1})
+	input.FailFast = true
+
+	env.ExecuteWorkflow(DAGWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), "node process failed")
+
+	// Items after the failure are not started.
+	assert.NotContains(t, processed, "later")
+}
+
+func TestDAGWorkflow_MapInvalidItems(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			return &payload.FunctionExecutionOutput{Name: in.Name, Success: true, Result: map[string]string{"partitions": "p0,p1"}}, nil
+		})
+
+	input := mapDAGInput(payload.MapSpec{FromNode: "list", ResultKey: "partitions"})
+	input.FailFast = true
+
+	env.ExecuteWorkflow(DAGWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), "not a JSON array")
+}
+
+func TestDAGWorkflow_MapOffloadsLargeResults(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	raw := store.NewMemoryStore()
+	blob := strings.Repeat("x", nodeDataInlineLimit/2)
+
+	var report payload.FunctionExecutionInput
+	registerArtifactActivity(env, raw)
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			switch in.Name {
+			case "list-partitions":
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true, Data: []byte(`["a","b","c"]`)}, nil
+			case "process-partition":
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true,
+					Result: map[string]string{"blob": blob}}, nil
+			default:
+				report = in
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true}, nil
+			}
+		})
+
+	input := mapDAGInput(payload.MapSpec{FromNode: "list"})
+	input.ClaimCheck = true
+	input.Nodes[2].Inputs = nil
+
+	env.ExecuteWorkflow(withArtifactStore(raw), input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.FunctionDAGWorkflowOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	process := result.Results["process"]
+	assert.Equal(t, "3", process.Result["count"])
+	assert.NotContains(t, process.Result, "results")
+	assert.Nil(t, process.Data)
+
+	// The report node gets the aggregate by reference.
+	require.NotEmpty(t, report.DataRef)
+	assert.Nil(t, report.Data)
+	data, err := store.NewBytesStore(raw).Load(context.Background(), report.DataRef)
+	require.NoError(t, err)
+	var items []payload.MapItemResult
+	require.NoError(t, json.Unmarshal(data, &items))
+	require.Len(t, items, 3)
+	assert.Equal(t, blob, items[2].Result["blob"])
+}
+
+func TestDAGWorkflow_MapKeepsSmallResultsInline(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			if in.Name == "list-partitions" {
+				return &payload.FunctionExecutionOutput{Name: in.Name, Success: true, Data: []byte(`["a"]`)}, nil
+			}
+			return &payload.FunctionExecutionOutput{Name: in.Name, Success: true}, nil
+		})
+
+	input := mapDAGInput(payload.MapSpec{FromNode: "list"})
+	input.ClaimCheck = true
+	env.ExecuteWorkflow(withArtifactStore(store.NewMemoryStore()), input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.FunctionDAGWorkflowOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	process := result.Results["process"]
+	assert.Contains(t, process.Result, "results")
+	assert.NotEmpty(t, process.Data)
+	assert.Empty(t, process.DataRef)
+}
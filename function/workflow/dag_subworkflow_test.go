@@ -0,0 +1,218 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+	wf "go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/function/payload"
+	generic "github.com/jasoet/go-wf/v2/workflow"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+type releaseInput struct {
+	Channel string `json:"channel"`
+}
+
+type releaseOutput struct {
+	Version    string `json:"version"`
+	WorkflowID string `json:"workflow_id"`
+}
+
+func TestDAGWorkflow_SubWorkflowByType(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	var childChannel string
+	env.RegisterWorkflowWithOptions(func(ctx wf.Context, in releaseInput) (*releaseOutput, error) {
+		childChannel = in.Channel
+		return &releaseOutput{Version: "1.4.0", WorkflowID: wf.GetInfo(ctx).WorkflowExecution.ID}, nil
+	}, wf.RegisterOptions{Name: "ReleaseWorkflow"})
+
+	var announce payload.FunctionExecutionInput
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			announce = in
+			return &payload.FunctionExecutionOutput{Name: in.Name, Success: true}, nil
+		})
+
+	childInput, err := json.Marshal(releaseInput{Channel: "stable"})
+	require.NoError(t, err)
+
+	input := payload.DAGWorkflowInput{
+		Nodes: []payload.FunctionDAGNode{
+			{
+				Name: "release",
+				SubWorkflow: &payload.SubWorkflowNode{SubWorkflow: generic.SubWorkflow{
+					Definition:   "release",
+					WorkflowType: "ReleaseWorkflow",
+					Input:        childInput,
+					WorkflowID:   "{{parent_id}}-{{definition}}",
+					Outputs: []generic.SubWorkflowOutput{
+						{Name: "version", Path: "$.version"},
+						{Name: "child_id", Path: "$.workflow_id"},
+						{Name: "notes", Path: "$.notes", Default: "none"},
+					},
+				}},
+			},
+			{
+				Name:         "announce",
+				Function:     payload.FunctionExecutionInput{Name: "announce"},
+				Dependencies: []string{"release"},
+				Inputs:       []payload.FunctionInputMapping{{Name: "version", From: "release.version", Required: true}},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(DAGWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.FunctionDAGWorkflowOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, 2, result.TotalSuccess)
+	assert.Equal(t, "stable", childChannel)
+	assert.Equal(t, "1.4.0", announce.Args["version"])
+	assert.Equal(t, "none", result.StepOutputs["release"]["notes"])
+	assert.Equal(t, "default-test-workflow-id-release", result.StepOutputs["release"]["child_id"])
+}
+
+func TestDAGWorkflow_SubWorkflowInlineDAG(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+	env.RegisterWorkflowWithOptions(InstrumentedDAGWorkflow, wf.RegisterOptions{Name: payload.DAGWorkflowType})
+
+	var calls []string
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			calls = append(calls, in.Name)
+			return &payload.FunctionExecutionOutput{Name: in.Name, Success: true, Result: map[string]string{"digest": "sha256:abc"}}, nil
+		})
+
+	input := payload.DAGWorkflowInput{
+		Nodes: []payload.FunctionDAGNode{
+			{
+				Name: "build",
+				SubWorkflow: &payload.SubWorkflowNode{
+					SubWorkflow: generic.SubWorkflow{
+						Outputs: []generic.SubWorkflowOutput{{Name: "digest", Path: "$.step_outputs.image.digest"}},
+					},
+					DAG: &payload.DAGWorkflowInput{
+						Nodes: []payload.FunctionDAGNode{
+							{Name: "compile", Function: payload.FunctionExecutionInput{Name: "compile"}},
+							{
+								Name:         "image",
+								Function:     payload.FunctionExecutionInput{Name: "image"},
+								Dependencies: []string{"compile"},
+								Outputs:      []payload.OutputMapping{{Name: "digest", ResultKey: "digest"}},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:         "deploy",
+				Function:     payload.FunctionExecutionInput{Name: "deploy"},
+				Dependencies: []string{"build"},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(DAGWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.FunctionDAGWorkflowOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, []string{"compile", "image", "deploy"}, calls)
+	assert.Equal(t, "sha256:abc", result.StepOutputs["build"]["digest"])
+}
+
+func TestDAGWorkflow_SubWorkflowFailedSteps(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+	env.RegisterWorkflowWithOptions(func(wf.Context) (*generic.PipelineOutput[payload.FunctionExecutionOutput], error) {
+		return &generic.PipelineOutput[payload.FunctionExecutionOutput]{TotalFailed: 2}, nil
+	}, wf.RegisterOptions{Name: payload.PipelineWorkflowType})
+
+	input := payload.DAGWorkflowInput{
+		FailFast: true,
+		Nodes: []payload.FunctionDAGNode{
+			{
+				Name:        "release",
+				SubWorkflow: &payload.SubWorkflowNode{SubWorkflow: generic.SubWorkflow{WorkflowType: payload.PipelineWorkflowType}},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(DAGWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), "node release failed")
+}
+
+func TestDAGWorkflow_SubWorkflowOffloadsLargeResult(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	blob := strings.Repeat("x", nodeDataInlineLimit)
+	env.RegisterWorkflowWithOptions(func(wf.Context) (string, error) {
+		return blob, nil
+	}, wf.RegisterOptions{Name: "ReportWorkflow"})
+
+	raw := store.NewMemoryStore()
+	env.RegisterWorkflowWithOptions(withArtifactStore(raw), wf.RegisterOptions{Name: "ArtifactDAGWorkflow"})
+
+	var publish payload.FunctionExecutionInput
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			publish = in
+			return &payload.FunctionExecutionOutput{Name: in.Name, Success: true}, nil
+		})
+
+	input := payload.DAGWorkflowInput{
+		ClaimCheck: true,
+		Nodes: []payload.FunctionDAGNode{
+			{
+				Name:        "report",
+				SubWorkflow: &payload.SubWorkflowNode{SubWorkflow: generic.SubWorkflow{WorkflowType: "ReportWorkflow"}},
+			},
+			{
+				Name:         "publish",
+				Function:     payload.FunctionExecutionInput{Name: "publish"},
+				Dependencies: []string{"report"},
+				DataInput:    &payload.DataMapping{FromNode: "report"},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow("ArtifactDAGWorkflow", input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.FunctionDAGWorkflowOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	report := result.Results["report"]
+	assert.True(t, report.Success)
+	assert.Nil(t, report.Data)
+	require.NotEmpty(t, report.DataRef)
+
+	// The downstream node gets the child result by reference.
+	assert.Equal(t, report.DataRef, publish.DataRef)
+	assert.Nil(t, publish.Data)
+	data, err := store.NewBytesStore(raw).Load(context.Background(), report.DataRef)
+	require.NoError(t, err)
+	assert.JSONEq(t, strconv.Quote(blob), string(data))
+}
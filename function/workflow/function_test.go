@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -67,3 +68,32 @@ func TestExecuteFunctionWorkflow_ActivityError(t *testing.T) {
 	require.True(t, env.IsWorkflowCompleted())
 	assert.Error(t, env.GetWorkflowError())
 }
+
+func TestExecuteFunctionWorkflow_RoutesVersion(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	input := payload.FunctionExecutionInput{
+		Name:   "process-order",
+		Labels: map[string]string{"tenant": "beta"},
+		Routes: []payload.VersionRoute{
+			{Version: "v1", Weight: 100},
+			{Version: "v2", Match: map[string]string{"tenant": "beta"}},
+		},
+	}
+
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			return &payload.FunctionExecutionOutput{Name: in.Name, Version: in.Version, Success: true}, nil
+		})
+
+	env.ExecuteWorkflow(ExecuteFunctionWorkflow, input)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result payload.FunctionExecutionOutput
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, "v2", result.Version)
+}
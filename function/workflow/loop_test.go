@@ -42,6 +42,42 @@ func TestLoopWorkflow_Success(t *testing.T) {
 	assert.Equal(t, 3, result.ItemCount)
 }
 
+func TestLoopWorkflow_RoutesEachItem(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerFunctionActivity(env)
+
+	items := make([]string, 20)
+	for i := range items {
+		items[i] = "order"
+	}
+	input := generic.LoopInput[*payload.FunctionExecutionInput, payload.FunctionExecutionOutput]{
+		Items: items,
+		Template: &payload.FunctionExecutionInput{
+			Name:   "process",
+			Routes: []payload.VersionRoute{{Version: "v1", Weight: 50}, {Version: "v2", Weight: 50}},
+		},
+	}
+
+	env.OnActivity("ExecuteFunctionActivity", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, in payload.FunctionExecutionInput) (*payload.FunctionExecutionOutput, error) {
+			return &payload.FunctionExecutionOutput{Name: in.Name, Version: in.Version, Success: true}, nil
+		})
+
+	env.ExecuteWorkflow(LoopWorkflow, input)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result generic.LoopOutput[payload.FunctionExecutionOutput]
+	require.NoError(t, env.GetWorkflowResult(&result))
+	versions := map[string]int{}
+	for _, r := range result.Results {
+		versions[r.Version]++
+	}
+	assert.Len(t, versions, 2, "items of one workflow spread across weighted routes")
+}
+
 func TestParameterizedLoopWorkflow_Success(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
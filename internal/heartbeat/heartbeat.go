@@ -1,7 +1,7 @@
 // Package heartbeat provides shared Temporal-activity heartbeat helpers used by
-// datasync/activity and datasync/chunk. Living under internal/ keeps these
-// helpers off the public API while still allowing reuse across sibling
-// packages within datasync.
+// datasync/activity, datasync/chunk and function/activity. Living under
+// internal/ keeps these helpers off the public API while still allowing reuse
+// across the module.
 package heartbeat
 
 import (
@@ -35,6 +35,28 @@ func Loop(
 	interval time.Duration,
 	message func() string,
 	done <-chan struct{},
+) {
+	LoopDetails(ctx, interval, func() any { return message() }, done)
+}
+
+// LoopDetails is Loop for structured heartbeat details, such as a resume
+// cursor that a retried attempt reads back with activity.GetHeartbeatDetails.
+func LoopDetails(
+	ctx context.Context,
+	interval time.Duration,
+	details func() any,
+	done <-chan struct{},
+) {
+	LoopFunc(ctx, interval, func() { activity.RecordHeartbeat(ctx, details()) }, done)
+}
+
+// LoopFunc is Loop for callers that record the heartbeat themselves, for
+// example with several details: beat is called once per tick.
+func LoopFunc(
+	ctx context.Context,
+	interval time.Duration,
+	beat func(),
+	done <-chan struct{},
 ) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -45,7 +67,7 @@ func Loop(
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			activity.RecordHeartbeat(ctx, message())
+			beat()
 		}
 	}
 }
@@ -0,0 +1,23 @@
+// Package sqlident validates SQL identifiers that are interpolated into
+// statements, because SQL placeholders cannot parameterise them. It is shared
+// by datasync/sqlsync, datasync/chunk/pgtracker and workflow/store, so table
+// and column names follow one rule across the module.
+package sqlident
+
+import "regexp"
+
+var (
+	qualified   = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+	unqualified = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+// Valid reports whether name is a simple lowercase identifier, optionally
+// qualified by a schema or table alias ("events", "audit.events", "o.id").
+func Valid(name string) bool {
+	return qualified.MatchString(name)
+}
+
+// ValidUnqualified is Valid without the qualifier, for column names.
+func ValidUnqualified(name string) bool {
+	return unqualified.MatchString(name)
+}
@@ -0,0 +1,22 @@
+package sqlident
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	for _, name := range []string{"events", "_tmp", "audit.events", "o.id", "t2"} {
+		assert.True(t, Valid(name), name)
+	}
+	for _, name := range []string{"", "Events", "2t", "a.b.c", "events;drop", `"events"`, "a-b", ".events"} {
+		assert.False(t, Valid(name), name)
+	}
+}
+
+func TestValidUnqualified(t *testing.T) {
+	assert.True(t, ValidUnqualified("created_at"))
+	assert.False(t, ValidUnqualified("o.created_at"))
+	assert.False(t, ValidUnqualified("Created"))
+}
@@ -0,0 +1,120 @@
+package workflow
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jasoet/pkg/v2/temporal/job"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+	wf "go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/internal/heartbeat"
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+// UploadArtifactActivityName is the activity DAG workflows store output
+// artifacts with. Register it with RegisterArtifactActivities.
+const UploadArtifactActivityName = "UploadArtifactActivity"
+
+// ArtifactUpload is the input of UploadArtifactActivityName: the file or
+// directory at Path, or the object at CopyFrom, stored under Key.
+type ArtifactUpload struct {
+	// Key is the store key to upload to.
+	Key string `json:"key"`
+
+	// Path is the local file or directory to upload.
+	Path string `json:"path,omitempty"`
+
+	// CopyFrom is a store key to copy to Key instead of uploading Path.
+	CopyFrom string `json:"copy_from,omitempty"`
+
+	// Type is "file", "directory" or "archive"; empty detects it from Path.
+	Type string `json:"type,omitempty"`
+
+	// Format, Include and Exclude are the archive options of a directory.
+	Format  string   `json:"format,omitempty"`
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+func (u ArtifactUpload) archiveOptions() []store.ArchiveOption {
+	var opts []store.ArchiveOption
+	if u.Format != "" {
+		opts = append(opts, store.WithArchiveFormat(u.Format))
+	}
+	if len(u.Include) > 0 {
+		opts = append(opts, store.WithInclude(u.Include...))
+	}
+	if len(u.Exclude) > 0 {
+		opts = append(opts, store.WithExclude(u.Exclude...))
+	}
+	return opts
+}
+
+// NewUploadArtifactActivity returns the artifact upload activity for raw.
+// It heartbeats while it uploads, and part-based uploads heartbeat their
+// progress (store.HeartbeatUploads), so a retried attempt resumes the
+// upload instead of starting over.
+func NewUploadArtifactActivity(raw store.RawStore) func(context.Context, ArtifactUpload) error {
+	return func(ctx context.Context, in ArtifactUpload) error {
+		if activity.IsActivity(ctx) {
+			var last atomic.Pointer[store.UploadProgress]
+			if prev, ok := store.LastUploadHeartbeat(ctx); ok {
+				last.Store(&prev)
+			}
+			ctx = store.WithUploadProgress(ctx, func(p store.UploadProgress) { last.Store(&p) })
+
+			done := make(chan struct{})
+			defer close(done)
+			interval := heartbeat.Interval(activity.GetInfo(ctx).HeartbeatTimeout)
+			go heartbeat.LoopFunc(ctx, interval, func() {
+				// Repeat the last upload progress, so the resume handle
+				// stays the latest heartbeat.
+				if p := last.Load(); p != nil {
+					activity.RecordHeartbeat(ctx, nil, *p)
+					return
+				}
+				activity.RecordHeartbeat(ctx, "uploading "+in.Key)
+			}, done)
+			ctx = store.HeartbeatUploads(ctx, nil)
+		}
+
+		if in.CopyFrom != "" {
+			reader, err := raw.Download(ctx, in.CopyFrom)
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+			return raw.Upload(ctx, in.Key, reader)
+		}
+		return store.UploadFile(ctx, raw, in.Key, in.Path, in.Type, in.archiveOptions()...)
+	}
+}
+
+// RegisterArtifactActivities registers the artifact upload activity for
+// raw on w. Workers that run DAG workflows with an ArtifactStore register
+// it with the same store.
+func RegisterArtifactActivities(w worker.Worker, raw store.RawStore) {
+	job.RegisterActivityOnce(w, UploadArtifactActivityName, NewUploadArtifactActivity(raw), activity.RegisterOptions{
+		Name: UploadArtifactActivityName,
+	})
+}
+
+// WithArtifactUploadOptions returns ctx with the activity options of
+// artifact uploads: the upload may take long, so it is bounded by its
+// heartbeat rather than its start-to-close timeout.
+func WithArtifactUploadOptions(ctx wf.Context) wf.Context {
+	return wf.WithActivityOptions(ctx, wf.ActivityOptions{
+		StartToCloseTimeout: time.Hour,
+		HeartbeatTimeout:    time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    3,
+		},
+	})
+}
@@ -0,0 +1,49 @@
+package workflow
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+func readKey(t *testing.T, raw store.RawStore, key string) string {
+	t.Helper()
+	reader, err := raw.Download(context.Background(), key)
+	require.NoError(t, err)
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestUploadArtifactActivity(t *testing.T) {
+	raw := store.NewMemoryStore()
+	path := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, os.WriteFile(path, []byte("report"), 0o600))
+	require.NoError(t, raw.Upload(context.Background(), "tmp/data", strings.NewReader("data")))
+
+	suite := &testsuite.WorkflowTestSuite{}
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivityWithOptions(NewUploadArtifactActivity(raw), activity.RegisterOptions{Name: UploadArtifactActivityName})
+
+	_, err := env.ExecuteActivity(UploadArtifactActivityName, ArtifactUpload{Key: "run/report", Path: path, Type: "file"})
+	require.NoError(t, err)
+	assert.Equal(t, "report", readKey(t, raw, "run/report"))
+
+	_, err = env.ExecuteActivity(UploadArtifactActivityName, ArtifactUpload{Key: "run/data", CopyFrom: "tmp/data"})
+	require.NoError(t, err)
+	assert.Equal(t, "data", readKey(t, raw, "run/data"))
+
+	_, err = env.ExecuteActivity(UploadArtifactActivityName, ArtifactUpload{Key: "run/missing", CopyFrom: "tmp/missing"})
+	assert.Error(t, err)
+}
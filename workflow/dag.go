@@ -128,3 +128,6 @@ type DAGOutput[O TaskOutput] struct {
 	TotalFailed   int             `json:"total_failed"`
 	TotalDuration time.Duration   `json:"total_duration"`
 }
+
+// FailedSteps implements ChildOutput.
+func (o *DAGOutput[O]) FailedSteps() int { return o.TotalFailed }
@@ -0,0 +1,29 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// TimeoutErrorType is the ApplicationError type a task timeout arrives as
+// once it crosses Temporal, such as the function activity's TimeoutError.
+const TimeoutErrorType = "TimeoutError"
+
+// FromTemporal maps an error returned through Temporal, such as by an
+// activity future, back to the sentinel it stands for, so errors.Is keeps
+// working in workflow code. An ApplicationError of type TimeoutErrorType and
+// a Temporal timeout both match ErrTimeout; the original error stays in the
+// chain. Other errors, and nil, are returned unchanged.
+func FromTemporal(err error) error {
+	if err == nil || stderrors.Is(err, ErrTimeout) {
+		return err
+	}
+	var appErr *temporal.ApplicationError
+	var timeoutErr *temporal.TimeoutError
+	if (stderrors.As(err, &appErr) && appErr.Type() == TimeoutErrorType) || stderrors.As(err, &timeoutErr) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
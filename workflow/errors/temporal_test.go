@@ -0,0 +1,49 @@
+package errors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestFromTemporal(t *testing.T) {
+	timeout := temporal.NewApplicationError("function slow timed out after 1s", TimeoutErrorType)
+	other := temporal.NewApplicationError("boom", "ExecutionError")
+	startToClose := temporal.NewTimeoutError(enumspb.TIMEOUT_TYPE_START_TO_CLOSE, nil)
+
+	tests := []struct {
+		name    string
+		err     error
+		timeout bool
+	}{
+		{name: "nil", err: nil},
+		{name: "task timeout", err: timeout, timeout: true},
+		{name: "temporal timeout", err: startToClose, timeout: true},
+		{name: "other application error", err: other},
+		{name: "plain error", err: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromTemporal(tt.err)
+			assert.Equal(t, tt.timeout, errors.Is(got, ErrTimeout))
+			if tt.err != nil {
+				assert.ErrorIs(t, got, tt.err)
+			} else {
+				assert.NoError(t, got)
+			}
+		})
+	}
+}
+
+func TestFromTemporal_KeepsApplicationError(t *testing.T) {
+	err := FromTemporal(temporal.NewApplicationError("timed out", TimeoutErrorType))
+
+	var appErr *temporal.ApplicationError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, TimeoutErrorType, appErr.Type())
+	assert.Equal(t, err, FromTemporal(err), "mapping twice is a no-op")
+}
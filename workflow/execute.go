@@ -5,6 +5,8 @@ import (
 	"time"
 
 	wf "go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/workflow/errors"
 )
 
 // ExecuteTaskWorkflow runs a single task and returns results.
@@ -17,10 +19,10 @@ func ExecuteTaskWorkflow[I TaskInput, O TaskOutput](ctx wf.Context, input I) (*O
 	}
 
 	ao := DefaultActivityOptions()
-	ctx = wf.WithActivityOptions(ctx, ao)
+	ctx = PrepareTask(wf.WithActivityOptions(ctx, ao), input, "")
 
 	var output O
-	err := wf.ExecuteActivity(ctx, input.ActivityName(), input).Get(ctx, &output)
+	err := errors.FromTemporal(wf.ExecuteActivity(ctx, input.ActivityName(), input).Get(ctx, &output))
 	if err != nil {
 		logger.Error("Task execution failed", "error", err)
 		return nil, err
@@ -41,10 +43,10 @@ func ExecuteTaskWorkflowWithTimeout[I TaskInput, O TaskOutput](ctx wf.Context, i
 
 	ao := DefaultActivityOptions()
 	ao.StartToCloseTimeout = timeout
-	ctx = wf.WithActivityOptions(ctx, ao)
+	ctx = PrepareTask(wf.WithActivityOptions(ctx, ao), input, "")
 
 	var output O
-	err := wf.ExecuteActivity(ctx, input.ActivityName(), input).Get(ctx, &output)
+	err := errors.FromTemporal(wf.ExecuteActivity(ctx, input.ActivityName(), input).Get(ctx, &output))
 	if err != nil {
 		logger.Error("Task execution failed", "error", err)
 		return nil, err
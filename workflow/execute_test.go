@@ -1,14 +1,20 @@
 package workflow
 
 import (
+	"context"
+	stderrors "errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
 	wf "go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/workflow/errors"
 )
 
 // executeTaskWrapper is a non-generic workflow wrapper for testing.
@@ -90,3 +96,74 @@ func TestExecuteTaskWorkflowWithTimeout_Success(t *testing.T) {
 	require.NoError(t, env.GetWorkflowResult(&result))
 	assert.True(t, result.Success)
 }
+
+// reportStartToClose returns the activity's StartToCloseTimeout as its result.
+func reportStartToClose(ctx context.Context, _ testInput) (*testOutput, error) {
+	return &testOutput{Result: activity.GetInfo(ctx).StartToCloseTimeout.String(), Success: true}, nil
+}
+
+func TestExecuteTaskWorkflow_TaskTimeoutRaisesStartToClose(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{name: "no task timeout keeps default", want: 10 * time.Minute},
+		{name: "short task timeout keeps default", timeout: time.Minute, want: 10 * time.Minute},
+		{name: "long task timeout raises", timeout: time.Hour, want: time.Hour + TaskTimeoutGrace},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			registerTestActivity(env)
+			env.OnActivity("TestActivity", mock.Anything, mock.Anything).Return(reportStartToClose)
+
+			env.ExecuteWorkflow(executeTaskWrapper, testInput{Name: "test", Value: "v", Timeout: tt.timeout})
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+
+			var result testOutput
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, tt.want.String(), result.Result)
+		})
+	}
+}
+
+// taskTimedOutWrapper reports whether ExecuteTaskWorkflow's error matches
+// errors.ErrTimeout inside the workflow.
+func taskTimedOutWrapper(ctx wf.Context, input testInput) (bool, error) {
+	_, err := ExecuteTaskWorkflow[testInput, testOutput](ctx, input)
+	return stderrors.Is(err, errors.ErrTimeout), nil
+}
+
+func TestExecuteTaskWorkflow_MapsTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		timeout bool
+	}{
+		{name: "task timeout", err: temporal.NewApplicationError("function slow timed out after 1s", errors.TimeoutErrorType), timeout: true},
+		{name: "other failure", err: temporal.NewApplicationError("boom", "ExecutionError")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			registerTestActivity(env)
+			env.OnActivity("TestActivity", mock.Anything, mock.Anything).Return(nil, tt.err)
+
+			env.ExecuteWorkflow(taskTimedOutWrapper, testInput{Name: "test", Value: "v"})
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+
+			var timedOut bool
+			require.NoError(t, env.GetWorkflowResult(&timedOut))
+			assert.Equal(t, tt.timeout, timedOut)
+		})
+	}
+}
@@ -42,12 +42,58 @@ func ResolveActivityOptions(opts *ExecutionOptions) wf.ActivityOptions {
 	if opts.StartToCloseTimeout > 0 {
 		ao.StartToCloseTimeout = opts.StartToCloseTimeout
 	}
+	if opts.HeartbeatTimeout > 0 {
+		ao.HeartbeatTimeout = opts.HeartbeatTimeout
+	}
 	if opts.RetryPolicy != nil {
 		ao.RetryPolicy = opts.RetryPolicy
 	}
 	return ao
 }
 
+// TaskTimeoutGrace is added to a task's own timeout when raising the activity
+// StartToCloseTimeout, so the activity can report the task timeout itself
+// before Temporal abandons the attempt.
+const TaskTimeoutGrace = 30 * time.Second
+
+// WithTaskTimeout raises the StartToCloseTimeout of the activity options in
+// ctx when task implements TimedTaskInput and its timeout (plus
+// TaskTimeoutGrace) exceeds it. Otherwise ctx is returned unchanged.
+func WithTaskTimeout(ctx wf.Context, task any) wf.Context {
+	timed, ok := task.(TimedTaskInput)
+	if !ok {
+		return ctx
+	}
+	timeout := timed.TaskTimeout()
+	if timeout <= 0 {
+		return ctx
+	}
+
+	ao := wf.GetActivityOptions(ctx)
+	if needed := timeout + TaskTimeoutGrace; needed > ao.StartToCloseTimeout {
+		ao.StartToCloseTimeout = needed
+		return wf.WithActivityOptions(ctx, ao)
+	}
+	return ctx
+}
+
+// PrepareTask readies task for scheduling from the workflow in ctx: a
+// RoutedTaskInput is routed with the workflow ID and step as its key, then
+// WithTaskTimeout applies the task's own timeout. step names the task within
+// the workflow (a DAG node, a loop or pipeline index) so the calls of one
+// workflow spread across weighted routes; it is empty for a workflow that
+// runs a single task. It returns the context to schedule the activity with.
+func PrepareTask(ctx wf.Context, task any, step string) wf.Context {
+	if routed, ok := task.(RoutedTaskInput); ok {
+		key := wf.GetInfo(ctx).WorkflowExecution.ID
+		if step != "" {
+			key += "/" + step
+		}
+		routed.RouteTask(key)
+	}
+	return WithTaskTimeout(ctx, task)
+}
+
 // SubstituteTemplate replaces template variables in a string.
 // Supports: {{item}}, {{index}}, and {{.paramName}}/{{paramName}} syntax.
 func SubstituteTemplate(tmpl, item string, index int, params map[string]string) string {
@@ -331,6 +331,12 @@ func TestResolveActivityOptions(t *testing.T) {
 		})
 	}
 
+	t.Run("heartbeat timeout override", func(t *testing.T) {
+		assert.Zero(t, ResolveActivityOptions(nil).HeartbeatTimeout)
+		ao := ResolveActivityOptions(&ExecutionOptions{HeartbeatTimeout: 30 * time.Second})
+		assert.Equal(t, 30*time.Second, ao.HeartbeatTimeout)
+	})
+
 	t.Run("retry policy override", func(t *testing.T) {
 		rp := &temporal.RetryPolicy{MaximumAttempts: 5}
 		ao := ResolveActivityOptions(&ExecutionOptions{RetryPolicy: rp})
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"fmt"
+	"strconv"
 
 	wf "go.temporal.io/sdk/workflow"
 )
@@ -45,7 +46,7 @@ func executeParallelLoop[I TaskInput, O TaskOutput](ctx wf.Context, input LoopIn
 	futures := make([]wf.Future, len(input.Items))
 	for i, item := range input.Items {
 		taskInput := substitutor(input.Template, item, i, nil)
-		futures[i] = wf.ExecuteActivity(ctx, taskInput.ActivityName(), taskInput)
+		futures[i] = wf.ExecuteActivity(PrepareTask(ctx, taskInput, strconv.Itoa(i)), taskInput.ActivityName(), taskInput)
 	}
 
 	for _, future := range futures {
@@ -67,7 +68,7 @@ func executeSequentialLoop[I TaskInput, O TaskOutput](ctx wf.Context, input Loop
 	for i, item := range input.Items {
 		taskInput := substitutor(input.Template, item, i, nil)
 		var result O
-		err := wf.ExecuteActivity(ctx, taskInput.ActivityName(), taskInput).Get(ctx, &result)
+		err := wf.ExecuteActivity(PrepareTask(ctx, taskInput, strconv.Itoa(i)), taskInput.ActivityName(), taskInput).Get(ctx, &result)
 		output.Results = append(output.Results, result)
 		if err != nil || !result.IsSuccess() {
 			output.TotalFailed++
@@ -102,7 +103,7 @@ func ParameterizedLoopWorkflow[I TaskInput, O TaskOutput](ctx wf.Context, input
 		futures := make([]wf.Future, len(combinations))
 		for i, params := range combinations {
 			taskInput := substitutor(input.Template, "", i, params)
-			futures[i] = wf.ExecuteActivity(ctx, taskInput.ActivityName(), taskInput)
+			futures[i] = wf.ExecuteActivity(PrepareTask(ctx, taskInput, strconv.Itoa(i)), taskInput.ActivityName(), taskInput)
 		}
 		for _, future := range futures {
 			var result O
@@ -121,7 +122,7 @@ func ParameterizedLoopWorkflow[I TaskInput, O TaskOutput](ctx wf.Context, input
 		for i, params := range combinations {
 			taskInput := substitutor(input.Template, "", i, params)
 			var result O
-			err := wf.ExecuteActivity(ctx, taskInput.ActivityName(), taskInput).Get(ctx, &result)
+			err := wf.ExecuteActivity(PrepareTask(ctx, taskInput, strconv.Itoa(i)), taskInput.ActivityName(), taskInput).Get(ctx, &result)
 			output.Results = append(output.Results, result)
 			if err != nil || !result.IsSuccess() {
 				output.TotalFailed++
@@ -2,8 +2,11 @@ package workflow
 
 import (
 	"fmt"
+	"strconv"
 
 	wf "go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/workflow/errors"
 )
 
 // ParallelWorkflow executes tasks in parallel.
@@ -22,7 +25,7 @@ func ParallelWorkflow[I TaskInput, O TaskOutput](ctx wf.Context, input ParallelI
 	// use Temporal's MaxConcurrentActivityExecutionSize for worker-level limiting.
 	futures := make([]wf.Future, len(input.Tasks))
 	for i, task := range input.Tasks {
-		futures[i] = wf.ExecuteActivity(ctx, task.ActivityName(), task)
+		futures[i] = wf.ExecuteActivity(PrepareTask(ctx, task, strconv.Itoa(i)), task.ActivityName(), task)
 	}
 
 	output := &ParallelOutput[O]{
@@ -31,7 +34,7 @@ func ParallelWorkflow[I TaskInput, O TaskOutput](ctx wf.Context, input ParallelI
 
 	for i, future := range futures {
 		var result O
-		err := future.Get(ctx, &result)
+		err := errors.FromTemporal(future.Get(ctx, &result))
 		output.Results = append(output.Results, result)
 
 		if err != nil || !result.IsSuccess() {
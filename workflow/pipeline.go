@@ -2,8 +2,11 @@ package workflow
 
 import (
 	"fmt"
+	"strconv"
 
 	wf "go.temporal.io/sdk/workflow"
+
+	"github.com/jasoet/go-wf/v2/workflow/errors"
 )
 
 // PipelineWorkflow executes tasks sequentially.
@@ -26,7 +29,7 @@ func PipelineWorkflow[I TaskInput, O TaskOutput](ctx wf.Context, input PipelineI
 		logger.Info("Executing pipeline step", "step", i+1)
 
 		var result O
-		err := wf.ExecuteActivity(ctx, task.ActivityName(), task).Get(ctx, &result)
+		err := errors.FromTemporal(wf.ExecuteActivity(PrepareTask(ctx, task, strconv.Itoa(i)), task.ActivityName(), task).Get(ctx, &result))
 		output.Results = append(output.Results, result)
 
 		if err != nil || !result.IsSuccess() {
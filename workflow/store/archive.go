@@ -2,7 +2,11 @@ package store
 
 import (
 	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -11,18 +15,85 @@ import (
 	"strings"
 )
 
-// ArchiveDirectory creates a tar.gz archive of a directory.
-func ArchiveDirectory(sourceDir string, writer io.Writer) (err error) {
-	gzipWriter := gzip.NewWriter(writer)
-	defer func() {
-		if closeErr := gzipWriter.Close(); closeErr != nil && err == nil {
-			err = closeErr
-		}
-	}()
+// Archive formats for directory artifacts.
+const (
+	// ArchiveFormatTarGz is a gzip-compressed tar archive (the default).
+	ArchiveFormatTarGz = "tar.gz"
+	// ArchiveFormatTar is an uncompressed tar archive.
+	ArchiveFormatTar = "tar"
+	// ArchiveFormatZip is a zip archive.
+	ArchiveFormatZip = "zip"
+)
+
+// maxExtractFileSize limits each extracted file to 1GB to prevent decompression bombs.
+const maxExtractFileSize = 1 << 30
+
+// ArchiveOption configures ArchiveDirectory and ExtractArchive.
+type ArchiveOption func(*archiveConfig)
+
+type archiveConfig struct {
+	format  string
+	include []string
+	exclude []string
+}
+
+// WithArchiveFormat selects the archive format (ArchiveFormatTarGz,
+// ArchiveFormatTar or ArchiveFormatZip). When archiving, the default is
+// tar.gz; when extracting, the format is detected from the data if unset.
+func WithArchiveFormat(format string) ArchiveOption {
+	return func(c *archiveConfig) { c.format = format }
+}
+
+// WithInclude limits the archive to paths matching at least one of the
+// gitignore-style patterns. When extracting, only matching entries are
+// written. See pathFilter for the pattern syntax.
+func WithInclude(patterns ...string) ArchiveOption {
+	return func(c *archiveConfig) { c.include = append(c.include, patterns...) }
+}
+
+// WithExclude drops paths matching the gitignore-style patterns, for example
+// "node_modules/" or "*.log". Patterns starting with "!" re-include paths.
+func WithExclude(patterns ...string) ArchiveOption {
+	return func(c *archiveConfig) { c.exclude = append(c.exclude, patterns...) }
+}
+
+func newArchiveConfig(opts []ArchiveOption) (archiveConfig, *pathFilter, error) {
+	var cfg archiveConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 
-	tarWriter := tar.NewWriter(gzipWriter)
+	switch cfg.format {
+	case "", ArchiveFormatTarGz, ArchiveFormatTar, ArchiveFormatZip:
+	default:
+		return cfg, nil, fmt.Errorf("unsupported archive format: %s", cfg.format)
+	}
+
+	filter, err := newPathFilter(cfg.include, cfg.exclude)
+	if err != nil {
+		return cfg, nil, err
+	}
+	return cfg, filter, nil
+}
+
+// archiveWriter abstracts the tar and zip writers used by ArchiveDirectory.
+type archiveWriter interface {
+	writeDir(name string, fi fs.FileInfo) error
+	writeFile(name string, fi fs.FileInfo, r io.Reader) error
+	Close() error
+}
+
+// ArchiveDirectory writes an archive of a directory, tar.gz by default.
+// Symlinks are skipped to avoid including files outside the directory.
+func ArchiveDirectory(sourceDir string, writer io.Writer, opts ...ArchiveOption) (err error) {
+	cfg, filter, err := newArchiveConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	aw := newArchiveWriter(cfg.format, writer)
 	defer func() {
-		if closeErr := tarWriter.Close(); closeErr != nil && err == nil {
+		if closeErr := aw.Close(); closeErr != nil && err == nil {
 			err = closeErr
 		}
 	}()
@@ -37,36 +108,35 @@ func ArchiveDirectory(sourceDir string, writer io.Writer) (err error) {
 			return nil
 		}
 
-		fi, err := d.Info()
+		relPath, err := filepath.Rel(sourceDir, file)
 		if err != nil {
 			return err
 		}
+		if relPath == "." {
+			return nil
+		}
+		rel := filepath.ToSlash(relPath)
 
-		// Create tar header
-		header, err := tar.FileInfoHeader(fi, file)
-		if err != nil {
-			return err
+		if d.IsDir() && filter.skipDir(rel) {
+			return filepath.SkipDir
+		}
+		if !filter.allows(rel, d.IsDir()) {
+			return nil
 		}
 
-		// Update header name to be relative to source
-		relPath, err := filepath.Rel(sourceDir, file)
+		fi, err := d.Info()
 		if err != nil {
 			return err
 		}
-		header.Name = relPath
 
-		// Write header
-		if err := tarWriter.WriteHeader(header); err != nil {
-			return err
+		if d.IsDir() {
+			return aw.writeDir(rel, fi)
 		}
-
-		// If not a regular file, skip
 		if !fi.Mode().IsRegular() {
 			return nil
 		}
 
-		// Write file data
-		f, err := os.Open(file) //#nosec G304,G122 -- path from filepath.Walk within controlled sourceDir
+		f, err := os.Open(file) //#nosec G304,G122 -- path from filepath.WalkDir within controlled sourceDir
 		if err != nil {
 			return err
 		}
@@ -76,74 +146,268 @@ func ArchiveDirectory(sourceDir string, writer io.Writer) (err error) {
 			}
 		}()
 
-		_, err = io.Copy(tarWriter, f)
-		return err
+		return aw.writeFile(rel, fi, f)
 	})
 }
 
-// ExtractArchive extracts a tar.gz archive to a directory.
-func ExtractArchive(reader io.Reader, destDir string) error {
-	gzipReader, err := gzip.NewReader(reader)
+func newArchiveWriter(format string, w io.Writer) archiveWriter {
+	switch format {
+	case ArchiveFormatZip:
+		return &zipArchiveWriter{zw: zip.NewWriter(w)}
+	case ArchiveFormatTar:
+		return &tarArchiveWriter{tw: tar.NewWriter(w)}
+	default:
+		gz := gzip.NewWriter(w)
+		return &tarArchiveWriter{tw: tar.NewWriter(gz), gz: gz}
+	}
+}
+
+type tarArchiveWriter struct {
+	tw *tar.Writer
+	gz *gzip.Writer // nil for uncompressed tar
+}
+
+func (t *tarArchiveWriter) writeDir(name string, fi fs.FileInfo) error {
+	header, err := tar.FileInfoHeader(fi, "")
 	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
+		return err
 	}
-	defer func() {
-		if closeErr := gzipReader.Close(); closeErr != nil && err == nil {
-			err = closeErr
+	header.Name = name + "/"
+	return t.tw.WriteHeader(header)
+}
+
+func (t *tarArchiveWriter) writeFile(name string, fi fs.FileInfo, r io.Reader) error {
+	header, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := t.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(t.tw, r)
+	return err
+}
+
+func (t *tarArchiveWriter) Close() error {
+	err := t.tw.Close()
+	if t.gz != nil {
+		if gzErr := t.gz.Close(); err == nil {
+			err = gzErr
 		}
-	}()
+	}
+	return err
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (z *zipArchiveWriter) writeDir(name string, fi fs.FileInfo) error {
+	header, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return err
+	}
+	header.Name = name + "/"
+	_, err = z.zw.CreateHeader(header)
+	return err
+}
+
+func (z *zipArchiveWriter) writeFile(name string, fi fs.FileInfo, r io.Reader) error {
+	header, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	w, err := z.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (z *zipArchiveWriter) Close() error {
+	return z.zw.Close()
+}
+
+// ExtractArchive extracts an archive into a directory. The format is
+// detected from the data unless WithArchiveFormat is given, and WithInclude
+// and WithExclude restrict which entries are written.
+func ExtractArchive(reader io.Reader, destDir string, opts ...ArchiveOption) error {
+	cfg, filter, err := newArchiveConfig(opts)
+	if err != nil {
+		return err
+	}
 
-	tarReader := tar.NewReader(gzipReader)
 	absDestDir, err := filepath.Abs(destDir)
 	if err != nil {
 		return fmt.Errorf("failed to resolve destination directory: %w", err)
 	}
 
+	format := cfg.format
+	if format == "" {
+		buffered := bufio.NewReader(reader)
+		format = detectArchiveFormat(buffered)
+		reader = buffered
+	}
+
+	switch format {
+	case ArchiveFormatZip:
+		return extractZip(reader, absDestDir, filter)
+	case ArchiveFormatTar:
+		return extractTar(tar.NewReader(reader), absDestDir, filter)
+	default:
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gzipReader.Close() //nolint:errcheck // read errors surface from the tar reader
+		return extractTar(tar.NewReader(gzipReader), absDestDir, filter)
+	}
+}
+
+// detectArchiveFormat sniffs the archive format from its magic bytes. Data
+// that matches neither zip nor tar is treated as tar.gz, the default format,
+// so corrupt input is reported by the gzip reader.
+func detectArchiveFormat(r *bufio.Reader) string {
+	magic, _ := r.Peek(tarMagicOffset + len(tarMagic)) //nolint:errcheck // a short read simply fails to match
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return ArchiveFormatZip
+	case len(magic) >= tarMagicOffset+len(tarMagic) &&
+		bytes.Equal(magic[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic):
+		return ArchiveFormatTar
+	default:
+		return ArchiveFormatTarGz
+	}
+}
+
+// tarMagic is the "ustar" marker found in POSIX and GNU tar headers.
+var tarMagic = []byte("ustar")
+
+const tarMagicOffset = 257
+
+// archiveTarget resolves an entry name inside absDestDir, rejecting entries
+// that would escape it.
+func archiveTarget(absDestDir, name string) (string, error) {
+	target := filepath.Join(absDestDir, filepath.Clean(filepath.FromSlash(name)))
+	if !strings.HasPrefix(target, absDestDir+string(filepath.Separator)) && target != absDestDir {
+		return "", fmt.Errorf("illegal file path in archive: %s", name)
+	}
+	return target, nil
+}
+
+// entryPath normalises an archive entry name for filtering.
+func entryPath(name string) string {
+	return strings.Trim(filepath.ToSlash(filepath.Clean(name)), "/")
+}
+
+func extractTar(tarReader *tar.Reader, absDestDir string, filter *pathFilter) error {
 	for {
 		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
+		if errors.Is(err, io.EOF) {
+			return nil
 		}
 		if err != nil {
 			return fmt.Errorf("failed to read tar header: %w", err)
 		}
 
 		// Sanitize path to prevent directory traversal.
-		target := filepath.Join(absDestDir, filepath.Clean(header.Name))
-		if !strings.HasPrefix(target, absDestDir+string(filepath.Separator)) && target != absDestDir {
-			return fmt.Errorf("illegal file path in archive: %s", header.Name)
+		target, err := archiveTarget(absDestDir, header.Name)
+		if err != nil {
+			return err
 		}
+		rel := entryPath(header.Name)
 
 		switch header.Typeflag {
 		case tar.TypeDir:
+			if rel != "." && !filter.allows(rel, true) {
+				continue
+			}
 			if err := os.MkdirAll(target, 0o750); err != nil {
 				return fmt.Errorf("failed to create directory: %w", err)
 			}
 		case tar.TypeReg:
-			// Create parent directories
-			if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
-				return fmt.Errorf("failed to create parent directory: %w", err)
+			if !filter.allows(rel, false) {
+				continue
 			}
-
-			if err := extractFileFromArchive(tarReader, target); err != nil {
+			if err := extractFile(tarReader, target); err != nil {
 				return err
 			}
 		}
 	}
+}
 
+func extractZip(reader io.Reader, absDestDir string, filter *pathFilter) error {
+	// zip needs random access to its central directory, so spool to disk.
+	spool, err := os.CreateTemp("", "wf-archive-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to buffer zip archive: %w", err)
+	}
+	defer os.Remove(spool.Name()) //nolint:errcheck // best-effort cleanup of the spool file
+	defer spool.Close()           //nolint:errcheck // read-only use after the copy
+
+	size, err := io.Copy(spool, io.LimitReader(reader, MaxUploadSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to buffer zip archive: %w", err)
+	}
+	if size > MaxUploadSize {
+		return ErrUploadTooLarge
+	}
+
+	zipReader, err := zip.NewReader(spool, size)
+	if err != nil {
+		return fmt.Errorf("failed to read zip archive: %w", err)
+	}
+
+	for _, entry := range zipReader.File {
+		target, err := archiveTarget(absDestDir, entry.Name)
+		if err != nil {
+			return err
+		}
+		rel := entryPath(entry.Name)
+
+		if entry.FileInfo().IsDir() {
+			if rel != "." && !filter.allows(rel, true) {
+				continue
+			}
+			if err := os.MkdirAll(target, 0o750); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			continue
+		}
+		if !entry.Mode().IsRegular() || !filter.allows(rel, false) {
+			continue
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open zip entry %s: %w", entry.Name, err)
+		}
+		err = extractFile(rc, target)
+		_ = rc.Close() //nolint:errcheck // content was fully read or the error is returned
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// extractFileFromArchive writes a single file from the tar reader with size-limited copy.
-func extractFileFromArchive(tarReader *tar.Reader, target string) error {
+// extractFile writes a single archive entry with a size-limited copy,
+// creating parent directories as needed.
+func extractFile(r io.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
 	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) //#nosec G304 -- target is sanitized against path traversal
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 
-	// Limit extraction to 1GB to prevent decompression bomb.
-	const maxFileSize = 1 << 30
-	if _, err := io.Copy(f, io.LimitReader(tarReader, maxFileSize)); err != nil {
+	if _, err := io.Copy(f, io.LimitReader(r, maxExtractFileSize)); err != nil {
 		if closeErr := f.Close(); closeErr != nil {
 			return fmt.Errorf("failed to write file: %w (close error: %v)", err, closeErr)
 		}
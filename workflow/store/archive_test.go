@@ -2,6 +2,7 @@ package store
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"os"
@@ -122,3 +123,109 @@ func TestArchiveDirectory_SkipsSymlinks(t *testing.T) {
 	_, err = os.Stat(filepath.Join(destDir, "link.txt"))
 	assert.True(t, os.IsNotExist(err))
 }
+
+// writeTree creates files (relative slash paths) with their path as content.
+func writeTree(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, name := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte(name), 0o644))
+	}
+}
+
+// listTree returns the slash paths of all regular files under root.
+func listTree(t *testing.T, root string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestArchiveDirectory_Formats(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTree(t, srcDir, "a.txt", "nested/b.txt")
+
+	for _, format := range []string{ArchiveFormatTarGz, ArchiveFormatTar, ArchiveFormatZip} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, ArchiveDirectory(srcDir, &buf, WithArchiveFormat(format)))
+
+			// Extraction detects the format without being told.
+			destDir := t.TempDir()
+			require.NoError(t, ExtractArchive(&buf, destDir))
+			assert.ElementsMatch(t, []string{"a.txt", "nested/b.txt"}, listTree(t, destDir))
+
+			content, err := os.ReadFile(filepath.Join(destDir, "nested", "b.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, []byte("nested/b.txt"), content)
+		})
+	}
+}
+
+func TestArchiveDirectory_UnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := ArchiveDirectory(t.TempDir(), &buf, WithArchiveFormat("rar"))
+	assert.ErrorContains(t, err, "unsupported archive format")
+}
+
+func TestArchiveDirectory_IncludeExclude(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTree(t, srcDir,
+		"main.go",
+		"debug.log",
+		"web/node_modules/lib/index.js",
+		"web/app.js",
+		"logs/keep.log",
+	)
+
+	var buf bytes.Buffer
+	err := ArchiveDirectory(srcDir, &buf,
+		WithExclude("node_modules/", "*.log", "!logs/keep.log"),
+	)
+	require.NoError(t, err)
+
+	destDir := t.TempDir()
+	require.NoError(t, ExtractArchive(&buf, destDir))
+	assert.ElementsMatch(t, []string{"main.go", "web/app.js", "logs/keep.log"}, listTree(t, destDir))
+	_, err = os.Stat(filepath.Join(destDir, "web", "node_modules"))
+	assert.True(t, os.IsNotExist(err), "excluded directories must not be archived")
+}
+
+func TestExtractArchive_Selective(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTree(t, srcDir, "reports/index.html", "reports/raw/data.csv", "model.bin")
+
+	for _, format := range []string{ArchiveFormatTarGz, ArchiveFormatZip} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, ArchiveDirectory(srcDir, &buf, WithArchiveFormat(format)))
+
+			destDir := t.TempDir()
+			err := ExtractArchive(&buf, destDir, WithInclude("reports/"), WithExclude("*.csv"))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"reports/index.html"}, listTree(t, destDir))
+		})
+	}
+}
+
+func TestExtractArchive_ZipDirectoryTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("../../etc/passwd")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("evil"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	err = ExtractArchive(&buf, t.TempDir())
+	assert.ErrorContains(t, err, "illegal file path")
+}
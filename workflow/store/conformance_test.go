@@ -0,0 +1,32 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/workflow/store"
+	"github.com/jasoet/go-wf/v2/workflow/store/storetest"
+)
+
+func TestMemoryStore_Conformance(t *testing.T) {
+	// Buffering 1GB in memory to prove the upload cap is not worth the CI cost;
+	// MemoryStore shares limitUpload with the other backends.
+	storetest.Run(t, func(t *testing.T) store.RawStore {
+		return store.NewMemoryStore()
+	}, storetest.Skip("OversizeUpload"))
+}
+
+func TestLocalStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.RawStore {
+		s, err := store.NewLocalStore(t.TempDir())
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestInstrumentedStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.RawStore {
+		return store.NewInstrumentedStore(store.NewMemoryStore())
+	}, storetest.Skip("OversizeUpload"))
+}
@@ -4,8 +4,9 @@
 // The two main interfaces are:
 //
 //   - [RawStore] — byte-level storage with Upload, Download, Delete, Exists, and
-//     List operations.  Concrete implementations include [LocalStore] (filesystem)
-//     and [S3Store] (S3-compatible object storage).
+//     List operations.  Concrete implementations include [LocalStore] (filesystem,
+//     with optional TTL), [S3Store] (S3-compatible object storage), [SQLStore]
+//     (chunked blobs in a database/sql table) and [MemoryStore] (in-process).
 //
 //   - [Store] — a typed wrapper around [RawStore] that uses a [Codec] to
 //     serialize and deserialize Go values of any type T.  [JSONCodec] is the
@@ -14,4 +15,7 @@
 // Keys are built with the [KeyBuilder] helper to ensure consistent, hierarchical
 // naming across stores.  The [InstrumentedStore] decorator adds OpenTelemetry
 // tracing and metrics to any [RawStore] implementation.
+//
+// The storetest subpackage holds a conformance suite that every backend,
+// including third-party ones, can run against itself.
 package store
@@ -13,16 +13,20 @@ const (
 	FileTypeDirectory = "directory"
 	// FileTypeFile represents a single-file artifact type.
 	FileTypeFile = "file"
-	// FileTypeArchive represents a tar.gz archive artifact type.
+	// FileTypeArchive represents an archive artifact type (tar.gz unless
+	// WithArchiveFormat selects another format).
 	FileTypeArchive = "archive"
 )
 
 // UploadFile uploads a file or directory from the local filesystem to the store
 // under the given key. Supported types: "file" uploads the file as-is;
-// "directory"/"archive" stream a tar.gz of the directory via io.Pipe.
+// "directory"/"archive" stream an archive of the directory via io.Pipe.
 // An empty typ auto-detects from the source path. Uploads are capped at
 // MaxUploadSize (enforced by RawStore implementations).
-func UploadFile(ctx context.Context, raw RawStore, key, sourcePath, typ string) error {
+//
+// opts select the archive format and include/exclude patterns for
+// directory artifacts; they are ignored for single files.
+func UploadFile(ctx context.Context, raw RawStore, key, sourcePath, typ string, opts ...ArchiveOption) error {
 	// Determine artifact type if not specified
 	if typ == "" {
 		fileInfo, err := os.Stat(sourcePath)
@@ -41,7 +45,7 @@ func UploadFile(ctx context.Context, raw RawStore, key, sourcePath, typ string)
 	case FileTypeFile:
 		return uploadSingleFile(ctx, raw, key, sourcePath)
 	case FileTypeDirectory, FileTypeArchive:
-		return uploadDirectoryArchive(ctx, raw, key, sourcePath)
+		return uploadDirectoryArchive(ctx, raw, key, sourcePath, opts)
 	default:
 		return fmt.Errorf("unsupported artifact type: %s", typ)
 	}
@@ -62,14 +66,14 @@ func uploadSingleFile(ctx context.Context, raw RawStore, key, sourcePath string)
 	return raw.Upload(ctx, key, file)
 }
 
-// uploadDirectoryArchive creates a tar.gz archive and uploads it using streaming.
-func uploadDirectoryArchive(ctx context.Context, raw RawStore, key, sourcePath string) error {
+// uploadDirectoryArchive creates an archive and uploads it using streaming.
+func uploadDirectoryArchive(ctx context.Context, raw RawStore, key, sourcePath string, opts []ArchiveOption) error {
 	pr, pw := io.Pipe()
 
 	// Archive in a goroutine, streaming to the pipe writer.
 	var archiveErr error
 	go func() {
-		archiveErr = ArchiveDirectory(sourcePath, pw)
+		archiveErr = ArchiveDirectory(sourcePath, pw, opts...)
 		pw.CloseWithError(archiveErr)
 	}()
 
@@ -92,8 +96,12 @@ func uploadDirectoryArchive(ctx context.Context, raw RawStore, key, sourcePath s
 
 // DownloadFile downloads the data stored under key to a local path.
 // Supported types: "file" writes the file (creating parent directories);
-// "directory"/"archive" extracts a tar.gz stream into destPath.
-func DownloadFile(ctx context.Context, raw RawStore, key, destPath, typ string) (err error) {
+// "directory"/"archive" extracts an archive stream into destPath.
+//
+// For directory artifacts, opts restrict extraction to matching entries so
+// a consumer can pull a subset of a large archive; the format is detected
+// from the data unless WithArchiveFormat is given.
+func DownloadFile(ctx context.Context, raw RawStore, key, destPath, typ string, opts ...ArchiveOption) (err error) {
 	reader, err := raw.Download(ctx, key)
 	if err != nil {
 		return fmt.Errorf("failed to download %s: %w", key, err)
@@ -108,7 +116,7 @@ func DownloadFile(ctx context.Context, raw RawStore, key, destPath, typ string)
 	case FileTypeFile:
 		return downloadSingleFile(reader, destPath)
 	case FileTypeDirectory, FileTypeArchive:
-		return downloadDirectoryArchive(reader, destPath)
+		return downloadDirectoryArchive(reader, destPath, opts)
 	default:
 		return fmt.Errorf("unsupported artifact type: %s", typ)
 	}
@@ -142,14 +150,14 @@ func downloadSingleFile(reader io.Reader, destPath string) error {
 }
 
 // downloadDirectoryArchive extracts an archive to a directory.
-func downloadDirectoryArchive(reader io.Reader, destPath string) error {
+func downloadDirectoryArchive(reader io.Reader, destPath string, opts []ArchiveOption) error {
 	// Create destination directory
 	if err := os.MkdirAll(destPath, 0o750); err != nil {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
 	// Extract archive
-	if err := ExtractArchive(reader, destPath); err != nil {
+	if err := ExtractArchive(reader, destPath, opts...); err != nil {
 		return fmt.Errorf("failed to extract archive: %w", err)
 	}
 
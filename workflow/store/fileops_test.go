@@ -64,6 +64,26 @@ func TestUploadDownloadDirectory(t *testing.T) {
 	assert.Equal(t, []byte("content2"), content2)
 }
 
+func TestUploadDownloadDirectory_ArchiveOptions(t *testing.T) {
+	raw, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	srcDir := t.TempDir()
+	writeTree(t, srcDir, "out/report.html", "out/data.csv", "tmp/scratch.txt")
+
+	key := "wf-1/run-1/step-1/filtered"
+	err = UploadFile(ctx, raw, key, srcDir, FileTypeDirectory,
+		WithArchiveFormat(ArchiveFormatZip), WithExclude("tmp/"))
+	require.NoError(t, err)
+
+	destDir := t.TempDir()
+	err = DownloadFile(ctx, raw, key, destDir, FileTypeDirectory, WithInclude("*.html"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"out/report.html"}, listTree(t, destDir))
+}
+
 func TestUploadDownloadArchiveType(t *testing.T) {
 	raw, err := NewLocalStore(t.TempDir())
 	require.NoError(t, err)
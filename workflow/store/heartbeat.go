@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// HeartbeatUploads returns a context for uploading from a Temporal
+// activity: part-based uploads heartbeat their UploadProgress, and an upload
+// a previous attempt of the activity heartbeated is resumed (see
+// WithResumeUpload) instead of started over.
+//
+// The progress is recorded as the second heartbeat detail, after the value
+// keep returns, so an activity that heartbeats its own checkpoint keeps it;
+// keep may be nil. An UploadProgressFunc already in ctx is still called.
+// Outside a regular activity, ctx is returned unchanged.
+func HeartbeatUploads(ctx context.Context, keep func() any) context.Context {
+	if !activity.IsActivity(ctx) || activity.GetInfo(ctx).IsLocalActivity {
+		return ctx
+	}
+	if prev, ok := LastUploadHeartbeat(ctx); ok {
+		ctx = WithResumeUpload(ctx, prev)
+	}
+	inner := uploadProgressFromContext(ctx)
+	return WithUploadProgress(ctx, func(p UploadProgress) {
+		inner(p)
+		var first any
+		if keep != nil {
+			first = keep()
+		}
+		activity.RecordHeartbeat(ctx, first, p)
+	})
+}
+
+// LastUploadHeartbeat returns the UploadProgress a previous attempt of the
+// activity heartbeated through HeartbeatUploads.
+func LastUploadHeartbeat(ctx context.Context) (UploadProgress, bool) {
+	if !activity.IsActivity(ctx) || !activity.HasHeartbeatDetails(ctx) {
+		return UploadProgress{}, false
+	}
+	var (
+		first any
+		prev  UploadProgress
+	)
+	if err := activity.GetHeartbeatDetails(ctx, &first, &prev); err != nil || prev.UploadID == "" {
+		return UploadProgress{}, false
+	}
+	return prev, true
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestHeartbeatUploads_RecordsProgressAfterCheckpoint(t *testing.T) {
+	env := &testsuite.WorkflowTestSuite{}
+	testEnv := env.NewTestActivityEnvironment()
+
+	var (
+		mu         sync.Mutex
+		checkpoint string
+		recorded   UploadProgress
+	)
+	testEnv.SetOnActivityHeartbeatListener(func(_ *activity.Info, details converter.EncodedValues) {
+		var (
+			first string
+			p     UploadProgress
+		)
+		if details.Get(&first, &p) == nil {
+			mu.Lock()
+			checkpoint, recorded = first, p
+			mu.Unlock()
+		}
+	})
+
+	client := newFakeS3()
+	client.failPart = 2
+	s := newFakeS3Store(client, 1024)
+	s.concurrency = 1
+	upload := func(ctx context.Context) error {
+		ctx = HeartbeatUploads(ctx, func() any { return "handler-checkpoint" })
+		return s.Upload(ctx, "big", bytes.NewReader(patterned(3*1024)))
+	}
+	testEnv.RegisterActivity(upload)
+
+	_, err := testEnv.ExecuteActivity(upload)
+	require.Error(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "handler-checkpoint", checkpoint)
+	assert.Equal(t, "big", recorded.Key)
+	assert.Contains(t, client.uploads, recorded.UploadID, "the heartbeated upload is the one left to resume")
+}
+
+func TestHeartbeatUploads_OutsideActivity(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, ctx, HeartbeatUploads(ctx, nil))
+	_, ok := LastUploadHeartbeat(ctx)
+	assert.False(t, ok)
+}
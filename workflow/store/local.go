@@ -2,26 +2,39 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
-const (
-	// MaxUploadSize is the maximum size for uploads (1GB).
-	MaxUploadSize = 1 << 30
-)
+// tempFilePrefix marks in-flight uploads, which List and Sweep ignore.
+const tempFilePrefix = ".wf-upload-"
 
 // LocalStore implements RawStore using the local filesystem.
 type LocalStore struct {
 	basePath string
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// LocalOption configures a LocalStore.
+type LocalOption func(*LocalStore)
+
+// WithLocalTTL makes stored files expire ttl after they were last written.
+// Expired files are reported as missing by Download, Exists and List and are
+// removed lazily on access or eagerly by Sweep. A zero ttl disables expiry.
+func WithLocalTTL(ttl time.Duration) LocalOption {
+	return func(s *LocalStore) { s.ttl = ttl }
 }
 
 // NewLocalStore creates a new LocalStore rooted at basePath.
 // The base directory is created if it does not exist.
-func NewLocalStore(basePath string) (*LocalStore, error) {
+func NewLocalStore(basePath string, opts ...LocalOption) (*LocalStore, error) {
 	if err := os.MkdirAll(basePath, 0o750); err != nil {
 		return nil, fmt.Errorf("failed to create base directory: %w", err)
 	}
@@ -31,9 +44,23 @@ func NewLocalStore(basePath string) (*LocalStore, error) {
 		return nil, fmt.Errorf("failed to resolve base path: %w", err)
 	}
 
-	return &LocalStore{
+	s := &LocalStore{
 		basePath: absPath,
-	}, nil
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.ttl < 0 {
+		return nil, fmt.Errorf("ttl must not be negative, got %s", s.ttl)
+	}
+
+	return s, nil
+}
+
+// expired reports whether the file described by info has outlived the TTL.
+func (s *LocalStore) expired(info os.FileInfo) bool {
+	return s.ttl > 0 && s.now().Sub(info.ModTime()) > s.ttl
 }
 
 // validateKey checks that the key does not escape the base directory.
@@ -95,21 +122,28 @@ func (s *LocalStore) Upload(_ context.Context, key string, data io.Reader) error
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	file, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) //#nosec G304 -- path validated by validateKey
+	// Write to a temporary file and rename it into place, so readers and
+	// concurrent writers never observe a partially written or oversized file.
+	file, err := os.CreateTemp(filepath.Dir(fullPath), tempFilePrefix+"*")
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
-	defer file.Close() //nolint:errcheck // best-effort close; write errors caught by io.Copy
+	tmpPath := file.Name()
+	defer os.Remove(tmpPath) //nolint:errcheck // no-op once renamed into place
 
-	limitedData := io.LimitReader(data, MaxUploadSize)
-	if _, err := io.Copy(file, limitedData); err != nil {
+	if _, err := io.Copy(file, limitUpload(data)); err != nil {
+		_ = file.Close() //nolint:errcheck // already returning the write error
+		if errors.Is(err, ErrUploadTooLarge) {
+			return err
+		}
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	// Check if data was truncated by attempting to read one more byte.
-	var extra [1]byte
-	if n, _ := data.Read(extra[:]); n > 0 { //nolint:errcheck // intentionally ignore; only checking for excess data
-		return fmt.Errorf("data exceeds maximum upload size of 1GB")
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
 	}
 
 	return nil
@@ -125,11 +159,24 @@ func (s *LocalStore) Download(_ context.Context, key string) (io.ReadCloser, err
 	file, err := os.Open(fullPath) //#nosec G304 -- path validated by validateKey
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("key not found: %s", key)
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
 		}
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 
+	if s.ttl > 0 {
+		info, err := file.Stat()
+		if err != nil {
+			_ = file.Close() //nolint:errcheck // already returning the stat error
+			return nil, fmt.Errorf("failed to stat file: %w", err)
+		}
+		if s.expired(info) {
+			_ = file.Close()        //nolint:errcheck // expired file is discarded
+			_ = os.Remove(fullPath) //nolint:errcheck // lazy cleanup; Sweep retries
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+	}
+
 	return file, nil
 }
 
@@ -157,7 +204,7 @@ func (s *LocalStore) Exists(_ context.Context, key string) (bool, error) {
 		return false, err
 	}
 
-	_, err = os.Stat(fullPath)
+	info, err := os.Stat(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
@@ -165,12 +212,24 @@ func (s *LocalStore) Exists(_ context.Context, key string) (bool, error) {
 		return false, fmt.Errorf("failed to stat file: %w", err)
 	}
 
+	if s.expired(info) {
+		_ = os.Remove(fullPath) //nolint:errcheck // lazy cleanup; Sweep retries
+		return false, nil
+	}
+
 	return true, nil
 }
 
-// List returns all keys matching the given prefix.
+// List returns all keys that start with the given prefix, in lexical order.
+// The prefix is matched as a string, not as a directory, so "run/st" matches
+// "run/step/data.bin".
 func (s *LocalStore) List(_ context.Context, prefix string) ([]string, error) {
-	searchPath, err := s.validatePrefix(prefix)
+	if _, err := s.validatePrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	// Walk only the deepest directory the prefix fully names.
+	searchPath, err := s.validatePrefix(prefix[:strings.LastIndex(prefix, "/")+1])
 	if err != nil {
 		return nil, err
 	}
@@ -179,10 +238,15 @@ func (s *LocalStore) List(_ context.Context, prefix string) ([]string, error) {
 
 	err = filepath.Walk(searchPath, func(path string, info os.FileInfo, walkErr error) error {
 		if walkErr != nil {
+			// Entries can vanish mid-walk when a concurrent upload renames
+			// its temporary file into place.
+			if os.IsNotExist(walkErr) && path != searchPath {
+				return nil
+			}
 			return walkErr
 		}
 
-		if info.IsDir() {
+		if info.IsDir() || strings.HasPrefix(info.Name(), tempFilePrefix) || s.expired(info) {
 			return nil
 		}
 
@@ -192,7 +256,10 @@ func (s *LocalStore) List(_ context.Context, prefix string) ([]string, error) {
 		}
 
 		// Convert OS path separators to forward slashes for consistent keys.
-		keys = append(keys, filepath.ToSlash(relPath))
+		key := filepath.ToSlash(relPath)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
 
 		return nil
 	})
@@ -203,9 +270,47 @@ func (s *LocalStore) List(_ context.Context, prefix string) ([]string, error) {
 		return nil, fmt.Errorf("failed to list keys: %w", err)
 	}
 
+	// Walk orders entries per directory, which is not lexical across
+	// separators ("a-b" sorts before "a/x" but is visited after it).
+	sort.Strings(keys)
+
 	return keys, nil
 }
 
+// Sweep removes every expired file under the base directory and returns the
+// number of files removed. It is a no-op when no TTL is configured.
+func (s *LocalStore) Sweep(ctx context.Context) (int, error) {
+	if s.ttl <= 0 {
+		return 0, nil
+	}
+
+	removed := 0
+	err := filepath.Walk(s.basePath, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) {
+				return nil
+			}
+			return walkErr
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() || !s.expired(info) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to sweep expired keys: %w", err)
+	}
+
+	return removed, nil
+}
+
 // Close is a no-op for LocalStore.
 func (s *LocalStore) Close() error {
 	return nil
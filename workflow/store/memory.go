@@ -0,0 +1,99 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryStore implements RawStore in process memory.
+// It is safe for concurrent use and is intended for unit tests and
+// short-lived workers that do not need durable storage.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		objects: make(map[string][]byte),
+	}
+}
+
+// Upload stores a copy of data under the given key, replacing any existing value.
+func (s *MemoryStore) Upload(_ context.Context, key string, data io.Reader) error {
+	if key == "" {
+		return fmt.Errorf("key must not be empty")
+	}
+
+	content, err := io.ReadAll(limitUpload(data))
+	if err != nil {
+		if errors.Is(err, ErrUploadTooLarge) {
+			return err
+		}
+		return fmt.Errorf("failed to read data: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = content
+	return nil
+}
+
+// Download returns a reader over a snapshot of the data stored under key.
+// Later uploads to the same key do not affect readers already returned.
+func (s *MemoryStore) Download(_ context.Context, key string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	content, ok := s.objects[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	// Upload never mutates a stored slice in place, so sharing it is safe.
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// Delete removes the data stored under the given key.
+// Deleting a missing key is a no-op.
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// Exists checks whether data exists under the given key.
+func (s *MemoryStore) Exists(_ context.Context, key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+// List returns all keys matching the given prefix in lexical order.
+func (s *MemoryStore) List(_ context.Context, prefix string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close is a no-op for MemoryStore; stored data remains accessible.
+func (s *MemoryStore) Close() error {
+	return nil
+}
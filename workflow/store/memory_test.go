@@ -0,0 +1,60 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_DownloadIsSnapshot(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	require.NoError(t, s.Upload(ctx, "k", bytes.NewReader([]byte("v1"))))
+	rc, err := s.Download(ctx, "k")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Upload(ctx, "k", bytes.NewReader([]byte("v2"))))
+
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), got)
+}
+
+func TestMemoryStore_UploadCopiesInput(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	data := []byte("original")
+	require.NoError(t, s.Upload(ctx, "k", bytes.NewReader(data)))
+	copy(data, "mutated!")
+
+	rc, err := s.Download(ctx, "k")
+	require.NoError(t, err)
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("original"), got)
+}
+
+func TestMemoryStore_ListSorted(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	for _, key := range []string{"p/c", "p/a", "q/z", "p/b"} {
+		require.NoError(t, s.Upload(ctx, key, bytes.NewReader(nil)))
+	}
+
+	keys, err := s.List(ctx, "p/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p/a", "p/b", "p/c"}, keys)
+}
+
+func TestMemoryStore_EmptyKey(t *testing.T) {
+	s := NewMemoryStore()
+	err := s.Upload(context.Background(), "", bytes.NewReader([]byte("x")))
+	assert.Error(t, err)
+}
@@ -164,6 +164,12 @@ func (s *InstrumentedStore) Close() error {
 	return s.inner.Close()
 }
 
+// Unwrap returns the wrapped store, so capability lookups such as
+// PresignerOf can see through the decorator.
+func (s *InstrumentedStore) Unwrap() RawStore {
+	return s.inner
+}
+
 // recordStoreMetrics records counter and histogram metrics for store operations.
 func recordStoreMetrics(ctx context.Context, operation, status string, duration time.Duration) {
 	cfg := pkgotel.ConfigFromContext(ctx)
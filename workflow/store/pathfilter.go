@@ -0,0 +1,185 @@
+package store
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// pathFilter selects archive entries with gitignore-style include and
+// exclude patterns.
+//
+// Pattern syntax follows .gitignore:
+//
+//   - "*", "?" and "[...]" match within a single path segment.
+//   - "**" matches any number of segments ("**/cache", "logs/**", "a/**/b").
+//   - A pattern without a slash (other than a trailing one) matches at any
+//     depth; a leading or inner slash anchors it to the archive root.
+//   - A trailing slash matches directories only.
+//   - A leading "!" in Exclude re-includes paths excluded by an earlier
+//     pattern; the last matching pattern wins. As in git, a file cannot be
+//     re-included when one of its parent directories is excluded.
+//
+// A path matching a directory pattern implicitly covers everything under it.
+type pathFilter struct {
+	include []globPattern
+	exclude []globPattern
+}
+
+// globPattern is one compiled gitignore-style pattern.
+type globPattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// newPathFilter compiles include and exclude patterns. A nil filter is
+// returned when both lists are empty; its methods allow everything.
+func newPathFilter(include, exclude []string) (*pathFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+
+	f := &pathFilter{}
+	for _, raw := range include {
+		p, err := compileGlob(raw, false)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, p)
+	}
+	for _, raw := range exclude {
+		p, err := compileGlob(raw, true)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, p)
+	}
+	return f, nil
+}
+
+func compileGlob(raw string, allowNegate bool) (globPattern, error) {
+	var p globPattern
+	pattern := strings.TrimSpace(raw)
+
+	if strings.HasPrefix(pattern, "!") {
+		if !allowNegate {
+			return p, fmt.Errorf("negated pattern %q is only valid in exclude patterns", raw)
+		}
+		p.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		p.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return p, fmt.Errorf("empty pattern %q", raw)
+	}
+
+	p.segments = strings.Split(pattern, "/")
+	if !anchored {
+		p.segments = append([]string{"**"}, p.segments...)
+	}
+	for _, segment := range p.segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return p, fmt.Errorf("invalid pattern %q: %w", raw, err)
+		}
+	}
+	return p, nil
+}
+
+// matches reports whether the pattern matches rel itself.
+func (p globPattern) matches(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return matchSegments(p.segments, strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok { //nolint:errcheck // patterns are validated in compileGlob
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// excluded reports whether the exclude list, evaluated in order, excludes rel.
+func (f *pathFilter) excluded(rel string, isDir bool) bool {
+	excluded := false
+	for _, p := range f.exclude {
+		if p.matches(rel, isDir) {
+			excluded = !p.negate
+		}
+	}
+	return excluded
+}
+
+// included reports whether rel or one of its parent directories matches an
+// include pattern. An empty include list includes everything.
+func (f *pathFilter) included(rel string, isDir bool) bool {
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, candidate := range selfAndParents(rel, isDir) {
+		for _, p := range f.include {
+			if p.matches(candidate.path, candidate.isDir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allows reports whether the slash-separated relative path should be kept.
+// A nil filter allows everything.
+func (f *pathFilter) allows(rel string, isDir bool) bool {
+	if f == nil {
+		return true
+	}
+	for _, candidate := range selfAndParents(rel, isDir) {
+		if f.excluded(candidate.path, candidate.isDir) {
+			return false
+		}
+	}
+	return f.included(rel, isDir)
+}
+
+// skipDir reports whether a directory walk can skip dir entirely: it is
+// excluded, so nothing beneath it can be re-included.
+func (f *pathFilter) skipDir(rel string) bool {
+	return f != nil && f.excluded(rel, true)
+}
+
+type pathCandidate struct {
+	path  string
+	isDir bool
+}
+
+// selfAndParents returns rel's parent directories, outermost first, followed
+// by rel itself.
+func selfAndParents(rel string, isDir bool) []pathCandidate {
+	segments := strings.Split(rel, "/")
+	candidates := make([]pathCandidate, 0, len(segments))
+	for i := 1; i < len(segments); i++ {
+		candidates = append(candidates, pathCandidate{path: strings.Join(segments[:i], "/"), isDir: true})
+	}
+	return append(candidates, pathCandidate{path: rel, isDir: isDir})
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathFilter_NilAllowsEverything(t *testing.T) {
+	f, err := newPathFilter(nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, f)
+	assert.True(t, f.allows("any/path.txt", false))
+	assert.False(t, f.skipDir("any"))
+}
+
+func TestPathFilter_Matching(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{name: "unanchored matches any depth", exclude: []string{"*.log"}, path: "a/b/debug.log", want: false},
+		{name: "unanchored keeps non-matching", exclude: []string{"*.log"}, path: "a/b/main.go", want: true},
+		{name: "anchored matches root only", exclude: []string{"/build"}, path: "src/build", isDir: true, want: true},
+		{name: "anchored excludes root", exclude: []string{"/build"}, path: "build", isDir: true, want: false},
+		{name: "excluded parent covers children", exclude: []string{"node_modules/"}, path: "web/node_modules/x/index.js", want: false},
+		{name: "dir-only ignores files", exclude: []string{"cache/"}, path: "cache", want: true},
+		{name: "double star middle", exclude: []string{"a/**/b"}, path: "a/x/y/b", want: false},
+		{name: "double star zero segments", exclude: []string{"a/**/b"}, path: "a/b", want: false},
+		{name: "trailing double star", exclude: []string{"logs/**"}, path: "logs/2024/app.txt", want: false},
+		{name: "negation re-includes", exclude: []string{"*.log", "!keep.log"}, path: "out/keep.log", want: true},
+		{name: "last match wins", exclude: []string{"!keep.log", "*.log"}, path: "keep.log", want: false},
+		{name: "negation cannot escape excluded dir", exclude: []string{"tmp/", "!tmp/keep.txt"}, path: "tmp/keep.txt", want: false},
+		{name: "include matches file", include: []string{"*.html"}, path: "reports/index.html", want: true},
+		{name: "include rejects others", include: []string{"*.html"}, path: "reports/data.csv", want: false},
+		{name: "include dir covers children", include: []string{"reports/"}, path: "reports/deep/data.csv", want: true},
+		{name: "exclude beats include", include: []string{"reports/"}, exclude: []string{"*.csv"}, path: "reports/data.csv", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newPathFilter(tt.include, tt.exclude)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, f.allows(tt.path, tt.isDir))
+		})
+	}
+}
+
+func TestPathFilter_SkipDir(t *testing.T) {
+	f, err := newPathFilter([]string{"*.go"}, []string{"vendor/"})
+	require.NoError(t, err)
+
+	assert.True(t, f.skipDir("vendor"))
+	assert.True(t, f.skipDir("pkg/vendor"))
+	// Directories that are merely not included must still be walked.
+	assert.False(t, f.skipDir("pkg"))
+}
+
+func TestPathFilter_InvalidPatterns(t *testing.T) {
+	_, err := newPathFilter([]string{"!negated"}, nil)
+	assert.ErrorContains(t, err, "only valid in exclude")
+
+	_, err = newPathFilter(nil, []string{"/"})
+	assert.ErrorContains(t, err, "empty pattern")
+
+	_, err = newPathFilter(nil, []string{"[unclosed"})
+	assert.ErrorContains(t, err, "invalid pattern")
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Presigner is implemented by stores that can issue time-limited URLs for a
+// key, so clients can read or write an object without store credentials.
+type Presigner interface {
+	// PresignGet returns a URL that downloads key with an HTTP GET until expiry elapses.
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+
+	// PresignPut returns a URL that uploads key with an HTTP PUT until expiry elapses.
+	PresignPut(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// PresignerOf returns the Presigner behind raw, looking through decorators
+// such as InstrumentedStore that expose an Unwrap method.
+func PresignerOf(raw RawStore) (Presigner, bool) {
+	for raw != nil {
+		if p, ok := raw.(Presigner); ok {
+			return p, true
+		}
+		u, ok := raw.(interface{ Unwrap() RawStore })
+		if !ok {
+			return nil, false
+		}
+		raw = u.Unwrap()
+	}
+	return nil, false
+}
+
+// Query parameters carried by LocalPresigner URLs.
+const (
+	localExpiresParam   = "X-Expires"
+	localSignatureParam = "X-Signature"
+)
+
+// minPresignSecretLen is the shortest HMAC secret LocalPresigner accepts.
+const minPresignSecretLen = 32
+
+// LocalPresigner adds presigned URLs to a LocalStore using an HMAC-signed
+// token scheme, and serves those URLs as an http.Handler.
+//
+// It embeds the LocalStore, so it is itself a RawStore and can be used as an
+// artifact store wherever the LocalStore was. URLs have the form
+//
+//	<baseURL>/<key>?X-Expires=<unix>&X-Signature=<hex hmac-sha256>
+//
+// and the handler expects request paths relative to baseURL; mount it with
+// http.StripPrefix when baseURL has a path component.
+type LocalPresigner struct {
+	*LocalStore
+	baseURL string
+	secret  []byte
+}
+
+// NewLocalPresigner returns a LocalPresigner for s that issues URLs under
+// baseURL (for example "https://artifacts.internal/files") signed with
+// secret. The secret must be at least 32 bytes and shared by every process
+// that serves or issues URLs.
+func NewLocalPresigner(s *LocalStore, baseURL string, secret []byte) (*LocalPresigner, error) {
+	if s == nil {
+		return nil, errors.New("local store is required")
+	}
+	if len(secret) < minPresignSecretLen {
+		return nil, fmt.Errorf("presign secret must be at least %d bytes", minPresignSecretLen)
+	}
+	if _, err := url.Parse(baseURL); err != nil || baseURL == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+
+	return &LocalPresigner{
+		LocalStore: s,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		secret:     secret,
+	}, nil
+}
+
+// Unwrap returns the underlying LocalStore.
+func (p *LocalPresigner) Unwrap() RawStore {
+	return p.LocalStore
+}
+
+// PresignGet returns a signed URL that downloads key until expiry elapses.
+func (p *LocalPresigner) PresignGet(_ context.Context, key string, expiry time.Duration) (string, error) {
+	return p.presign(http.MethodGet, key, expiry)
+}
+
+// PresignPut returns a signed URL that uploads key until expiry elapses.
+func (p *LocalPresigner) PresignPut(_ context.Context, key string, expiry time.Duration) (string, error) {
+	return p.presign(http.MethodPut, key, expiry)
+}
+
+func (p *LocalPresigner) presign(method, key string, expiry time.Duration) (string, error) {
+	if expiry <= 0 {
+		return "", fmt.Errorf("presign expiry must be positive, got %s", expiry)
+	}
+	if _, err := p.validateKey(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(p.now().Add(expiry).Unix(), 10)
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	query := url.Values{}
+	query.Set(localExpiresParam, expires)
+	query.Set(localSignatureParam, p.sign(method, key, expires))
+
+	return p.baseURL + "/" + strings.Join(segments, "/") + "?" + query.Encode(), nil
+}
+
+// sign returns the hex HMAC-SHA256 of the method, key and expiry.
+func (p *LocalPresigner) sign(method, key, expires string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a request's signature and expiry for the given method.
+func (p *LocalPresigner) verify(method, key string, query url.Values) error {
+	expires := query.Get(localExpiresParam)
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return errors.New("missing or malformed expiry")
+	}
+	if p.now().After(time.Unix(unix, 0)) {
+		return errors.New("signature expired")
+	}
+
+	want := p.sign(method, key, expires)
+	if !hmac.Equal([]byte(want), []byte(query.Get(localSignatureParam))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// ServeHTTP serves GET, HEAD and PUT requests for URLs issued by PresignGet
+// and PresignPut. Requests with a missing, expired or mismatched signature
+// are rejected with 403.
+func (p *LocalPresigner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+
+	var signedMethod string
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		signedMethod = http.MethodGet
+	case http.MethodPut:
+		signedMethod = http.MethodPut
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := p.verify(signedMethod, key, r.URL.Query()); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if signedMethod == http.MethodPut {
+		p.serveUpload(w, r, key)
+		return
+	}
+	p.serveDownload(w, r, key)
+}
+
+func (p *LocalPresigner) serveDownload(w http.ResponseWriter, r *http.Request, key string) {
+	rc, err := p.Download(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "download failed", http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close() //nolint:errcheck // best-effort close after serving
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = io.Copy(w, rc) //nolint:errcheck // the client connection may drop mid-stream
+}
+
+func (p *LocalPresigner) serveUpload(w http.ResponseWriter, r *http.Request, key string) {
+	if err := p.Upload(r.Context(), key, r.Body); err != nil {
+		if errors.Is(err, ErrUploadTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "upload failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,171 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPresignSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestPresigner(t *testing.T) (*LocalPresigner, *httptest.Server) {
+	t.Helper()
+	local, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(nil)
+	p, err := NewLocalPresigner(local, "http://"+server.Listener.Addr().String()+"/files", testPresignSecret)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/files/", http.StripPrefix("/files", p))
+	server.Config.Handler = mux
+	server.Start()
+	t.Cleanup(server.Close)
+	return p, server
+}
+
+func doRequest(t *testing.T, method, rawURL string, body io.Reader) (int, []byte) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, rawURL, body)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, data
+}
+
+func TestLocalPresigner_PutThenGet(t *testing.T) {
+	p, _ := newTestPresigner(t)
+	ctx := context.Background()
+
+	putURL, err := p.PresignPut(ctx, "run/step/out put.bin", time.Minute)
+	require.NoError(t, err)
+	status, _ := doRequest(t, http.MethodPut, putURL, strings.NewReader("artifact body"))
+	assert.Equal(t, http.StatusOK, status)
+
+	getURL, err := p.PresignGet(ctx, "run/step/out put.bin", time.Minute)
+	require.NoError(t, err)
+	status, body := doRequest(t, http.MethodGet, getURL, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "artifact body", string(body))
+}
+
+func TestLocalPresigner_RejectsBadRequests(t *testing.T) {
+	p, _ := newTestPresigner(t)
+	ctx := context.Background()
+	require.NoError(t, p.Upload(ctx, "secret/data", bytes.NewReader([]byte("x"))))
+
+	getURL, err := p.PresignGet(ctx, "secret/data", time.Minute)
+	require.NoError(t, err)
+
+	t.Run("tampered key", func(t *testing.T) {
+		status, _ := doRequest(t, http.MethodGet, strings.Replace(getURL, "secret/data", "secret/other", 1), nil)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("tampered signature", func(t *testing.T) {
+		u, err := url.Parse(getURL)
+		require.NoError(t, err)
+		q := u.Query()
+		q.Set(localSignatureParam, strings.Repeat("0", 64))
+		u.RawQuery = q.Encode()
+		status, _ := doRequest(t, http.MethodGet, u.String(), nil)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("get URL used for put", func(t *testing.T) {
+		status, _ := doRequest(t, http.MethodPut, getURL, strings.NewReader("overwrite"))
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("unsupported method", func(t *testing.T) {
+		status, _ := doRequest(t, http.MethodDelete, getURL, nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		p.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+		t.Cleanup(func() { p.now = time.Now })
+		status, _ := doRequest(t, http.MethodGet, getURL, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+}
+
+func TestLocalPresigner_MissingKey(t *testing.T) {
+	p, _ := newTestPresigner(t)
+
+	getURL, err := p.PresignGet(context.Background(), "nothing/here", time.Minute)
+	require.NoError(t, err)
+	status, _ := doRequest(t, http.MethodGet, getURL, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestNewLocalPresigner_Validation(t *testing.T) {
+	local, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = NewLocalPresigner(local, "http://localhost", []byte("short"))
+	assert.Error(t, err)
+
+	_, err = NewLocalPresigner(nil, "http://localhost", testPresignSecret)
+	assert.Error(t, err)
+
+	p, err := NewLocalPresigner(local, "http://localhost", testPresignSecret)
+	require.NoError(t, err)
+	_, err = p.PresignGet(context.Background(), "../escape", time.Minute)
+	assert.Error(t, err)
+	_, err = p.PresignGet(context.Background(), "ok", 0)
+	assert.Error(t, err)
+}
+
+func TestPresignerOf(t *testing.T) {
+	local, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	p, err := NewLocalPresigner(local, "http://localhost", testPresignSecret)
+	require.NoError(t, err)
+
+	_, ok := PresignerOf(local)
+	assert.False(t, ok, "a plain LocalStore cannot presign")
+
+	got, ok := PresignerOf(NewInstrumentedStore(p))
+	assert.True(t, ok, "PresignerOf must see through InstrumentedStore")
+	assert.Same(t, p, got)
+
+	_, ok = PresignerOf(NewMemoryStore())
+	assert.False(t, ok)
+}
+
+func TestS3Store_Presign(t *testing.T) {
+	// Presigning is computed locally, so no server is needed.
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String("http://localhost:9000"),
+		Credentials:  credentials.NewStaticCredentialsProvider("ak", "sk", ""),
+		UsePathStyle: true,
+	})
+	s := &S3Store{client: client, presign: s3.NewPresignClient(client), bucket: "bucket", prefix: "data/"}
+
+	getURL, err := s.PresignGet(context.Background(), "run/out.bin", 15*time.Minute)
+	require.NoError(t, err)
+	assert.Contains(t, getURL, "/bucket/data/run/out.bin")
+	assert.Contains(t, getURL, "X-Amz-Expires=900")
+
+	putURL, err := s.PresignPut(context.Background(), "run/out.bin", time.Minute)
+	require.NoError(t, err)
+	assert.Contains(t, putURL, "X-Amz-Signature=")
+}
@@ -0,0 +1,70 @@
+package store
+
+import "context"
+
+// UploadProgress reports how far a multipart upload has got. Backends that
+// upload in parts (currently S3Store) emit it once the upload is started and
+// after every completed part.
+type UploadProgress struct {
+	// Key is the store key being uploaded.
+	Key string `json:"key"`
+
+	// UploadID identifies the backend's multipart upload.
+	UploadID string `json:"uploadId"`
+
+	// PartsCompleted is the number of parts stored so far, including resumed ones.
+	PartsCompleted int `json:"partsCompleted"`
+
+	// PartsResumed is the number of parts reused from an earlier, interrupted
+	// attempt instead of being uploaded again.
+	PartsResumed int `json:"partsResumed"`
+
+	// BytesCompleted is the number of bytes stored so far.
+	BytesCompleted int64 `json:"bytesCompleted"`
+}
+
+// UploadProgressFunc receives UploadProgress updates. Calls are serialized.
+type UploadProgressFunc func(UploadProgress)
+
+type uploadProgressKey struct{}
+
+// WithUploadProgress returns a context that makes part-based uploads report
+// progress to fn, for example to record it in an activity heartbeat.
+func WithUploadProgress(ctx context.Context, fn UploadProgressFunc) context.Context {
+	return context.WithValue(ctx, uploadProgressKey{}, fn)
+}
+
+// uploadProgressFromContext returns the progress callback in ctx, or a no-op.
+func uploadProgressFromContext(ctx context.Context) UploadProgressFunc {
+	if fn, ok := ctx.Value(uploadProgressKey{}).(UploadProgressFunc); ok && fn != nil {
+		return fn
+	}
+	return func(UploadProgress) {}
+}
+
+// hasUploadProgress reports whether ctx carries a progress callback, which
+// is what lets a later attempt resume an interrupted upload.
+func hasUploadProgress(ctx context.Context) bool {
+	fn, ok := ctx.Value(uploadProgressKey{}).(UploadProgressFunc)
+	return ok && fn != nil
+}
+
+type resumeUploadKey struct{}
+
+// WithResumeUpload returns a context that makes a part-based upload of
+// prev.Key continue the multipart upload prev.UploadID, as reported to an
+// UploadProgressFunc by an earlier attempt, instead of starting a new one.
+// Parts already stored with the same content are not sent again. Uploads
+// are only resumed this way, so an upload another caller has in progress
+// for the same key is never taken over.
+func WithResumeUpload(ctx context.Context, prev UploadProgress) context.Context {
+	return context.WithValue(ctx, resumeUploadKey{}, prev)
+}
+
+// resumeUploadID returns the upload ID ctx asks to resume for key, or "".
+func resumeUploadID(ctx context.Context, key string) string {
+	if prev, ok := ctx.Value(resumeUploadKey{}).(UploadProgress); ok && prev.Key == key {
+		return prev.UploadID
+	}
+	return ""
+}
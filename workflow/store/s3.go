@@ -1,11 +1,14 @@
 package store
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
@@ -36,18 +39,59 @@ type S3Config struct {
 
 	// Region is the bucket region (defaults to "us-east-1" if empty)
 	Region string
+
+	// PartSize is the size in bytes of each part of a multipart upload
+	// (defaults to DefaultS3PartSize). Streams no larger than one part are
+	// sent with a single PutObject. Must be at least MinS3PartSize.
+	PartSize int64
+
+	// UploadConcurrency is the number of parts uploaded in parallel
+	// (defaults to DefaultS3UploadConcurrency).
+	UploadConcurrency int
+}
+
+// s3API is the subset of the S3 client used by S3Store, so tests can
+// substitute an in-memory fake.
+type s3API interface {
+	s3.ListObjectsV2APIClient
+	s3.ListPartsAPIClient
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
 }
 
 // S3Store implements RawStore using S3-compatible storage via AWS SDK v2.
 type S3Store struct {
-	client *s3.Client
-	bucket string
-	prefix string
+	client      s3API
+	presign     *s3.PresignClient
+	bucket      string
+	prefix      string
+	partSize    int64
+	concurrency int
+	// parts recycles part buffers (*[]byte of partSize) between uploads.
+	parts sync.Pool
 }
 
 // NewS3Store creates a new S3 raw store.
 // It auto-creates the bucket if it does not exist.
 func NewS3Store(ctx context.Context, cfg S3Config) (RawStore, error) {
+	partSize := cfg.PartSize
+	if partSize == 0 {
+		partSize = DefaultS3PartSize
+	}
+	if partSize < MinS3PartSize {
+		return nil, fmt.Errorf("part size %d is below the S3 minimum of %d bytes", partSize, MinS3PartSize)
+	}
+	concurrency := cfg.UploadConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultS3UploadConcurrency
+	}
+
 	region := cfg.Region
 	if region == "" {
 		region = "us-east-1"
@@ -84,28 +128,99 @@ func NewS3Store(ctx context.Context, cfg S3Config) (RawStore, error) {
 	}
 
 	return &S3Store{
-		client: client,
-		bucket: cfg.Bucket,
-		prefix: cfg.Prefix,
+		client:      client,
+		presign:     s3.NewPresignClient(client),
+		bucket:      cfg.Bucket,
+		prefix:      cfg.Prefix,
+		partSize:    partSize,
+		concurrency: concurrency,
 	}, nil
 }
 
-// Upload stores data under the given key.
+// Upload stores data under the given key. Streams no larger than one part are
+// sent with a single PutObject; larger streams use a multipart upload (see
+// uploadMultipart). Data larger than MaxUploadSize is rejected with
+// ErrUploadTooLarge and no object is created.
+//
+// Upload first reads at most s3PeekSize bytes, so small objects do not
+// allocate a whole part; part buffers are pooled across uploads.
 func (s *S3Store) Upload(ctx context.Context, key string, data io.Reader) error {
 	objectKey := s.fullKey(key)
+	limited := limitUpload(data)
+
+	peek := make([]byte, min(s3PeekSize, s.partSize))
+	n, err := io.ReadFull(limited, peek)
+	if done, err := uploadReadDone(err); done {
+		if err != nil {
+			return err
+		}
+		return s.putObject(ctx, objectKey, peek[:n])
+	}
+
+	first := s.partBuffer()
+	copy(first, peek[:n])
+	m, err := io.ReadFull(limited, first[n:])
+	n += m
+	if done, err := uploadReadDone(err); done {
+		defer s.releasePart(first)
+		if err != nil {
+			return err
+		}
+		return s.putObject(ctx, objectKey, first[:n])
+	}
+
+	return s.uploadMultipart(ctx, key, objectKey, first, limited)
+}
+
+// s3PeekSize is how much Upload reads before it allocates a whole part.
+const s3PeekSize = 64 << 10
+
+// uploadReadDone interprets the error of filling a buffer from the upload
+// stream: it reports whether the stream ended (or failed) and the error to
+// return, if any.
+func uploadReadDone(err error) (bool, error) {
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true, nil
+	case errors.Is(err, ErrUploadTooLarge):
+		return true, err
+	default:
+		return true, fmt.Errorf("failed to read upload data: %w", err)
+	}
+}
 
+// putObject stores data with a single PutObject.
+func (s *S3Store) putObject(ctx context.Context, objectKey string, data []byte) error {
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(objectKey),
-		Body:   data,
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(objectKey),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to upload object: %w", err)
 	}
-
 	return nil
 }
 
+// partBuffer returns a buffer of partSize bytes, reusing a released one when
+// possible.
+func (s *S3Store) partBuffer() []byte {
+	if buf, ok := s.parts.Get().(*[]byte); ok {
+		return *buf
+	}
+	return make([]byte, s.partSize)
+}
+
+// releasePart returns a buffer from partBuffer, or a slice of one, to the
+// pool.
+func (s *S3Store) releasePart(buf []byte) {
+	buf = buf[:cap(buf)]
+	s.parts.Put(&buf)
+}
+
 // Download retrieves data for the given key.
 // The caller must close the returned ReadCloser.
 func (s *S3Store) Download(ctx context.Context, key string) (io.ReadCloser, error) {
@@ -117,7 +232,7 @@ func (s *S3Store) Download(ctx context.Context, key string) (io.ReadCloser, erro
 	})
 	if err != nil {
 		if isS3ObjectNotFound(err) {
-			return nil, fmt.Errorf("object not found: %s", key)
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
 		}
 		return nil, fmt.Errorf("failed to download object: %w", err)
 	}
@@ -188,6 +303,36 @@ func (s *S3Store) List(ctx context.Context, prefix string) ([]string, error) {
 	return keys, nil
 }
 
+// PresignGet returns a presigned GetObject URL for key, valid for expiry.
+func (s *S3Store) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if s.presign == nil {
+		return "", errors.New("presigning is not available for this store")
+	}
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.fullKey(key)),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download: %w", err)
+	}
+	return req.URL, nil
+}
+
+// PresignPut returns a presigned PutObject URL for key, valid for expiry.
+func (s *S3Store) PresignPut(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if s.presign == nil {
+		return "", errors.New("presigning is not available for this store")
+	}
+	req, err := s.presign.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.fullKey(key)),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload: %w", err)
+	}
+	return req.URL, nil
+}
+
 // Close releases any resources held by the store (no-op for S3).
 func (s *S3Store) Close() error {
 	return nil
@@ -0,0 +1,29 @@
+//go:build integration
+
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/workflow/store"
+	"github.com/jasoet/go-wf/v2/workflow/store/storetest"
+)
+
+func TestS3Store_Conformance(t *testing.T) {
+	var n atomic.Int64
+
+	storetest.Run(t, func(t *testing.T) store.RawStore {
+		// A unique prefix per check gives every check an empty store.
+		cfg := store.S3TestConfig()
+		cfg.Prefix = fmt.Sprintf("conformance-%d/", n.Add(1))
+
+		s, err := store.NewS3Store(context.Background(), cfg)
+		require.NoError(t, err)
+		return s
+	})
+}
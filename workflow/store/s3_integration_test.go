@@ -164,3 +164,35 @@ func TestS3Store_DownloadNotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not found")
 }
+
+// S3TestConfig exposes the container-backed config to the external
+// conformance test in package store_test.
+func S3TestConfig() S3Config {
+	return testS3Config
+}
+
+func TestS3Store_MultipartUpload(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := testS3Config
+	cfg.PartSize = MinS3PartSize
+	rawStore, err := NewS3Store(ctx, cfg)
+	require.NoError(t, err)
+	defer rawStore.Close()
+
+	content := bytes.Repeat([]byte("multipart-"), (2*MinS3PartSize+1024)/10)
+
+	var last UploadProgress
+	progressCtx := WithUploadProgress(ctx, func(p UploadProgress) { last = p })
+	require.NoError(t, rawStore.Upload(progressCtx, "test/multipart.bin", bytes.NewReader(content)))
+	assert.Equal(t, 3, last.PartsCompleted)
+
+	reader, err := rawStore.Download(ctx, "test/multipart.bin")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(reader)
+	require.NoError(t, err)
+	assert.Equal(t, content, buf.Bytes())
+}
@@ -0,0 +1,263 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //#nosec G501 -- MD5 matches S3 part ETags; it is not used for security
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// MinS3PartSize is the smallest part size S3 accepts for all but the last part (5MB).
+	MinS3PartSize = 5 << 20
+
+	// DefaultS3PartSize is the default multipart part size (16MB).
+	DefaultS3PartSize = 16 << 20
+
+	// DefaultS3UploadConcurrency is the default number of parts uploaded in parallel.
+	DefaultS3UploadConcurrency = 4
+)
+
+// uploadedPart is a part already stored by S3 for a multipart upload.
+type uploadedPart struct {
+	etag string
+	size int64
+}
+
+// s3Part is a part read from the input stream, waiting to be uploaded.
+type s3Part struct {
+	number int32
+	data   []byte
+}
+
+// uploadMultipart streams the rest of data to S3 in parts of s.partSize,
+// uploading up to s.concurrency parts at once. first is the already-read
+// first part.
+//
+// Uploads are resumable: when ctx carries the UploadProgress of an earlier
+// attempt for the same key (WithResumeUpload), its multipart upload is
+// continued, and parts whose size and MD5 match what S3 already holds are
+// not sent again. A retried activity re-reads its input but only uploads the
+// parts that are missing; see HeartbeatUploads.
+//
+// When ctx is canceled, the data exceeds MaxUploadSize, or ctx has no
+// UploadProgressFunc to hand the upload to a later attempt, the multipart
+// upload is aborted so no orphaned parts remain. Other failures leave it in
+// place for the next attempt to resume.
+func (s *S3Store) uploadMultipart(ctx context.Context, key, objectKey string, first []byte, rest io.Reader) (err error) {
+	uploadID, existing := s.findResumableUpload(ctx, key, objectKey)
+	if uploadID == "" {
+		created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(objectKey),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start multipart upload: %w", err)
+		}
+		uploadID = aws.ToString(created.UploadId)
+	}
+
+	defer func() {
+		if err == nil || !shouldAbortUpload(ctx, err) {
+			return
+		}
+		// The upload context may be canceled; aborting must still go through.
+		_, abortErr := s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(objectKey),
+			UploadId: aws.String(uploadID),
+		})
+		if abortErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to abort multipart upload: %w", abortErr))
+		}
+	}()
+
+	uploadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	report := uploadProgressFromContext(ctx)
+	progress := UploadProgress{Key: key, UploadID: uploadID}
+	// Report the upload before any part, so an attempt that fails early can
+	// still be resumed.
+	report(progress)
+
+	var (
+		mu        sync.Mutex
+		completed []types.CompletedPart
+		firstErr  error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	parts := make(chan s3Part)
+	var wg sync.WaitGroup
+	for range s.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range parts {
+				etag, resumed, err := s.uploadPart(uploadCtx, objectKey, uploadID, part, existing)
+				size := len(part.data)
+				s.releasePart(part.data)
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				mu.Lock()
+				completed = append(completed, types.CompletedPart{
+					ETag:       aws.String(etag),
+					PartNumber: aws.Int32(part.number),
+				})
+				progress.PartsCompleted++
+				progress.BytesCompleted += int64(size)
+				if resumed {
+					progress.PartsResumed++
+				}
+				report(progress)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	readErr := s.readParts(uploadCtx, first, rest, parts)
+	close(parts)
+	wg.Wait()
+
+	switch {
+	case firstErr != nil && ctx.Err() == nil:
+		return firstErr
+	case readErr != nil:
+		return readErr
+	case ctx.Err() != nil:
+		return fmt.Errorf("multipart upload interrupted: %w", ctx.Err())
+	}
+
+	slices.SortFunc(completed, func(a, b types.CompletedPart) int {
+		return int(aws.ToInt32(a.PartNumber) - aws.ToInt32(b.PartNumber))
+	})
+
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(objectKey),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+// readParts splits the stream into parts and feeds them to the upload
+// workers. The channel is unbuffered, so at most concurrency+1 parts are held
+// in memory at once; their buffers come from the store's pool.
+func (s *S3Store) readParts(ctx context.Context, first []byte, rest io.Reader, parts chan<- s3Part) error {
+	send := func(part s3Part) bool {
+		select {
+		case parts <- part:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if !send(s3Part{number: 1, data: first}) {
+		return nil
+	}
+
+	for number := int32(2); ; number++ {
+		buf := s.partBuffer()
+		n, err := io.ReadFull(rest, buf)
+		if n == 0 {
+			s.releasePart(buf)
+		} else if !send(s3Part{number: number, data: buf[:n]}) {
+			return nil
+		}
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			return nil
+		case errors.Is(err, ErrUploadTooLarge):
+			return err
+		case err != nil:
+			return fmt.Errorf("failed to read upload data: %w", err)
+		}
+	}
+}
+
+// uploadPart uploads one part unless an identical part is already stored.
+// It returns the part's ETag and whether it was reused.
+func (s *S3Store) uploadPart(ctx context.Context, objectKey, uploadID string, part s3Part, existing map[int32]uploadedPart) (string, bool, error) {
+	sum := md5.Sum(part.data) //#nosec G401 -- compared against S3 ETags, not used for security
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	if prev, ok := existing[part.number]; ok && prev.etag == etag && prev.size == int64(len(part.data)) {
+		return etag, true, nil
+	}
+
+	out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(objectKey),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int32(part.number),
+		Body:          bytes.NewReader(part.data),
+		ContentLength: aws.Int64(int64(len(part.data))),
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to upload part %d: %w", part.number, err)
+	}
+	return aws.ToString(out.ETag), false, nil
+}
+
+// findResumableUpload returns the upload ctx asks to resume for key (see
+// WithResumeUpload) and its stored parts. An upload that no longer exists,
+// or whose parts cannot be listed, is not resumed: the upload simply starts
+// from scratch.
+func (s *S3Store) findResumableUpload(ctx context.Context, key, objectKey string) (string, map[int32]uploadedPart) {
+	uploadID := resumeUploadID(ctx, key)
+	if uploadID == "" {
+		return "", nil
+	}
+
+	existing := make(map[int32]uploadedPart)
+	paginator := s3.NewListPartsPaginator(s.client, &s3.ListPartsInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(objectKey),
+		UploadId: aws.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", nil
+		}
+		for _, part := range page.Parts {
+			existing[aws.ToInt32(part.PartNumber)] = uploadedPart{
+				etag: aws.ToString(part.ETag),
+				size: aws.ToInt64(part.Size),
+			}
+		}
+	}
+	return uploadID, existing
+}
+
+// shouldAbortUpload reports whether a failed multipart upload should be
+// discarded rather than kept for a later attempt to resume. Without a
+// progress callback nobody learns the upload ID, so nothing could resume it.
+func shouldAbortUpload(ctx context.Context, err error) bool {
+	return errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, ErrUploadTooLarge) || !hasUploadProgress(ctx)
+}
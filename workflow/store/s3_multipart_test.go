@@ -0,0 +1,363 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //#nosec G501 -- test fake mirrors S3 ETags
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is an in-memory s3API covering the calls S3Store makes.
+type fakeS3 struct {
+	mu          sync.Mutex
+	objects     map[string][]byte
+	uploads     map[string]*fakeUpload
+	nextID      int
+	putCalls    int
+	partCalls   int
+	aborted     []string
+	failPart    int32 // part number whose upload fails, 0 for none
+	onPartStart func(number int32)
+}
+
+type fakeUpload struct {
+	key       string
+	initiated time.Time
+	parts     map[int32][]byte
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, uploads: map[string]*fakeUpload{}}
+}
+
+func newFakeS3Store(client *fakeS3, partSize int64) *S3Store {
+	return &S3Store{client: client, bucket: "test", partSize: partSize, concurrency: 3}
+}
+
+func etagOf(data []byte) string {
+	sum := md5.Sum(data) //#nosec G401 -- test fake mirrors S3 ETags
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func (f *fakeS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.putCalls++
+	f.objects[aws.ToString(in.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[aws.ToString(in.Key)]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (f *fakeS3) HeadObject(_ context.Context, in *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.objects[aws.ToString(in.Key)]; !ok {
+		return nil, &types.NotFound{}
+	}
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (f *fakeS3) DeleteObject(_ context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, aws.ToString(in.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3) ListObjectsV2(_ context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for key := range f.objects {
+		if bytes.HasPrefix([]byte(key), []byte(aws.ToString(in.Prefix))) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	out := &s3.ListObjectsV2Output{}
+	for _, key := range keys {
+		out.Contents = append(out.Contents, types.Object{Key: aws.String(key)})
+	}
+	return out, nil
+}
+
+func (f *fakeS3) CreateMultipartUpload(_ context.Context, in *s3.CreateMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	id := fmt.Sprintf("upload-%d", f.nextID)
+	f.uploads[id] = &fakeUpload{key: aws.ToString(in.Key), initiated: time.Now(), parts: map[int32][]byte{}}
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(id)}, nil
+}
+
+func (f *fakeS3) UploadPart(ctx context.Context, in *s3.UploadPartInput, _ ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	number := aws.ToInt32(in.PartNumber)
+	if f.onPartStart != nil {
+		f.onPartStart(number)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if number == f.failPart {
+		return nil, errors.New("injected part failure")
+	}
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.partCalls++
+	upload, ok := f.uploads[aws.ToString(in.UploadId)]
+	if !ok {
+		return nil, errors.New("no such upload")
+	}
+	upload.parts[number] = data
+	return &s3.UploadPartOutput{ETag: aws.String(etagOf(data))}, nil
+}
+
+func (f *fakeS3) CompleteMultipartUpload(_ context.Context, in *s3.CompleteMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := aws.ToString(in.UploadId)
+	upload, ok := f.uploads[id]
+	if !ok {
+		return nil, errors.New("no such upload")
+	}
+	var data []byte
+	for i, part := range in.MultipartUpload.Parts {
+		number := aws.ToInt32(part.PartNumber)
+		if number != int32(i+1) {
+			return nil, fmt.Errorf("parts out of order: got %d at %d", number, i)
+		}
+		stored := upload.parts[number]
+		if etagOf(stored) != aws.ToString(part.ETag) {
+			return nil, fmt.Errorf("etag mismatch for part %d", number)
+		}
+		data = append(data, stored...)
+	}
+	f.objects[upload.key] = data
+	delete(f.uploads, id)
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3) AbortMultipartUpload(_ context.Context, in *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := aws.ToString(in.UploadId)
+	delete(f.uploads, id)
+	f.aborted = append(f.aborted, id)
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3) ListParts(_ context.Context, in *s3.ListPartsInput, _ ...func(*s3.Options)) (*s3.ListPartsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	upload, ok := f.uploads[aws.ToString(in.UploadId)]
+	if !ok {
+		return nil, errors.New("no such upload")
+	}
+	out := &s3.ListPartsOutput{}
+	for number, data := range upload.parts {
+		out.Parts = append(out.Parts, types.Part{
+			PartNumber: aws.Int32(number), ETag: aws.String(etagOf(data)), Size: aws.Int64(int64(len(data))),
+		})
+	}
+	return out, nil
+}
+
+// patterned returns n bytes that differ from part to part.
+func patterned(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 7 % 251)
+	}
+	return data
+}
+
+func TestS3Store_SmallUploadUsesPutObject(t *testing.T) {
+	client := newFakeS3()
+	s := newFakeS3Store(client, 1024)
+
+	require.NoError(t, s.Upload(context.Background(), "small", bytes.NewReader([]byte("tiny"))))
+
+	assert.Equal(t, 1, client.putCalls)
+	assert.Equal(t, 0, client.partCalls)
+	assert.Equal(t, []byte("tiny"), client.objects["small"])
+}
+
+func TestS3Store_UploadSizesAroundPeekAndPart(t *testing.T) {
+	const partSize = 2 * s3PeekSize
+	for _, size := range []int{0, s3PeekSize - 1, s3PeekSize, s3PeekSize + 1, partSize, partSize + 1, 3*partSize + 5} {
+		client := newFakeS3()
+		s := newFakeS3Store(client, partSize)
+		data := patterned(size)
+
+		require.NoError(t, s.Upload(context.Background(), "obj", bytes.NewReader(data)), "size %d", size)
+		assert.Equal(t, data, client.objects["obj"], "size %d", size)
+		if size < partSize {
+			assert.Equal(t, 1, client.putCalls, "size %d is sent with one PutObject", size)
+		} else {
+			assert.Zero(t, client.putCalls, "size %d uses a multipart upload", size)
+		}
+	}
+}
+
+func TestS3Store_UploadSplitsIntoParts(t *testing.T) {
+	client := newFakeS3()
+	s := newFakeS3Store(client, 1024)
+	data := patterned(5*1024 + 100)
+
+	var updates []UploadProgress
+	ctx := WithUploadProgress(context.Background(), func(p UploadProgress) {
+		updates = append(updates, p)
+	})
+
+	require.NoError(t, s.Upload(ctx, "big", bytes.NewReader(data)))
+
+	assert.Equal(t, 0, client.putCalls)
+	assert.Equal(t, 6, client.partCalls)
+	assert.Equal(t, data, client.objects["big"])
+	assert.Empty(t, client.uploads, "completed upload must not linger")
+
+	require.Len(t, updates, 7, "one update when the upload starts, then one per part")
+	assert.Zero(t, updates[0].PartsCompleted)
+	assert.NotEmpty(t, updates[0].UploadID)
+	last := updates[len(updates)-1]
+	assert.Equal(t, 6, last.PartsCompleted)
+	assert.Equal(t, int64(len(data)), last.BytesCompleted)
+	assert.Equal(t, "big", last.Key)
+}
+
+func TestS3Store_MultipartResumesAfterFailure(t *testing.T) {
+	client := newFakeS3()
+	s := newFakeS3Store(client, 1024)
+	s.concurrency = 1
+	data := patterned(4 * 1024)
+
+	client.failPart = 3
+	var recorded UploadProgress
+	ctx := WithUploadProgress(context.Background(), func(p UploadProgress) { recorded = p })
+	err := s.Upload(ctx, "resume", bytes.NewReader(data))
+	require.Error(t, err)
+	require.Len(t, client.uploads, 1, "a failed upload is kept for resumption")
+	assert.Empty(t, client.aborted)
+	assert.Equal(t, 2, client.partCalls)
+
+	client.failPart = 0
+	var last UploadProgress
+	ctx = WithUploadProgress(WithResumeUpload(context.Background(), recorded), func(p UploadProgress) { last = p })
+	require.NoError(t, s.Upload(ctx, "resume", bytes.NewReader(data)))
+
+	assert.Equal(t, data, client.objects["resume"])
+	assert.Equal(t, 4, client.partCalls, "only the two missing parts are uploaded again")
+	assert.Equal(t, 2, last.PartsResumed)
+	assert.Equal(t, 4, last.PartsCompleted)
+}
+
+func TestS3Store_MultipartResumeReuploadsChangedParts(t *testing.T) {
+	client := newFakeS3()
+	s := newFakeS3Store(client, 1024)
+	s.concurrency = 1
+
+	client.failPart = 2
+	var recorded UploadProgress
+	ctx := WithUploadProgress(context.Background(), func(p UploadProgress) { recorded = p })
+	require.Error(t, s.Upload(ctx, "changed", bytes.NewReader(patterned(3*1024))))
+
+	client.failPart = 0
+	changed := bytes.Repeat([]byte{'x'}, 3*1024)
+	require.NoError(t, s.Upload(WithResumeUpload(context.Background(), recorded), "changed", bytes.NewReader(changed)))
+
+	assert.Equal(t, changed, client.objects["changed"])
+}
+
+func TestS3Store_MultipartDoesNotTakeOverOtherUploads(t *testing.T) {
+	client := newFakeS3()
+	s := newFakeS3Store(client, 1024)
+	s.concurrency = 1
+	data := patterned(3 * 1024)
+
+	client.failPart = 2
+	other := WithUploadProgress(context.Background(), func(UploadProgress) {})
+	require.Error(t, s.Upload(other, "shared", bytes.NewReader(data)))
+	require.Len(t, client.uploads, 1)
+
+	client.failPart = 0
+	require.NoError(t, s.Upload(context.Background(), "shared", bytes.NewReader(data)))
+	assert.Equal(t, 4, client.partCalls, "another caller's upload is not resumed")
+	assert.Len(t, client.uploads, 1, "the other upload is left alone")
+
+	stale := UploadProgress{Key: "shared", UploadID: "gone"}
+	require.NoError(t, s.Upload(WithResumeUpload(context.Background(), stale), "shared", bytes.NewReader(data)))
+	assert.Equal(t, data, client.objects["shared"], "an upload that no longer exists starts over")
+}
+
+func TestS3Store_MultipartAbortsOnCancel(t *testing.T) {
+	client := newFakeS3()
+	s := newFakeS3Store(client, 1024)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.onPartStart = func(number int32) {
+		if number == 2 {
+			cancel()
+		}
+	}
+
+	err := s.Upload(ctx, "canceled", bytes.NewReader(patterned(4*1024)))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, client.aborted, 1)
+	assert.Empty(t, client.uploads, "canceled upload must not leave orphaned parts")
+	assert.NotContains(t, client.objects, "canceled")
+}
+
+func TestS3Store_MultipartAbortsWithoutResumeHandle(t *testing.T) {
+	client := newFakeS3()
+	s := newFakeS3Store(client, 1024)
+	client.failPart = 2
+
+	require.Error(t, s.Upload(context.Background(), "orphan", bytes.NewReader(patterned(3*1024))))
+	assert.Len(t, client.aborted, 1)
+	assert.Empty(t, client.uploads, "an upload nobody can resume is aborted")
+}
+
+func TestS3Store_DownloadMissingReturnsErrNotFound(t *testing.T) {
+	s := newFakeS3Store(newFakeS3(), 1024)
+
+	_, err := s.Download(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestNewS3Store_RejectsSmallPartSize(t *testing.T) {
+	_, err := NewS3Store(context.Background(), S3Config{Bucket: "b", PartSize: 1024})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "part size")
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasoet/go-wf/v2/internal/sqlident"
+)
+
+const (
+	// DefaultSQLTable is the table name used by SQLStore when none is configured.
+	DefaultSQLTable = "wf_blob"
+
+	// DefaultSQLChunkSize is the default size of each stored blob chunk (1MB).
+	DefaultSQLChunkSize = 1 << 20
+)
+
+// SQLStore implements RawStore on top of a database/sql table.
+//
+// Each blob is split into fixed-size chunks stored as one row per chunk, so
+// large artifacts never need a single oversized row. Queries use $n
+// placeholders and are written for Postgres; the caller supplies the driver
+// and the pool, so this adds no driver dependency to go-wf.
+type SQLStore struct {
+	db        *sql.DB
+	table     string
+	chunkSize int
+}
+
+// SQLOption configures a SQLStore.
+type SQLOption func(*SQLStore)
+
+// WithSQLTable overrides the table name (default: wf_blob). The name may be a
+// bare identifier or schema-qualified, lowercase, and must already be a valid
+// SQL identifier — it is interpolated, not parameterised.
+func WithSQLTable(name string) SQLOption {
+	return func(s *SQLStore) { s.table = name }
+}
+
+// WithSQLChunkSize overrides the size in bytes of each stored chunk
+// (default: 1MB).
+func WithSQLChunkSize(size int) SQLOption {
+	return func(s *SQLStore) { s.chunkSize = size }
+}
+
+// NewSQLStore returns a store over db. Call EnsureSchema once at startup, or
+// create the table yourself with the DDL from SchemaDDL.
+func NewSQLStore(db *sql.DB, opts ...SQLOption) (*SQLStore, error) {
+	if db == nil {
+		return nil, errors.New("sql store: db is required")
+	}
+
+	s := &SQLStore{db: db, table: DefaultSQLTable, chunkSize: DefaultSQLChunkSize}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if !sqlident.Valid(s.table) {
+		return nil, fmt.Errorf("sql store: unsafe table name %q", s.table)
+	}
+	if s.chunkSize <= 0 {
+		return nil, fmt.Errorf("sql store: chunk size must be positive, got %d", s.chunkSize)
+	}
+	return s, nil
+}
+
+// SchemaDDL returns the CREATE TABLE statement for this store's table, for
+// applications that manage their own migrations.
+func (s *SQLStore) SchemaDDL() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	object_key  TEXT    NOT NULL,
+	chunk_index INTEGER NOT NULL,
+	chunk_data  BYTEA   NOT NULL,
+	PRIMARY KEY (object_key, chunk_index)
+)`, s.table)
+}
+
+// EnsureSchema creates the blob table if it does not exist. Safe to call on
+// every startup.
+func (s *SQLStore) EnsureSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, s.SchemaDDL()); err != nil {
+		return fmt.Errorf("sql store: ensure schema: %w", err)
+	}
+	return nil
+}
+
+// Upload stores data under the given key, replacing any existing value.
+// The previous chunks are removed and the new ones written in a single
+// transaction, so readers never observe a partially written blob.
+func (s *SQLStore) Upload(ctx context.Context, key string, data io.Reader) (err error) {
+	if key == "" {
+		return fmt.Errorf("key must not be empty")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sql store: begin upload: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback() //nolint:errcheck // rollback is best-effort; the original error is returned
+		}
+	}()
+
+	//nolint:gosec // G201: identifier interpolation, validated at construction.
+	deleteQuery := fmt.Sprintf(`DELETE FROM %s WHERE object_key = $1`, s.table)
+	if _, err := tx.ExecContext(ctx, deleteQuery, key); err != nil {
+		return fmt.Errorf("sql store: replace %q: %w", key, err)
+	}
+
+	//nolint:gosec // G201: identifier interpolation, validated at construction.
+	insertQuery := fmt.Sprintf(`INSERT INTO %s (object_key, chunk_index, chunk_data) VALUES ($1, $2, $3)`, s.table)
+
+	limited := limitUpload(data)
+	buf := make([]byte, s.chunkSize)
+	for index := 0; ; index++ {
+		n, readErr := io.ReadFull(limited, buf)
+		if errors.Is(readErr, ErrUploadTooLarge) {
+			return readErr
+		}
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("failed to read data: %w", readErr)
+		}
+
+		// Always write chunk 0, even when empty, so an empty blob still exists.
+		if n > 0 || index == 0 {
+			if _, err := tx.ExecContext(ctx, insertQuery, key, index, buf[:n]); err != nil {
+				return fmt.Errorf("sql store: write chunk %d of %q: %w", index, key, err)
+			}
+		}
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sql store: commit upload of %q: %w", key, err)
+	}
+	return nil
+}
+
+// Download retrieves data for the given key. Chunks are streamed from the
+// database as the returned reader is consumed; the caller must close it to
+// release the underlying connection.
+func (s *SQLStore) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	//nolint:gosec // G201: identifier interpolation, validated at construction.
+	query := fmt.Sprintf(`SELECT chunk_data FROM %s WHERE object_key = $1 ORDER BY chunk_index`, s.table)
+
+	rows, err := s.db.QueryContext(ctx, query, key)
+	if err != nil {
+		return nil, fmt.Errorf("sql store: download %q: %w", key, err)
+	}
+
+	reader := &sqlChunkReader{rows: rows}
+	found, err := reader.next()
+	if err != nil {
+		_ = rows.Close() //nolint:errcheck // already returning the scan error
+		return nil, fmt.Errorf("sql store: download %q: %w", key, err)
+	}
+	if !found {
+		_ = rows.Close() //nolint:errcheck // nothing was read
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return reader, nil
+}
+
+// Delete removes the data stored under the given key.
+// Deleting a missing key is a no-op.
+func (s *SQLStore) Delete(ctx context.Context, key string) error {
+	//nolint:gosec // G201: identifier interpolation, validated at construction.
+	query := fmt.Sprintf(`DELETE FROM %s WHERE object_key = $1`, s.table)
+
+	if _, err := s.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("sql store: delete %q: %w", key, err)
+	}
+	return nil
+}
+
+// Exists checks whether data exists under the given key.
+func (s *SQLStore) Exists(ctx context.Context, key string) (bool, error) {
+	//nolint:gosec // G201: identifier interpolation, validated at construction.
+	query := fmt.Sprintf(`SELECT 1 FROM %s WHERE object_key = $1 AND chunk_index = 0`, s.table)
+
+	var one int
+	err := s.db.QueryRowContext(ctx, query, key).Scan(&one)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("sql store: exists %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// List returns all keys matching the given prefix in byte-wise lexical order,
+// independent of the database's default collation.
+func (s *SQLStore) List(ctx context.Context, prefix string) ([]string, error) {
+	//nolint:gosec // G201: identifier interpolation, validated at construction.
+	query := fmt.Sprintf(`SELECT object_key FROM %s
+		WHERE chunk_index = 0 AND object_key LIKE $1 ESCAPE '\'
+		ORDER BY object_key COLLATE "C"`, s.table)
+
+	rows, err := s.db.QueryContext(ctx, query, escapeLike(prefix)+"%")
+	if err != nil {
+		return nil, fmt.Errorf("sql store: list %q: %w", prefix, err)
+	}
+	defer rows.Close() //nolint:errcheck // read errors are reported by rows.Err
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("sql store: list %q: %w", prefix, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sql store: list %q: %w", prefix, err)
+	}
+	return keys, nil
+}
+
+// Close is a no-op; the caller owns the *sql.DB and closes it.
+func (s *SQLStore) Close() error {
+	return nil
+}
+
+// escapeLike escapes LIKE wildcards so the prefix is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// sqlChunkReader streams blob chunks from an open result set.
+type sqlChunkReader struct {
+	rows    *sql.Rows
+	current bytes.Reader
+}
+
+// next loads the following chunk into the reader and reports whether one existed.
+func (r *sqlChunkReader) next() (bool, error) {
+	if !r.rows.Next() {
+		return false, r.rows.Err()
+	}
+	var chunk []byte
+	if err := r.rows.Scan(&chunk); err != nil {
+		return false, err
+	}
+	r.current.Reset(chunk)
+	return true, nil
+}
+
+func (r *sqlChunkReader) Read(p []byte) (int, error) {
+	for r.current.Len() == 0 {
+		found, err := r.next()
+		if err != nil {
+			return 0, fmt.Errorf("sql store: read chunk: %w", err)
+		}
+		if !found {
+			return 0, io.EOF
+		}
+	}
+	return r.current.Read(p)
+}
+
+func (r *sqlChunkReader) Close() error {
+	return r.rows.Close()
+}
@@ -0,0 +1,104 @@
+//go:build integration
+
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/jasoet/go-wf/v2/workflow/store"
+	"github.com/jasoet/go-wf/v2/workflow/store/storetest"
+)
+
+func startPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	container, err := tcpostgres.Run(ctx, "postgres:18-alpine",
+		tcpostgres.WithDatabase("store"),
+		tcpostgres.WithUsername("test"),
+		tcpostgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(2*time.Minute),
+		),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = testcontainers.TerminateContainer(container) })
+
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestSQLStore_Conformance(t *testing.T) {
+	db := startPostgres(t)
+	ctx := context.Background()
+
+	storetest.Run(t, func(t *testing.T) store.RawStore {
+		// A small chunk size exercises multi-chunk blobs in every check.
+		s, err := store.NewSQLStore(db, store.WithSQLChunkSize(4))
+		require.NoError(t, err)
+		require.NoError(t, s.EnsureSchema(ctx))
+
+		_, err = db.ExecContext(ctx, "TRUNCATE "+store.DefaultSQLTable)
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestSQLStore_ChunkedLargeBlob(t *testing.T) {
+	db := startPostgres(t)
+	ctx := context.Background()
+
+	s, err := store.NewSQLStore(db, store.WithSQLTable("chunked_blob"), store.WithSQLChunkSize(1024))
+	require.NoError(t, err)
+	require.NoError(t, s.EnsureSchema(ctx))
+
+	data := bytes.Repeat([]byte("0123456789"), 1000) // 10 chunks
+	require.NoError(t, s.Upload(ctx, "big/blob", bytes.NewReader(data)))
+
+	var chunks int
+	require.NoError(t, db.QueryRowContext(ctx,
+		"SELECT count(*) FROM chunked_blob WHERE object_key = $1", "big/blob").Scan(&chunks))
+	assert.Equal(t, 10, chunks)
+
+	rc, err := s.Download(ctx, "big/blob")
+	require.NoError(t, err)
+	defer rc.Close()
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestSQLStore_ListEscapesWildcards(t *testing.T) {
+	db := startPostgres(t)
+	ctx := context.Background()
+
+	s, err := store.NewSQLStore(db)
+	require.NoError(t, err)
+	require.NoError(t, s.EnsureSchema(ctx))
+
+	require.NoError(t, s.Upload(ctx, "a_b/1", bytes.NewReader([]byte("x"))))
+	require.NoError(t, s.Upload(ctx, "axb/2", bytes.NewReader([]byte("y"))))
+
+	keys, err := s.List(ctx, "a_b/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a_b/1"}, keys)
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubDB returns a non-nil *sql.DB for constructor tests. These tests never
+// execute a query; behavior against a real server is covered by the
+// integration tests.
+func stubDB(t *testing.T) *sql.DB {
+	t.Helper()
+	return &sql.DB{}
+}
+
+func TestNewSQLStore_RequiresDB(t *testing.T) {
+	_, err := NewSQLStore(nil)
+	assert.Error(t, err)
+}
+
+func TestNewSQLStore_Defaults(t *testing.T) {
+	s, err := NewSQLStore(stubDB(t))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSQLTable, s.table)
+	assert.Equal(t, DefaultSQLChunkSize, s.chunkSize)
+}
+
+func TestNewSQLStore_RejectsUnsafeTableNames(t *testing.T) {
+	unsafe := []string{
+		"wf blob",
+		"wf_blob;DROP TABLE users",
+		"WF_Blob",
+		"wf-blob",
+		"",
+		"a.b.c",
+	}
+
+	for _, name := range unsafe {
+		_, err := NewSQLStore(stubDB(t), WithSQLTable(name))
+		assert.Error(t, err, "table name %q was accepted", name)
+	}
+}
+
+func TestNewSQLStore_AcceptsSchemaQualifiedTable(t *testing.T) {
+	s, err := NewSQLStore(stubDB(t), WithSQLTable("artifacts.blob"))
+	require.NoError(t, err)
+	assert.Contains(t, s.SchemaDDL(), "CREATE TABLE IF NOT EXISTS artifacts.blob")
+}
+
+func TestNewSQLStore_RejectsNonPositiveChunkSize(t *testing.T) {
+	_, err := NewSQLStore(stubDB(t), WithSQLChunkSize(0))
+	assert.Error(t, err)
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `a\%b\_c\\d`, escapeLike(`a%b_c\d`))
+	assert.Equal(t, "plain/prefix/", escapeLike("plain/prefix/"))
+}
@@ -2,16 +2,32 @@ package store
 
 import (
 	"context"
+	"errors"
 	"io"
 )
 
+// MaxUploadSize is the maximum size for uploads (1GB).
+const MaxUploadSize = 1 << 30
+
+var (
+	// ErrNotFound is returned (wrapped) by RawStore.Download when no data is
+	// stored under the requested key. Check for it with errors.Is.
+	ErrNotFound = errors.New("key not found")
+
+	// ErrUploadTooLarge is returned (wrapped) by RawStore.Upload when the data
+	// exceeds MaxUploadSize. A rejected upload leaves no data under the key.
+	ErrUploadTooLarge = errors.New("data exceeds maximum upload size of 1GB")
+)
+
 // RawStore is a byte-level storage interface.
 // Implementations handle raw byte persistence with string keys.
 type RawStore interface {
-	// Upload stores data under the given key.
+	// Upload stores data under the given key, replacing any existing value.
+	// Data larger than MaxUploadSize is rejected with ErrUploadTooLarge.
 	Upload(ctx context.Context, key string, data io.Reader) error
 
 	// Download retrieves data for the given key.
+	// It returns an error wrapping ErrNotFound when the key does not exist.
 	// The caller must close the returned ReadCloser.
 	Download(ctx context.Context, key string) (io.ReadCloser, error)
 
@@ -21,7 +37,8 @@ type RawStore interface {
 	// Exists checks whether data exists under the given key.
 	Exists(ctx context.Context, key string) (bool, error)
 
-	// List returns all keys matching the given prefix.
+	// List returns all keys that start with the given prefix, in lexical
+	// order. An empty prefix lists every key.
 	List(ctx context.Context, prefix string) ([]string, error)
 
 	// Close releases any resources held by the store.
@@ -121,3 +138,23 @@ func NewJSONStore[T any](raw RawStore) Store[T] {
 func NewBytesStore(raw RawStore) Store[[]byte] {
 	return NewTypedStore[[]byte](raw, &BytesCodec{})
 }
+
+// uploadLimitReader fails with ErrUploadTooLarge once more than MaxUploadSize
+// bytes have been read, so backends can stream uploads while enforcing the cap.
+type uploadLimitReader struct {
+	r    io.Reader
+	read int64
+}
+
+func limitUpload(r io.Reader) io.Reader {
+	return &uploadLimitReader{r: r}
+}
+
+func (l *uploadLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > MaxUploadSize {
+		return n, ErrUploadTooLarge
+	}
+	return n, err
+}
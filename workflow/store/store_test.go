@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -420,3 +423,68 @@ func (zeroReader) Read(p []byte) (int, error) {
 	}
 	return len(p), nil
 }
+
+// --- LocalStore TTL Tests ---
+
+func TestLocalStore_TTLExpiresKeys(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir(), WithLocalTTL(time.Hour))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, s.Upload(ctx, "ttl/fresh", bytes.NewReader([]byte("x"))))
+
+	exists, err := s.Exists(ctx, "ttl/fresh")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Move the clock past the TTL.
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	exists, err = s.Exists(ctx, "ttl/fresh")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = s.Download(ctx, "ttl/fresh")
+	assert.Error(t, err)
+
+	keys, err := s.List(ctx, "ttl")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestLocalStore_Sweep(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStore(dir, WithLocalTTL(time.Hour))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, s.Upload(ctx, "sweep/a", bytes.NewReader([]byte("a"))))
+	require.NoError(t, s.Upload(ctx, "sweep/b", bytes.NewReader([]byte("b"))))
+
+	removed, err := s.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	removed, err = s.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	_, err = os.Stat(filepath.Join(dir, "sweep", "a"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalStore_SweepWithoutTTL(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	removed, err := s.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
+
+func TestLocalStore_NegativeTTL(t *testing.T) {
+	_, err := NewLocalStore(t.TempDir(), WithLocalTTL(-time.Second))
+	assert.Error(t, err)
+}
@@ -0,0 +1,300 @@
+// Package storetest provides a conformance suite for store.RawStore
+// implementations.
+//
+// Backends shipped with go-wf run it against themselves, and third-party
+// backends can do the same from their own tests:
+//
+//	func TestMyStore(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) store.RawStore {
+//			return newMyStore(t)
+//		})
+//	}
+//
+// The factory is called once per check and must return an empty store.
+// Checks can be skipped by name with [Skip]; checks marked Slow are skipped
+// automatically under go test -short.
+package storetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jasoet/go-wf/v2/workflow/store"
+)
+
+// Factory returns a fresh, empty RawStore for a single conformance check.
+// Cleanup should be registered on t.
+type Factory func(t *testing.T) store.RawStore
+
+// Check is a single named conformance check.
+type Check struct {
+	Name string
+	Run  func(t *testing.T, s store.RawStore)
+
+	// Slow marks checks that move a lot of data; they are skipped under -short.
+	Slow bool
+}
+
+// Checks is the table of conformance checks executed by Run.
+var Checks = []Check{
+	{Name: "RoundTrip", Run: checkRoundTrip},
+	{Name: "Overwrite", Run: checkOverwrite},
+	{Name: "EmptyValue", Run: checkEmptyValue},
+	{Name: "DownloadMissing", Run: checkDownloadMissing},
+	{Name: "ExistsMissing", Run: checkExistsMissing},
+	{Name: "DeleteMissing", Run: checkDeleteMissing},
+	{Name: "ListPrefix", Run: checkListPrefix},
+	{Name: "ListPartialSegment", Run: checkListPartialSegment},
+	{Name: "ListEmptyPrefix", Run: checkListEmptyPrefix},
+	{Name: "ListSorted", Run: checkListSorted},
+	{Name: "ListNoMatch", Run: checkListNoMatch},
+	{Name: "ConcurrentUploads", Run: checkConcurrentUploads},
+	{Name: "ConcurrentOverwrite", Run: checkConcurrentOverwrite},
+	{Name: "OversizeUpload", Run: checkOversizeUpload, Slow: true},
+}
+
+// Option configures Run.
+type Option func(*config)
+
+type config struct {
+	skip []string
+}
+
+// Skip excludes the named checks, for backends that deliberately deviate
+// from one of them. Prefer fixing the backend where possible.
+func Skip(names ...string) Option {
+	return func(c *config) { c.skip = append(c.skip, names...) }
+}
+
+// Run executes every check in Checks as a subtest, each against a fresh store
+// from newStore.
+func Run(t *testing.T, newStore Factory, opts ...Option) {
+	t.Helper()
+
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	for _, check := range Checks {
+		t.Run(check.Name, func(t *testing.T) {
+			if slices.Contains(cfg.skip, check.Name) {
+				t.Skip("skipped by storetest.Skip")
+			}
+			if check.Slow && testing.Short() {
+				t.Skip("slow conformance check skipped in -short mode")
+			}
+			s := newStore(t)
+			check.Run(t, s)
+		})
+	}
+}
+
+func upload(t *testing.T, s store.RawStore, key string, data []byte) {
+	t.Helper()
+	require.NoError(t, s.Upload(context.Background(), key, bytes.NewReader(data)), "upload %q", key)
+}
+
+func download(t *testing.T, s store.RawStore, key string) []byte {
+	t.Helper()
+	rc, err := s.Download(context.Background(), key)
+	require.NoError(t, err, "download %q", key)
+	defer rc.Close() //nolint:errcheck // best-effort close after read
+
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err, "read %q", key)
+	return data
+}
+
+func checkRoundTrip(t *testing.T, s store.RawStore) {
+	ctx := context.Background()
+	key := "conformance/roundtrip/data.bin"
+	data := []byte("hello conformance")
+
+	upload(t, s, key, data)
+
+	exists, err := s.Exists(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.Equal(t, data, download(t, s, key))
+
+	require.NoError(t, s.Delete(ctx, key))
+
+	exists, err = s.Exists(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func checkOverwrite(t *testing.T, s store.RawStore) {
+	key := "conformance/overwrite/data.bin"
+
+	upload(t, s, key, []byte("first version, longer than the second"))
+	upload(t, s, key, []byte("second"))
+
+	assert.Equal(t, []byte("second"), download(t, s, key))
+}
+
+func checkEmptyValue(t *testing.T, s store.RawStore) {
+	ctx := context.Background()
+	key := "conformance/empty/data.bin"
+
+	upload(t, s, key, nil)
+
+	exists, err := s.Exists(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, exists, "an empty value must still exist")
+	assert.Empty(t, download(t, s, key))
+}
+
+func checkDownloadMissing(t *testing.T, s store.RawStore) {
+	rc, err := s.Download(context.Background(), "conformance/missing/data.bin")
+	if rc != nil {
+		_ = rc.Close() //nolint:errcheck // unexpected reader, closed to avoid leaks
+	}
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "want store.ErrNotFound, got %v", err)
+}
+
+func checkExistsMissing(t *testing.T, s store.RawStore) {
+	exists, err := s.Exists(context.Background(), "conformance/missing/data.bin")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func checkDeleteMissing(t *testing.T, s store.RawStore) {
+	assert.NoError(t, s.Delete(context.Background(), "conformance/missing/data.bin"))
+}
+
+func checkListPrefix(t *testing.T, s store.RawStore) {
+	upload(t, s, "conformance/list/a/one.bin", []byte("1"))
+	upload(t, s, "conformance/list/a/two.bin", []byte("2"))
+	upload(t, s, "conformance/list/b/three.bin", []byte("3"))
+
+	keys, err := s.List(context.Background(), "conformance/list/a/")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"conformance/list/a/one.bin", "conformance/list/a/two.bin"}, keys)
+}
+
+func checkListPartialSegment(t *testing.T, s store.RawStore) {
+	upload(t, s, "conformance/partial/step-a/out.bin", []byte("a"))
+	upload(t, s, "conformance/partial/step-b/out.bin", []byte("b"))
+	upload(t, s, "conformance/partial/other/out.bin", []byte("c"))
+
+	// Prefixes are matched as strings, not as directory names.
+	keys, err := s.List(context.Background(), "conformance/partial/step-")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"conformance/partial/step-a/out.bin",
+		"conformance/partial/step-b/out.bin",
+	}, keys)
+}
+
+func checkListEmptyPrefix(t *testing.T, s store.RawStore) {
+	upload(t, s, "conformance/all/one.bin", []byte("1"))
+	upload(t, s, "conformance/all/nested/two.bin", []byte("2"))
+
+	keys, err := s.List(context.Background(), "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"conformance/all/one.bin", "conformance/all/nested/two.bin"}, keys)
+}
+
+func checkListSorted(t *testing.T, s store.RawStore) {
+	for _, key := range []string{"conformance/sorted/c", "conformance/sorted/a/x", "conformance/sorted/b"} {
+		upload(t, s, key, []byte(key))
+	}
+
+	keys, err := s.List(context.Background(), "conformance/sorted/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"conformance/sorted/a/x", "conformance/sorted/b", "conformance/sorted/c"}, keys)
+}
+
+func checkListNoMatch(t *testing.T, s store.RawStore) {
+	keys, err := s.List(context.Background(), "conformance/nothing-here/")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func checkConcurrentUploads(t *testing.T, s store.RawStore) {
+	const workers = 8
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := fmt.Sprintf("conformance/concurrent/%d.bin", i)
+			errs[i] = s.Upload(context.Background(), key, bytes.NewReader([]byte(key)))
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		require.NoError(t, err, "worker %d", i)
+	}
+	for i := range workers {
+		key := fmt.Sprintf("conformance/concurrent/%d.bin", i)
+		assert.Equal(t, []byte(key), download(t, s, key))
+	}
+}
+
+func checkConcurrentOverwrite(t *testing.T, s store.RawStore) {
+	const workers = 8
+	key := "conformance/contended/data.bin"
+
+	payloads := make([][]byte, workers)
+	for i := range workers {
+		// Distinct lengths make interleaved writes detectable.
+		payloads[i] = bytes.Repeat([]byte{byte('a' + i)}, 1024*(i+1))
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.Upload(context.Background(), key, bytes.NewReader(payloads[i]))
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		require.NoError(t, err, "worker %d", i)
+	}
+
+	got := download(t, s, key)
+	assert.True(t, slices.ContainsFunc(payloads, func(p []byte) bool { return bytes.Equal(p, got) }),
+		"stored value (%d bytes) is not one of the uploaded payloads", len(got))
+}
+
+func checkOversizeUpload(t *testing.T, s store.RawStore) {
+	ctx := context.Background()
+	key := "conformance/oversize/data.bin"
+
+	err := s.Upload(ctx, key, io.LimitReader(zeroReader{}, store.MaxUploadSize+1))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, store.ErrUploadTooLarge), "want store.ErrUploadTooLarge, got %v", err)
+
+	exists, err := s.Exists(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, exists, "a rejected upload must not leave data behind")
+}
+
+// zeroReader is an io.Reader that produces an infinite stream of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,338 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jasoet/pkg/v2/temporal/job"
+	enumspb "go.temporal.io/api/enums/v1"
+	wf "go.temporal.io/sdk/workflow"
+)
+
+// Parent-close policies for SubWorkflow.ParentClosePolicy.
+const (
+	ParentCloseTerminate     = "terminate"
+	ParentCloseAbandon       = "abandon"
+	ParentCloseRequestCancel = "request_cancel"
+)
+
+// DefaultSubWorkflowID is the child workflow ID template used when
+// SubWorkflow.WorkflowID is empty.
+const DefaultSubWorkflowID = "{{parent_id}}-{{node}}"
+
+// MaxSubWorkflowDepth bounds how deeply sub-workflows may nest.
+const MaxSubWorkflowDepth = 8
+
+// SubWorkflowOutput maps a value from a child workflow's JSON result into the
+// parent node's step outputs.
+type SubWorkflowOutput struct {
+	// Name is the output name in the parent's StepOutputs.
+	Name string `json:"name" validate:"required"`
+
+	// Path is a JSON path into the child result, for example
+	// "$.step_outputs.build.version".
+	Path string `json:"path" validate:"required"`
+
+	// Default is used when the path is missing.
+	Default string `json:"default,omitempty"`
+}
+
+// SubWorkflow runs a DAG node as a Temporal child workflow. The child is a
+// registered workflow type (usually resolved from a job.Definition with
+// ResolveDefinition) or an inline nested input provided by the node.
+type SubWorkflow struct {
+	// Definition is the name of the job.Definition the reference was
+	// resolved from. It names the child in logs and cycle checks.
+	Definition string `json:"definition,omitempty"`
+
+	// WorkflowType is the registered workflow type to start.
+	WorkflowType string `json:"workflow_type,omitempty"`
+
+	// TaskQueue is the child's task queue. Empty uses the parent's.
+	TaskQueue string `json:"task_queue,omitempty"`
+
+	// Input is the JSON-encoded child workflow input.
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// WorkflowID is the child workflow ID template. It supports
+	// {{parent_id}}, {{parent_run_id}}, {{node}} and {{definition}}, and
+	// defaults to DefaultSubWorkflowID.
+	WorkflowID string `json:"workflow_id,omitempty"`
+
+	// ParentClosePolicy is what happens to the child when the parent closes:
+	// "terminate" (default), "abandon" or "request_cancel".
+	ParentClosePolicy string `json:"parent_close_policy,omitempty" validate:"omitempty,oneof=terminate abandon request_cancel"`
+
+	// ExecutionTimeout caps the child run, including retries. Zero means no
+	// limit.
+	ExecutionTimeout time.Duration `json:"execution_timeout,omitempty" validate:"gte=0"`
+
+	// Outputs maps values from the child result into the node's outputs.
+	Outputs []SubWorkflowOutput `json:"outputs,omitempty" validate:"dive"`
+}
+
+// SubWorkflowOption configures a SubWorkflow.
+type SubWorkflowOption func(*SubWorkflow)
+
+// WithChildWorkflowID sets the child workflow ID template.
+func WithChildWorkflowID(template string) SubWorkflowOption {
+	return func(s *SubWorkflow) { s.WorkflowID = template }
+}
+
+// WithParentClosePolicy sets what happens to the child when the parent
+// closes.
+func WithParentClosePolicy(policy string) SubWorkflowOption {
+	return func(s *SubWorkflow) { s.ParentClosePolicy = policy }
+}
+
+// WithChildTaskQueue overrides the child's task queue.
+func WithChildTaskQueue(taskQueue string) SubWorkflowOption {
+	return func(s *SubWorkflow) { s.TaskQueue = taskQueue }
+}
+
+// WithChildExecutionTimeout caps the child run.
+func WithChildExecutionTimeout(timeout time.Duration) SubWorkflowOption {
+	return func(s *SubWorkflow) { s.ExecutionTimeout = timeout }
+}
+
+// WithChildOutputs appends mappings from the child result into the node's
+// outputs.
+func WithChildOutputs(outputs ...SubWorkflowOutput) SubWorkflowOption {
+	return func(s *SubWorkflow) { s.Outputs = append(s.Outputs, outputs...) }
+}
+
+// Apply applies opts to s.
+func (s *SubWorkflow) Apply(opts ...SubWorkflowOption) {
+	for _, opt := range opts {
+		opt(s)
+	}
+}
+
+// Validate checks the options shared by referenced and inline children.
+func (s *SubWorkflow) Validate() error {
+	if err := pkgValidator.Struct(s); err != nil {
+		return err
+	}
+	if s.Input != nil && !json.Valid(s.Input) {
+		return fmt.Errorf("sub-workflow %s: input is not valid JSON", s.label())
+	}
+	return nil
+}
+
+func (s *SubWorkflow) label() string {
+	if s.Definition != "" {
+		return s.Definition
+	}
+	return s.WorkflowType
+}
+
+// EnterSubWorkflow returns chain extended with definition for a recursive
+// validation step. It reports a cycle when definition already appears in
+// chain and an error when nesting exceeds MaxSubWorkflowDepth. Inline
+// children pass an empty definition, which only counts towards the depth.
+func EnterSubWorkflow(chain []string, definition string) ([]string, error) {
+	if definition != "" && slices.Contains(chain, definition) {
+		path := append(slices.DeleteFunc(slices.Clone(chain), func(s string) bool { return s == "" }), definition)
+		return nil, fmt.Errorf("sub-workflow cycle: %s", strings.Join(path, " -> "))
+	}
+	if len(chain) >= MaxSubWorkflowDepth {
+		return nil, fmt.Errorf("sub-workflows nested deeper than %d levels", MaxSubWorkflowDepth)
+	}
+	return append(slices.Clone(chain), definition), nil
+}
+
+// WorkflowTypeTag prefixes the job.Definition tag that records the
+// registered workflow type the definition starts.
+const WorkflowTypeTag = "workflow-type:"
+
+// WithWorkflowType records the registered workflow type a job.Definition
+// starts, so ResolveDefinition can run it as a child workflow. The builders
+// in this module set it; hand-written definitions pass it to job.New after
+// any job.WithTags, which replaces the tags.
+func WithWorkflowType(workflowType string) job.Option {
+	return func(d *job.Definition) {
+		d.Tags = append(d.Tags, WorkflowTypeTag+workflowType)
+	}
+}
+
+// DefinitionWorkflowType returns the workflow type recorded on def by
+// WithWorkflowType.
+func DefinitionWorkflowType(def *job.Definition) (string, bool) {
+	for _, tag := range def.Tags {
+		if workflowType, ok := strings.CutPrefix(tag, WorkflowTypeTag); ok && workflowType != "" {
+			return workflowType, true
+		}
+	}
+	return "", false
+}
+
+// ResolveDefinition builds a SubWorkflow that runs def as a child workflow
+// on def.TaskQueue with input. A nil input uses def.NewInput(), the
+// definition's own snapshot. The definition must record its workflow type
+// with WithWorkflowType, as the builders in this module do.
+func ResolveDefinition(def *job.Definition, input any, opts ...SubWorkflowOption) (SubWorkflow, error) {
+	if def == nil {
+		return SubWorkflow{}, fmt.Errorf("resolve sub-workflow: definition is nil")
+	}
+	workflowType, ok := DefinitionWorkflowType(def)
+	if !ok {
+		return SubWorkflow{}, fmt.Errorf("resolve sub-workflow %s: definition does not record its workflow type (see WithWorkflowType)", def.Name)
+	}
+	if input == nil {
+		input = def.NewInput()
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return SubWorkflow{}, fmt.Errorf("resolve sub-workflow %s: encode input: %w", def.Name, err)
+	}
+	sub := SubWorkflow{
+		Definition:   def.Name,
+		WorkflowType: workflowType,
+		TaskQueue:    def.TaskQueue,
+		Input:        data,
+	}
+	sub.Apply(opts...)
+	return sub, nil
+}
+
+// ResolveRegistered is ResolveDefinition for the definition registered
+// under name in reg.
+func ResolveRegistered(reg *job.Registry, name string, input any, opts ...SubWorkflowOption) (SubWorkflow, error) {
+	def, ok := reg.Get(name)
+	if !ok {
+		return SubWorkflow{}, fmt.Errorf("resolve sub-workflow: definition %q is not registered", name)
+	}
+	return ResolveDefinition(def, input, opts...)
+}
+
+// ChildOutput is the output contract of a child workflow whose result counts
+// failed steps, such as a DAG, pipeline, parallel or loop output. A child
+// that completes with failed steps is reported as unsuccessful.
+type ChildOutput interface {
+	FailedSteps() int
+}
+
+// ChildOutputs maps the workflow types a sub-workflow node may start to a
+// constructor of the ChildOutput their result decodes into.
+type ChildOutputs map[string]func() ChildOutput
+
+// New returns the output of workflowType, or nil when its result carries
+// no failure count.
+func (c ChildOutputs) New(workflowType string) ChildOutput {
+	if newOutput, ok := c[workflowType]; ok {
+		return newOutput()
+	}
+	return nil
+}
+
+// SubWorkflowResult is the outcome of a child workflow run.
+type SubWorkflowResult struct {
+	WorkflowID string
+	RunID      string
+
+	// Result is the child's JSON result; nil if it failed.
+	Result json.RawMessage
+
+	// Outputs holds the values mapped by SubWorkflow.Outputs.
+	Outputs map[string]string
+
+	Success    bool
+	Error      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// ExecuteSubWorkflow runs workflowType with input as a child of the current
+// workflow for the DAG node named node. A nil input starts the child without
+// arguments. When output is not nil the child's result is decoded into it,
+// and a child that reports failed steps is unsuccessful without an error.
+func ExecuteSubWorkflow(ctx wf.Context, node string, sub SubWorkflow, workflowType string, input any, output ChildOutput) (*SubWorkflowResult, error) {
+	info := wf.GetInfo(ctx)
+	result := &SubWorkflowResult{
+		WorkflowID: expandSubWorkflowID(sub, node, info),
+		StartedAt:  wf.Now(ctx),
+	}
+
+	opts := wf.ChildWorkflowOptions{
+		WorkflowID:               result.WorkflowID,
+		TaskQueue:                sub.TaskQueue,
+		WorkflowExecutionTimeout: sub.ExecutionTimeout,
+		ParentClosePolicy:        parentClosePolicy(sub.ParentClosePolicy),
+	}
+	childCtx := wf.WithChildOptions(ctx, opts)
+
+	var args []any
+	if input != nil {
+		args = append(args, input)
+	}
+	future := wf.ExecuteChildWorkflow(childCtx, workflowType, args...)
+
+	var exec wf.Execution
+	if err := future.GetChildWorkflowExecution().Get(ctx, &exec); err == nil {
+		result.RunID = exec.RunID
+	}
+
+	var raw json.RawMessage
+	err := future.Get(ctx, &raw)
+	result.FinishedAt = wf.Now(ctx)
+	if err != nil {
+		result.Error = err.Error()
+		return result, fmt.Errorf("sub-workflow %s (%s): %w", node, result.WorkflowID, err)
+	}
+
+	result.Result = raw
+	result.Success = true
+	if output != nil {
+		if err := json.Unmarshal(raw, output); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			return result, fmt.Errorf("sub-workflow %s (%s): decode %s result: %w", node, result.WorkflowID, workflowType, err)
+		}
+		if failed := output.FailedSteps(); failed > 0 {
+			result.Success = false
+			result.Error = fmt.Sprintf("sub-workflow %s: %d steps failed", node, failed)
+		}
+	}
+
+	result.Outputs = make(map[string]string, len(sub.Outputs))
+	for _, out := range sub.Outputs {
+		value, err := ExtractJSONPath(string(raw), out.Path)
+		if err != nil {
+			if out.Default == "" {
+				wf.GetLogger(ctx).Error("Sub-workflow output not found", "node", node, "path", out.Path, "error", err)
+				continue
+			}
+			value = out.Default
+		}
+		result.Outputs[out.Name] = value
+	}
+	return result, nil
+}
+
+func expandSubWorkflowID(sub SubWorkflow, node string, info *wf.Info) string {
+	id := sub.WorkflowID
+	if id == "" {
+		id = DefaultSubWorkflowID
+	}
+	return strings.NewReplacer(
+		"{{parent_id}}", info.WorkflowExecution.ID,
+		"{{parent_run_id}}", info.WorkflowExecution.RunID,
+		"{{node}}", node,
+		"{{definition}}", sub.Definition,
+	).Replace(id)
+}
+
+func parentClosePolicy(policy string) enumspb.ParentClosePolicy {
+	switch policy {
+	case ParentCloseAbandon:
+		return enumspb.PARENT_CLOSE_POLICY_ABANDON
+	case ParentCloseRequestCancel:
+		return enumspb.PARENT_CLOSE_POLICY_REQUEST_CANCEL
+	default:
+		return enumspb.PARENT_CLOSE_POLICY_TERMINATE
+	}
+}
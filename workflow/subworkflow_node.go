@@ -0,0 +1,112 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// NestedDAG is the input of an inline nested DAG, validated within chain,
+// the enclosing definitions, for cycle detection.
+type NestedDAG interface {
+	comparable
+	ValidateWithin(chain []string) error
+}
+
+// NestedPipeline is the input of an inline nested pipeline.
+type NestedPipeline interface {
+	comparable
+	Validate() error
+}
+
+// InlineWorkflowTypes names the workflows a DAG package starts for inline
+// nested DAGs and pipelines.
+type InlineWorkflowTypes struct {
+	DAG      string
+	Pipeline string
+}
+
+// SubWorkflowNode runs a DAG node as a child workflow instead of a task:
+// either a registered workflow (see ResolveDefinition) or an inline nested
+// DAG or pipeline. Exactly one of WorkflowType, DAG and Pipeline must be
+// set. The container and function payload packages instantiate it with
+// their own DAG and pipeline inputs.
+type SubWorkflowNode[D NestedDAG, P NestedPipeline] struct {
+	SubWorkflow
+
+	// DAG is an inline nested DAG. Its ArtifactStore is not passed to the
+	// child.
+	DAG D `json:"dag,omitempty"`
+
+	// Pipeline is an inline nested pipeline.
+	Pipeline P `json:"pipeline,omitempty"`
+}
+
+// Target returns the workflow type and input to start the child with.
+func (n *SubWorkflowNode[D, P]) Target(types InlineWorkflowTypes) (string, any) {
+	var noDAG D
+	var noPipeline P
+	switch {
+	case n.DAG != noDAG:
+		return types.DAG, n.DAG
+	case n.Pipeline != noPipeline:
+		return types.Pipeline, n.Pipeline
+	case len(n.Input) > 0:
+		return n.WorkflowType, n.Input
+	default:
+		return n.WorkflowType, nil
+	}
+}
+
+// ValidateWithin checks the node and, recursively, any nested DAG or
+// pipeline it can decode, including the input of a registered workflow of
+// one of types. chain holds the enclosing definitions for cycle detection.
+func (n *SubWorkflowNode[D, P]) ValidateWithin(chain []string, types InlineWorkflowTypes) error {
+	var noDAG D
+	var noPipeline P
+	set := 0
+	for _, ok := range []bool{n.WorkflowType != "", n.DAG != noDAG, n.Pipeline != noPipeline} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of workflow_type, dag or pipeline is required")
+	}
+	if n.WorkflowType == "" && len(n.Input) > 0 {
+		return fmt.Errorf("input requires workflow_type")
+	}
+	if err := n.SubWorkflow.Validate(); err != nil {
+		return err
+	}
+
+	chain, err := EnterSubWorkflow(chain, n.Definition)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case n.DAG != noDAG:
+		return n.DAG.ValidateWithin(chain)
+	case n.Pipeline != noPipeline:
+		return n.Pipeline.Validate()
+	case n.WorkflowType == types.DAG && len(n.Input) > 0:
+		var nested D
+		if err := json.Unmarshal(n.Input, &nested); err != nil {
+			return fmt.Errorf("decode %s input: %w", n.Definition, err)
+		}
+		if nested == noDAG {
+			return fmt.Errorf("decode %s input: input is null", n.Definition)
+		}
+		return nested.ValidateWithin(chain)
+	case n.WorkflowType == types.Pipeline && len(n.Input) > 0:
+		var nested P
+		if err := json.Unmarshal(n.Input, &nested); err != nil {
+			return fmt.Errorf("decode %s input: %w", n.Definition, err)
+		}
+		if nested == noPipeline {
+			return fmt.Errorf("decode %s input: input is null", n.Definition)
+		}
+		return nested.Validate()
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jasoet/pkg/v2/temporal/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+type childInput struct {
+	Target string `json:"target"`
+}
+
+func newChildDefinition(t *testing.T, name string) *job.Definition {
+	t.Helper()
+	def, err := job.New(name, "child-queue",
+		job.WithRegister(func(worker.Worker) {}),
+		job.WithExecute(func(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in any) (client.WorkflowRun, error) {
+			return c.ExecuteWorkflow(ctx, opts, "ChildWorkflow", in)
+		}),
+		job.WithNewInput(func() any { return &childInput{Target: "default"} }),
+		job.WithTags("team-a"),
+		WithWorkflowType("ChildWorkflow"),
+	)
+	require.NoError(t, err)
+	return def
+}
+
+func TestResolveDefinition(t *testing.T) {
+	def := newChildDefinition(t, "child")
+
+	sub, err := ResolveDefinition(def, nil, WithParentClosePolicy(ParentCloseAbandon), WithChildWorkflowID("{{node}}-x"))
+	require.NoError(t, err)
+	assert.Equal(t, "child", sub.Definition)
+	assert.Equal(t, "ChildWorkflow", sub.WorkflowType)
+	assert.Equal(t, "child-queue", sub.TaskQueue)
+	assert.JSONEq(t, `{"target":"default"}`, string(sub.Input))
+	assert.Equal(t, ParentCloseAbandon, sub.ParentClosePolicy)
+	assert.Equal(t, "{{node}}-x", sub.WorkflowID)
+
+	sub, err = ResolveDefinition(def, childInput{Target: "custom"})
+	require.NoError(t, err)
+	var in childInput
+	require.NoError(t, json.Unmarshal(sub.Input, &in))
+	assert.Equal(t, "custom", in.Target)
+}
+
+func TestResolveRegistered(t *testing.T) {
+	reg := job.NewRegistry(newChildDefinition(t, "child"))
+
+	sub, err := ResolveRegistered(reg, "child", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ChildWorkflow", sub.WorkflowType)
+
+	_, err = ResolveRegistered(reg, "missing", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"missing" is not registered`)
+}
+
+func TestResolveDefinition_WithoutWorkflowType(t *testing.T) {
+	def, err := job.New("untyped", "q",
+		job.WithRegister(func(worker.Worker) {}),
+		job.WithExecute(func(ctx context.Context, c client.Client, opts client.StartWorkflowOptions, in any) (client.WorkflowRun, error) {
+			return c.ExecuteWorkflow(ctx, opts, "ChildWorkflow", in)
+		}),
+		job.WithNewInput(func() any { return &childInput{} }),
+	)
+	require.NoError(t, err)
+
+	_, err = ResolveDefinition(def, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not record its workflow type")
+}
+
+func TestDefinitionWorkflowType(t *testing.T) {
+	def := newChildDefinition(t, "child")
+
+	workflowType, ok := DefinitionWorkflowType(def)
+	assert.True(t, ok)
+	assert.Equal(t, "ChildWorkflow", workflowType)
+	assert.Contains(t, def.Tags, "team-a", "other tags are kept")
+}
+
+func TestSubWorkflow_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     SubWorkflow
+		wantErr bool
+	}{
+		{name: "minimal", sub: SubWorkflow{WorkflowType: "W"}},
+		{name: "all policies", sub: SubWorkflow{WorkflowType: "W", ParentClosePolicy: ParentCloseRequestCancel}},
+		{name: "unknown policy", sub: SubWorkflow{WorkflowType: "W", ParentClosePolicy: "detach"}, wantErr: true},
+		{name: "output without path", sub: SubWorkflow{WorkflowType: "W", Outputs: []SubWorkflowOutput{{Name: "v"}}}, wantErr: true},
+		{name: "invalid input", sub: SubWorkflow{WorkflowType: "W", Input: json.RawMessage(`{`)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sub.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func TestEnterSubWorkflow(t *testing.T) {
+	chain, err := EnterSubWorkflow(nil, "a")
+	require.NoError(t, err)
+	chain, err = EnterSubWorkflow(chain, "")
+	require.NoError(t, err)
+	chain, err = EnterSubWorkflow(chain, "b")
+	require.NoError(t, err)
+
+	_, err = EnterSubWorkflow(chain, "a")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sub-workflow cycle: a -> b -> a")
+
+	deep := make([]string, MaxSubWorkflowDepth)
+	_, err = EnterSubWorkflow(deep, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deeper than")
+}
+
+func TestChildOutputs_New(t *testing.T) {
+	outputs := ChildOutputs{
+		"PipelineWorkflow": func() ChildOutput { return &PipelineOutput[testOutput]{} },
+	}
+
+	output := outputs.New("PipelineWorkflow")
+	require.NotNil(t, output)
+	require.NoError(t, json.Unmarshal([]byte(`{"total_failed":2}`), output))
+	assert.Equal(t, 2, output.FailedSteps())
+
+	assert.Nil(t, outputs.New("ReleaseWorkflow"), "unknown workflow types carry no failure count")
+}
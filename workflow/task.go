@@ -1,5 +1,7 @@
 package workflow
 
+import "time"
+
 // TaskInput is the interface constraint that every workflow task input must
 // satisfy.  Validate returns an error if the input is invalid, and
 // ActivityName returns the Temporal activity name used to dispatch the task.
@@ -15,3 +17,17 @@ type TaskOutput interface {
 	IsSuccess() bool
 	GetError() string
 }
+
+// TimedTaskInput is implemented by task inputs that carry their own execution
+// timeout, enforced by the activity. TaskTimeout returns zero when the task
+// has no timeout of its own.
+type TimedTaskInput interface {
+	TaskTimeout() time.Duration
+}
+
+// RoutedTaskInput is implemented by task inputs that choose an
+// implementation version in the workflow. RouteTask must be deterministic in
+// key, so replays route the same way.
+type RoutedTaskInput interface {
+	RouteTask(key string)
+}
@@ -3,6 +3,7 @@ package workflow
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/testsuite"
@@ -10,9 +11,10 @@ import (
 
 // testInput is a mock TaskInput for testing generic workflows.
 type testInput struct {
-	Name     string `json:"name"`
-	Value    string `json:"value"`
-	Activity string `json:"activity"`
+	Name     string        `json:"name"`
+	Value    string        `json:"value"`
+	Activity string        `json:"activity"`
+	Timeout  time.Duration `json:"timeout,omitempty"`
 }
 
 // Validate validates the test input.
@@ -31,6 +33,9 @@ func (t testInput) ActivityName() string {
 	return "TestActivity"
 }
 
+// TaskTimeout returns the task's own timeout.
+func (t testInput) TaskTimeout() time.Duration { return t.Timeout }
+
 // testOutput is a mock TaskOutput for testing generic workflows.
 type testOutput struct {
 	Result  string `json:"result"`
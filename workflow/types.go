@@ -17,6 +17,9 @@ var pkgValidator = validator.New()
 type ExecutionOptions struct {
 	// StartToCloseTimeout caps a single activity attempt. Zero keeps the default (10m).
 	StartToCloseTimeout time.Duration `json:"start_to_close_timeout,omitempty"`
+	// HeartbeatTimeout fails an activity attempt that stops heartbeating for this long.
+	// Zero disables heartbeat timeouts (the default).
+	HeartbeatTimeout time.Duration `json:"heartbeat_timeout,omitempty"`
 	// RetryPolicy replaces the default retry policy (3 attempts, 2.0 backoff) when set.
 	RetryPolicy *temporal.RetryPolicy `json:"retry_policy,omitempty"`
 }
@@ -50,6 +53,9 @@ type PipelineOutput[O TaskOutput] struct {
 	TotalDuration time.Duration `json:"total_duration"`
 }
 
+// FailedSteps implements ChildOutput.
+func (o *PipelineOutput[O]) FailedSteps() int { return o.TotalFailed }
+
 // ParallelInput defines parallel task execution.
 type ParallelInput[I TaskInput, O TaskOutput] struct {
 	Tasks []I `json:"tasks" validate:"required,min=1"`
@@ -81,6 +87,9 @@ type ParallelOutput[O TaskOutput] struct {
 	TotalDuration time.Duration `json:"total_duration"`
 }
 
+// FailedSteps implements ChildOutput.
+func (o *ParallelOutput[O]) FailedSteps() int { return o.TotalFailed }
+
 // LoopInput defines loop iteration over items.
 type LoopInput[I TaskInput, O TaskOutput] struct {
 	Items    []string `json:"items" validate:"required,min=1"`
@@ -134,3 +143,6 @@ type LoopOutput[O TaskOutput] struct {
 	TotalDuration time.Duration `json:"total_duration"`
 	ItemCount     int           `json:"item_count"`
 }
+
+// FailedSteps implements ChildOutput.
+func (o *LoopOutput[O]) FailedSteps() int { return o.TotalFailed }